@@ -22,6 +22,8 @@ const (
 	ErrFailedToInitializeLogger        = "failed to initialize logger: %w"
 	ErrApplicationInitializationFailed = "application initialization failed: %w"
 	ErrApplicationExecutionFailed      = "application execution failed: %w"
+	ErrFailedToBuildPlan               = "failed to build execution plan: %w"
+	ErrFailedToWriteDoctorReport       = "failed to write doctor report: %w"
 	ErrFailedToGetUserHomeDirectory    = "failed to get user home directory: %w"
 )
 
@@ -78,6 +80,7 @@ const (
 // Input environment variable names
 const (
 	EnvInputToken          = "INPUT_TOKEN"
+	EnvInputTokenSource    = "INPUT_TOKEN_SOURCE"
 	EnvInputVault          = "INPUT_VAULT"
 	EnvInputRecord         = "INPUT_RECORD"
 	EnvInputReturnType     = "INPUT_RETURN_TYPE"
@@ -8,6 +8,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -79,6 +80,45 @@ var supportedVersionsCmd = &cobra.Command{
 	},
 }
 
+var printDBCmd = &cobra.Command{
+	Use:   "print-db",
+	Short: "Print the effective 1Password CLI versions database as YAML",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		content, err := cli.ExportEffectiveDB()
+		if err != nil {
+			return fmt.Errorf("failed to export versions database: %w", err)
+		}
+		fmt.Print(string(content))
+		return nil
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Report whether this environment is ready to run the action",
+	Long: `Report the platform key, the versions database in effect and the CLI
+versions it knows, the op binary on PATH with its version and checksum
+status, and whether the token and GitHub Actions variables are set. No
+token or secret value is ever printed.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		report := app.Doctor()
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf(ErrFailedToWriteDoctorReport, err)
+			}
+			fmt.Println(string(data))
+		} else if err := report.WriteText(os.Stdout); err != nil {
+			return fmt.Errorf(ErrFailedToWriteDoctorReport, err)
+		}
+		if !report.Ready {
+			return fmt.Errorf("environment is not ready to run the action")
+		}
+		return nil
+	},
+}
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configuration management commands",
@@ -275,6 +315,7 @@ var configCleanupCmd = &cobra.Command{
 var (
 	// CLI flags
 	flagToken             string
+	flagTokenSource       string
 	flagVault             string
 	flagRecord            string
 	flagReturnType        string
@@ -288,6 +329,7 @@ var (
 	flagDisableFileLog    bool
 	flagDisableStderr     bool
 	flagStandardizeOutput bool
+	flagPlan              bool
 )
 
 func init() {
@@ -295,6 +337,8 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(supportedVersionsCmd)
+	rootCmd.AddCommand(printDBCmd)
+	rootCmd.AddCommand(doctorCmd)
 
 	// Add configuration subcommands
 	configCmd.AddCommand(configValidateCmd)
@@ -313,6 +357,9 @@ func init() {
 	configListCmd.Flags().Bool("templates", false, "Show only templates")
 	configListCmd.Flags().Bool("profiles", false, "Show only profiles")
 
+	// Add flags for doctor command
+	doctorCmd.Flags().Bool("json", false, "Print the report as JSON")
+
 	// Add flags for config export command
 	configExportCmd.Flags().StringP("format", "f", "yaml", "Export format (yaml, json)")
 
@@ -321,9 +368,10 @@ func init() {
 
 	// Add flags
 	// Token CLI flag removed: token must be provided via INPUT_TOKEN or OP_TOKEN environment variable
+	rootCmd.Flags().StringVar(&flagTokenSource, "token-source", "", "Set to 'stdin' to read the token from standard input (local runs only)")
 	rootCmd.Flags().StringVar(&flagVault, "vault", "", "Vault name or ID where secrets are stored (required)")
 	rootCmd.Flags().StringVar(&flagRecord, "record", "", "Secret specification: 'secret/field' or JSON/YAML for multiple (required)")
-	rootCmd.Flags().StringVar(&flagReturnType, "return-type", "output", "How to return values: 'output', 'env', or 'both'")
+	rootCmd.Flags().StringVar(&flagReturnType, "return-type", "output", "How to return values: 'output', 'env', 'both', 'file', or 'json'")
 	rootCmd.Flags().StringVar(&flagProfile, "profile", "", "Configuration profile to use (development, staging, production)")
 	rootCmd.Flags().StringVar(&flagConfigFile, "config", "", "Path to configuration file")
 	rootCmd.Flags().IntVar(&flagTimeout, "timeout", 0, "Operation timeout in seconds")
@@ -331,6 +379,7 @@ func init() {
 	rootCmd.Flags().BoolVar(&flagCacheEnabled, "cache", false, "Enable caching")
 	rootCmd.Flags().StringVar(&flagCLIVersion, "cli-version", "", "1Password CLI version to use")
 	rootCmd.Flags().BoolVar(&flagDebug, "debug", false, "Enable debug logging")
+	rootCmd.Flags().BoolVar(&flagPlan, "plan", false, "Print the resolved execution plan as JSON without fetching secrets")
 
 	// Logging control flags
 	rootCmd.Flags().BoolVar(&flagDisableFileLog, "disable-file-logging", false, "Disable file logging (auto-detected in CI/CD)")
@@ -364,9 +413,18 @@ func init() {
   # Enable debug logging
   op-secrets-action --debug --vault="my-vault" --record="secret/field"
 
+  # Pipe the token in when running locally
+  op read "op://Private/ci-token/credential" | op-secrets-action --token-source=stdin --vault="my-vault" --record="secret/field"
+
+  # Review the resolved plan without fetching any secrets
+  op-secrets-action --plan --vault="my-vault" --record='{"db_pass": "database/password"}'
+
   # Show version information
   op-secrets-action version
 
+  # Check that the environment is ready, as JSON for automation
+  op-secrets-action doctor --json
+
   # Configuration management examples
   op-secrets-action config list
   op-secrets-action config init production
@@ -384,9 +442,18 @@ func runAction(_ *cobra.Command, _ []string) error {
 	// Prevent unused global variable error after removing CLI token flag support
 	_ = flagToken
 
-	// Enforce token from environment variables only
-	if os.Getenv(EnvInputToken) == "" && os.Getenv("OP_TOKEN") == "" {
-		return fmt.Errorf("missing 1Password token: set INPUT_TOKEN or OP_TOKEN environment variable")
+	if flagTokenSource != "" {
+		_ = os.Setenv(EnvInputTokenSource, flagTokenSource)
+	}
+
+	// Enforce token from environment variables, a token file or, for local
+	// runs, standard input; a Connect token replaces the service account
+	// token
+	if os.Getenv(EnvInputToken) == "" && os.Getenv("OP_TOKEN") == "" &&
+		os.Getenv("INPUT_TOKEN_FILE") == "" && os.Getenv("OP_TOKEN_FILE") == "" &&
+		os.Getenv(EnvInputTokenSource) == "" && os.Getenv("OP_TOKEN_SOURCE") == "" &&
+		os.Getenv("INPUT_CONNECT_TOKEN") == "" && os.Getenv("OP_CONNECT_TOKEN") == "" {
+		return fmt.Errorf("missing 1Password token: set INPUT_TOKEN or OP_TOKEN environment variable, or use --token-source=stdin")
 	}
 
 	// Override environment variables with CLI flags if provided
@@ -462,6 +529,10 @@ func runAction(_ *cobra.Command, _ []string) error {
 	// Ensure resources are cleaned up even on early return
 	defer func() { _ = application.Destroy() }()
 
+	if flagPlan {
+		return printPlan(application)
+	}
+
 	// Run the application
 	if err := application.Run(ctx); err != nil {
 		log.ErrorSensitive("Application failed", "error", err)
@@ -472,6 +543,22 @@ func runAction(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// printPlan writes the resolved execution plan to stdout as JSON
+func printPlan(application *app.App) error {
+	plan, err := application.Plan()
+	if err != nil {
+		return fmt.Errorf(ErrFailedToBuildPlan, err)
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf(ErrFailedToBuildPlan, err)
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		// Ensure single-line errors are emitted where appropriate; do not add extra stderr here
@@ -3,6 +3,7 @@ module github.com/ModeSevenIndustrialSolutions/1password-secrets-action
 go 1.25
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/sys v0.25.0
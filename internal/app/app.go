@@ -8,7 +8,11 @@ package app
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -17,8 +21,10 @@ import (
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/auth"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/connect"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/metrics"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/monitoring"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/output"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
@@ -32,13 +38,31 @@ type App struct {
 	logger        *logger.Logger
 	monitor       *monitoring.Monitor
 	cliManager    *cli.Manager
+	cliClient     *cli.Client
 	authManager   *auth.Manager
 	secretsEngine *secrets.Engine
+	connectClient *connect.Client
 	outputManager *output.Manager
+	delivered     bool       // Set once outputs, including secret files, have been delivered
+	runMetrics    RunMetrics // Phase timings for the metrics step summary
+	metrics       metrics.Metrics
+}
+
+// Options holds optional collaborators for an application instance.
+type Options struct {
+	// Metrics receives secret resolution latencies and CLI download sizes;
+	// nil discards them
+	Metrics metrics.Metrics
 }
 
 // New creates a new application instance with the provided configuration
 func New(cfg *config.Config, log *logger.Logger) (*App, error) {
+	return NewWithOptions(cfg, log, Options{})
+}
+
+// NewWithOptions creates a new application instance with the provided
+// configuration and optional collaborators
+func NewWithOptions(cfg *config.Config, log *logger.Logger, opts Options) (*App, error) {
 	if cfg == nil {
 		return nil, errors.NewConfigurationError(
 			errors.ErrCodeInvalidConfig,
@@ -68,36 +92,28 @@ func New(cfg *config.Config, log *logger.Logger) (*App, error) {
 		cfg.LogLevel = "info" // Default log level
 	}
 
-	// Validate configuration before proceeding
-	if err := cfg.Validate(); err != nil {
-		// Check if it's a token-related error for proper error code
-		if cfg.Token == "" {
-			return nil, errors.NewAuthenticationError(
-				errors.ErrCodeTokenInvalid,
-				"Token is required",
-				err,
-			)
-		}
-		if strings.Contains(err.Error(), "token format") ||
-			strings.Contains(err.Error(), "Token is too short") ||
-			strings.Contains(err.Error(), "Token is too long") ||
-			strings.Contains(err.Error(), "Token contains invalid") {
-			return nil, errors.NewAuthenticationError(
-				errors.ErrCodeTokenInvalid,
-				"Invalid token format",
-				err,
-			)
+	// Validate configuration before proceeding, reporting every problem
+	if problems := cfg.ValidationErrors(); len(problems) > 0 {
+		return nil, configValidationError(cfg, problems)
+	}
+
+	// The logger is created before the configuration is loaded, so apply
+	// log_level now; debug mode never raises the level above debug
+	if level, err := logger.ParseLevel(cfg.LogLevel); err == nil {
+		if cfg.Debug && level > slog.LevelDebug {
+			level = slog.LevelDebug
 		}
-		return nil, errors.NewConfigurationError(
-			errors.ErrCodeInvalidConfig,
-			"Configuration validation failed",
-			err,
-		)
+		log.SetLevel(level)
+	}
+
+	for _, warning := range cfg.Warnings {
+		log.Warn(warning)
 	}
 
 	app := &App{
-		config: cfg,
-		logger: log,
+		config:  cfg,
+		logger:  log,
+		metrics: metrics.OrNop(opts.Metrics),
 	}
 
 	// Initialize monitoring system
@@ -114,6 +130,12 @@ func New(cfg *config.Config, log *logger.Logger) (*App, error) {
 
 	// Initialize components
 	if err := app.initializeComponents(); err != nil {
+		// Keep the code of a broken versions DB or a disallowed CLI version
+		// so they can be told apart from other initialization failures
+		if errors.IsErrorCode(err, errors.ErrCodeVersionsDBInvalid) ||
+			errors.IsErrorCode(err, errors.ErrCodeCLIVersionNotAllowed) {
+			return nil, err
+		}
 		return nil, errors.Wrap(
 			errors.ErrCodeInternalError,
 			"Failed to initialize application components",
@@ -124,41 +146,177 @@ func New(cfg *config.Config, log *logger.Logger) (*App, error) {
 	return app, nil
 }
 
-// initializeComponents sets up the CLI manager, auth manager, and secrets engine
+// tokenFormatProblems mark validation problems with the token's format.
+var tokenFormatProblems = []string{
+	"token format", "Token is too short", "Token is too long", "Token contains invalid",
+}
+
+// configValidationError wraps the problems found validating cfg into one
+// ActionableError listing each of them. A missing or malformed token is an
+// ErrCodeTokenInvalid error, any other problem an ErrCodeInvalidConfig one.
+func configValidationError(cfg *config.Config, problems []error) error {
+	var cause error = &config.ValidationError{Problems: problems}
+	if len(problems) == 1 {
+		cause = problems[0]
+	}
+	messages := make([]string, len(problems))
+	tokenProblem := false
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+		for _, pattern := range tokenFormatProblems {
+			tokenProblem = tokenProblem || strings.Contains(messages[i], pattern)
+		}
+	}
+
+	var err *errors.ActionableError
+	switch {
+	case cfg.Token == "" && cfg.TokenFile == "" && cfg.TokenSource == "" && !cfg.UsesConnect():
+		err = errors.NewAuthenticationError(errors.ErrCodeTokenInvalid, "Token is required", cause)
+	case tokenProblem:
+		err = errors.NewAuthenticationError(errors.ErrCodeTokenInvalid, "Invalid token format", cause)
+	default:
+		err = errors.NewConfigurationError(errors.ErrCodeInvalidConfig, "Configuration validation failed", cause)
+	}
+	if len(problems) > 1 {
+		err = err.WithDetails(map[string]interface{}{"problems": messages})
+	}
+	return err
+}
+
+// initializeComponents sets up the secret backend and the secrets engine
 func (a *App) initializeComponents() error {
 	op := a.monitor.StartOperation("initialize_components", map[string]interface{}{
 		"component": "cli_manager",
 	})
 
-	// Initialize CLI manager
-	cliVersion := cli.DefaultCLIVersion
-	if a.config.CLIVersion != "" {
-		cliVersion = a.config.CLIVersion
+	// Initialize the secret backend: a Connect server when configured,
+	// otherwise the 1Password CLI
+	var resolver secrets.SecretResolver
+	if a.config.UsesConnect() {
+		client, err := a.initializeConnect()
+		if err != nil {
+			op.FailOperation(err)
+			return err
+		}
+		resolver = client
+	} else {
+		cliClient, err := a.initializeCLI()
+		if err != nil {
+			op.FailOperation(err)
+			return err
+		}
+		a.cliClient = cliClient
+		resolver = secrets.NewCLIResolver(cliClient)
 	}
 
+	// Initialize secrets engine
+	secretsConfig := secrets.DefaultConfig()
+	secretsConfig.MaxConcurrentRequests = a.config.MaxConcurrency
+	batchMode := a.config.EffectiveBatchMode()
+	secretsConfig.FailFast = batchMode == config.BatchModeFailFast
+	secretsConfig.RequestTimeout = a.config.GetTimeout("record")
+	secretsConfig.AtomicOperations = batchMode != config.BatchModeBestEffort
+	secretsConfig.ZeroSecretsOnError = true
+	secretsConfig.CacheNegative = a.config.CacheNegative
+	secretsConfig.SecretCacheTTL = time.Duration(a.config.CacheTTL) * time.Second
+	secretsConfig.VaultPriority = a.config.VaultPriority
+	secretsConfig.BatchRead = a.config.BatchRead
+	secretsConfig.MaxRetries = 0 // Retries are driven by withRetry
+	secretsConfig.Metrics = a.metrics
+
+	var err error
+	a.secretsEngine, err = secrets.NewEngineWithResolver(resolver, a.logger, secretsConfig)
+	if err != nil {
+		op.FailOperation(err)
+		return errors.NewSecretError(
+			errors.ErrCodeSecretParsingFailed,
+			"Failed to create secrets engine",
+			err,
+		)
+	}
+
+	// Initialize output manager
+	outputConfig := output.DefaultConfig()
+	outputConfig.ReturnType = a.config.ReturnType
+	outputConfig.AtomicOperations = true
+	outputConfig.MaskAllSecrets = true
+
+	a.outputManager, err = output.NewManager(a.config, a.logger, outputConfig)
+	if err != nil {
+		op.FailOperation(err)
+		return errors.NewOutputError(
+			errors.ErrCodeOutputFailed,
+			"Failed to create output manager",
+			err,
+		)
+	}
+
+	op.CompleteOperation(map[string]interface{}{
+		"components_initialized": 5,
+	})
+	return nil
+}
+
+// initializeCLI sets up the CLI manager and auth manager and returns the
+// CLI client used to read secrets
+func (a *App) initializeCLI() (*cli.Client, error) {
+	// Initialize CLI manager. The manager resolves an empty or "latest"
+	// version, detecting the installed version in offline mode
+	cliVersion := a.config.CLIVersion
+
 	// Enable test mode if using dummy tokens
 	isTestMode := testdata.IsTestToken(a.config.Token)
 
 	cliConfig := &cli.Config{
-		CacheDir:         ".op-cache",
-		Timeout:          time.Duration(a.config.Timeout) * time.Second,
-		DownloadTimeout:  5 * time.Minute,
-		Version:          cliVersion,
-		TestMode:         isTestMode,
-		DisableStderrOut: a.logger.IsGitHubActions(), // Use logger's GitHub Actions detection
+		CacheDir:                 ".op-cache",
+		InstallDir:               a.config.CLIInstallDir,
+		Timeout:                  time.Duration(a.config.Timeout) * time.Second,
+		DownloadTimeout:          time.Duration(a.config.DownloadTimeout) * time.Second,
+		Version:                  cliVersion,
+		TestMode:                 isTestMode,
+		DisableStderrOut:         a.logger.IsGitHubActions(), // Use logger's GitHub Actions detection
+		FallbackExecDir:          a.config.ExecFallbackDir,
+		DownloadBaseURL:          a.config.CLIDownloadBaseURL,
+		AllowInsecure:            a.config.AllowInsecureDownload,
+		Offline:                  a.config.Offline,
+		BinaryPath:               a.config.CLIPath,
+		DisableBinaryCache:       a.config.DisableBinaryCache,
+		SkipChecksumVerification: a.config.SkipChecksumVerification,
+		AllowedVersions:          a.config.AllowedCLIVersions,
+		ProxyURL:                 a.config.ProxyURL,
+		MaxAttempts:              a.config.DownloadMaxAttempts,
+		RetryTimeout:             time.Duration(a.config.RetryTimeout) * time.Second,
+		Logger:                   a.logger,
+		Metrics:                  a.metrics,
+	}
+
+	if a.config.SkipChecksumVerification {
+		a.logger.Warn("skip_checksum_verification is set: the 1Password CLI binary will run " +
+			"WITHOUT checksum verification and could have been tampered with. " +
+			"Only use this on platforms the versions database has no checksum for")
 	}
 
 	var err error
 	a.cliManager, err = cli.NewManager(cliConfig)
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewCLIError(
+		// A broken versions DB or a disallowed version is reported as
+		// such, not as a missing CLI
+		var actionErr *errors.ActionableError
+		if stderrors.As(err, &actionErr) && (actionErr.Code == errors.ErrCodeVersionsDBInvalid ||
+			actionErr.Code == errors.ErrCodeCLIVersionNotAllowed) {
+			return nil, actionErr
+		}
+		return nil, errors.NewCLIError(
 			errors.ErrCodeCLINotFound,
 			"Failed to create CLI manager",
 			err,
 		)
 	}
 
+	a.logger.Debug("1Password CLI install path",
+		"install_dir", a.cliManager.InstallDir(),
+		"path", a.cliManager.GetBinaryPath())
+
 	// Mark binary as valid in test mode to skip actual CLI download/verification
 	if isTestMode {
 		a.cliManager.MarkBinaryValid()
@@ -167,8 +325,7 @@ func (a *App) initializeComponents() error {
 	// Create secure token
 	token, err := security.NewSecureStringFromString(a.config.Token)
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewAuthenticationError(
+		return nil, errors.NewAuthenticationError(
 			errors.ErrCodeTokenInvalid,
 			"Failed to create secure token",
 			err,
@@ -179,21 +336,27 @@ func (a *App) initializeComponents() error {
 	authConfig := auth.DefaultConfig()
 	authConfig.Token = token
 	authConfig.Timeout = time.Duration(a.config.Timeout) * time.Second
+	authConfig.MaxRetries = 0 // Retries are driven by withRetry
+	if a.config.CacheEnabled {
+		authConfig.CacheTTL = time.Duration(a.config.CacheTTL) * time.Second
+		authConfig.IdentityCacheDir = identityCacheDir()
+	}
 
 	// Create CLI client for auth manager
 	clientConfig := &cli.ClientConfig{
-		Token:   token,
-		Timeout: time.Duration(a.config.Timeout) * time.Second,
+		Token:          token,
+		Timeout:        time.Duration(a.config.Timeout) * time.Second,
+		ConnectTimeout: time.Duration(a.config.ConnectTimeout) * time.Second,
+		VaultCacheTTL:  time.Duration(a.config.CacheTTL) * time.Second,
 	}
 
 	cliClient, err := cli.NewClient(a.cliManager, clientConfig)
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewCLIError(
+		return nil, errors.NewCLIError(
 			errors.ErrCodeCLIExecutionFailed,
 			"Failed to create CLI client",
 			err,
-		)
+		).WithContext("install_path", a.cliManager.GetBinaryPath())
 	}
 
 	// Create CLI adapter for auth manager
@@ -201,61 +364,75 @@ func (a *App) initializeComponents() error {
 
 	a.authManager, err = auth.NewManager(cliAdapter, a.logger, authConfig)
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewAuthenticationError(
+		return nil, errors.NewAuthenticationError(
 			errors.ErrCodeAuthFailed,
 			"Failed to create authentication manager",
 			err,
 		)
 	}
 
-	// Initialize secrets engine
-	secretsConfig := secrets.DefaultConfig()
-	secretsConfig.MaxConcurrentRequests = 5
-	secretsConfig.RequestTimeout = 30 * time.Second
-	secretsConfig.AtomicOperations = true
-	secretsConfig.ZeroSecretsOnError = true
+	return cliClient, nil
+}
 
-	a.secretsEngine, err = secrets.NewEngine(a.authManager, cliClient, a.logger, secretsConfig)
+// initializeConnect sets up the 1Password Connect client used to read secrets
+func (a *App) initializeConnect() (*connect.Client, error) {
+	token, err := security.NewSecureStringFromString(a.config.ConnectToken)
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewSecretError(
-			errors.ErrCodeSecretParsingFailed,
-			"Failed to create secrets engine",
+		return nil, errors.NewAuthenticationError(
+			errors.ErrCodeTokenInvalid,
+			"Failed to create secure Connect token",
 			err,
 		)
 	}
 
-	// Initialize output manager
-	outputConfig := output.DefaultConfig()
-	outputConfig.ReturnType = a.config.ReturnType
-	outputConfig.AtomicOperations = true
-	outputConfig.MaskAllSecrets = true
-
-	a.outputManager, err = output.NewManager(a.config, a.logger, outputConfig)
+	a.connectClient, err = connect.NewClient(&connect.Config{
+		Host:     a.config.ConnectHost,
+		Token:    token,
+		Timeout:  time.Duration(a.config.Timeout) * time.Second,
+		ProxyURL: a.config.ProxyURL,
+	})
 	if err != nil {
-		op.FailOperation(err)
-		return errors.NewOutputError(
-			errors.ErrCodeOutputFailed,
-			"Failed to create output manager",
+		_ = token.Destroy()
+		return nil, errors.NewConfigurationError(
+			errors.ErrCodeInvalidConfig,
+			"Failed to create Connect client",
 			err,
 		)
 	}
-
-	op.CompleteOperation(map[string]interface{}{
-		"components_initialized": 5,
-	})
-	return nil
+	return a.connectClient, nil
 }
 
-// Run executes the main application logic
+// Run executes the main application logic. Cancelling ctx, as on SIGINT or
+// an external timeout, aborts in-flight downloads, CLI commands and retry
+// waits, and Run returns an ErrCodeCanceled error wrapping the context's
+// error; the caller is still responsible for calling Destroy.
 func (a *App) Run(ctx context.Context) error {
 	// Use panic recovery for the entire application run
+	start := time.Now()
 	return a.monitor.WithPanicRecovery(ctx, "application_run", func() error {
-		return a.runWithMonitoring(ctx)
+		err := a.runWithMonitoring(ctx)
+		if err != nil && ctx.Err() != nil {
+			err = canceledError(ctx, err)
+		}
+		a.writeMetricsSummary(time.Since(start))
+		return err
 	})
 }
 
+// canceledError reports a run stopped by its caller's context, whichever
+// step it was interrupted in. Both the context's cause and the step's
+// error remain in the chain.
+func canceledError(ctx context.Context, err error) error {
+	cause := context.Cause(ctx)
+	return errors.Wrap(
+		errors.ErrCodeCanceled,
+		"Secrets retrieval was canceled before it completed",
+		fmt.Errorf("%w: %w", cause, err),
+	).WithSuggestions(
+		"Check whether the job was canceled or hit its timeout-minutes limit",
+	)
+}
+
 // runWithMonitoring executes the main application logic with comprehensive monitoring
 func (a *App) runWithMonitoring(ctx context.Context) error {
 	// Start monitoring the main operation
@@ -284,6 +461,17 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 		)
 	}
 
+	// Check the runner is ready before downloading or authenticating
+	report, err := a.Preflight(ctx)
+	if err != nil {
+		mainOp.FailOperation(err)
+		return err
+	}
+	if err := report.Err(); err != nil {
+		mainOp.FailOperation(err)
+		return err
+	}
+
 	// Start GitHub Actions group for better log organization
 	a.logger.GitHubGroup("🔐 Retrieving secrets from 1Password")
 	defer a.logger.GitHubEndGroup()
@@ -319,44 +507,34 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 
 	a.logger.Info("Parsed secret requests", "count", len(requests))
 
-	// Ensure CLI is available and ready
-	cliOp := a.monitor.StartOperation("ensure_cli", nil)
-	a.logger.Info("Ensuring 1Password CLI is available")
-	if cliErr := a.cliManager.EnsureCLI(ctx); cliErr != nil {
-		cliOp.FailOperation(cliErr)
-		mainOp.FailOperation(cliErr)
+	var result *secrets.BatchResult
+	succeeded := false
+	defer func() { a.writeSummary(requests, result, succeeded) }()
 
-		// Enhanced error logging for CLI verification failures
-		errStr := cliErr.Error()
-		if strings.Contains(errStr, "SHA mismatch") || strings.Contains(errStr, "CLI verification failed") {
-			// Add platform information to the logger
-			a.logger.ErrorSensitive("CLI verification failed with platform details",
-				"error", cliErr,
-				"cli_version", a.cliManager.Version(),
-				"platform_os", runtime.GOOS,
-				"platform_arch", runtime.GOARCH,
-				"platform_combined", fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH),
-			)
+	// Ensure CLI is available and ready; a Connect server needs no CLI
+	if a.connectClient == nil {
+		if err := a.ensureCLI(ctx, mainOp); err != nil {
+			return err
+		}
+		if err := a.validateToken(ctx, mainOp); err != nil {
+			return err
 		}
-
-		return errors.NewCLIError(
-			errors.ErrCodeCLINotFound,
-			"Failed to ensure CLI availability",
-			cliErr,
-		)
 	}
-	cliOp.CompleteOperation(nil)
 
 	// Authenticate with 1Password
 	authOp := a.monitor.StartOperation("authenticate", nil)
 	a.logger.Info("Authenticating with 1Password")
-	if authErr := a.authManager.Authenticate(ctx); authErr != nil {
+	if authErr := a.withRetry(ctx, "authenticate", a.authenticate); authErr != nil {
 		authOp.FailOperation(authErr)
+		a.runMetrics.Auth = authOp.Duration()
 		mainOp.FailOperation(authErr)
 		a.monitor.LogAuthEvent(audit.EventAuthFailure, audit.OutcomeFailure, "Authentication with 1Password failed", map[string]interface{}{
 			"error": authErr.Error(),
 		})
 		a.logger.ErrorSensitive("Authentication with 1Password failed", "error", authErr)
+		if limitErr := rateLimitExhausted(authErr); limitErr != nil {
+			return limitErr
+		}
 		return errors.NewAuthenticationError(
 			errors.ErrCodeAuthFailed,
 			"Failed to authenticate with 1Password",
@@ -364,58 +542,111 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 		)
 	}
 	authOp.CompleteOperation(nil)
+	a.runMetrics.Auth = authOp.Duration()
 	a.monitor.LogAuthEvent(audit.EventAuthSuccess, audit.OutcomeSuccess, "Successfully authenticated with 1Password", nil)
 
-	// Resolve vault to ensure it exists and is accessible
-	vaultOp := a.monitor.StartOperation("resolve_vault", map[string]interface{}{
-		"vault_identifier": a.config.Vault,
-	})
-	a.logger.InfoSensitive("Resolving vault", "vault", a.config.Vault)
-	vaultMetadata, err := a.authManager.ResolveVault(ctx, a.config.Vault)
-	if err != nil {
-		vaultOp.FailOperation(err)
-		mainOp.FailOperation(err)
-		vaultResource := audit.CreateVaultResource("", a.config.Vault)
-		a.monitor.LogVaultEvent(audit.EventVaultResolve, audit.OutcomeFailure, "Failed to resolve vault", vaultResource,
+	// Resolve the default vault to ensure it exists and is accessible.
+	// Records in AnyVault are located per item by the secrets engine
+	// instead, and records naming their own vault resolve it as they run.
+	vaultMetadata := &auth.VaultMetadata{Name: a.config.Vault}
+	if a.config.Vault != "" && a.config.Vault != config.AnyVault {
+		vaultOp := a.monitor.StartOperation("resolve_vault", map[string]interface{}{
+			"vault_identifier": a.config.Vault,
+		})
+		a.logger.InfoSensitive("Resolving vault", "vault", a.config.Vault)
+		vaultMetadata, err = a.resolveVault(ctx, a.config.Vault)
+		if err != nil {
+			vaultOp.FailOperation(err)
+			mainOp.FailOperation(err)
+			vaultResource := audit.CreateVaultResource("", a.config.Vault)
+			a.monitor.LogVaultEvent(audit.EventVaultResolve, audit.OutcomeFailure, "Failed to resolve vault", vaultResource,
+				map[string]interface{}{
+					"vault_identifier": a.config.Vault,
+					"error":            err.Error(),
+				})
+			return errors.NewAuthenticationError(
+				errors.ErrCodeVaultNotFound,
+				"Failed to resolve vault",
+				err,
+			)
+		}
+		vaultOp.CompleteOperation(map[string]interface{}{
+			"vault_id":   vaultMetadata.ID,
+			"vault_name": vaultMetadata.Name,
+		})
+
+		a.logger.InfoSensitive("Vault resolved successfully",
+			"vault_id", vaultMetadata.ID,
+			"vault_name", vaultMetadata.Name)
+		vaultResource := audit.CreateVaultResource(vaultMetadata.ID, vaultMetadata.Name)
+		a.monitor.LogVaultEvent(audit.EventVaultAccess, audit.OutcomeSuccess, "Vault resolved successfully", vaultResource,
 			map[string]interface{}{
-				"vault_identifier": a.config.Vault,
-				"error":            err.Error(),
+				"vault_id":   vaultMetadata.ID,
+				"vault_name": vaultMetadata.Name,
 			})
-		return errors.NewAuthenticationError(
-			errors.ErrCodeVaultNotFound,
-			"Failed to resolve vault",
-			err,
-		)
 	}
-	vaultOp.CompleteOperation(map[string]interface{}{
-		"vault_id":   vaultMetadata.ID,
-		"vault_name": vaultMetadata.Name,
-	})
 
-	a.logger.InfoSensitive("Vault resolved successfully",
-		"vault_id", vaultMetadata.ID,
-		"vault_name", vaultMetadata.Name)
-	vaultResource := audit.CreateVaultResource(vaultMetadata.ID, vaultMetadata.Name)
-	a.monitor.LogVaultEvent(audit.EventVaultAccess, audit.OutcomeSuccess, "Vault resolved successfully", vaultResource,
-		map[string]interface{}{
-			"vault_id":   vaultMetadata.ID,
-			"vault_name": vaultMetadata.Name,
+	if a.config.DryRun {
+		if err := a.reportDryRun(); err != nil {
+			mainOp.FailOperation(err)
+			return err
+		}
+		mainOp.CompleteOperation(map[string]interface{}{
+			"dry_run":        true,
+			"requests_count": len(requests),
 		})
+		// The summary lists every record as not attempted
+		succeeded = true
+		return nil
+	}
+
+	// Expand wildcard records up front, so that the summary lists each field
+	if secrets.HasWildcard(requests) {
+		err = a.withRetry(ctx, "expand_wildcards", func(ctx context.Context) error {
+			expanded, expandErr := a.secretsEngine.ExpandWildcards(ctx, requests)
+			if expandErr != nil {
+				return expandErr
+			}
+			requests = expanded
+			return nil
+		})
+		if err != nil {
+			mainOp.FailOperation(err)
+			var actionErr *errors.ActionableError
+			if stderrors.As(err, &actionErr) {
+				return actionErr
+			}
+			return errors.NewSecretError(
+				errors.ErrCodeSecretNotFound,
+				"Failed to list the fields of a wildcard record",
+				err,
+			)
+		}
+		a.logger.Info("Expanded wildcard records", "count", len(requests))
+	}
 
 	// Retrieve secrets using the engine
 	secretsOp := a.monitor.StartOperation("retrieve_secrets", map[string]interface{}{
 		"secrets_count": len(requests),
 	})
 	a.logger.Info("Retrieving secrets from 1Password")
-	result, err := a.secretsEngine.RetrieveSecrets(ctx, requests)
+	err = a.withRetry(ctx, "retrieve_secrets", func(ctx context.Context) error {
+		var retrieveErr error
+		result, retrieveErr = a.secretsEngine.RetrieveSecrets(ctx, requests)
+		return retrieveErr
+	})
 	if err != nil {
 		secretsOp.FailOperation(err)
+		a.runMetrics.Fetch = secretsOp.Duration()
 		mainOp.FailOperation(err)
 		secretResource := audit.CreateSecretResource("multiple", "various", vaultMetadata.Name)
 		a.monitor.LogSecretEvent(audit.EventSecretRequest, audit.OutcomeFailure, "Secret retrieval failed", secretResource,
 			map[string]interface{}{
 				"error": err.Error(),
 			})
+		if limitErr := rateLimitExhausted(err); limitErr != nil {
+			return limitErr
+		}
 		return errors.NewSecretError(
 			errors.ErrCodeSecretAccessDenied,
 			"Secret retrieval failed",
@@ -427,18 +658,27 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 		"error_count":   result.ErrorCount,
 		"duration_ms":   result.TotalDuration.Milliseconds(),
 	})
+	a.runMetrics.Fetch = secretsOp.Duration()
+	a.runMetrics.SecretsFetched = result.SuccessCount
 
 	a.logger.Info("Secrets retrieved successfully",
 		"success_count", result.SuccessCount,
 		"error_count", result.ErrorCount,
 		"duration", result.TotalDuration)
 
+	// In best-effort mode only the secrets that were retrieved are output,
+	// and the run fails afterwards naming the rest
+	delivered := result
+	if result.ErrorCount > 0 {
+		delivered = result.Successful()
+	}
+
 	// Process secrets and set outputs using the output manager
 	outputOp := a.monitor.StartOperation("process_outputs", map[string]interface{}{
 		"success_count": result.SuccessCount,
 	})
 	a.logger.Info("Processing secrets for output")
-	outputResult, err := a.outputManager.ProcessSecrets(result)
+	outputResult, err := a.outputManager.ProcessSecrets(delivered)
 	if err != nil {
 		outputOp.FailOperation(err)
 		mainOp.FailOperation(err)
@@ -448,6 +688,7 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 			err,
 		)
 	}
+	a.delivered = true
 	outputOp.CompleteOperation(map[string]interface{}{
 		"outputs_set":   outputResult.OutputsSet,
 		"env_vars_set":  outputResult.EnvVarsSet,
@@ -470,17 +711,23 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 		})
 	}
 
-	// Record component metrics
-	authMetrics := a.authManager.GetMetrics()
+	// Record component metrics; a run through Connect has no auth manager
 	secretsMetrics := a.secretsEngine.GetMetrics()
-
-	a.monitor.RecordComponentMetrics("auth_manager", authMetrics)
 	a.monitor.RecordComponentMetrics("secrets_engine", secretsMetrics)
+	completedArgs := []any{"secrets_metrics", secretsMetrics, "output_success", outputResult.Success}
+	if a.authManager != nil {
+		authMetrics := a.authManager.GetMetrics()
+		a.monitor.RecordComponentMetrics("auth_manager", authMetrics)
+		completedArgs = append([]any{"auth_metrics", authMetrics}, completedArgs...)
+	}
 
-	a.logger.Info("Operation completed successfully",
-		"auth_metrics", authMetrics,
-		"secrets_metrics", secretsMetrics,
-		"output_success", outputResult.Success)
+	a.logger.Info("Operation completed successfully", completedArgs...)
+
+	if result.ErrorCount > 0 {
+		partialErr := partialBatchError(result)
+		mainOp.FailOperation(partialErr)
+		return partialErr
+	}
 
 	// Complete main operation
 	mainOp.CompleteOperation(map[string]interface{}{
@@ -490,9 +737,197 @@ func (a *App) runWithMonitoring(ctx context.Context) error {
 		"values_masked": outputResult.ValuesMasked,
 	})
 
+	succeeded = true
 	return nil
 }
 
+// reportDryRun logs the records a run would fetch and where each value
+// would be delivered. It reads no secret values and sets no outputs.
+func (a *App) reportDryRun() error {
+	plan, err := a.Plan()
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Dry run: configuration, authentication and vault checks passed; no secrets were read",
+		"backend", plan.Backend,
+		"records", len(plan.Records))
+	if plan.CLI != nil {
+		a.logger.Info("Dry run: 1Password CLI verified", "version", plan.CLI.Version, "platform", plan.CLI.Platform)
+	}
+	for _, record := range plan.Records {
+		a.logger.InfoSensitive("Dry run: would fetch secret",
+			"key", record.Key,
+			"vault", record.Vault,
+			"item", record.Item,
+			"field", record.Field,
+			"targets", strings.Join(record.Targets, ","))
+	}
+	return nil
+}
+
+// ensureCLI downloads and verifies the 1Password CLI if needed
+func (a *App) ensureCLI(ctx context.Context, mainOp *monitoring.OperationContext) error {
+	cliOp := a.monitor.StartOperation("ensure_cli", nil)
+	a.logger.Info("Ensuring 1Password CLI is available")
+	if cliErr := a.cliManager.EnsureCLI(ctx); cliErr != nil {
+		cliOp.FailOperation(cliErr)
+		mainOp.FailOperation(cliErr)
+
+		// Enhanced error logging for CLI verification failures
+		errStr := cliErr.Error()
+		if strings.Contains(errStr, "SHA mismatch") || strings.Contains(errStr, "CLI verification failed") {
+			// Add platform information to the logger
+			a.logger.ErrorSensitive("CLI verification failed with platform details",
+				"error", cliErr,
+				"cli_version", a.cliManager.Version(),
+				"platform_os", runtime.GOOS,
+				"platform_arch", runtime.GOARCH,
+				"platform_combined", fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH),
+			)
+		}
+
+		if errors.IsErrorCode(cliErr, errors.ErrCodeCLIVersionMismatch) ||
+			errors.IsErrorCode(cliErr, errors.ErrCodeCLIVersionNotAllowed) {
+			return cliErr
+		}
+
+		if stderrors.Is(cliErr, cli.ErrOfflineCLIMissing) {
+			return errors.NewCLIError(
+				errors.ErrCodeCLINotFound,
+				"Offline mode requires a pre-installed 1Password CLI on PATH or at cli_path",
+				cliErr,
+			).WithSuggestions(
+				fmt.Sprintf("Install op %s on the runner and make sure it is on PATH, or set cli_path to it",
+					a.cliManager.Version()),
+				"Unset offline to let the action download the CLI",
+			)
+		}
+
+		if stderrors.Is(cliErr, cli.ErrCLIVerificationFailed) {
+			return errors.NewCLIError(
+				errors.ErrCodeCLIVerificationFailed,
+				"The 1Password CLI binary does not match the versions database checksum",
+				cliErr,
+			).WithContext("install_path", a.cliManager.GetBinaryPath()).WithSuggestions(
+				fmt.Sprintf("Reinstall op %s from 1Password, or remove the binary so the action downloads it",
+					a.cliManager.Version()),
+				"Check that nothing on the runner replaced or modified the op binary",
+			)
+		}
+
+		if stderrors.Is(cliErr, cli.ErrInstallDirNotWritable) {
+			return errors.NewCLIError(
+				errors.ErrCodeFileSystemError,
+				"The 1Password CLI install directory is not writable",
+				cliErr,
+			).WithContext("install_dir", a.cliManager.InstallDir()).WithSuggestions(
+				"Set cli_install_dir to a directory the runner user can write to",
+				"Unset cli_install_dir to install the CLI into the default cache directory",
+			)
+		}
+
+		if downloadErr, ok := cliErr.(*cli.DownloadError); ok {
+			err := errors.NewCLIError(
+				errors.ErrCodeCLIDownloadFailed,
+				fmt.Sprintf("Failed to download 1Password CLI after %d attempt(s)", downloadErr.Attempts),
+				downloadErr.Err,
+			).WithDetails(map[string]interface{}{
+				"attempts": downloadErr.Attempts,
+			})
+			if stderrors.Is(downloadErr, cli.ErrDownloadTimeout) {
+				err = err.WithSuggestions(
+					"Increase download_timeout for slow networks or mirrors",
+					"Check that the download mirror is reachable and responsive",
+				)
+			}
+			return err
+		}
+
+		return errors.NewCLIError(
+			errors.ErrCodeCLINotFound,
+			"Failed to ensure CLI availability",
+			cliErr,
+		)
+	}
+	cliOp.CompleteOperation(nil)
+	return nil
+}
+
+// partialBatchError reports the secrets a best-effort batch could not
+// retrieve after the others were output. It names the failed outputs and
+// wraps their errors, which never hold secret values.
+func partialBatchError(result *secrets.BatchResult) error {
+	failed := result.FailedKeys()
+	return errors.Wrap(
+		errors.ErrCodeBatchOperationFailed,
+		fmt.Sprintf("%d of %d secrets could not be retrieved: %s",
+			result.ErrorCount, result.ErrorCount+result.SuccessCount, strings.Join(failed, ", ")),
+		stderrors.Join(result.Errors...),
+	).WithDetails(map[string]interface{}{
+		"failed_keys":   failed,
+		"success_count": result.SuccessCount,
+	}).WithSuggestions(
+		"The outputs of the other secrets were set; steps using the failed ones should check they are present",
+		"Use batch_mode atomic to set no outputs unless every secret is retrieved",
+	)
+}
+
+// validateToken checks the service account token with the CLI right after
+// it is installed, so a revoked or expired token fails fast with a clear code.
+// A token with a fresh cached identity was validated by a recent run and is
+// not checked again.
+func (a *App) validateToken(ctx context.Context, mainOp *monitoring.OperationContext) error {
+	if a.authManager != nil && a.authManager.IdentityCached() {
+		a.logger.Debug("Skipping service account token validation, identity is cached")
+		return nil
+	}
+
+	tokenOp := a.monitor.StartOperation("validate_token", nil)
+	a.logger.Info("Validating service account token")
+	if tokenErr := a.withRetry(ctx, "validate_token", a.cliClient.ValidateToken); tokenErr != nil {
+		tokenOp.FailOperation(tokenErr)
+		mainOp.FailOperation(tokenErr)
+		a.monitor.LogAuthEvent(audit.EventAuthFailure, audit.OutcomeFailure, "Service account token validation failed", map[string]interface{}{
+			"error": tokenErr.Error(),
+		})
+		a.logger.ErrorSensitive("Service account token validation failed", "error", tokenErr)
+
+		var actionable *errors.ActionableError
+		if stderrors.As(tokenErr, &actionable) {
+			return actionable
+		}
+		return errors.NewAuthenticationError(
+			errors.ErrCodeAuthFailed,
+			"Failed to validate the service account token",
+			tokenErr,
+		)
+	}
+	tokenOp.CompleteOperation(nil)
+	return nil
+}
+
+// authenticate verifies the credentials of the configured backend
+func (a *App) authenticate(ctx context.Context) error {
+	if a.connectClient != nil {
+		return a.connectClient.Authenticate(ctx)
+	}
+	return a.authManager.Authenticate(ctx)
+}
+
+// resolveVault resolves a vault name or ID through the configured backend
+func (a *App) resolveVault(ctx context.Context, identifier string) (*auth.VaultMetadata, error) {
+	if a.connectClient == nil {
+		return a.authManager.ResolveVault(ctx, identifier)
+	}
+
+	vault, err := a.connectClient.ResolveVault(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.VaultMetadata{ID: vault.ID, Name: vault.Name}, nil
+}
+
 // GetVersionInfo returns version information using provided version data
 func GetVersionInfo(version, buildTime, gitCommit string) map[string]string {
 	return map[string]string{
@@ -514,6 +949,23 @@ func (a *App) Destroy() error {
 	var cleanupErrors []error
 
 	if a.outputManager != nil {
+		// Secret files outlive a run that delivered its outputs so later
+		// workflow steps can read them, even when a best-effort batch then
+		// fails naming the secrets it missed, unless cleanup_files asks for
+		// removal on exit; files written before delivery failed are always
+		// removed
+		if !a.delivered || a.config.CleanupFiles {
+			if err := a.outputManager.RemoveFiles(); err != nil {
+				cleanupErr := errors.Wrap(
+					errors.ErrCodeInternalError,
+					"Secret file cleanup failed",
+					err,
+				)
+				cleanupErrors = append(cleanupErrors, cleanupErr)
+				a.monitor.HandleError(cleanupErr, "Secret file cleanup", nil)
+			}
+		}
+
 		if err := a.outputManager.Destroy(); err != nil {
 			cleanupErr := errors.Wrap(
 				errors.ErrCodeInternalError,
@@ -549,6 +1001,18 @@ func (a *App) Destroy() error {
 		}
 	}
 
+	if a.connectClient != nil {
+		if err := a.connectClient.Destroy(); err != nil {
+			cleanupErr := errors.Wrap(
+				errors.ErrCodeInternalError,
+				"Connect client cleanup failed",
+				err,
+			)
+			cleanupErrors = append(cleanupErrors, cleanupErr)
+			a.monitor.HandleError(cleanupErr, "Connect client cleanup", nil)
+		}
+	}
+
 	if a.cliManager != nil {
 		if err := a.cliManager.Cleanup(); err != nil {
 			cleanupErr := errors.Wrap(
@@ -586,3 +1050,13 @@ func (a *App) Destroy() error {
 	a.logger.Debug("Application cleanup completed successfully")
 	return nil
 }
+
+// identityCacheDir returns the per-user directory used to persist successful
+// authentication between runs, or an empty string if none is available.
+func identityCacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "1password-secrets-action", "identity")
+}
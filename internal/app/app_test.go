@@ -5,17 +5,27 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/testdata"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
 func TestNew(t *testing.T) {
@@ -92,6 +102,57 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNew_ValidationProblems(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	cfg := createValidConfig(t)
+	cfg.ReturnType = "stdout"
+	cfg.MaxConcurrency = 50
+	cfg.CacheTTL = -1
+
+	app, err := New(cfg, createTestLogger(t))
+	require.Error(t, err)
+	assert.Nil(t, app)
+
+	// Every problem is listed in one error
+	appError, ok := err.(*errors.ActionableError)
+	require.True(t, ok, "Expected ActionableError")
+	assert.Equal(t, errors.ErrCodeInvalidConfig, appError.Code)
+	problems, ok := appError.Details["problems"].([]string)
+	require.True(t, ok, "Expected problems in the error details")
+	assert.Len(t, problems, 3)
+	assert.Contains(t, err.Error(), "max_concurrency")
+	assert.Contains(t, err.Error(), "cache_ttl")
+
+	// A malformed token is reported as such, alongside the rest
+	cfg.Token = "not-a-token"
+	_, err = New(cfg, createTestLogger(t))
+	appError, ok = err.(*errors.ActionableError)
+	require.True(t, ok, "Expected ActionableError")
+	assert.Equal(t, errors.ErrCodeTokenInvalid, appError.Code)
+	assert.Contains(t, err.Error(), "max_concurrency")
+}
+
+func TestNew_InvalidVersionsDB(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	dbPath := filepath.Join(t.TempDir(), "versions.yaml")
+	require.NoError(t, os.WriteFile(dbPath, []byte("schema_version: 2\nversions: {}\n"), 0o600))
+	t.Setenv("OP_SECRETS_ACTION_VERSIONS_FILE", dbPath)
+
+	app, err := New(createValidConfig(t), createTestLogger(t))
+	require.Error(t, err)
+	assert.Nil(t, app)
+
+	// A broken versions DB is not reported as a CLI or internal failure
+	appError, ok := err.(*errors.ActionableError)
+	require.True(t, ok, "Expected ActionableError, got %T", err)
+	assert.Equal(t, errors.ErrCodeVersionsDBInvalid, appError.Code)
+	assert.Contains(t, appError.Message, dbPath)
+}
+
 func TestApp_Run_SingleSecret(t *testing.T) {
 	// Set up GitHub Actions environment
 	setupGitHubActionsEnv(t)
@@ -228,6 +289,629 @@ func TestApp_PanicRecovery(t *testing.T) {
 
 // Helper functions
 
+func TestApp_Plan(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	cfg := createMultipleSecretsConfig(t)
+	cfg.ReturnType = config.ReturnTypeBoth
+	cfg.Transforms = map[string][]string{"api_key": {"trim"}}
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+	defer func() { _ = app.Destroy() }()
+
+	plan, err := app.Plan()
+	require.NoError(t, err)
+
+	assert.Equal(t, "cli", plan.Backend)
+	assert.Equal(t, app.cliManager.Version(), plan.CLI.Version)
+	assert.NotEmpty(t, plan.CLI.SHA256)
+	assert.Equal(t, plan.CLI.SHA256, app.cliManager.ExpectedSHA())
+	assert.Contains(t, plan.CLI.DownloadURL, "v"+plan.CLI.Version)
+	assert.Equal(t, runtime.GOOS+"_"+runtime.GOARCH, plan.CLI.Platform)
+
+	require.Len(t, plan.Records, 2)
+	assert.Equal(t, PlanRecord{
+		Key:        "api_key",
+		Vault:      "test-vault",
+		Item:       "api",
+		Field:      "key",
+		Targets:    []string{"output:api_key", "env:api_key"},
+		Transforms: []string{"trim"},
+	}, plan.Records[0])
+	assert.Equal(t, "db_password", plan.Records[1].Key)
+	assert.Equal(t, "database", plan.Records[1].Item)
+	assert.Equal(t, "password", plan.Records[1].Field)
+
+	assert.Equal(t, config.ReturnTypeBoth, plan.Policies.ReturnType)
+	assert.Equal(t, config.DefaultOutputSchemaVersion, plan.Policies.OutputSchemaVersion)
+	assert.Equal(t, cfg.MaxConcurrency, plan.Policies.MaxConcurrency)
+	assert.True(t, plan.Policies.AtomicOperations)
+	assert.Equal(t, config.BatchModeAtomic, plan.Policies.BatchMode)
+	assert.Equal(t, 30, plan.Policies.RecordTimeout)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), cfg.Token)
+
+	// A best-effort run delivers what it can, so it is not atomic
+	cfg.BatchMode = config.BatchModeBestEffort
+	cfg.RecordTimeout = 45
+	plan, err = app.Plan()
+	require.NoError(t, err)
+	assert.False(t, plan.Policies.AtomicOperations)
+	assert.Equal(t, config.BatchModeBestEffort, plan.Policies.BatchMode)
+	assert.Equal(t, 45, plan.Policies.RecordTimeout)
+}
+
+func TestApp_Preflight(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	dir := t.TempDir()
+	cfg := createSingleSecretConfig(t)
+	cfg.GitHubOutput = filepath.Join(dir, "github_output")
+	require.NoError(t, os.WriteFile(cfg.GitHubOutput, nil, 0600))
+	cfg.GitHubEnv = ""
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+	defer func() { _ = app.Destroy() }()
+
+	report, err := app.Preflight(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Passed, "failed checks: %+v", report.Failed())
+	require.Len(t, report.Checks, 5)
+	for i, name := range []string{PreflightGitHubOutput, PreflightGitHubEnv, PreflightToken, PreflightPlatform, PreflightCLI} {
+		assert.Equal(t, name, report.Checks[i].Name)
+		assert.Empty(t, report.Checks[i].Remediation)
+	}
+	assert.Contains(t, report.Checks[1].Message, "not needed")
+	assert.NoError(t, report.Err())
+
+	// A missing file is created by the output manager, but not in a
+	// directory that does not exist
+	cfg.GitHubOutput = filepath.Join(dir, "missing", "github_output")
+	cfg.ReturnType = config.ReturnTypeBoth
+	report, err = app.Preflight(context.Background())
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+	failed := report.Failed()
+	require.Len(t, failed, 2)
+	assert.Equal(t, PreflightGitHubOutput, failed[0].Name)
+	assert.Equal(t, errors.ErrCodeFileSystemError, failed[0].Code)
+	assert.NotEmpty(t, failed[0].Remediation)
+	assert.Equal(t, PreflightGitHubEnv, failed[1].Name)
+	assert.Equal(t, errors.ErrCodeEnvironmentMissing, failed[1].Code)
+	assert.True(t, errors.IsErrorCode(report.Err(), errors.ErrCodeFileSystemError))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = app.Preflight(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDoctor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the op binary")
+	}
+	t.Setenv("OP_SECRETS_ACTION_VERSIONS_FILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	secret := "ops_" + strings.Repeat("s", 40)
+	t.Setenv("INPUT_TOKEN", "")
+	t.Setenv("OP_TOKEN", secret)
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(t.TempDir(), "output"))
+	t.Setenv("GITHUB_ENV", "")
+
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir)
+	report := Doctor()
+	assert.False(t, report.CLI.Found)
+	assert.True(t, report.VersionsDB.Loaded, report.VersionsDB.Error)
+	assert.Contains(t, report.VersionsDB.Versions, cli.DefaultCLIVersion)
+	assert.Equal(t, DoctorToken{Set: true, Source: "OP_TOKEN"}, report.Token)
+	assert.False(t, report.Ready, "GITHUB_ENV is not set")
+
+	// An op binary that is not the one the DB vouches for
+	script := "#!/bin/sh\necho " + cli.DefaultCLIVersion + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "op"), []byte(script), 0o700)) // #nosec G306 -- test executable
+	t.Setenv("GITHUB_ENV", filepath.Join(t.TempDir(), "env"))
+	report = Doctor()
+	assert.True(t, report.CLI.Found)
+	assert.Equal(t, cli.DefaultCLIVersion, report.CLI.Version)
+	if report.Platform.Error == "" {
+		assert.Equal(t, DoctorChecksumMismatch, report.CLI.Checksum)
+	}
+	assert.False(t, report.Ready)
+
+	var text strings.Builder
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "token: set in OP_TOKEN")
+	assert.Contains(t, text.String(), "Not ready to run")
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	for _, out := range []string{text.String(), string(data)} {
+		assert.NotContains(t, out, secret)
+	}
+}
+
+func TestApp_ConnectBackend(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	cfg := createSingleSecretConfig(t)
+	cfg.Token = ""
+	cfg.ConnectHost = "https://connect.example.com"
+	cfg.ConnectToken = "connect-test-token"
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+	defer func() { _ = app.Destroy() }()
+
+	assert.NotNil(t, app.connectClient)
+	assert.Nil(t, app.cliManager)
+	assert.Nil(t, app.authManager)
+	assert.NotNil(t, app.secretsEngine)
+
+	plan, err := app.Plan()
+	require.NoError(t, err)
+	assert.Equal(t, "connect", plan.Backend)
+	assert.Nil(t, plan.CLI)
+	assert.Equal(t, cfg.ConnectHost, plan.Connect.Host)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), cfg.ConnectToken)
+}
+
+func TestApp_Run_DryRun(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	var itemRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/vaults" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"id":"abcdefghijklmnopqrstuvwxyz","name":"test-vault"}]`))
+			return
+		}
+		itemRequests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	outputFile := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(outputFile, nil, 0600))
+
+	cfg := createMultipleSecretsConfig(t)
+	cfg.Token = ""
+	cfg.ConnectHost = server.URL
+	cfg.ConnectToken = "connect-test-token"
+	cfg.GitHubOutput = outputFile
+	cfg.DryRun = true
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+	defer func() { _ = app.Destroy() }()
+
+	require.NoError(t, app.Run(context.Background()))
+	assert.Zero(t, itemRequests, "a dry run must not read items")
+
+	written, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Empty(t, written, "a dry run must not set outputs")
+}
+
+// newFakeConnect serves a Connect vault "test-vault" holding only an item
+// "database" with a password field.
+func newFakeConnect(t *testing.T) *httptest.Server {
+	t.Helper()
+	const vaultID, itemID = "abcdefghijklmnopqrstuvwxy1", "abcdefghijklmnopqrstuvwxy2"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/vaults":
+			_, _ = fmt.Fprintf(w, `[{"id":%q,"name":"test-vault"}]`, vaultID)
+		case "/v1/vaults/" + vaultID + "/items":
+			if r.URL.Query().Get("filter") != `title eq "database"` {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `[{"id":%q,"title":"database"}]`, itemID)
+		case "/v1/vaults/" + vaultID + "/items/" + itemID:
+			_, _ = fmt.Fprintf(w, `{"id":%q,"title":"database","fields":[{"id":"password","label":"password","value":"s3cr3t"}]}`, itemID)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// connectFileConfig returns a config reading record through server and
+// writing the values as files under a temporary workspace.
+func connectFileConfig(t *testing.T, server *httptest.Server, record string) *config.Config {
+	t.Helper()
+	outputFile := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(outputFile, nil, 0600))
+
+	cfg := createSingleSecretConfig(t)
+	cfg.Token = ""
+	cfg.Record = record
+	cfg.ConnectHost = server.URL
+	cfg.ConnectToken = "connect-test-token"
+	cfg.ReturnType = config.ReturnTypeFile
+	cfg.GitHubWorkspace = t.TempDir()
+	cfg.GitHubOutput = outputFile
+	return cfg
+}
+
+func TestApp_Run_Connect(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	cfg := connectFileConfig(t, newFakeConnect(t), "database/password")
+	workspace, outputFile := cfg.GitHubWorkspace, cfg.GitHubOutput
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+
+	require.NoError(t, app.Run(context.Background()))
+	assert.True(t, app.delivered, "a successful Connect run must record its outputs as delivered")
+	assert.EqualValues(t, 0, app.monitor.GetMetrics()["errors_recorded"], "no panic may be recovered")
+	require.NoError(t, app.Destroy())
+
+	// The secret file outlives the run for later steps to read
+	secretsDir := filepath.Join(workspace, ".1password-secrets")
+	entries, err := os.ReadDir(secretsDir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	written, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), secretsDir)
+}
+
+func TestApp_Run_BestEffortKeepsDeliveredFiles(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	cfg := connectFileConfig(t, newFakeConnect(t), `{"db_password": "database/password", "api_key": "missing/key"}`)
+	cfg.BatchMode = config.BatchModeBestEffort
+
+	app, err := New(cfg, createTestLogger(t))
+	require.NoError(t, err)
+
+	err = app.Run(context.Background())
+	require.Error(t, err, "a partial best-effort batch fails the run")
+	assert.True(t, app.delivered)
+	require.NoError(t, app.Destroy())
+
+	// The delivered secret's file is still there for later steps
+	entries, err := os.ReadDir(filepath.Join(cfg.GitHubWorkspace, ".1password-secrets"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Contains(t, strings.Join(names, " "), "db_password")
+}
+
+func TestApp_Run_CanceledDuringDownload(t *testing.T) {
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+
+	// The download server sends part of the archive and then stalls
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		_, _ = w.Write([]byte("PK"))
+		w.(http.Flusher).Flush()
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+
+	app, err := New(createValidConfig(t), createTestLogger(t))
+	require.NoError(t, err)
+	defer func() { _ = app.Destroy() }()
+
+	app.cliManager, err = cli.NewManager(&cli.Config{
+		CacheDir:        t.TempDir(),
+		Timeout:         30 * time.Second,
+		DownloadTimeout: 30 * time.Second,
+		Version:         cli.DefaultCLIVersion,
+		TestMode:        true,
+		ExpectedSHA:     strings.Repeat("a", 64),
+		MaxAttempts:     3,
+		RetryTimeout:    30 * time.Second,
+	})
+	require.NoError(t, err)
+	app.cliManager.SetDownloadURL(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	begin := time.Now()
+	err = app.Run(ctx)
+	require.Error(t, err)
+	assert.Less(t, time.Since(begin), 5*time.Second, "Run should return promptly once canceled")
+
+	appError, ok := err.(*errors.ActionableError)
+	require.True(t, ok, "Expected ActionableError, got %T", err)
+	assert.Equal(t, errors.ErrCodeCanceled, appError.Code)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestApp_ValidateToken_CachedIdentity(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the op binary")
+	}
+	setupGitHubActionsEnv(t)
+	defer cleanupGitHubActionsEnv(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// The op binary records each command it runs
+	binDir := t.TempDir()
+	callLog := filepath.Join(binDir, "calls")
+	script := "#!/bin/sh\necho \"$1\" >> '" + callLog + "'\n" +
+		"case \"$1\" in\n" +
+		"whoami) echo '{\"user_type\":\"SERVICE_ACCOUNT\"}' ;;\n" +
+		"account) echo '[]' ;;\n" +
+		"esac\n"
+	opPath := filepath.Join(binDir, "op")
+	require.NoError(t, os.WriteFile(opPath, []byte(script), 0o700)) // #nosec G306 -- test executable
+
+	whoamiCalls := func() int {
+		data, err := os.ReadFile(callLog) // #nosec G304 -- test file
+		if os.IsNotExist(err) {
+			return 0
+		}
+		require.NoError(t, err)
+		return strings.Count(string(data), "whoami\n")
+	}
+
+	// run simulates a separate action invocation on the same runner
+	run := func() {
+		cfg := createSingleSecretConfig(t)
+		cfg.CacheEnabled = true
+		app, err := New(cfg, createTestLogger(t))
+		require.NoError(t, err)
+		defer func() { _ = app.Destroy() }()
+		app.cliManager.SetBinaryPath(opPath)
+
+		ctx := context.Background()
+		mainOp := app.monitor.StartOperation("run", nil)
+		require.NoError(t, app.validateToken(ctx, mainOp))
+		require.NoError(t, app.authenticate(ctx))
+	}
+
+	run()
+	require.Equal(t, 1, whoamiCalls())
+
+	run()
+	assert.Equal(t, 1, whoamiCalls(), "a cached identity should skip whoami")
+}
+
+func TestRetry(t *testing.T) {
+	log := createTestLogger(t)
+	policy := retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, timeout: time.Second}
+	transient := errors.Wrap(errors.ErrCodeNetworkError, "connection reset", nil)
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		calls := 0
+		err := retry(context.Background(), log, policy, "test", func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		calls := 0
+		err := retry(context.Background(), log, policy, "test", func(context.Context) error {
+			calls++
+			return transient
+		})
+		assert.Equal(t, transient, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("never retries an invalid token", func(t *testing.T) {
+		calls := 0
+		invalid := errors.NewAuthenticationError(errors.ErrCodeTokenInvalid, "Invalid token", transient)
+		err := retry(context.Background(), log, policy, "test", func(context.Context) error {
+			calls++
+			return invalid
+		})
+		assert.Equal(t, invalid, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("honors retry-after over backoff", func(t *testing.T) {
+		calls := 0
+		slowBackoff := retryPolicy{maxAttempts: 3, baseDelay: time.Minute, timeout: time.Second}
+		limited := errors.New(errors.ErrCodeRateLimited, "rate limited").WithRetryAfter(time.Millisecond)
+		err := retry(context.Background(), log, slowBackoff, "test", func(context.Context) error {
+			calls++
+			if calls < 2 {
+				return limited
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("bounded by retry timeout", func(t *testing.T) {
+		calls := 0
+		slow := retryPolicy{maxAttempts: 10, baseDelay: 40 * time.Millisecond, timeout: 50 * time.Millisecond}
+		err := retry(context.Background(), log, slow, "test", func(context.Context) error {
+			calls++
+			return transient
+		})
+		assert.Error(t, err)
+		assert.Less(t, calls, 10)
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"nil", context.Background(), nil, false},
+		{"network code", context.Background(), errors.New(errors.ErrCodeNetworkError, "down"), true},
+		{"rate limited", context.Background(), errors.New(errors.ErrCodeRateLimited, "slow down"), true},
+		{"token invalid", context.Background(), errors.Wrap(errors.ErrCodeTokenInvalid, "bad", context.DeadlineExceeded), false},
+		{"not found", context.Background(), errors.New(errors.ErrCodeSecretNotFound, "missing"), false},
+		{"cli network message", context.Background(), fmt.Errorf("authentication failed with exit code 1: dial tcp: connection refused"), true},
+		{"attempt deadline", context.Background(), fmt.Errorf("op: %w", context.DeadlineExceeded), true},
+		{"parent canceled", canceled, errors.New(errors.ErrCodeNetworkError, "down"), false},
+		{"plain failure", context.Background(), fmt.Errorf("item not found"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isRetryable(tt.ctx, tt.err))
+		})
+	}
+}
+
+func TestRateLimitExhausted(t *testing.T) {
+	assert.Nil(t, rateLimitExhausted(fmt.Errorf("item not found")))
+	assert.Nil(t, rateLimitExhausted(errors.New(errors.ErrCodeNetworkError, "down")))
+
+	limited := errors.New(errors.ErrCodeRateLimited, "slow down").WithRetryAfter(time.Second)
+	err := rateLimitExhausted(fmt.Errorf("batch: %w", limited))
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeRateLimited))
+	assert.NotEmpty(t, err.(*errors.ActionableError).GetSuggestions())
+}
+
+func TestPartialBatchError(t *testing.T) {
+	notFound := errors.New(errors.ErrCodeSecretNotFound, "item not found")
+	result := &secrets.BatchResult{
+		Results: map[string]*secrets.SecretResult{
+			"db_password": {},
+			"optional_b":  {Error: notFound},
+			"optional_a":  {Error: notFound},
+		},
+		SuccessCount: 1,
+		ErrorCount:   2,
+		Errors:       []error{notFound, notFound},
+	}
+
+	err := partialBatchError(result)
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeBatchOperationFailed))
+	assert.Contains(t, err.Error(), "2 of 3 secrets could not be retrieved: optional_a, optional_b")
+	assert.ErrorIs(t, err, notFound)
+}
+
+func TestWriteSummary(t *testing.T) {
+	path := t.TempDir() + "/audit/summary.json"
+
+	// Zero requests still yield a valid document with an empty list
+	require.NoError(t, WriteSummary(path, buildSummary(nil, nil, true)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var empty map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &empty))
+	assert.Equal(t, []interface{}{}, empty["secrets"])
+
+	value, err := security.NewSecureStringFromString("summary-secret-value")
+	require.NoError(t, err)
+	defer func() { _ = value.Destroy() }()
+
+	requests := []*secrets.SecretRequest{
+		{Key: "db_password", Vault: "Production", ItemName: "Database", FieldName: "password"},
+		{Key: "api_key", Vault: "Production", ItemName: "API", FieldName: "credential"},
+		{Key: "unused", Vault: "Production", ItemName: "Other", FieldName: "token"},
+	}
+	result := &secrets.BatchResult{Results: map[string]*secrets.SecretResult{
+		"db_password": {Request: requests[0], Value: value,
+			Metrics: &secrets.RetrievalMetrics{Duration: 1500 * time.Millisecond}},
+		"api_key": {Request: requests[1], Error: fmt.Errorf("item not found"),
+			Metrics: &secrets.RetrievalMetrics{}},
+	}}
+
+	require.NoError(t, WriteSummary(path, buildSummary(requests, result, false)))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "summary-secret-value")
+
+	var summary Summary
+	require.NoError(t, json.Unmarshal(data, &summary))
+	assert.False(t, summary.Success)
+	assert.Equal(t, []SummaryEntry{
+		{Output: "db_password", Vault: "Production", Item: "Database", Field: "password",
+			Status: SummaryStatusResolved, DurationMs: 1500},
+		{Output: "api_key", Vault: "Production", Item: "API", Field: "credential",
+			Status: SummaryStatusFailed},
+		{Output: "unused", Vault: "Production", Item: "Other", Field: "token",
+			Status: SummaryStatusNotAttempted},
+	}, summary.Secrets)
+}
+
+func TestStepSummaryMarkdown(t *testing.T) {
+	summary := &Summary{Secrets: []SummaryEntry{
+		{Output: "db_password", Vault: "Production", Item: "Database", Status: SummaryStatusResolved},
+		{Output: "api_key", Vault: "Prod|Vault", Item: "API\nKey", Status: SummaryStatusFailed},
+		{Output: "unused", Vault: "Production", Item: "Other", Status: SummaryStatusNotAttempted},
+	}}
+
+	markdown := StepSummaryMarkdown(summary)
+	lines := strings.Split(strings.TrimSuffix(markdown, "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "| `db_password` | Production/Database | `***` | ✅ Succeeded |", lines[2])
+	assert.Equal(t, "| `api_key` | Prod\\|Vault/API Key | `***` | ❌ Failed |", lines[3])
+	assert.Contains(t, lines[4], "Not attempted")
+
+	empty := StepSummaryMarkdown(&Summary{})
+	assert.Contains(t, empty, "No secrets requested")
+}
+
+func TestMetricsMarkdown(t *testing.T) {
+	markdown := MetricsMarkdown(&RunMetrics{
+		CLIVersion:     "2.30.0",
+		CLISource:      cli.SourceCached,
+		SecretsFetched: 3,
+		Total:          1500 * time.Millisecond,
+		Auth:           250400 * time.Microsecond,
+	})
+	lines := strings.Split(strings.TrimSuffix(markdown, "\n"), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, "| CLI version | 2.30.0 |", lines[2])
+	assert.Equal(t, "| CLI binary | cached |", lines[3])
+	assert.Equal(t, "| Secrets fetched | 3 |", lines[4])
+	assert.Equal(t, "| Total duration | 1.5s |", lines[5])
+	assert.Equal(t, "| Authentication | 250ms |", lines[6])
+	assert.Equal(t, "| Secret retrieval | - |", lines[7])
+
+	// A run through Connect uses no CLI
+	connect := MetricsMarkdown(&RunMetrics{})
+	assert.Contains(t, connect, "| CLI version | - |")
+	assert.Contains(t, connect, "| CLI binary | - |")
+}
+
 func createValidConfig(_ *testing.T) *config.Config {
 	return &config.Config{
 		Token:           testdata.ValidDummyToken,
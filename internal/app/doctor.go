@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package app
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
+)
+
+// Checksum statuses of the op binary found on PATH.
+const (
+	DoctorChecksumVerified    = "verified"
+	DoctorChecksumMismatch    = "mismatch"
+	DoctorChecksumUnknown     = "unknown"
+	DoctorChecksumNotVerified = "not_verified"
+)
+
+// doctorRequiredEnv lists the GitHub Actions variables a run writes to.
+var doctorRequiredEnv = []string{"GITHUB_OUTPUT", "GITHUB_ENV"}
+
+// doctorOptionalEnv lists GitHub Actions variables reported for context.
+var doctorOptionalEnv = []string{"GITHUB_ACTIONS", "GITHUB_WORKSPACE", "RUNNER_TEMP"}
+
+// doctorTokenEnv lists the variables a token is read from, in order of
+// precedence.
+var doctorTokenEnv = []string{"INPUT_TOKEN", "OP_TOKEN", "INPUT_CONNECT_TOKEN", "OP_CONNECT_TOKEN"}
+
+// DoctorReport describes whether the environment is ready to run the
+// action, for telling auth, CLI and config problems apart. It needs no
+// configuration and never contains the token or any secret: environment
+// variables are only reported as set or not.
+type DoctorReport struct {
+	Ready       bool             `json:"ready"`
+	Platform    DoctorPlatform   `json:"platform"`
+	VersionsDB  DoctorVersionsDB `json:"versions_db"`
+	CLI         DoctorCLI        `json:"cli"`
+	Token       DoctorToken      `json:"token"`
+	Environment []DoctorEnvVar   `json:"environment"`
+}
+
+// DoctorPlatform is the runner platform and its versions DB key.
+type DoctorPlatform struct {
+	Platform string `json:"platform"`
+	Key      string `json:"key,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DoctorVersionsDB is the versions DB in effect and the CLI versions it
+// knows, newest first.
+type DoctorVersionsDB struct {
+	Loaded   bool     `json:"loaded"`
+	Path     string   `json:"path,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// DoctorCLI is the op binary found on PATH, if any, and whether it matches
+// the versions DB. A missing binary is not a problem, as the action
+// downloads the CLI unless it runs offline.
+type DoctorCLI struct {
+	Found    bool   `json:"found"`
+	Path     string `json:"path,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DoctorToken reports whether a token is set and in which variable.
+type DoctorToken struct {
+	Set    bool   `json:"set"`
+	Source string `json:"source,omitempty"`
+}
+
+// DoctorEnvVar reports whether an environment variable is set.
+type DoctorEnvVar struct {
+	Name     string `json:"name"`
+	Set      bool   `json:"set"`
+	Required bool   `json:"required"`
+}
+
+// Doctor inspects the environment without configuration, authentication
+// or fetching secrets. The op binary on PATH is run with --version to learn
+// which checksum to verify it against. The versions DB is installed when
+// missing, as on a normal run.
+func Doctor() *DoctorReport {
+	report := &DoctorReport{
+		Platform:   doctorPlatform(),
+		VersionsDB: doctorVersionsDB(),
+		CLI:        doctorCLI(),
+		Token:      doctorToken(),
+	}
+
+	envReady := true
+	for _, name := range doctorRequiredEnv {
+		set := os.Getenv(name) != ""
+		envReady = envReady && set
+		report.Environment = append(report.Environment, DoctorEnvVar{Name: name, Set: set, Required: true})
+	}
+	for _, name := range doctorOptionalEnv {
+		report.Environment = append(report.Environment, DoctorEnvVar{Name: name, Set: os.Getenv(name) != ""})
+	}
+
+	report.Ready = report.Platform.Error == "" && report.VersionsDB.Loaded &&
+		report.CLI.Checksum != DoctorChecksumMismatch && report.Token.Set && envReady
+	return report
+}
+
+// doctorPlatform computes the versions DB key of this platform.
+func doctorPlatform() DoctorPlatform {
+	platform := DoctorPlatform{Platform: runtime.GOOS + "_" + runtime.GOARCH}
+	key, err := cli.ComputePlatformKey(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		platform.Error = err.Error()
+		return platform
+	}
+	platform.Key = key
+	return platform
+}
+
+// doctorVersionsDB loads the versions DB in effect.
+func doctorVersionsDB() DoctorVersionsDB {
+	db, path, err := cli.LoadOrInstallDB()
+	info := DoctorVersionsDB{Path: path}
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Loaded = true
+	for version := range db.Versions {
+		info.Versions = append(info.Versions, version)
+	}
+	sort.Slice(info.Versions, func(i, j int) bool {
+		a, errA := cli.ParseVersion(info.Versions[i])
+		b, errB := cli.ParseVersion(info.Versions[j])
+		if errA != nil || errB != nil {
+			return info.Versions[i] > info.Versions[j]
+		}
+		return a.Compare(b) > 0
+	})
+	return info
+}
+
+// doctorCLI finds op on PATH and verifies it against the versions DB.
+func doctorCLI() DoctorCLI {
+	binaryName := "op"
+	if runtime.GOOS == "windows" {
+		binaryName = "op.exe"
+	}
+	path, err := exec.LookPath(binaryName)
+	if err != nil {
+		return DoctorCLI{}
+	}
+
+	info := DoctorCLI{Found: true, Path: path, Checksum: DoctorChecksumNotVerified}
+	version, err := cli.DetectCLIVersion(path)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Version = version
+
+	err = cli.VerifyFileChecksum(path, version)
+	switch {
+	case err == nil:
+		info.Checksum = DoctorChecksumVerified
+	case stderrors.Is(err, cli.ErrChecksumMismatch):
+		info.Checksum = DoctorChecksumMismatch
+		info.Error = err.Error()
+	case stderrors.Is(err, cli.ErrUnsupportedVersion), stderrors.Is(err, cli.ErrPlatformChecksumMissing):
+		info.Checksum = DoctorChecksumUnknown
+		info.Error = err.Error()
+	default:
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// doctorToken reports the first variable holding a token.
+func doctorToken() DoctorToken {
+	for _, name := range doctorTokenEnv {
+		if strings.TrimSpace(os.Getenv(name)) != "" {
+			return DoctorToken{Set: true, Source: name}
+		}
+	}
+	return DoctorToken{}
+}
+
+// WriteText writes the report for people to read.
+func (r *DoctorReport) WriteText(w io.Writer) error {
+	var b strings.Builder
+	status := func(ok bool) string {
+		if ok {
+			return "ok  "
+		}
+		return "FAIL"
+	}
+
+	fmt.Fprintf(&b, "[%s] platform: %s", status(r.Platform.Error == ""), r.Platform.Platform)
+	if r.Platform.Error != "" {
+		fmt.Fprintf(&b, " (%s)", r.Platform.Error)
+	} else {
+		fmt.Fprintf(&b, " (key %s)", r.Platform.Key)
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "[%s] versions DB: %s", status(r.VersionsDB.Loaded), r.VersionsDB.Path)
+	if r.VersionsDB.Loaded {
+		fmt.Fprintf(&b, " (versions: %s)", strings.Join(r.VersionsDB.Versions, ", "))
+	} else {
+		fmt.Fprintf(&b, " (%s)", r.VersionsDB.Error)
+	}
+	b.WriteString("\n")
+
+	switch {
+	case !r.CLI.Found:
+		b.WriteString("[ok  ] op on PATH: not found, the action downloads the CLI\n")
+	case r.CLI.Version == "":
+		fmt.Fprintf(&b, "[ok  ] op on PATH: %s (%s)\n", r.CLI.Path, r.CLI.Error)
+	default:
+		fmt.Fprintf(&b, "[%s] op on PATH: %s version %s, checksum %s\n",
+			status(r.CLI.Checksum != DoctorChecksumMismatch), r.CLI.Path, r.CLI.Version, r.CLI.Checksum)
+	}
+
+	if r.Token.Set {
+		fmt.Fprintf(&b, "[ok  ] token: set in %s\n", r.Token.Source)
+	} else {
+		fmt.Fprintf(&b, "[FAIL] token: not set (%s)\n", strings.Join(doctorTokenEnv, ", "))
+	}
+
+	for _, env := range r.Environment {
+		state := "set"
+		if !env.Set {
+			state = "not set"
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", status(env.Set || !env.Required), env.Name, state)
+	}
+
+	if r.Ready {
+		b.WriteString("Ready to run\n")
+	} else {
+		b.WriteString("Not ready to run\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
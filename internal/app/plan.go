@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/output"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
+)
+
+// Plan is the fully resolved execution plan for a run. It is built without
+// downloading the CLI, authenticating or fetching secrets, and never contains
+// secret values, so it is safe to publish for review.
+type Plan struct {
+	Backend  string       `json:"backend"` // "cli" or "connect"
+	CLI      *PlanCLI     `json:"cli,omitempty"`
+	Connect  *PlanConnect `json:"connect,omitempty"`
+	Records  []PlanRecord `json:"records"`
+	Policies PlanPolicies `json:"policies"`
+}
+
+// PlanCLI describes the 1Password CLI the run would install.
+type PlanCLI struct {
+	Version     string `json:"version"`
+	SHA256      string `json:"sha256"`
+	SHA512      string `json:"sha512,omitempty"`
+	DownloadURL string `json:"download_url"`
+	Platform    string `json:"platform"`
+}
+
+// PlanConnect describes the 1Password Connect server the run would use.
+type PlanConnect struct {
+	Host string `json:"host"`
+}
+
+// PlanRecord describes how a single record would be resolved and delivered.
+type PlanRecord struct {
+	Key        string   `json:"key"`
+	Vault      string   `json:"vault"`
+	Item       string   `json:"item"`
+	Field      string   `json:"field"`
+	Targets    []string `json:"targets"`
+	Transforms []string `json:"transforms,omitempty"`
+}
+
+// PlanPolicies lists the settings that govern the run.
+type PlanPolicies struct {
+	ReturnType          string   `json:"return_type"`
+	OutputSchemaVersion int      `json:"output_schema_version"`
+	SecretsDir          string   `json:"secrets_dir,omitempty"`
+	MaskAllSecrets      bool     `json:"mask_all_secrets"`
+	AtomicOperations    bool     `json:"atomic_operations"`
+	BatchMode           string   `json:"batch_mode"`
+	CacheEnabled        bool     `json:"cache_enabled"`
+	CacheTTL            int      `json:"cache_ttl"`
+	CacheNegative       bool     `json:"cache_negative"`
+	BatchRead           bool     `json:"batch_read"`
+	MaxConcurrency      int      `json:"max_concurrency"`
+	Timeout             int      `json:"timeout"`
+	RetryTimeout        int      `json:"retry_timeout"`
+	RecordTimeout       int      `json:"record_timeout"`
+	DownloadMaxAttempts int      `json:"download_max_attempts"`
+	ExecFallbackDir     string   `json:"exec_fallback_dir,omitempty"`
+	CLIInstallDir       string   `json:"cli_install_dir,omitempty"`
+	SkipChecksum        bool     `json:"skip_checksum_verification,omitempty"`
+	AllowedCLIVersions  []string `json:"allowed_cli_versions,omitempty"`
+	VaultPriority       []string `json:"vault_priority,omitempty"`
+}
+
+// Plan resolves the execution plan for the configured records without
+// performing any network or vault access.
+func (a *App) Plan() (*Plan, error) {
+	requests, err := secrets.ParseRecordsToRequests(a.config)
+	if err != nil {
+		return nil, errors.NewConfigurationError(
+			errors.ErrCodeInvalidRecord,
+			"Failed to parse secret requests",
+			err,
+		)
+	}
+
+	var secretsDir string
+	if a.config.ReturnType == config.ReturnTypeFile {
+		secretsDir, err = output.ResolveSecretsDir(a.config)
+		if err != nil {
+			return nil, errors.NewConfigurationError(
+				errors.ErrCodeInvalidConfig,
+				"Failed to resolve secrets directory",
+				err,
+			)
+		}
+	}
+
+	records := make([]PlanRecord, 0, len(requests))
+	for _, req := range requests {
+		records = append(records, PlanRecord{
+			Key:        req.Key,
+			Vault:      req.Vault,
+			Item:       req.ItemName,
+			Field:      req.FieldName,
+			Targets:    planTargets(a.config, req.Key, secretsDir),
+			Transforms: req.Transforms,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+
+	schemaVersion := a.config.OutputSchemaVersion
+	if schemaVersion == 0 {
+		schemaVersion = config.DefaultOutputSchemaVersion
+	}
+
+	batchMode := a.config.EffectiveBatchMode()
+	plan := &Plan{
+		Backend: "cli",
+		Records: records,
+		Policies: PlanPolicies{
+			ReturnType:          a.config.ReturnType,
+			OutputSchemaVersion: schemaVersion,
+			SecretsDir:          secretsDir,
+			MaskAllSecrets:      true,
+			AtomicOperations:    batchMode != config.BatchModeBestEffort,
+			BatchMode:           batchMode,
+			CacheEnabled:        a.config.CacheEnabled,
+			CacheTTL:            a.config.CacheTTL,
+			CacheNegative:       a.config.CacheNegative,
+			BatchRead:           a.config.BatchRead,
+			MaxConcurrency:      a.config.MaxConcurrency,
+			Timeout:             a.config.Timeout,
+			RetryTimeout:        a.config.RetryTimeout,
+			RecordTimeout:       int(a.config.GetTimeout("record") / time.Second),
+			DownloadMaxAttempts: a.config.DownloadMaxAttempts,
+			ExecFallbackDir:     a.config.ExecFallbackDir,
+			CLIInstallDir:       a.config.CLIInstallDir,
+			SkipChecksum:        a.config.SkipChecksumVerification,
+			AllowedCLIVersions:  a.config.AllowedCLIVersions,
+			VaultPriority:       a.config.VaultPriority,
+		},
+	}
+
+	if a.connectClient != nil {
+		plan.Backend = "connect"
+		plan.Connect = &PlanConnect{Host: a.config.ConnectHost}
+	} else {
+		plan.CLI = &PlanCLI{
+			Version:     a.cliManager.Version(),
+			SHA256:      a.cliManager.ExpectedSHA(),
+			SHA512:      a.cliManager.ExpectedSHA512(),
+			DownloadURL: a.cliManager.DownloadURL(),
+			Platform:    fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH),
+		}
+	}
+	return plan, nil
+}
+
+// planTargets lists where a record's value would be delivered, e.g.
+// "output:db_password" or "file:/work/.1password-secrets/db_password".
+func planTargets(cfg *config.Config, key, secretsDir string) []string {
+	switch cfg.ReturnType {
+	case config.ReturnTypeEnv:
+		return []string{"env:" + key}
+	case config.ReturnTypeBoth:
+		return []string{"output:" + key, "env:" + key}
+	case config.ReturnTypeFile:
+		return []string{"file:" + output.SecretFilePath(secretsDir, key)}
+	case config.ReturnTypeJSON:
+		if cfg.JSONEnv {
+			return []string{"output:" + output.SecretsJSONOutput, "env:" + output.SecretsJSONEnv}
+		}
+		return []string{"output:" + output.SecretsJSONOutput}
+	default:
+		return []string{"output:" + key}
+	}
+}
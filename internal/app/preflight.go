@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package app
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
+)
+
+// Names of the preflight checks, in the order they run.
+const (
+	PreflightGitHubOutput = "github_output"
+	PreflightGitHubEnv    = "github_env"
+	PreflightToken        = "token"
+	PreflightPlatform     = "platform"
+	PreflightCLI          = "cli"
+)
+
+// PreflightReport describes whether the runner is ready for a run. It is
+// built without downloading the CLI, authenticating or fetching secrets,
+// and never contains secret values.
+type PreflightReport struct {
+	Passed bool             `json:"passed"`
+	Checks []PreflightCheck `json:"checks"`
+}
+
+// PreflightCheck is the outcome of a single preflight check. Remediation
+// and Code are only set when the check failed.
+type PreflightCheck struct {
+	Name        string           `json:"name"`
+	Passed      bool             `json:"passed"`
+	Message     string           `json:"message"`
+	Remediation string           `json:"remediation,omitempty"`
+	Code        errors.ErrorCode `json:"code,omitempty"`
+}
+
+// Failed returns the checks that did not pass.
+func (r *PreflightReport) Failed() []PreflightCheck {
+	var failed []PreflightCheck
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Err returns nil when every check passed, and otherwise an error with the
+// code of the first failed check, naming every failure and suggesting how
+// to fix it.
+func (r *PreflightReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(failed))
+	suggestions := make([]string, 0, len(failed))
+	names := make([]string, 0, len(failed))
+	for _, check := range failed {
+		messages = append(messages, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		suggestions = append(suggestions, check.Remediation)
+		names = append(names, check.Name)
+	}
+	return errors.New(failed[0].Code, "Preflight checks failed: "+strings.Join(messages, "; ")).
+		WithDetails(map[string]interface{}{"failed_checks": names}).
+		WithSuggestions(suggestions...)
+}
+
+// add appends a check and keeps Passed up to date.
+func (r *PreflightReport) add(check PreflightCheck) {
+	r.Checks = append(r.Checks, check)
+	r.Passed = r.Passed && check.Passed
+}
+
+// Preflight checks the runner before any work is done: that the GitHub
+// Actions files the return type writes to are writable, that the token is
+// well formed, and that the CLI supports and can be installed on this
+// platform. The returned error is only set when ctx ends before the checks
+// complete; failed checks are reported in the report.
+func (a *App) Preflight(ctx context.Context) (*PreflightReport, error) {
+	report := &PreflightReport{Passed: true}
+	checks := []func() PreflightCheck{
+		func() PreflightCheck {
+			return checkGitHubFile(PreflightGitHubOutput, "GITHUB_OUTPUT",
+				a.config.GitHubOutput, a.config.NeedsGitHubOutput(), a.config.ReturnType)
+		},
+		func() PreflightCheck {
+			return checkGitHubFile(PreflightGitHubEnv, "GITHUB_ENV",
+				a.config.GitHubEnv, a.config.NeedsGitHubEnv(), a.config.ReturnType)
+		},
+		a.checkToken,
+		a.checkPlatform,
+		a.checkCLI,
+	}
+	for _, check := range checks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.add(check())
+	}
+	return report, nil
+}
+
+// checkGitHubFile checks a GitHub Actions command file. A file that does not
+// exist yet passes when its directory accepts new files, as the output
+// manager creates it.
+func checkGitHubFile(name, variable, path string, needed bool, returnType string) PreflightCheck {
+	check := PreflightCheck{Name: name, Passed: true}
+	if path == "" {
+		if !needed {
+			check.Message = fmt.Sprintf("%s is not set and not needed for return_type '%s'", variable, returnType)
+			return check
+		}
+		return preflightFailure(check, errors.ErrCodeEnvironmentMissing,
+			fmt.Sprintf("%s is not set but return_type '%s' writes to it", variable, returnType),
+			"Run the action in a GitHub Actions job, or set "+variable+" to a writable file")
+	}
+
+	// #nosec G304 -- path is from GitHub Actions environment variables, not user input
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err == nil {
+		_ = file.Close()
+		check.Message = fmt.Sprintf("%s is writable", variable)
+		return check
+	}
+	if !os.IsNotExist(err) {
+		return preflightFailure(check, errors.ErrCodeFileSystemError,
+			fmt.Sprintf("%s file %s is not writable: %v", variable, path, err),
+			"Check the permissions of "+path+" and that the runner user owns it")
+	}
+
+	probe, probeErr := os.CreateTemp(filepath.Dir(path), ".preflight-*")
+	if probeErr != nil {
+		return preflightFailure(check, errors.ErrCodeFileSystemError,
+			fmt.Sprintf("%s file %s does not exist and cannot be created: %v", variable, path, probeErr),
+			"Point "+variable+" at a file in a directory the runner user can write to")
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	check.Message = fmt.Sprintf("%s does not exist yet and will be created", variable)
+	return check
+}
+
+// checkToken checks the format of the token for the configured backend.
+func (a *App) checkToken() PreflightCheck {
+	check := PreflightCheck{Name: PreflightToken, Passed: true}
+	if a.config.UsesConnect() {
+		if strings.TrimSpace(a.config.ConnectToken) == "" {
+			return preflightFailure(check, errors.ErrCodeTokenInvalid,
+				"Connect token is empty",
+				"Set connect_token to a 1Password Connect access token")
+		}
+		check.Message = "Connect token is set"
+		return check
+	}
+
+	if err := validation.ValidateServiceAccountToken(a.config.Token); err != nil {
+		return preflightFailure(check, errors.ErrCodeTokenInvalid,
+			fmt.Sprintf("service account token is malformed: %v", err),
+			"Pass a 1Password service account token starting with 'ops_' from a repository secret")
+	}
+	check.Message = "service account token is well formed"
+	return check
+}
+
+// checkPlatform checks the CLI is published for this operating system and
+// architecture. A Connect server needs no CLI.
+func (a *App) checkPlatform() PreflightCheck {
+	check := PreflightCheck{Name: PreflightPlatform, Passed: true}
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	if a.cliManager == nil {
+		check.Message = fmt.Sprintf("%s: no CLI is needed with a Connect server", platform)
+		return check
+	}
+	if _, err := cli.ComputePlatformKey(runtime.GOOS, runtime.GOARCH); err != nil {
+		return preflightFailure(check, errors.ErrCodeCLINotFound,
+			err.Error(),
+			"Run the job on a linux, macOS or Windows runner with a supported architecture, or use a Connect server")
+	}
+	check.Message = fmt.Sprintf("%s is supported", platform)
+	return check
+}
+
+// checkCLI checks the CLI is installed or can be installed without
+// downloading it.
+func (a *App) checkCLI() PreflightCheck {
+	check := PreflightCheck{Name: PreflightCLI, Passed: true}
+	if a.cliManager == nil {
+		check.Message = "no CLI is needed with a Connect server"
+		return check
+	}
+
+	err := a.cliManager.CheckInstallable()
+	switch {
+	case err == nil:
+		check.Message = fmt.Sprintf("op %s is installed in or can be installed into %s",
+			a.cliManager.Version(), a.cliManager.InstallDir())
+		return check
+	case stderrors.Is(err, cli.ErrOfflineCLIMissing):
+		return preflightFailure(check, errors.ErrCodeCLINotFound,
+			err.Error(),
+			fmt.Sprintf("Install op %s on the runner and make sure it is on PATH, or set cli_path to it",
+				a.cliManager.Version()))
+	default:
+		return preflightFailure(check, errors.ErrCodeFileSystemError,
+			err.Error(),
+			"Set cli_install_dir to a directory the runner user can write to")
+	}
+}
+
+// preflightFailure marks check as failed.
+func preflightFailure(check PreflightCheck, code errors.ErrorCode, message, remediation string) PreflightCheck {
+	check.Passed = false
+	check.Message = message
+	check.Remediation = remediation
+	check.Code = code
+	return check
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package app
+
+import (
+	"context"
+	stderrors "errors"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+)
+
+// transientPatterns match CLI failures that carry no error code, where
+// only the 1Password CLI's message tells a network fault apart.
+var transientPatterns = []string{
+	"connection refused",
+	"connection reset",
+	"timeout",
+	"timed out",
+	"temporary failure",
+	"network is unreachable",
+	"no route to host",
+	"service unavailable",
+	"bad gateway",
+	"gateway timeout",
+	"too many requests",
+	"rate limit",
+}
+
+// Retry defaults used when the configuration leaves them unset
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 1 * time.Second
+)
+
+// retryPolicy bounds the retries of one operation.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	timeout     time.Duration // total time spent waiting between attempts
+}
+
+// retryPolicy returns the policy configured by retry_max_attempts,
+// retry_base_delay and retry_timeout.
+func (a *App) retryPolicy() retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: a.config.RetryMaxAttempts,
+		baseDelay:   time.Duration(a.config.RetryBaseDelay) * time.Second,
+		timeout:     time.Duration(a.config.RetryTimeout) * time.Second,
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.baseDelay <= 0 {
+		policy.baseDelay = defaultRetryBaseDelay
+	}
+	return policy
+}
+
+// withRetry runs fn under the configured retry policy.
+func (a *App) withRetry(ctx context.Context, operation string, fn func(context.Context) error) error {
+	return retry(ctx, a.logger, a.retryPolicy(), operation, fn)
+}
+
+// retry runs fn until it succeeds, fails with an error that is not
+// retryable, or the policy is exhausted. Waits grow exponentially from the
+// base delay with jitter, unless the error carries a RetryAfter hint from
+// the server, which is honored instead. A wait that would overrun the retry
+// timeout ends the loop early. The last error is returned.
+func retry(ctx context.Context, log *logger.Logger, policy retryPolicy, operation string, fn func(context.Context) error) error {
+	deadline := time.Now().Add(policy.timeout)
+	delay := policy.baseDelay
+
+	var (
+		err  error
+		wait time.Duration
+	)
+	attempt := 0
+	for attempt < policy.maxAttempts {
+		attempt++
+		if err = fn(ctx); err == nil {
+			break
+		}
+		log.Debug("Attempt failed",
+			"operation", operation,
+			"attempt", attempt,
+			"max_attempts", policy.maxAttempts,
+			"retryable", isRetryable(ctx, err))
+
+		if attempt == policy.maxAttempts || !isRetryable(ctx, err) {
+			break
+		}
+
+		wait = withJitter(delay)
+		if retryAfter := errors.GetRetryAfter(err); retryAfter > 0 {
+			wait = retryAfter
+		}
+		if time.Now().Add(wait).After(deadline) {
+			log.Debug("Retry timeout reached",
+				"operation", operation,
+				"attempt", attempt,
+				"retry_timeout", policy.timeout)
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+
+	if attempt > 1 {
+		log.Debug("Retries finished",
+			"operation", operation,
+			"attempts", attempt,
+			"final_delay", wait,
+			"success", err == nil)
+	}
+	return err
+}
+
+// isRetryable reports whether err is a transient failure worth another
+// attempt. An error with a code is classified by ActionableError.IsRetryable;
+// one without, typically reported by the CLI, by its cause or message.
+// Nothing is retried once ctx is done.
+func isRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var actionableErr *errors.ActionableError
+	if stderrors.As(err, &actionableErr) {
+		return actionableErr.IsRetryable()
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) || stderrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, pattern := range transientPatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitExhausted returns the terminal ErrCodeRateLimited error for an
+// operation that was still rate limited when its retries ran out, or nil if
+// err is not a rate limit.
+func rateLimitExhausted(err error) error {
+	var actionableErr *errors.ActionableError
+	if !stderrors.As(err, &actionableErr) || actionableErr.Code != errors.ErrCodeRateLimited {
+		return nil
+	}
+	return errors.Wrap(errors.ErrCodeRateLimited,
+		"1Password rate limit still exceeded after retrying", err).
+		WithSuggestions(
+			"Lower max_concurrency to send fewer requests at once",
+			"Raise retry_max_attempts or retry_timeout to wait out the limit",
+			"Spread secret retrieval across fewer workflow runs at a time",
+		)
+}
+
+// withJitter returns a random duration in [d/2, d] so concurrent runners do
+// not retry in lockstep.
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	// #nosec G404 -- jitter does not require cryptographic randomness
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
+)
+
+// Summary entry statuses
+const (
+	SummaryStatusResolved     = "resolved"
+	SummaryStatusFailed       = "failed"
+	SummaryStatusNotAttempted = "not_attempted"
+)
+
+// Summary is the audit record of a run written to summary_path. It names the
+// secrets the run touched and never contains their values.
+type Summary struct {
+	GeneratedAt string         `json:"generated_at"`
+	Success     bool           `json:"success"`
+	Secrets     []SummaryEntry `json:"secrets"`
+}
+
+// SummaryEntry describes how a single record was resolved.
+type SummaryEntry struct {
+	Output     string `json:"output"`
+	Vault      string `json:"vault"`
+	Item       string `json:"item"`
+	Field      string `json:"field"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// RunMetrics holds the figures shown in the metrics step summary. Durations
+// are zero for phases that did not run, and no secret values are recorded.
+type RunMetrics struct {
+	CLIVersion     string // Empty when secrets came from a Connect server
+	CLISource      string // cli.SourceDownloaded, SourceCached or SourcePreinstalled
+	SecretsFetched int
+	Total          time.Duration
+	Auth           time.Duration
+	Fetch          time.Duration
+}
+
+// buildSummary lists every request in order with its outcome in result,
+// which may be nil when retrieval never ran.
+func buildSummary(requests []*secrets.SecretRequest, result *secrets.BatchResult, success bool) *Summary {
+	summary := &Summary{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Success:     success,
+		Secrets:     make([]SummaryEntry, 0, len(requests)),
+	}
+
+	for _, request := range requests {
+		entry := SummaryEntry{
+			Output: request.Key,
+			Vault:  request.Vault,
+			Item:   request.ItemName,
+			Field:  request.FieldName,
+			Status: SummaryStatusNotAttempted,
+		}
+		if result != nil {
+			if secretResult, ok := result.Results[request.Key]; ok {
+				entry.Status = SummaryStatusResolved
+				if secretResult.Error != nil {
+					entry.Status = SummaryStatusFailed
+				}
+				if secretResult.Metrics != nil {
+					entry.DurationMs = secretResult.Metrics.Duration.Milliseconds()
+				}
+			}
+		}
+		summary.Secrets = append(summary.Secrets, entry)
+	}
+
+	return summary
+}
+
+// WriteSummary writes summary to path as indented JSON, readable only by
+// the current user.
+func WriteSummary(path string, summary *Summary) error {
+	if summary.Secrets == nil {
+		summary.Secrets = []SummaryEntry{}
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create summary directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// StepSummaryMarkdown renders summary as a markdown table for the GitHub
+// Actions step summary. Values are always shown as "***".
+func StepSummaryMarkdown(summary *Summary) string {
+	var b strings.Builder
+	b.WriteString("| Output | Source | Value | Status |\n")
+	b.WriteString("|--------|--------|-------|--------|\n")
+	for _, entry := range summary.Secrets {
+		status := "✅ Succeeded"
+		switch entry.Status {
+		case SummaryStatusFailed:
+			status = "❌ Failed"
+		case SummaryStatusNotAttempted:
+			status = "⏭️ Not attempted"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s/%s | `***` | %s |\n",
+			markdownCell(entry.Output), markdownCell(entry.Vault), markdownCell(entry.Item), status)
+	}
+	if len(summary.Secrets) == 0 {
+		b.WriteString("| - | - | - | No secrets requested |\n")
+	}
+	return b.String()
+}
+
+// MetricsMarkdown renders metrics as a markdown table for the GitHub Actions
+// step summary.
+func MetricsMarkdown(metrics *RunMetrics) string {
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return markdownCell(s)
+	}
+	duration := func(d time.Duration) string {
+		if d <= 0 {
+			return "-"
+		}
+		return d.Round(time.Millisecond).String()
+	}
+
+	var b strings.Builder
+	b.WriteString("| Metric | Value |\n")
+	b.WriteString("|--------|-------|\n")
+	fmt.Fprintf(&b, "| CLI version | %s |\n", orDash(metrics.CLIVersion))
+	fmt.Fprintf(&b, "| CLI binary | %s |\n", orDash(metrics.CLISource))
+	fmt.Fprintf(&b, "| Secrets fetched | %d |\n", metrics.SecretsFetched)
+	fmt.Fprintf(&b, "| Total duration | %s |\n", duration(metrics.Total))
+	fmt.Fprintf(&b, "| Authentication | %s |\n", duration(metrics.Auth))
+	fmt.Fprintf(&b, "| Secret retrieval | %s |\n", duration(metrics.Fetch))
+	return b.String()
+}
+
+// markdownCell keeps s on one line inside a table cell.
+func markdownCell(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ", "`", "'").Replace(s)
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeSummary records the outcome of the run to summary_path and the step
+// summary, as configured. A failure to write either is logged rather than
+// failing the run.
+func (a *App) writeSummary(requests []*secrets.SecretRequest, result *secrets.BatchResult, success bool) {
+	if a.config.SummaryPath == "" && !a.config.WriteStepSummaryEnabled() {
+		return
+	}
+	summary := buildSummary(requests, result, success)
+
+	if a.config.SummaryPath != "" {
+		if err := WriteSummary(a.config.SummaryPath, summary); err != nil {
+			a.logger.Warn("Failed to write run summary", "path", a.config.SummaryPath, "error", err.Error())
+		} else {
+			a.logger.Debug("Wrote run summary", "path", a.config.SummaryPath, "secrets", len(requests))
+		}
+	}
+
+	if a.config.WriteStepSummaryEnabled() {
+		if err := a.logger.GitHubSummarySection("1Password Secrets", StepSummaryMarkdown(summary)); err != nil {
+			a.logger.Warn("Failed to write step summary", "error", err.Error())
+		}
+	}
+}
+
+// writeMetricsSummary appends the run's metrics to the step summary when
+// step_summary is enabled. A failure to write it is logged rather than
+// failing the run.
+func (a *App) writeMetricsSummary(total time.Duration) {
+	if !a.config.StepSummaryEnabled() {
+		return
+	}
+
+	metrics := a.runMetrics
+	metrics.Total = total
+	if a.connectClient == nil && a.cliManager != nil {
+		metrics.CLIVersion = a.cliManager.Version()
+		metrics.CLISource = a.cliManager.Source()
+	}
+
+	if err := a.logger.GitHubSummarySection("1Password Secrets Metrics", MetricsMarkdown(&metrics)); err != nil {
+		a.logger.Warn("Failed to write metrics step summary", "error", err.Error())
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// identityRecord is the on-disk form of a successful identity check. It never
+// contains the token; entries are addressed by a hash of the token instead.
+type identityRecord struct {
+	Account     string    `json:"account,omitempty"`
+	ValidatedAt time.Time `json:"validated_at"`
+}
+
+// identityCache persists successful identity checks across runs so that many
+// short jobs on the same runner do not each repeat the live CLI call.
+type identityCache struct {
+	dir string
+	key string
+}
+
+// newIdentityCache returns a cache rooted at dir for the given token, or nil
+// when persistence is not configured.
+func newIdentityCache(dir string, token *security.SecureString) *identityCache {
+	if dir == "" || token == nil {
+		return nil
+	}
+	return &identityCache{dir: dir, key: tokenCacheKey(token)}
+}
+
+// tokenCacheKey derives a stable, non-reversible cache key from the token.
+func tokenCacheKey(token *security.SecureString) string {
+	data := token.Bytes()
+	defer security.SecureZero(data)
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// path returns the file holding the identity record for this token.
+func (c *identityCache) path() string {
+	return filepath.Join(c.dir, "identity-"+c.key+".json")
+}
+
+// load returns the cached identity if it was validated within ttl.
+func (c *identityCache) load(ttl time.Duration) (*identityRecord, bool) {
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		return nil, false
+	}
+
+	var record identityRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false
+	}
+
+	age := time.Since(record.ValidatedAt)
+	if age < 0 || age > ttl {
+		return nil, false
+	}
+
+	return &record, true
+}
+
+// store writes the identity record, replacing any previous entry atomically.
+func (c *identityCache) store(record *identityRecord) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create identity cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode identity record: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "identity-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create identity cache file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write identity cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close identity cache file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, c.path()); err != nil {
+		return fmt.Errorf("failed to store identity cache file: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the cached identity for this token, if any.
+func (c *identityCache) remove() {
+	_ = os.Remove(c.path())
+}
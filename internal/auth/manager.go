@@ -33,12 +33,13 @@ type VaultInfo struct {
 
 // Manager handles authentication and vault operations with caching and retry logic.
 type Manager struct {
-	client  CLIClient
-	logger  *logger.Logger
-	config  *Config
-	cache   *cache
-	metrics *metrics
-	mu      sync.RWMutex
+	client   CLIClient
+	logger   *logger.Logger
+	config   *Config
+	cache    *cache
+	identity *identityCache
+	metrics  *metrics
+	mu       sync.RWMutex
 }
 
 // Config holds configuration for the authentication manager.
@@ -59,6 +60,10 @@ type Config struct {
 	MaxCacheSize  int
 	EnableCaching bool
 
+	// IdentityCacheDir persists successful authentication across runs,
+	// keyed by a hash of the token. Empty disables persistence.
+	IdentityCacheDir string
+
 	// Rate limiting
 	RateLimit       int           // Requests per window
 	RateLimitWindow time.Duration // Window duration
@@ -150,7 +155,8 @@ func NewManager(client CLIClient, log *logger.Logger, config *Config) (*Manager,
 		cache: &cache{
 			vaults: make(map[string]*VaultMetadata),
 		},
-		metrics: &metrics{},
+		identity: newIdentityCache(config.IdentityCacheDir, config.Token),
+		metrics:  &metrics{},
 	}, nil
 }
 
@@ -215,9 +221,29 @@ func (m *Manager) Authenticate(ctx context.Context) error {
 		return nil
 	}
 
+	// Reuse an identity validated by a recent run with the same token
+	if m.config.EnableCaching && m.identity != nil {
+		if record, ok := m.identity.load(m.config.CacheTTL); ok {
+			m.logger.Debug("Using persisted authentication state",
+				"validated_at", record.ValidatedAt)
+			m.metrics.incrementCacheHits()
+			m.cache.setAuthState(&State{
+				Authenticated: true,
+				Account:       record.Account,
+				ValidatedAt:   record.ValidatedAt,
+				TTL:           m.config.CacheTTL,
+			})
+			return nil
+		}
+		m.metrics.incrementCacheMisses()
+	}
+
 	// Perform authentication with retry logic
 	err := m.authenticateWithRetry(ctx)
 	if err != nil {
+		if m.identity != nil {
+			m.identity.remove()
+		}
 		m.metrics.incrementAuthFailures()
 		m.cache.setAuthState(&State{
 			Authenticated: false,
@@ -229,19 +255,47 @@ func (m *Manager) Authenticate(ctx context.Context) error {
 	}
 
 	// Cache successful authentication
+	validatedAt := time.Now()
 	m.cache.setAuthState(&State{
 		Authenticated: true,
 		Account:       m.config.Account,
-		ValidatedAt:   time.Now(),
+		ValidatedAt:   validatedAt,
 		TTL:           m.config.CacheTTL,
 		LastError:     nil,
 	})
 
+	if m.config.EnableCaching && m.identity != nil {
+		record := &identityRecord{Account: m.config.Account, ValidatedAt: validatedAt}
+		if err := m.identity.store(record); err != nil {
+			m.logger.Debug("Failed to persist authentication state", "error", err.Error())
+		}
+	}
+
 	m.metrics.incrementAuthSuccesses()
 	m.logger.Info("Authentication successful")
 	return nil
 }
 
+// IdentityCached reports whether the token has a fresh validated identity,
+// either from this run or persisted by a recent run with the same token, so
+// that callers can skip their own live checks of the token.
+func (m *Manager) IdentityCached() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.config.EnableCaching {
+		return false
+	}
+	if m.isAuthCacheValid() {
+		return true
+	}
+	if m.identity == nil {
+		return false
+	}
+	_, ok := m.identity.load(m.config.CacheTTL)
+	return ok
+}
+
 // authenticateWithRetry performs authentication with exponential backoff retry logic.
 func (m *Manager) authenticateWithRetry(ctx context.Context) error {
 	backoff := m.config.InitialBackoff
@@ -257,7 +311,10 @@ func (m *Manager) authenticateWithRetry(ctx context.Context) error {
 
 			select {
 			case <-retryCtx.Done():
-				return fmt.Errorf("authentication retry timeout exceeded")
+				if ctx.Err() != nil {
+					return fmt.Errorf("authentication canceled: %w", context.Cause(ctx))
+				}
+				return fmt.Errorf("authentication retry timeout exceeded: %w", retryCtx.Err())
 			case <-time.After(backoff):
 				// Continue with retry
 			}
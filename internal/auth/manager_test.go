@@ -6,6 +6,9 @@ package auth
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -513,6 +516,121 @@ func TestCaching(t *testing.T) {
 	}
 }
 
+func TestAuthenticatePersistedIdentity(t *testing.T) {
+	cacheDir := t.TempDir()
+	calls := 0
+	client := &mockCLIClient{
+		authenticateFunc: func(_ context.Context) error {
+			calls++
+			return nil
+		},
+	}
+
+	// newRun simulates a separate action invocation sharing the runner's cache.
+	newRun := func(config *Config) *Manager {
+		manager, err := NewManager(client, createTestLogger(), config)
+		if err != nil {
+			t.Fatalf("failed to create manager: %v", err)
+		}
+		t.Cleanup(func() { _ = manager.Destroy() })
+		return manager
+	}
+
+	ctx := context.Background()
+	config := createTestConfig()
+	config.IdentityCacheDir = cacheDir
+
+	if err := newRun(config).Authenticate(ctx); err != nil {
+		t.Fatalf("first run: unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("first run: expected 1 live call, got %d", calls)
+	}
+
+	second := newRun(config)
+	if err := second.Authenticate(ctx); err != nil {
+		t.Fatalf("second run: unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("second run within TTL should skip the live call, got %d calls", calls)
+	}
+	if second.metrics.CacheHits != 1 {
+		t.Errorf("expected 1 cache hit, got %d", second.metrics.CacheHits)
+	}
+
+	// The cache file is keyed by a hash and must never contain the token
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("failed to read cache dir: %v", err)
+	}
+	token := config.Token.String()
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), token) {
+			t.Errorf("cache file name %q contains the token", entry.Name())
+		}
+		data, err := os.ReadFile(filepath.Join(cacheDir, entry.Name()))
+		if err != nil {
+			t.Fatalf("failed to read cache file: %v", err)
+		}
+		if strings.Contains(string(data), token) {
+			t.Errorf("cache file %q contains the token", entry.Name())
+		}
+	}
+
+	// A different token is a cache miss
+	otherConfig := createTestConfig()
+	otherConfig.Token, _ = security.NewSecureStringFromString("ops_zyxwvutsrqponmlkjihgfedcba")
+	otherConfig.IdentityCacheDir = cacheDir
+	if err := newRun(otherConfig).Authenticate(ctx); err != nil {
+		t.Fatalf("other token: unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("different token should make a live call, got %d calls", calls)
+	}
+
+	// Disabled caching always falls through to a live call
+	disabled := createTestConfig()
+	disabled.EnableCaching = false
+	disabled.IdentityCacheDir = cacheDir
+	if err := newRun(disabled).Authenticate(ctx); err != nil {
+		t.Fatalf("disabled cache: unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("disabled cache should make a live call, got %d calls", calls)
+	}
+}
+
+func TestAuthenticatePersistedIdentityExpires(t *testing.T) {
+	calls := 0
+	client := &mockCLIClient{
+		authenticateFunc: func(_ context.Context) error {
+			calls++
+			return nil
+		},
+	}
+
+	config := createTestConfig()
+	config.CacheTTL = 50 * time.Millisecond
+	config.IdentityCacheDir = t.TempDir()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		manager, err := NewManager(client, createTestLogger(), config)
+		if err != nil {
+			t.Fatalf("failed to create manager: %v", err)
+		}
+		if err := manager.Authenticate(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = manager.Destroy()
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if calls != 2 {
+		t.Errorf("expired identity should make a live call, got %d calls", calls)
+	}
+}
+
 func TestMetrics(t *testing.T) {
 	client := &mockCLIClient{
 		authenticateFunc: func(_ context.Context) error { return nil },
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// ErrBatchOutput reports op inject output that could not be split back into
+// the values of a batch.
+var ErrBatchOutput = errors.New("unexpected op inject output")
+
+// injectablePattern matches the item and field names op inject accepts in a
+// template: the characters 1Password documents for secret references, with
+// "/" separating a section from its field.
+var injectablePattern = regexp.MustCompile(`^[A-Za-z0-9 _.\-]+(/[A-Za-z0-9 _.\-]+)?$`)
+
+// ReadRef identifies a field read by ReadBatch. Field may name the section
+// holding it as "section/field", as for GetSecret.
+type ReadRef struct {
+	Vault string
+	Item  string
+	Field string
+}
+
+// Injectable reports whether ref can be read by ReadBatch. Names with other
+// characters must be read with GetSecret.
+func Injectable(ref ReadRef) bool {
+	return injectablePattern.MatchString(ref.Item) && injectablePattern.MatchString(ref.Field) &&
+		!strings.Contains(ref.Item+ref.Field, "..")
+}
+
+// ReadBatch reads every ref with a single op inject process instead of one
+// op read per field, returning the values in the order of refs. The
+// template places each reference between lines holding a random marker,
+// which split the injected output back into values; output that does not
+// match the markers is an ErrBatchOutput error. As op inject fails as a
+// whole when any reference fails, callers should read the refs one at a
+// time after an error to learn which of them failed.
+func (c *Client) ReadBatch(ctx context.Context, refs []ReadRef) ([]*security.SecureString, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	marker, err := batchMarker()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reference vaults by ID, as GetSecret does, resolving each vault once
+	vaultIDs := make(map[string]string)
+	var template bytes.Buffer
+	for i, ref := range refs {
+		if !Injectable(ref) {
+			return nil, fmt.Errorf("reference %d cannot be read in a batch", i)
+		}
+		vaultID, ok := vaultIDs[ref.Vault]
+		if !ok {
+			vaultInfo, resolveErr := c.ResolveVault(ctx, ref.Vault)
+			if resolveErr != nil {
+				return nil, fmt.Errorf("failed to resolve vault: %w", resolveErr)
+			}
+			vaultID = vaultInfo.ID
+			vaultIDs[ref.Vault] = vaultID
+		}
+		fmt.Fprintf(&template, "%s%d\n{{ op://%s/%s/%s }}\n", marker, i, vaultID, ref.Item, ref.Field)
+	}
+	fmt.Fprintf(&template, "%send\n", marker)
+
+	input, err := security.NewSecureString(template.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secure string: %w", err)
+	}
+	defer func() { _ = input.Destroy() }()
+
+	args := []string{"inject"}
+	opts := &ExecutionOptions{
+		Timeout: c.timeout,
+		Env:     c.getAuthEnv(),
+		Input:   input,
+	}
+
+	result, err := c.executor.Execute(ctx, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject secrets: %w", err)
+	}
+	defer result.Destroy()
+
+	if result.ExitCode != 0 {
+		return nil, commandError("secret injection", result.ExitCode, c.stderrOf(result))
+	}
+
+	if result.Stdout == nil {
+		return nil, fmt.Errorf("no secret values received")
+	}
+
+	output := result.Stdout.Bytes()
+	defer security.SecureZero(output)
+	return splitInjected(output, marker, len(refs))
+}
+
+// batchMarker returns a marker no secret value is expected to contain.
+func batchMarker() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate batch marker: %w", err)
+	}
+	return "op-secrets-action-" + hex.EncodeToString(nonce) + ":", nil
+}
+
+// splitInjected splits the output of a ReadBatch template into its n
+// values. Blank lines are not kept by the executor, so a value is the lines
+// between one marker and the next, joined by newlines.
+func splitInjected(output []byte, marker string, n int) ([]*security.SecureString, error) {
+	values := make([]*security.SecureString, 0, n)
+	fail := func(format string, args ...interface{}) ([]*security.SecureString, error) {
+		for _, value := range values {
+			_ = value.Destroy()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrBatchOutput, fmt.Sprintf(format, args...))
+	}
+
+	var current [][]byte
+	started := false
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		label, isMarker := bytes.CutPrefix(line, []byte(marker))
+		if !isMarker {
+			if !started {
+				return fail("output before the first marker")
+			}
+			current = append(current, line)
+			continue
+		}
+
+		if started {
+			joined := bytes.Join(current, []byte("\n"))
+			value, err := security.NewSecureString(joined)
+			security.SecureZero(joined)
+			if err != nil {
+				return fail("failed to create secure string: %v", err)
+			}
+			values = append(values, value)
+			current = nil
+		}
+
+		if string(label) == "end" {
+			if len(values) != n {
+				return fail("got %d of %d values", len(values), n)
+			}
+			return values, nil
+		}
+		if string(label) != strconv.Itoa(len(values)) {
+			return fail("marker %q out of order", label)
+		}
+		started = true
+	}
+	return fail("missing end marker")
+}
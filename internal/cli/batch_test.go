@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// batchMockScript answers vault listings and op read, and op inject by
+// replacing each reference with "<item>-<field>-value". A field named
+// "multiline" injects two lines.
+const batchMockScript = `#!/bin/sh
+if [ "$1" = "vault" ]; then
+    echo '[{"id":"VAULT1","name":"Personal","description":"Personal vault"}]'
+elif [ "$1" = "read" ]; then
+    echo 'secret-value'
+elif [ "$1" = "inject" ]; then
+    sed -E -e 's#\{\{ op://[^/]*/([^/]*)/multiline \}\}#first line\
+second line#' -e 's#\{\{ op://[^/]*/([^/]*)/([^ ]*) \}\}#\1-\2-value#'
+else
+    echo "Unknown command" >&2
+    exit 1
+fi
+`
+
+// newBatchMockClient returns a client running script as the op binary.
+func newBatchMockClient(tb testing.TB, script string) *Client {
+	tb.Helper()
+	tempDir := tb.TempDir()
+	mockBinary := filepath.Join(tempDir, "mock-op")
+
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(script), 0700); err != nil {
+		tb.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		tb.Fatalf("NewManager() failed: %v", err)
+	}
+	tb.Cleanup(func() { _ = manager.Cleanup() })
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		tb.Fatalf("Failed to create secure string: %v", err)
+	}
+	tb.Cleanup(func() { _ = token.Destroy() })
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		tb.Fatalf("NewClient() failed: %v", err)
+	}
+	tb.Cleanup(func() { _ = client.Destroy() })
+	return client
+}
+
+func TestClientReadBatchWithMock(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Shell script mock not supported on Windows")
+	}
+	client := newBatchMockClient(t, batchMockScript)
+
+	refs := []ReadRef{
+		{Vault: "Personal", Item: "database", Field: "password"},
+		{Vault: "Personal", Item: "api", Field: "credentials/token"},
+		{Vault: "VAULT1", Item: "cert", Field: "multiline"},
+	}
+	values, err := client.ReadBatch(context.Background(), refs)
+	if err != nil {
+		t.Fatalf("ReadBatch() failed: %v", err)
+	}
+	defer func() {
+		for _, value := range values {
+			_ = value.Destroy()
+		}
+	}()
+
+	want := []string{"database-password-value", "api-credentials/token-value", "first line\nsecond line"}
+	if len(values) != len(want) {
+		t.Fatalf("ReadBatch() returned %d values, want %d", len(values), len(want))
+	}
+	for i, value := range values {
+		if value.String() != want[i] {
+			t.Errorf("value %d = %q, want %q", i, value.String(), want[i])
+		}
+	}
+
+	if _, err := client.ReadBatch(context.Background(), []ReadRef{{Vault: "Personal", Item: "db{x}", Field: "password"}}); err == nil {
+		t.Error("ReadBatch() accepted a name op inject cannot read")
+	}
+}
+
+func TestInjectable(t *testing.T) {
+	tests := []struct {
+		ref  ReadRef
+		want bool
+	}{
+		{ReadRef{Vault: "v", Item: "database", Field: "password"}, true},
+		{ReadRef{Vault: "v", Item: "My Item.prod", Field: "api-key_2"}, true},
+		{ReadRef{Vault: "v", Item: "database", Field: "section/password"}, true},
+		{ReadRef{Vault: "v", Item: "database", Field: "a/b/c"}, false},
+		{ReadRef{Vault: "v", Item: "db}}", Field: "password"}, false},
+		{ReadRef{Vault: "v", Item: "..", Field: "password"}, false},
+		{ReadRef{Vault: "v", Item: "café", Field: "password"}, false},
+		{ReadRef{Vault: "v", Item: "", Field: "password"}, false},
+	}
+	for _, tt := range tests {
+		if got := Injectable(tt.ref); got != tt.want {
+			t.Errorf("Injectable(%+v) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestSplitInjected(t *testing.T) {
+	const marker = "m:"
+	tests := []struct {
+		name    string
+		output  string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{name: "values", output: "m:0\none\nm:1\ntwo\nm:end", n: 2, want: []string{"one", "two"}},
+		{name: "multiline and empty", output: "m:0\na\nb\nm:1\nm:end", n: 2, want: []string{"a\nb", ""}},
+		{name: "output before marker", output: "oops\nm:0\none\nm:end", n: 1, wantErr: true},
+		{name: "out of order", output: "m:1\none\nm:0\ntwo\nm:end", n: 2, wantErr: true},
+		{name: "missing value", output: "m:0\none\nm:end", n: 2, wantErr: true},
+		{name: "missing end", output: "m:0\none", n: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := splitInjected([]byte(tt.output), marker, tt.n)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBatchOutput) {
+					t.Fatalf("splitInjected() error = %v, want ErrBatchOutput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitInjected() failed: %v", err)
+			}
+			if len(values) != len(tt.want) {
+				t.Fatalf("splitInjected() returned %d values, want %d", len(values), len(tt.want))
+			}
+			for i, value := range values {
+				if value.String() != tt.want[i] {
+					t.Errorf("value %d = %q, want %q", i, value.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// benchmarkRefs is the batch size the README quotes the speedup for.
+const benchmarkRefs = 20
+
+func BenchmarkClientGetSecretSequential(b *testing.B) {
+	if runtime.GOOS == windowsOS {
+		b.Skip("Shell script mock not supported on Windows")
+	}
+	client := newBatchMockClient(b, batchMockScript)
+	ctx := context.Background()
+
+	for b.Loop() {
+		for i := 0; i < benchmarkRefs; i++ {
+			secret, err := client.GetSecret(ctx, "VAULT1", fmt.Sprintf("item%d", i), "password")
+			if err != nil {
+				b.Fatalf("GetSecret() failed: %v", err)
+			}
+			_ = secret.Destroy()
+		}
+	}
+}
+
+func BenchmarkClientReadBatch(b *testing.B) {
+	if runtime.GOOS == windowsOS {
+		b.Skip("Shell script mock not supported on Windows")
+	}
+	client := newBatchMockClient(b, batchMockScript)
+	ctx := context.Background()
+
+	refs := make([]ReadRef, benchmarkRefs)
+	for i := range refs {
+		refs[i] = ReadRef{Vault: "VAULT1", Item: fmt.Sprintf("item%d", i), Field: "password"}
+	}
+
+	for b.Loop() {
+		values, err := client.ReadBatch(ctx, refs)
+		if err != nil {
+			b.Fatalf("ReadBatch() failed: %v", err)
+		}
+		for _, value := range values {
+			_ = value.Destroy()
+		}
+	}
+}
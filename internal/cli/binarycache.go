@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// binaryCacheKeyLength is the number of checksum characters naming a cache
+// entry; the entry is still verified against the full checksum.
+const binaryCacheKeyLength = 16
+
+// binaryCacheEntry returns the directory of the cache entry for the
+// configured version, platform and checksum, and the directory holding the
+// entries of every checksum for that version and platform. Both are empty
+// when the cache is disabled or there is no checksum to key it by, as an
+// unverified binary is never cached.
+func (m *Manager) binaryCacheEntry() (entry, parent string) {
+	if m.binaryCacheDir == "" || !m.hasChecksum() {
+		return "", ""
+	}
+	platform, err := ComputePlatformKey(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", ""
+	}
+	key := m.checksum()
+	if len(key) > binaryCacheKeyLength {
+		key = key[:binaryCacheKeyLength]
+	}
+	parent = filepath.Join(m.binaryCacheDir, "v"+m.version, platform)
+	return filepath.Join(parent, key), parent
+}
+
+// restoreFromBinaryCache installs the cached binary for this version,
+// platform and checksum, and reports whether it did. An entry that no longer
+// matches the checksum is removed so the binary is downloaded again.
+func (m *Manager) restoreFromBinaryCache() bool {
+	entry, _ := m.binaryCacheEntry()
+	if entry == "" {
+		return false
+	}
+	binaryPath := m.GetBinaryPath()
+	cached := filepath.Join(entry, filepath.Base(binaryPath))
+	if _, err := os.Stat(cached); err != nil {
+		return false
+	}
+
+	if err := m.verifyChecksum(cached); err != nil {
+		m.debug("Discarding cached CLI binary that fails verification", "path", cached, "error", err)
+		_ = os.RemoveAll(entry)
+		return false
+	}
+	if err := copyExecutable(cached, binaryPath); err != nil {
+		m.debug("Failed to restore cached CLI binary", "path", cached, "error", err)
+		return false
+	}
+	if err := m.verifyChecksum(binaryPath); err != nil {
+		_ = os.Remove(binaryPath)
+		return false
+	}
+
+	m.debug("Using cached 1Password CLI binary", "path", cached)
+	return true
+}
+
+// storeInBinaryCache copies the verified binary into the cache, replacing
+// the entries of other checksums for the same version and platform, which
+// the versions DB no longer vouches for. Failures only cost a download on a
+// later run and are not reported as errors.
+func (m *Manager) storeInBinaryCache() {
+	entry, parent := m.binaryCacheEntry()
+	if entry == "" {
+		return
+	}
+	binaryPath := m.GetBinaryPath()
+	cached := filepath.Join(entry, filepath.Base(binaryPath))
+
+	// Copy under a temporary name so a concurrent run never restores a
+	// partial binary
+	tmp := cached + ".tmp"
+	if err := copyExecutable(binaryPath, tmp); err != nil {
+		m.debug("Failed to cache CLI binary", "path", cached, "error", err)
+		_ = os.Remove(tmp)
+		return
+	}
+	if err := os.Rename(tmp, cached); err != nil {
+		m.debug("Failed to cache CLI binary", "path", cached, "error", err)
+		_ = os.Remove(tmp)
+		return
+	}
+
+	if stale, err := os.ReadDir(parent); err == nil {
+		for _, other := range stale {
+			if other.Name() != filepath.Base(entry) {
+				_ = os.RemoveAll(filepath.Join(parent, other.Name()))
+			}
+		}
+	}
+	m.debug("Cached 1Password CLI binary", "path", cached)
+}
@@ -4,21 +4,34 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
+// vaultIDPattern matches 1Password vault IDs.
+var vaultIDPattern = regexp.MustCompile(`^[a-z0-9]{26}$`)
+
 // Client provides high-level 1Password operations.
 type Client struct {
-	executor *Executor
-	token    *security.SecureString
-	account  string
-	timeout  time.Duration
+	executor       *Executor
+	token          *security.SecureString
+	account        string
+	timeout        time.Duration
+	connectTimeout time.Duration
+	vaults         *vaultCache    // Nil unless ClientConfig.VaultCacheTTL is positive
+	logger         *logger.Logger // Redacts known secrets from CLI stderr; may be nil
 }
 
 // ClientConfig holds configuration for the 1Password client.
@@ -26,6 +39,16 @@ type ClientConfig struct {
 	Token   *security.SecureString
 	Account string
 	Timeout time.Duration
+
+	// ConnectTimeout bounds the authentication handshake; zero uses Timeout
+	ConnectTimeout time.Duration
+
+	// VaultCacheTTL reuses the vault a name resolved to for this long
+	// instead of listing vaults for every read; 0 disables
+	VaultCacheTTL time.Duration
+
+	// Clock measures VaultCacheTTL; nil uses SystemClock
+	Clock Clock
 }
 
 // VaultInfo contains information about a 1Password vault.
@@ -35,6 +58,9 @@ type VaultInfo struct {
 	Description string `json:"description"`
 }
 
+// DocumentCategory is the category op reports for Document items.
+const DocumentCategory = "DOCUMENT"
+
 // ItemInfo contains information about a 1Password item.
 type ItemInfo struct {
 	ID    string `json:"id"`
@@ -43,15 +69,31 @@ type ItemInfo struct {
 		ID   string `json:"id"`
 		Name string `json:"name"`
 	} `json:"vault"`
-	Category string `json:"category"`
+	Category string      `json:"category"`
+	Fields   []FieldInfo `json:"fields,omitempty"`
+	Files    []FileInfo  `json:"files,omitempty"`
+}
+
+// FileInfo describes a file attached to an item.
+type FileInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
 }
 
 // FieldInfo contains information about an item field.
 type FieldInfo struct {
-	ID      string `json:"id"`
-	Label   string `json:"label"`
-	Type    string `json:"type"`
-	Purpose string `json:"purpose"`
+	ID      string       `json:"id"`
+	Label   string       `json:"label"`
+	Type    string       `json:"type"`
+	Purpose string       `json:"purpose"`
+	Section *SectionInfo `json:"section,omitempty"` // Nil for fields outside any section
+}
+
+// SectionInfo identifies the section of an item holding a field.
+type SectionInfo struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
 }
 
 // NewClient creates a new 1Password client.
@@ -69,14 +111,25 @@ func NewClient(manager *Manager, config *ClientConfig) (*Client, error) {
 		timeout = config.Timeout
 	}
 
+	connectTimeout := timeout
+	if config.ConnectTimeout > 0 {
+		connectTimeout = config.ConnectTimeout
+	}
+
 	executor := NewExecutor(manager, timeout)
 
-	return &Client{
-		executor: executor,
-		token:    config.Token,
-		account:  config.Account,
-		timeout:  timeout,
-	}, nil
+	client := &Client{
+		executor:       executor,
+		token:          config.Token,
+		account:        config.Account,
+		timeout:        timeout,
+		connectTimeout: connectTimeout,
+		logger:         manager.logger,
+	}
+	if config.VaultCacheTTL > 0 {
+		client.vaults = newVaultCache(config.VaultCacheTTL, config.Clock)
+	}
+	return client, nil
 }
 
 // Authenticate verifies the client can connect to 1Password.
@@ -88,7 +141,8 @@ func (c *Client) Authenticate(ctx context.Context) error {
 	}
 
 	opts := &ExecutionOptions{
-		Timeout: c.timeout,
+		Timeout: c.connectTimeout,
+		Connect: true,
 		Env:     c.getAuthEnv(),
 	}
 
@@ -99,17 +153,158 @@ func (c *Client) Authenticate(ctx context.Context) error {
 	defer result.Destroy()
 
 	if result.ExitCode != 0 {
-		stderrStr := ""
-		if result.Stderr != nil {
-			stderrStr = result.Stderr.String()
-		}
-		return fmt.Errorf("authentication failed with exit code %d: %s",
-			result.ExitCode, stderrStr)
+		return commandError("authentication", result.ExitCode, c.stderrOf(result))
 	}
 
 	return nil
 }
 
+// revokedTokenPatterns mark CLI errors for a token that was valid once but
+// can no longer be used.
+var revokedTokenPatterns = []string{
+	"expired", "revoked", "deleted", "suspended", "deactivated",
+}
+
+// rejectedTokenPatterns mark CLI errors for credentials 1Password refused.
+var rejectedTokenPatterns = []string{
+	"unauthorized", "(401)", "invalid token", "authentication", "not currently signed in",
+}
+
+// ValidateToken checks the token against 1Password with a lightweight
+// whoami call, so that an unusable token fails before any secret is read.
+// A revoked or expired token is an ErrCodeTokenExpired error and any other
+// rejected token an ErrCodeAuthFailed error; other failures, such as
+// network errors, are returned as is and may be retried.
+func (c *Client) ValidateToken(ctx context.Context) error {
+	args := []string{"whoami", "--format=json"}
+
+	if err := c.executor.ValidateArgs(args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	opts := &ExecutionOptions{
+		Timeout: c.connectTimeout,
+		Connect: true,
+		Env:     c.getAuthEnv(),
+	}
+
+	result, err := c.executor.Execute(ctx, args, opts)
+	if err != nil {
+		return fmt.Errorf("token validation failed: %w", err)
+	}
+	defer result.Destroy()
+
+	if result.ExitCode == 0 {
+		return nil
+	}
+
+	stderrStr := strings.TrimSpace(c.stderrOf(result))
+	cause := commandError("whoami", result.ExitCode, stderrStr)
+	if apperrors.IsErrorCode(cause, apperrors.ErrCodeRateLimited) {
+		return cause
+	}
+
+	lower := strings.ToLower(stderrStr)
+	for _, pattern := range revokedTokenPatterns {
+		if strings.Contains(lower, pattern) {
+			return apperrors.Wrap(
+				apperrors.ErrCodeTokenExpired,
+				"The service account token has expired or been revoked",
+				cause,
+			).WithSuggestions(
+				"Create a new service account token in 1Password",
+				"Update the repository secret holding the token",
+			)
+		}
+	}
+	for _, pattern := range rejectedTokenPatterns {
+		if strings.Contains(lower, pattern) {
+			return apperrors.Wrap(
+				apperrors.ErrCodeAuthFailed,
+				"1Password rejected the service account token",
+				cause,
+			).WithSuggestions(
+				"Check that the token secret holds the complete service account token",
+				"Check that the service account still exists",
+			)
+		}
+	}
+
+	return cause
+}
+
+// rateLimitPatterns mark CLI errors for a request 1Password refused because
+// of its rate limits.
+var rateLimitPatterns = []string{
+	"(429)", "too many requests", "rate limit",
+}
+
+// retryAfterPattern matches a wait the CLI reports with a rate limit error,
+// such as "retry after 30 seconds" or "Retry-After: 30".
+var retryAfterPattern = regexp.MustCompile(`(?i)retry[- ]after:?\s*(\d+)`)
+
+// itemNotFoundPatterns and fieldNotFoundPatterns tell apart the CLI errors
+// for a missing item and for an item that lacks the requested field.
+var (
+	itemNotFoundPatterns   = []string{"isn't an item", "item not found", "could not find item"}
+	fieldNotFoundPatterns  = []string{"does not have a field", "isn't a field", "could not find field"}
+	fieldAmbiguousPatterns = []string{"more than one field"}
+)
+
+// stderrOf returns the stderr of a finished command with every secret the
+// logger has registered redacted, so a value op echoes back does not reach
+// an error message.
+func (c *Client) stderrOf(result *ExecutionResult) string {
+	if result.Stderr == nil {
+		return ""
+	}
+	return c.logger.Redact(result.Stderr.String())
+}
+
+// commandError describes a CLI command that exited with a non-zero code. A
+// command refused by 1Password's rate limits is an ErrCodeRateLimited error
+// carrying any wait the CLI reports, so that retries back off accordingly.
+// A missing item or field is an ErrCodeSecretNotFound or ErrCodeFieldNotFound
+// error respectively, and any other failure an ErrCodeCLIExecutionFailed
+// error. Callers pass stderr redacted, see stderrOf.
+func commandError(operation string, exitCode int, stderr string) error {
+	cause := fmt.Errorf("%s failed with exit code %d: %s", operation, exitCode, stderr)
+
+	lower := strings.ToLower(stderr)
+	for _, pattern := range rateLimitPatterns {
+		if strings.Contains(lower, pattern) {
+			var retryAfter time.Duration
+			if match := retryAfterPattern.FindStringSubmatch(stderr); match != nil {
+				if seconds, err := strconv.Atoi(match[1]); err == nil {
+					retryAfter = time.Duration(seconds) * time.Second
+				}
+			}
+			return apperrors.Wrap(apperrors.ErrCodeRateLimited,
+				"1Password rate limit exceeded", cause).WithRetryAfter(retryAfter)
+		}
+	}
+	for _, pattern := range fieldAmbiguousPatterns {
+		if strings.Contains(lower, pattern) {
+			return apperrors.Wrap(apperrors.ErrCodeFieldAmbiguous,
+				"Field matches several fields of the 1Password item", cause)
+		}
+	}
+	for _, pattern := range fieldNotFoundPatterns {
+		if strings.Contains(lower, pattern) {
+			return apperrors.Wrap(apperrors.ErrCodeFieldNotFound,
+				"Field not found on 1Password item", cause)
+		}
+	}
+	for _, pattern := range itemNotFoundPatterns {
+		if strings.Contains(lower, pattern) {
+			return apperrors.Wrap(apperrors.ErrCodeSecretNotFound,
+				"1Password item not found", cause)
+		}
+	}
+	return apperrors.Wrap(apperrors.ErrCodeCLIExecutionFailed,
+		"1Password CLI command failed", cause)
+}
+
 // ListVaults retrieves all available vaults.
 func (c *Client) ListVaults(ctx context.Context) ([]VaultInfo, error) {
 	args := []string{"vault", "list", "--format=json"}
@@ -130,12 +325,7 @@ func (c *Client) ListVaults(ctx context.Context) ([]VaultInfo, error) {
 	defer result.Destroy()
 
 	if result.ExitCode != 0 {
-		stderrStr := ""
-		if result.Stderr != nil {
-			stderrStr = result.Stderr.String()
-		}
-		return nil, fmt.Errorf("vault listing failed with exit code %d: %s",
-			result.ExitCode, stderrStr)
+		return nil, commandError("vault listing", result.ExitCode, c.stderrOf(result))
 	}
 
 	if result.Stdout == nil {
@@ -147,16 +337,33 @@ func (c *Client) ListVaults(ctx context.Context) ([]VaultInfo, error) {
 		return nil, fmt.Errorf("failed to parse vault list: %w", err)
 	}
 
+	c.vaults.reconcile(vaults)
 	return vaults, nil
 }
 
-// ResolveVault resolves a vault name or ID to a VaultInfo.
+// ResolveVault resolves a vault name or ID to a VaultInfo. An identifier in
+// the vault ID format is used as is, without listing vaults. A name is
+// matched exactly, then case-insensitively; a name shared by several vaults
+// is an error listing their IDs rather than a silent pick of one of them.
+// With a vault cache, a name is only looked up again once its entry expires
+// or a later listing resolves it to a different vault.
 func (c *Client) ResolveVault(ctx context.Context, vaultIdentifier string) (*VaultInfo, error) {
-	vaults, err := c.ListVaults(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list vaults: %w", err)
+	if vaultIDPattern.MatchString(vaultIdentifier) {
+		return &VaultInfo{ID: vaultIdentifier, Name: vaultIdentifier}, nil
 	}
 
+	vault, _, err := c.vaults.resolve(ctx, vaultIdentifier, func() (*VaultInfo, error) {
+		vaults, err := c.ListVaults(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vaults: %w", err)
+		}
+		return findVault(vaults, vaultIdentifier)
+	})
+	return vault, err
+}
+
+// findVault picks the vault identified by an ID or name from vaults.
+func findVault(vaults []VaultInfo, vaultIdentifier string) (*VaultInfo, error) {
 	// Try exact ID match first
 	for _, vault := range vaults {
 		if vault.ID == vaultIdentifier {
@@ -164,22 +371,40 @@ func (c *Client) ResolveVault(ctx context.Context, vaultIdentifier string) (*Vau
 		}
 	}
 
-	// Try exact name match
-	for _, vault := range vaults {
-		if vault.Name == vaultIdentifier {
-			return &vault, nil
+	// Try exact name match, then case-insensitive name match
+	matches := matchVaultNames(vaults, func(name string) bool {
+		return name == vaultIdentifier
+	})
+	if len(matches) == 0 {
+		lowerIdentifier := strings.ToLower(vaultIdentifier)
+		matches = matchVaultNames(vaults, func(name string) bool {
+			return strings.ToLower(name) == lowerIdentifier
+		})
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("vault not found: %s", vaultIdentifier)
+	case 1:
+		return &matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, vault := range matches {
+			ids = append(ids, vault.ID)
 		}
+		return nil, apperrors.NewVaultAmbiguousError(vaultIdentifier, ids)
 	}
+}
 
-	// Try case-insensitive name match
-	lowerIdentifier := strings.ToLower(vaultIdentifier)
+// matchVaultNames returns the vaults whose name satisfies match.
+func matchVaultNames(vaults []VaultInfo, match func(name string) bool) []VaultInfo {
+	var matches []VaultInfo
 	for _, vault := range vaults {
-		if strings.ToLower(vault.Name) == lowerIdentifier {
-			return &vault, nil
+		if match(vault.Name) {
+			matches = append(matches, vault)
 		}
 	}
-
-	return nil, fmt.Errorf("vault not found: %s", vaultIdentifier)
+	return matches
 }
 
 // GetSecret retrieves a secret from a 1Password item.
@@ -190,8 +415,9 @@ func (c *Client) GetSecret(ctx context.Context, vault, itemReference, fieldLabel
 		return nil, fmt.Errorf("failed to resolve vault: %w", err)
 	}
 
-	// Build the item reference
-	itemRef := fmt.Sprintf("op://%s/%s/%s", vaultInfo.Name, itemReference, fieldLabel)
+	// Build the item reference by vault ID, which stays unambiguous when
+	// several vaults share a name
+	itemRef := fmt.Sprintf("op://%s/%s/%s", vaultInfo.ID, itemReference, fieldLabel)
 
 	args := []string{"read", itemRef}
 
@@ -211,12 +437,11 @@ func (c *Client) GetSecret(ctx context.Context, vault, itemReference, fieldLabel
 	defer result.Destroy()
 
 	if result.ExitCode != 0 {
-		stderrStr := ""
-		if result.Stderr != nil {
-			stderrStr = result.Stderr.String()
+		err := commandError("secret retrieval", result.ExitCode, c.stderrOf(result))
+		if apperrors.IsErrorCode(err, apperrors.ErrCodeFieldAmbiguous) {
+			return nil, c.ambiguousFieldError(ctx, vaultInfo.ID, itemReference, fieldLabel, err)
 		}
-		return nil, fmt.Errorf("secret retrieval failed with exit code %d: %s",
-			result.ExitCode, stderrStr)
+		return nil, err
 	}
 
 	if result.Stdout == nil {
@@ -224,8 +449,9 @@ func (c *Client) GetSecret(ctx context.Context, vault, itemReference, fieldLabel
 	}
 
 	// Remove trailing newline if present
-	secretValue := strings.TrimSuffix(result.Stdout.String(), "\n")
-	secret, err := security.NewSecureStringFromString(secretValue)
+	secretValue := result.Stdout.Bytes()
+	defer security.SecureZero(secretValue)
+	secret, err := security.NewSecureString(bytes.TrimSuffix(secretValue, []byte("\n")))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create secure string: %w", err)
 	}
@@ -233,6 +459,39 @@ func (c *Client) GetSecret(ctx context.Context, vault, itemReference, fieldLabel
 	return secret, nil
 }
 
+// ambiguousFieldError names the sections holding the fields labeled field,
+// for a read the CLI refused because several fields match. The CLI's error
+// is returned as is when the item cannot be read.
+func (c *Client) ambiguousFieldError(ctx context.Context, vault, itemReference, field string, err error) error {
+	item, itemErr := c.GetItem(ctx, vault, itemReference)
+	if itemErr != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var sections []string
+	for _, f := range item.Fields {
+		if !strings.EqualFold(f.Label, field) {
+			continue
+		}
+		name := "(no section)"
+		if f.Section != nil {
+			name = f.Section.Label
+			if name == "" {
+				name = f.Section.ID
+			}
+		}
+		if !seen[name] {
+			seen[name] = true
+			sections = append(sections, name)
+		}
+	}
+	if len(sections) < 2 {
+		return err
+	}
+	return apperrors.NewFieldAmbiguousError(item.Title, field, sections)
+}
+
 // GetItem retrieves complete information about an item.
 func (c *Client) GetItem(ctx context.Context, vault, itemReference string) (*ItemInfo, error) {
 	// Resolve vault to ensure it exists
@@ -260,12 +519,7 @@ func (c *Client) GetItem(ctx context.Context, vault, itemReference string) (*Ite
 	defer result.Destroy()
 
 	if result.ExitCode != 0 {
-		stderrStr := ""
-		if result.Stderr != nil {
-			stderrStr = result.Stderr.String()
-		}
-		return nil, fmt.Errorf("item retrieval failed with exit code %d: %s",
-			result.ExitCode, stderrStr)
+		return nil, commandError("item retrieval", result.ExitCode, c.stderrOf(result))
 	}
 
 	if result.Stdout == nil {
@@ -280,6 +534,212 @@ func (c *Client) GetItem(ctx context.Context, vault, itemReference string) (*Ite
 	return &item, nil
 }
 
+// GetOTP retrieves the current one-time password generated from the TOTP
+// secret of an item. The code is valid for a single 30 second period.
+func (c *Client) GetOTP(ctx context.Context, vault, itemReference string) (*security.SecureString, error) {
+	vaultInfo, err := c.ResolveVault(ctx, vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vault: %w", err)
+	}
+
+	args := []string{"item", "get", itemReference,
+		"--vault", vaultInfo.ID, "--otp"}
+
+	if validateErr := c.executor.ValidateArgs(args); validateErr != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", validateErr)
+	}
+
+	opts := &ExecutionOptions{
+		Timeout: c.timeout,
+		Env:     c.getAuthEnv(),
+	}
+
+	result, err := c.executor.Execute(ctx, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get one-time password: %w", err)
+	}
+	defer result.Destroy()
+
+	if result.ExitCode != 0 {
+		return nil, commandError("one-time password retrieval", result.ExitCode, c.stderrOf(result))
+	}
+
+	if result.Stdout == nil {
+		return nil, fmt.Errorf("no one-time password received")
+	}
+
+	code := result.Stdout.Bytes()
+	defer security.SecureZero(code)
+	otp, err := security.NewSecureString(bytes.TrimSpace(code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secure string: %w", err)
+	}
+
+	return otp, nil
+}
+
+// GetAttachment downloads the file attached to an item under the given
+// name. The CLI writes the file into a private temporary directory, as its
+// standard output is read line by line and would not preserve binary
+// content; the file is removed once read. A missing attachment is reported
+// with ErrCodeAttachmentNotFound.
+func (c *Client) GetAttachment(ctx context.Context, vault, itemReference, name string) (*security.SecureString, error) {
+	item, err := c.GetItem(ctx, vault, itemReference)
+	if err != nil {
+		return nil, err
+	}
+
+	file := findFile(item.Files, name)
+	if file == nil {
+		names := make([]string, 0, len(item.Files))
+		for _, f := range item.Files {
+			names = append(names, f.Name)
+		}
+		return nil, apperrors.NewAttachmentNotFoundError(itemReference, name, names)
+	}
+	if file.Size > MaxOutputSize {
+		return nil, fmt.Errorf("attachment '%s' is %d bytes, larger than the %d byte limit",
+			name, file.Size, MaxOutputSize)
+	}
+
+	return c.download(ctx, "attachment", func(path string) []string {
+		return []string{"read", "--out-file", path,
+			fmt.Sprintf("op://%s/%s/%s", item.Vault.ID, item.ID, file.ID)}
+	})
+}
+
+// GetDocument downloads the file of a Document item, such as a certificate
+// or keystore stored on its own rather than attached to another item. It is
+// written to disk by op document get in the same way as an attachment.
+func (c *Client) GetDocument(ctx context.Context, vault, itemReference string) (*security.SecureString, error) {
+	item, err := c.GetItem(ctx, vault, itemReference)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(item.Category, DocumentCategory) {
+		return nil, fmt.Errorf("item '%s' is a %s item, not a document; "+
+			"read its attached files with vault/item/file:<name>", itemReference, item.Category)
+	}
+
+	return c.download(ctx, "document", func(path string) []string {
+		return []string{"document", "get", item.ID, "--vault", item.Vault.ID, "--out-file", path}
+	})
+}
+
+// download runs the command args returns for a path inside a private
+// temporary directory and reads back the file the CLI wrote there. The CLI
+// streams the content to disk, so nothing larger than MaxOutputSize is ever
+// read into memory. The directory is removed before returning.
+func (c *Client) download(ctx context.Context, kind string, args func(path string) []string) (*security.SecureString, error) {
+	dir, err := os.MkdirTemp("", "op-"+kind+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", kind, err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, kind)
+
+	command := args(path)
+	if validateErr := c.executor.ValidateArgs(command); validateErr != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", validateErr)
+	}
+
+	opts := &ExecutionOptions{
+		Timeout: c.timeout,
+		Env:     c.getAuthEnv(),
+	}
+
+	result, err := c.executor.Execute(ctx, command, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", kind, err)
+	}
+	defer result.Destroy()
+
+	if result.ExitCode != 0 {
+		return nil, commandError(kind+" retrieval", result.ExitCode, c.stderrOf(result))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", kind, err)
+	}
+	if info.Size() > MaxOutputSize {
+		return nil, fmt.Errorf("%s is %d bytes, larger than the %d byte limit",
+			kind, info.Size(), MaxOutputSize)
+	}
+
+	// #nosec G304 -- path is inside the temporary directory created above
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", kind, err)
+	}
+	defer security.SecureZero(data)
+
+	content, err := security.NewSecureString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secure string: %w", err)
+	}
+
+	return content, nil
+}
+
+// findFile returns the file with the given name, matched exactly and then
+// case-insensitively.
+func findFile(files []FileInfo, name string) *FileInfo {
+	for i := range files {
+		if files[i].Name == name {
+			return &files[i]
+		}
+	}
+	for i := range files {
+		if strings.EqualFold(files[i].Name, name) {
+			return &files[i]
+		}
+	}
+	return nil
+}
+
+// FindItems lists the items in every vault the token can access whose title
+// or ID equals itemReference.
+func (c *Client) FindItems(ctx context.Context, itemReference string) ([]ItemInfo, error) {
+	args := []string{"item", "list", "--format=json"}
+
+	if err := c.executor.ValidateArgs(args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	opts := &ExecutionOptions{
+		Timeout: c.timeout,
+		Env:     c.getAuthEnv(),
+	}
+
+	result, err := c.executor.Execute(ctx, args, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	defer result.Destroy()
+
+	if result.ExitCode != 0 {
+		return nil, commandError("item listing", result.ExitCode, c.stderrOf(result))
+	}
+
+	if result.Stdout == nil {
+		return nil, fmt.Errorf("no output received")
+	}
+
+	var items []ItemInfo
+	if err := json.Unmarshal(result.Stdout.Bytes(), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse item list: %w", err)
+	}
+
+	var matches []ItemInfo
+	for _, item := range items {
+		if item.Title == itemReference || item.ID == itemReference {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
 // ValidateAccess checks if the client can access a specific vault and item.
 func (c *Client) ValidateAccess(ctx context.Context, vault, itemReference string) error {
 	// Try to resolve vault
@@ -5,6 +5,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +14,8 @@ import (
 	"testing"
 	"time"
 
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -187,6 +190,186 @@ func TestClientAuthenticateWithMock(t *testing.T) {
 	}
 }
 
+func TestClientValidateTokenWithMock(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("mock binary uses a POSIX shell")
+	}
+
+	tests := []struct {
+		name     string
+		script   string
+		wantErr  bool
+		wantCode apperrors.ErrorCode
+	}{
+		{
+			name:   "valid token",
+			script: `echo '{"user_type":"SERVICE_ACCOUNT"}'`,
+		},
+		{
+			name:     "revoked token",
+			script:   `echo '[ERROR] service account token has been revoked' >&2; exit 1`,
+			wantErr:  true,
+			wantCode: apperrors.ErrCodeTokenExpired,
+		},
+		{
+			name:     "expired token",
+			script:   `echo '[ERROR] (401) Unauthorized: token expired' >&2; exit 1`,
+			wantErr:  true,
+			wantCode: apperrors.ErrCodeTokenExpired,
+		},
+		{
+			name:     "rejected token",
+			script:   `echo "[ERROR] (401) Unauthorized: You aren't authorized to perform this action" >&2; exit 1`,
+			wantErr:  true,
+			wantCode: apperrors.ErrCodeAuthFailed,
+		},
+		{
+			name:    "other failure",
+			script:  `echo '[ERROR] dial tcp: connection refused' >&2; exit 1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			mockBinary := filepath.Join(tempDir, "mock-op")
+			scriptContent := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" != \"whoami\" ]; then exit 2; fi\n%s\n", tt.script)
+
+			// #nosec G306 -- executable binary requires 0700 permissions
+			if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+				t.Fatalf("Failed to create mock binary: %v", err)
+			}
+
+			manager, err := NewManager(&Config{
+				CacheDir: tempDir,
+				Version:  DefaultCLIVersion,
+				TestMode: true, ExpectedSHA: "test-sha",
+			})
+			if err != nil {
+				t.Fatalf("NewManager() failed: %v", err)
+			}
+			defer func() { _ = manager.Cleanup() }()
+
+			manager.SetBinaryPath(mockBinary)
+			manager.MarkBinaryValid()
+
+			token, err := security.NewSecureStringFromString("test-token")
+			if err != nil {
+				t.Fatalf("Failed to create secure string: %v", err)
+			}
+			defer func() { _ = token.Destroy() }()
+
+			client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+			defer func() { _ = client.Destroy() }()
+
+			err = client.ValidateToken(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode != "" && !apperrors.IsErrorCode(err, tt.wantCode) {
+				t.Errorf("ValidateToken() error = %v, want %s", err, tt.wantCode)
+			}
+			if err != nil && tt.wantCode == "" && apperrors.IsErrorCode(err, apperrors.ErrCodeAuthFailed) {
+				t.Errorf("ValidateToken() error = %v, want an unclassified error", err)
+			}
+		})
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	tests := []struct {
+		name           string
+		stderr         string
+		wantCode       apperrors.ErrorCode
+		wantRetryAfter time.Duration
+	}{
+		{"other failure", "[ERROR] unexpected response", apperrors.ErrCodeCLIExecutionFailed, 0},
+		{"http status", "[ERROR] (429) Too Many Requests", apperrors.ErrCodeRateLimited, 0},
+		{"rate limit text", "[ERROR] rate limit exceeded", apperrors.ErrCodeRateLimited, 0},
+		{"retry after hint", "[ERROR] Too many requests, retry after 30 seconds", apperrors.ErrCodeRateLimited, 30 * time.Second},
+		{"retry-after header", "[ERROR] (429) Too Many Requests. Retry-After: 5", apperrors.ErrCodeRateLimited, 5 * time.Second},
+		{"item not found", `[ERROR] "db" isn't an item in the "ci" vault`, apperrors.ErrCodeSecretNotFound, 0},
+		{"field not found", `[ERROR] item "ci/db" does not have a field "password"`, apperrors.ErrCodeFieldNotFound, 0},
+		{"field ambiguous", `[ERROR] more than one field matches "password"`, apperrors.ErrCodeFieldAmbiguous, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := commandError("secret retrieval", 1, tt.stderr)
+			if !strings.Contains(err.Error(), "secret retrieval failed with exit code 1") {
+				t.Errorf("commandError() = %v, missing the operation and exit code", err)
+			}
+			if got := apperrors.GetErrorCode(err); got != tt.wantCode {
+				t.Fatalf("commandError() = %v, code = %q, want %q", err, got, tt.wantCode)
+			}
+			if got := apperrors.GetRetryAfter(err); got != tt.wantRetryAfter {
+				t.Errorf("GetRetryAfter() = %v, want %v", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestClientRedactsStderr(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("test uses a shell script binary")
+	}
+
+	const secret = "hunter2-correct-horse"
+	tempDir := t.TempDir()
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	script := "#!/bin/sh\necho \"[ERROR] unexpected value '" + secret + "' in response\" >&2\nexit 1\n"
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(script), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	log, err := logger.New()
+	if err != nil {
+		t.Fatalf("logger.New() failed: %v", err)
+	}
+	defer func() { _ = log.Cleanup() }()
+	log.RegisterSecret(secret)
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+		Logger: log,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	_, err = client.ListVaults(context.Background())
+	var actionErr *apperrors.ActionableError
+	if !errors.As(err, &actionErr) || actionErr.Code != apperrors.ErrCodeCLIExecutionFailed {
+		t.Fatalf("ListVaults() error = %v, want %s", err, apperrors.ErrCodeCLIExecutionFailed)
+	}
+	if message := actionErr.Error(); strings.Contains(message, secret) || !strings.Contains(message, "unexpected value '***'") {
+		t.Errorf("ListVaults() error = %q, want the secret redacted", message)
+	}
+}
+
 func TestClientListVaultsWithMock(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -292,7 +475,10 @@ func TestClientResolveVault(t *testing.T) {
 	vaultsJSON := `[
 		{"id":"VAULT1","name":"Personal","description":"Personal vault"},
 		{"id":"VAULT2","name":"Work","description":"Work vault"},
-		{"id":"VAULT3","name":"Test Vault","description":"Test vault with spaces"}
+		{"id":"VAULT3","name":"Test Vault","description":"Test vault with spaces"},
+		{"id":"VAULT5","name":"Shared","description":"Shared vault"},
+		{"id":"VAULT4","name":"Shared","description":"Same name, different vault"},
+		{"id":"VAULT6","name":"shared","description":"Same name, different case"}
 	]`
 
 	var scriptContent string
@@ -347,6 +533,7 @@ func TestClientResolveVault(t *testing.T) {
 		wantID     string
 		wantName   string
 		wantErr    bool
+		wantCode   apperrors.ErrorCode
 	}{
 		{
 			name:       "resolve by exact ID",
@@ -381,6 +568,32 @@ func TestClientResolveVault(t *testing.T) {
 			identifier: "NonExistent",
 			wantErr:    true,
 		},
+		{
+			name:       "vault ID bypasses name resolution",
+			identifier: "abcdefghijklmnopqrstuvwxy1",
+			wantID:     "abcdefghijklmnopqrstuvwxy1",
+			wantName:   "abcdefghijklmnopqrstuvwxy1",
+			wantErr:    false,
+		},
+		{
+			name:       "name shared by several vaults",
+			identifier: "Shared",
+			wantErr:    true,
+			wantCode:   apperrors.ErrCodeVaultAmbiguous,
+		},
+		{
+			name:       "case-insensitive name shared by several vaults",
+			identifier: "SHARED",
+			wantErr:    true,
+			wantCode:   apperrors.ErrCodeVaultAmbiguous,
+		},
+		{
+			name:       "exact name preferred over case-insensitive matches",
+			identifier: "shared",
+			wantID:     "VAULT6",
+			wantName:   "shared",
+			wantErr:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -390,6 +603,14 @@ func TestClientResolveVault(t *testing.T) {
 			if tt.wantErr {
 				if err == nil {
 					t.Error("ResolveVault() should have failed")
+					return
+				}
+				if tt.wantCode != "" && !apperrors.IsErrorCode(err, tt.wantCode) {
+					t.Errorf("ResolveVault() error = %v, want code %s", err, tt.wantCode)
+				}
+				if tt.wantCode == apperrors.ErrCodeVaultAmbiguous &&
+					(!strings.Contains(err.Error(), "VAULT4, VAULT5") || strings.Contains(err.Error(), "VAULT1")) {
+					t.Errorf("ResolveVault() error should list the matching vault IDs: %v", err)
 				}
 				return
 			}
@@ -415,6 +636,150 @@ func TestClientResolveVault(t *testing.T) {
 	}
 }
 
+func TestClientResolveVaultCache(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("vault cache test uses a shell script binary")
+	}
+
+	tempDir := t.TempDir()
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	listings := filepath.Join(tempDir, "listings")
+	vaultsFile := filepath.Join(tempDir, "vaults.json")
+	scriptContent := fmt.Sprintf(`#!/bin/sh
+case "$1 $2" in
+"vault list")
+    echo x >> '%s'
+    cat '%s' ;;
+read*)
+    echo 'secret-value' ;;
+*)
+    echo "Unknown command: $*" >&2
+    exit 1 ;;
+esac
+`, listings, vaultsFile)
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+	writeVaults := func(json string) {
+		if err := os.WriteFile(vaultsFile, []byte(json), 0600); err != nil {
+			t.Fatalf("Failed to write vault list: %v", err)
+		}
+	}
+	countListings := func() int {
+		data, err := os.ReadFile(listings)
+		if os.IsNotExist(err) {
+			return 0
+		}
+		if err != nil {
+			t.Fatalf("Failed to read listing count: %v", err)
+		}
+		return strings.Count(string(data), "x")
+	}
+	writeVaults(`[{"id":"VAULT1","name":"Personal"},{"id":"VAULT2","name":"Work"}]`)
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{
+		Token:         token,
+		Timeout:       30 * time.Second,
+		VaultCacheTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	// Reading several secrets from one vault lists vaults once
+	ctx := context.Background()
+	for _, item := range []string{"db", "api", "cache"} {
+		secret, err := client.GetSecret(ctx, "Personal", item, "password")
+		if err != nil {
+			t.Fatalf("GetSecret() failed: %v", err)
+		}
+		_ = secret.Destroy()
+	}
+	if got := countListings(); got != 1 {
+		t.Errorf("vault listings = %d, want 1", got)
+	}
+
+	// A listing that resolves a cached name differently invalidates it
+	writeVaults(`[{"id":"VAULT3","name":"Personal"},{"id":"VAULT2","name":"Work"}]`)
+	if _, err := client.ResolveVault(ctx, "Work"); err != nil {
+		t.Fatalf("ResolveVault() failed: %v", err)
+	}
+	vault, err := client.ResolveVault(ctx, "Personal")
+	if err != nil {
+		t.Fatalf("ResolveVault() failed: %v", err)
+	}
+	if vault.ID != "VAULT3" {
+		t.Errorf("ResolveVault() ID = %s, want VAULT3 after the vault was replaced", vault.ID)
+	}
+	if got := countListings(); got != 3 {
+		t.Errorf("vault listings = %d, want 3", got)
+	}
+}
+
+func TestVaultCacheExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := newVaultCache(time.Minute, clock)
+
+	loads := 0
+	load := func() (*VaultInfo, error) {
+		loads++
+		return &VaultInfo{ID: "VAULT1", Name: "Personal"}, nil
+	}
+
+	ctx := context.Background()
+	if _, cached, _ := cache.resolve(ctx, "Personal", load); cached {
+		t.Error("first resolve reported a cache hit")
+	}
+	clock.Advance(59 * time.Second)
+	if _, cached, _ := cache.resolve(ctx, "Personal", load); !cached {
+		t.Error("resolve within the TTL missed the cache")
+	}
+	clock.Advance(time.Second)
+	if _, cached, _ := cache.resolve(ctx, "Personal", load); cached {
+		t.Error("resolve after the TTL reported a cache hit")
+	}
+	if loads != 2 {
+		t.Errorf("loads = %d, want 2", loads)
+	}
+
+	// Failed lookups are not cached
+	_, _, err := cache.resolve(ctx, "Missing", func() (*VaultInfo, error) {
+		return nil, fmt.Errorf("vault not found: Missing")
+	})
+	if err == nil {
+		t.Error("resolve() of a missing vault succeeded")
+	}
+	if cache.size() != 1 {
+		t.Errorf("size() = %d, want 1", cache.size())
+	}
+
+	// A nil cache always loads
+	var disabled *vaultCache
+	if _, cached, _ := disabled.resolve(ctx, "Personal", load); cached || loads != 3 {
+		t.Errorf("nil cache: cached = %v, loads = %d", cached, loads)
+	}
+}
+
 func TestClientGetSecretWithMock(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -501,6 +866,363 @@ exit 0
 	}
 }
 
+func TestClientGetSecretCanceled(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("cancellation test uses a shell script binary")
+	}
+
+	// The read hangs in a child process that keeps the output pipes open
+	// after op itself is killed
+	tempDir := t.TempDir()
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	scriptContent := `#!/bin/sh
+case "$1" in
+vault)
+    echo '[{"id":"VAULT1","name":"Personal"}]' ;;
+read)
+    sleep 30 ;;
+esac
+`
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = client.GetSecret(ctx, "Personal", "item", "password")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetSecret() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetSecret() returned %s after cancellation, want promptly", elapsed)
+	}
+}
+
+func TestClientGetSecretSections(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("section test uses a shell script binary")
+	}
+
+	tempDir := t.TempDir()
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	scriptContent := `#!/bin/sh
+case "$1 $2" in
+"vault list")
+    echo '[{"id":"VAULT1","name":"Personal"}]' ;;
+"read op://VAULT1/cluster/Replica/password")
+    echo 'replica-secret' ;;
+"read op://VAULT1/cluster/password")
+    echo '[ERROR] more than one field matches "password"' >&2
+    exit 1 ;;
+"item get")
+    echo '{"id":"ITEM1","title":"cluster","fields":[
+      {"id":"f1","label":"password"},
+      {"id":"f2","label":"password","section":{"id":"s1","label":"Primary"}},
+      {"id":"f3","label":"password","section":{"id":"s2","label":"Replica"}}]}' ;;
+*)
+    echo "Unknown command: $*" >&2
+    exit 1 ;;
+esac
+`
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	// A section-qualified field is read as section/field
+	secret, err := client.GetSecret(context.Background(), "Personal", "cluster", "Replica/password")
+	if err != nil {
+		t.Fatalf("GetSecret() failed: %v", err)
+	}
+	defer func() { _ = secret.Destroy() }()
+	if secret.String() != "replica-secret" {
+		t.Errorf("GetSecret() = %s, want replica-secret", secret.String())
+	}
+
+	// A label shared across sections names the sections
+	_, err = client.GetSecret(context.Background(), "Personal", "cluster", "password")
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeFieldAmbiguous) {
+		t.Fatalf("GetSecret() error = %v, want %s", err, apperrors.ErrCodeFieldAmbiguous)
+	}
+	if !strings.Contains(err.Error(), "in 3 sections: (no section), Primary, Replica") {
+		t.Errorf("GetSecret() error = %v, want the sections named", err)
+	}
+}
+
+func TestClientGetOTPWithMock(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create mock binary that answers vault list and item get --otp
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	if runtime.GOOS == windowsOS {
+		mockBinary += exeExtension
+	}
+
+	var scriptContent string
+	if runtime.GOOS == windowsOS {
+		scriptContent = `@echo off
+if "%1"=="vault" (
+    echo [{"id":"VAULT1","name":"Personal","description":"Personal vault"}]
+) else if "%6"=="--otp" (
+    echo 123456
+) else (
+    echo Unknown command >&2
+    exit 1
+)
+exit 0
+`
+	} else {
+		scriptContent = `#!/bin/sh
+if [ "$1" = "vault" ]; then
+    echo '[{"id":"VAULT1","name":"Personal","description":"Personal vault"}]'
+elif [ "$*" = "item get test-item --vault VAULT1 --otp" ]; then
+    echo '123456'
+else
+    echo "Unknown command: $*" >&2
+    exit 1
+fi
+exit 0
+`
+	}
+
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	config := &Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	}
+
+	manager, err := NewManager(config)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	otp, err := client.GetOTP(context.Background(), "Personal", "test-item")
+	if err != nil {
+		t.Fatalf("GetOTP() failed: %v", err)
+	}
+	defer func() { _ = otp.Destroy() }()
+
+	if otp.String() != "123456" {
+		t.Errorf("GetOTP() = %s, want 123456", otp.String())
+	}
+}
+
+func TestClientGetAttachmentWithMock(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("mock binary writes raw bytes through a POSIX shell")
+	}
+	tempDir := t.TempDir()
+
+	// Create mock binary that answers item get and writes the attachment
+	// to the requested --out-file path
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	scriptContent := `#!/bin/sh
+if [ "$1" = "vault" ]; then
+    echo '[{"id":"VAULT1","name":"Personal","description":"Personal vault"}]'
+elif [ "$*" = "item get release --vault VAULT1 --format=json" ]; then
+    echo '{"id":"ITEM1","title":"release","vault":{"id":"VAULT1","name":"Personal"},"files":[{"id":"FILE1","name":"signing.key","size":14}]}'
+elif [ "$1 $2 $4" = "read --out-file op://VAULT1/ITEM1/FILE1" ]; then
+    printf ' key\r\n\r\n\001end\n ' > "$3"
+else
+    echo "Unknown command: $*" >&2
+    exit 1
+fi
+exit 0
+`
+
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	config := &Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	}
+
+	manager, err := NewManager(config)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	attachment, err := client.GetAttachment(context.Background(), "Personal", "release", "Signing.key")
+	if err != nil {
+		t.Fatalf("GetAttachment() failed: %v", err)
+	}
+	defer func() { _ = attachment.Destroy() }()
+
+	want := " key\r\n\r\n\x01end\n "
+	if attachment.String() != want {
+		t.Errorf("GetAttachment() = %q, want %q", attachment.String(), want)
+	}
+
+	_, err = client.GetAttachment(context.Background(), "Personal", "release", "missing.pem")
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeAttachmentNotFound) {
+		t.Errorf("GetAttachment() error = %v, want %s", err, apperrors.ErrCodeAttachmentNotFound)
+	}
+}
+
+func TestClientGetDocumentWithMock(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("mock binary writes raw bytes through a POSIX shell")
+	}
+	tempDir := t.TempDir()
+
+	// Create mock binary that answers item get and writes the document to
+	// the requested --out-file path
+	mockBinary := filepath.Join(tempDir, "mock-op")
+	scriptContent := `#!/bin/sh
+if [ "$1" = "vault" ]; then
+    echo '[{"id":"VAULT1","name":"Personal","description":"Personal vault"}]'
+elif [ "$*" = "item get keystore --vault VAULT1 --format=json" ]; then
+    echo '{"id":"ITEM1","title":"keystore","category":"DOCUMENT","vault":{"id":"VAULT1","name":"Personal"}}'
+elif [ "$*" = "item get login --vault VAULT1 --format=json" ]; then
+    echo '{"id":"ITEM2","title":"login","category":"LOGIN","vault":{"id":"VAULT1","name":"Personal"}}'
+elif [ "$1 $2 $3 $4 $5 $6" = "document get ITEM1 --vault VAULT1 --out-file" ]; then
+    printf '\376\355\376\355\000\000\000\002' > "$7"
+else
+    echo "Unknown command: $*" >&2
+    exit 1
+fi
+exit 0
+`
+
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	manager, err := NewManager(&Config{
+		CacheDir: tempDir,
+		Version:  DefaultCLIVersion,
+		TestMode: true, ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+
+	token, err := security.NewSecureStringFromString("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create secure string: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(manager, &ClientConfig{Token: token, Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer func() { _ = client.Destroy() }()
+
+	document, err := client.GetDocument(context.Background(), "Personal", "keystore")
+	if err != nil {
+		t.Fatalf("GetDocument() failed: %v", err)
+	}
+	defer func() { _ = document.Destroy() }()
+
+	want := "\xfe\xed\xfe\xed\x00\x00\x00\x02"
+	if document.String() != want {
+		t.Errorf("GetDocument() = %q, want %q", document.String(), want)
+	}
+
+	_, err = client.GetDocument(context.Background(), "Personal", "login")
+	if err == nil || !strings.Contains(err.Error(), "not a document") {
+		t.Errorf("GetDocument() error = %v, want not a document", err)
+	}
+}
+
 func TestClientGetVersionWithMock(t *testing.T) {
 	tempDir := t.TempDir()
 
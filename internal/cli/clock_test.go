@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestClockOrSystem(t *testing.T) {
+	if clockOrSystem(nil) != SystemClock {
+		t.Error("clockOrSystem(nil) is not SystemClock")
+	}
+	clock := &fakeClock{}
+	if clockOrSystem(clock) != clock {
+		t.Error("clockOrSystem() replaced the given clock")
+	}
+}
+
+func TestNewClientVaultCacheUsesClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)}
+	token, err := security.NewSecureStringFromString("dummy-token")
+	if err != nil {
+		t.Fatalf("NewSecureStringFromString() error: %v", err)
+	}
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(&Manager{}, &ClientConfig{Token: token, VaultCacheTTL: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+
+	loads := 0
+	load := func() (*VaultInfo, error) {
+		loads++
+		return &VaultInfo{ID: "VAULT1", Name: "Personal"}, nil
+	}
+	ctx := context.Background()
+	_, _, _ = client.vaults.resolve(ctx, "Personal", load)
+	clock.Advance(2 * time.Minute)
+	if _, cached, _ := client.vaults.resolve(ctx, "Personal", load); cached || loads != 2 {
+		t.Errorf("resolve past the TTL: cached = %v, loads = %d", cached, loads)
+	}
+}
+
+func TestVersionsDB_SaveToPathWithOptions(t *testing.T) {
+	pk := currentPlatformKey(t)
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", pk, strings.Repeat("a", 64))
+
+	db, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("loadDBFromPath() error: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2025, 7, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))}
+	if err := db.SaveToPathWithOptions(dbPath, WriteOptions{Clock: clock}); err != nil {
+		t.Fatalf("SaveToPathWithOptions() error: %v", err)
+	}
+	reloaded, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("reloading saved DB: %v", err)
+	}
+	if want := "2025-07-01T10:30:00Z"; reloaded.GeneratedAt != want || db.GeneratedAt != want {
+		t.Errorf("generated_at = %q (in memory %q), want %q", reloaded.GeneratedAt, db.GeneratedAt, want)
+	}
+}
+
+func TestWriteBundledDBIfMissingWithOptions(t *testing.T) {
+	t.Setenv(envVersionsFile, "")
+	tmpCfg := t.TempDir()
+	if runtime.GOOS == windowsOS {
+		t.Setenv("APPDATA", tmpCfg)
+	} else {
+		t.Setenv("XDG_CONFIG_HOME", tmpCfg)
+	}
+
+	clock := &fakeClock{now: time.Date(2025, 7, 28, 0, 0, 0, 0, time.UTC)}
+	if err := WriteBundledDBIfMissingWithOptions(WriteOptions{Clock: clock}); err != nil {
+		t.Fatalf("WriteBundledDBIfMissingWithOptions() error: %v", err)
+	}
+	path, err := DefaultDBPath()
+	if err != nil {
+		t.Fatalf("DefaultDBPath() error: %v", err)
+	}
+	// #nosec G304 -- test reads the file it just wrote
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("bundled DB not written: %v", err)
+	}
+	if !strings.Contains(string(content), `generated_at: "2025-07-28T00:00:00Z"`) {
+		t.Errorf("bundled DB does not carry the clock's generated_at:\n%s", content)
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+// Embedded checksum of the versions database. A db_checksum at the top of
+// the file is the SHA256 of the canonical form of its versions map; when
+// present it must match on load. It guards a versions file committed to a
+// repository against edits that did not go through SetChecksum, and is no
+// substitute for a signature against a deliberate attacker, who can simply
+// recompute it. Files without db_checksum load as before.
+//
+// The canonical form is the compact JSON object mapping each version key to
+// an object of its non-empty fields, named by their YAML keys, with object
+// keys sorted, so formatting, comments and key order in the file do not
+// affect the checksum.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrDBChecksumMismatch indicates the versions map does not match the
+// db_checksum recorded in the file.
+var ErrDBChecksumMismatch = errors.New("versions DB checksum mismatch")
+
+// ComputeChecksum returns the hex SHA256 of the canonical versions map.
+func (db *VersionsDB) ComputeChecksum() (string, error) {
+	canonical := make(map[string]map[string]string, len(db.Versions))
+	for ver, pcs := range db.Versions {
+		canonical[ver] = checksumFields(pcs)
+	}
+	// encoding/json writes map keys sorted, which makes the form canonical
+	content, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize versions DB: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SetChecksum records the checksum of the current versions map in
+// DBChecksum. SaveToPath keeps it up to date from then on.
+func (db *VersionsDB) SetChecksum() error {
+	sum, err := db.ComputeChecksum()
+	if err != nil {
+		return err
+	}
+	db.DBChecksum = sum
+	return nil
+}
+
+// VerifyChecksum checks the versions map against DBChecksum, failing with
+// ErrDBChecksumMismatch when they differ. A DB without a checksum passes.
+func (db *VersionsDB) VerifyChecksum() error {
+	if db.DBChecksum == "" {
+		return nil
+	}
+	sum, err := db.ComputeChecksum()
+	if err != nil {
+		return err
+	}
+	if sum != db.DBChecksum {
+		return fmt.Errorf("%w: db_checksum is %s but the versions map hashes to %s; "+
+			"the file was edited without updating db_checksum",
+			ErrDBChecksumMismatch, db.DBChecksum, sum)
+	}
+	return nil
+}
+
+// checksumFields returns the non-empty fields of pcs by YAML key.
+func checksumFields(pcs PlatformChecksums) map[string]string {
+	fields := map[string]string{
+		"linux_amd64":          pcs.LinuxAMD64,
+		"linux_arm64":          pcs.LinuxARM64,
+		"darwin_amd64":         pcs.DarwinAMD64,
+		"darwin_arm64":         pcs.DarwinARM64,
+		"windows_amd64":        pcs.WindowsAMD64,
+		"linux_ppc64le":        pcs.LinuxPPC64LE,
+		"linux_s390x":          pcs.LinuxS390X,
+		"linux_amd64_sha512":   pcs.LinuxAMD64SHA512,
+		"linux_arm64_sha512":   pcs.LinuxARM64SHA512,
+		"darwin_amd64_sha512":  pcs.DarwinAMD64SHA512,
+		"darwin_arm64_sha512":  pcs.DarwinARM64SHA512,
+		"windows_amd64_sha512": pcs.WindowsAMD64SHA512,
+		"linux_ppc64le_sha512": pcs.LinuxPPC64LESHA512,
+		"linux_s390x_sha512":   pcs.LinuxS390XSHA512,
+		"released":             pcs.Released,
+	}
+	for key, value := range fields {
+		if value == "" {
+			delete(fields, key)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Versions DB file formats. The format of a file is chosen by its
+// extension; the bundled DB is YAML.
+const (
+	DBFormatYAML = "yaml"
+	DBFormatJSON = "json"
+	DBFormatTOML = "toml"
+)
+
+// dbFormat returns the format of the versions DB at path: JSON for a .json
+// file, TOML for .toml, and YAML for .yaml, .yml and anything else.
+func dbFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return DBFormatJSON
+	case ".toml":
+		return DBFormatTOML
+	default:
+		return DBFormatYAML
+	}
+}
+
+// unmarshalDB decodes content in the given format into db. Keys unknown to
+// the schema are ignored in every format, as they always were for YAML.
+func unmarshalDB(format string, content []byte, db *VersionsDB) error {
+	switch format {
+	case DBFormatJSON:
+		return json.Unmarshal(content, db)
+	case DBFormatTOML:
+		_, err := toml.Decode(string(content), db)
+		return err
+	case DBFormatYAML:
+		return yaml.Unmarshal(content, db)
+	default:
+		return fmt.Errorf("unknown versions DB format %q", format)
+	}
+}
+
+// marshalDB encodes db in the given format.
+func marshalDB(format string, db *VersionsDB) ([]byte, error) {
+	switch format {
+	case DBFormatJSON:
+		content, err := json.MarshalIndent(db, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(content, '\n'), nil
+	case DBFormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(db); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case DBFormatYAML:
+		return yaml.Marshal(db)
+	default:
+		return nil, fmt.Errorf("unknown versions DB format %q", format)
+	}
+}
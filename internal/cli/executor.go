@@ -4,16 +4,20 @@
 package cli
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
 	"time"
 
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/proxy"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -37,6 +41,7 @@ type ExecutionResult struct {
 // ExecutionOptions configure how a command is executed.
 type ExecutionOptions struct {
 	Timeout    time.Duration
+	Connect    bool // Handshake phase: running out of time is a connection timeout
 	Env        []string
 	WorkingDir string
 	Input      *security.SecureString
@@ -47,7 +52,7 @@ func NewExecutor(manager *Manager, timeout time.Duration) *Executor {
 	return &Executor{
 		manager: manager,
 		timeout: timeout,
-		env:     getMinimalEnv(),
+		env:     append(getMinimalEnv(), proxy.Env(manager.proxyURL)...),
 	}
 }
 
@@ -66,13 +71,85 @@ func (e *Executor) Execute(ctx context.Context, args []string, opts *ExecutionOp
 	defer execParams.cancel()
 
 	// Create and configure command
-	cmd, pipes, err := e.createCommand(execParams, args)
+	binaryPath := e.manager.GetBinaryPath()
+	cmd, pipes, err := e.createCommand(execParams, binaryPath, args)
 	if err != nil {
 		return nil, err
 	}
 
 	// Execute command and capture results
-	return e.executeCommand(cmd, pipes, execParams.opts, execParams.ctx)
+	startTime := time.Now()
+	e.manager.trace("Running 1Password CLI command", "binary", binaryPath, "args", args)
+	result, err := e.executeCommand(cmd, pipes, execParams.opts, execParams.ctx)
+	e.traceResult(args, result, err, time.Since(startTime))
+	if err == nil || !isExecPermissionError(err) {
+		return result, execParams.timeoutError(ctx, err, time.Since(startTime))
+	}
+
+	// The binary exists but may not be executed, usually because the cache
+	// directory is on a noexec mount. Relocate it once and retry.
+	if relocateErr := e.manager.relocateForExec(binaryPath); relocateErr != nil {
+		return nil, relocateErr
+	}
+
+	cmd, pipes, err = e.createCommand(execParams, e.manager.GetBinaryPath(), args)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = e.executeCommand(cmd, pipes, execParams.opts, execParams.ctx)
+	e.traceResult(args, result, err, time.Since(startTime))
+	if err != nil && isExecPermissionError(err) {
+		return nil, fmt.Errorf("%w: %s is not executable either: %v", ErrExecDenied, e.manager.GetBinaryPath(), err)
+	}
+	return result, execParams.timeoutError(ctx, err, time.Since(startTime))
+}
+
+// traceResult logs how a command ended at trace level. Output is never
+// logged, as it may hold secret values.
+func (e *Executor) traceResult(args []string, result *ExecutionResult, err error, elapsed time.Duration) {
+	if err != nil || result == nil {
+		e.manager.trace("1Password CLI command failed", "args", args, "duration", elapsed, "error", err)
+		return
+	}
+	e.manager.trace("1Password CLI command finished", "args", args, "exit_code", result.ExitCode, "duration", elapsed)
+}
+
+// timeoutError turns a command that ran out of time into an ActionableError
+// saying which limit was hit. A command in the connect phase that exceeds
+// its own timeout is a connection timeout (connect_timeout); a command that
+// exceeds the operation timeout, or the deadline of the whole run in parent,
+// is an operation timeout. Other errors are returned unchanged.
+func (p *executionParams) timeoutError(parent context.Context, err error, elapsed time.Duration) error {
+	if err == nil || !errors.Is(p.ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	elapsed = elapsed.Round(time.Millisecond)
+
+	if parent.Err() != nil {
+		return apperrors.Wrap(apperrors.ErrCodeCLITimeout,
+			fmt.Sprintf("1Password CLI command stopped when the total operation timeout was reached (elapsed %s)",
+				elapsed), err).
+			WithSuggestions("Increase the timeout input to allow more time for the whole run")
+	}
+	if p.opts.Connect {
+		return apperrors.Wrap(apperrors.ErrCodeCLIConnectTimeout,
+			fmt.Sprintf("1Password CLI could not connect within the %s connection timeout (elapsed %s)",
+				p.timeout, elapsed), err).
+			WithSuggestions("Check network access to 1Password from the runner",
+				"Increase the connect_timeout input")
+	}
+	return apperrors.Wrap(apperrors.ErrCodeCLITimeout,
+		fmt.Sprintf("1Password CLI command exceeded the %s operation timeout (elapsed %s)",
+			p.timeout, elapsed), err).
+		WithSuggestions("Increase the timeout input")
+}
+
+// isExecPermissionError reports whether starting a command failed because
+// the operating system denied execution of the binary.
+func isExecPermissionError(err error) bool {
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr) && errors.Is(pathErr.Err, fs.ErrPermission)
 }
 
 // executionParams holds parameters for command execution
@@ -126,9 +203,9 @@ func (e *Executor) prepareExecutionParams(ctx context.Context, opts *ExecutionOp
 }
 
 // createCommand creates and configures the command with pipes
-func (e *Executor) createCommand(params *executionParams, args []string) (*exec.Cmd, *commandPipes, error) {
+func (e *Executor) createCommand(params *executionParams, binaryPath string, args []string) (*exec.Cmd, *commandPipes, error) {
 	// #nosec G204 -- args are validated by ValidateArgs before reaching this point
-	cmd := exec.CommandContext(params.ctx, e.manager.GetBinaryPath(), args...)
+	cmd := exec.CommandContext(params.ctx, binaryPath, args...)
 	cmd.Dir = params.workingDir
 	cmd.Env = params.env
 
@@ -168,6 +245,11 @@ func (e *Executor) executeCommand(cmd *exec.Cmd, pipes *commandPipes, opts *Exec
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
+	// Killing the command on cancellation does not close its output while
+	// a child process still holds it, so stop reading as well
+	stop := context.AfterFunc(execCtx, func() { e.closePipes(pipes) })
+	defer stop()
+
 	// Handle input
 	e.handleInput(pipes.stdin, opts.Input)
 
@@ -175,6 +257,11 @@ func (e *Executor) executeCommand(cmd *exec.Cmd, pipes *commandPipes, opts *Exec
 	stdoutResult, stderrResult, captureErr := e.captureAllOutput(pipes)
 	if captureErr != nil {
 		e.cleanupResults(stdoutResult, stderrResult)
+		if contextErr := execCtx.Err(); contextErr != nil {
+			// The command has been killed; reap it
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("command execution failed: %w", contextErr)
+		}
 		return nil, captureErr
 	}
 
@@ -287,34 +374,72 @@ func (e *Executor) determineExitCode(cmdErr, contextErr error) (int, error) {
 	return 0, nil
 }
 
-// captureOutput securely captures output from a reader.
+// captureOutput securely captures output from a reader. The output is read
+// into buffers owned here, not a bufio.Scanner whose internal buffer cannot
+// be zeroed, and every one of them is zeroed, so the only copy left is the
+// returned SecureString. Blank lines and carriage returns are dropped.
 func (e *Executor) captureOutput(reader io.Reader) (*security.SecureString, error) {
-	var lines []string
-	scanner := bufio.NewScanner(reader)
-
-	// Set a reasonable buffer size limit
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, MaxOutputSize)
+	var output, line []byte
+	lines := 0
+	chunk := make([]byte, 32*1024)
+
+	defer func() {
+		security.SecureZero(chunk)
+		security.SecureZero(line[:cap(line)])
+		security.SecureZero(output[:cap(output)])
+	}()
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	// endLine appends the pending line to the output, dropping a trailing
+	// carriage return and skipping blank lines
+	endLine := func() error {
+		line = bytes.TrimSuffix(line, []byte{'\r'})
 		if len(line) > 0 {
-			lines = append(lines, line)
+			if lines > 0 {
+				output = security.SecureAppend(output, '\n')
+			}
+			output = security.SecureAppend(output, line...)
+			lines++
 		}
+		line = line[:0]
 
 		// Prevent memory exhaustion
-		if len(lines) > 10000 {
-			return nil, fmt.Errorf("output too large: too many lines")
+		if lines > 10000 {
+			return fmt.Errorf("output too large: too many lines")
 		}
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read output: %w", err)
+	for {
+		n, readErr := reader.Read(chunk)
+		data := chunk[:n]
+		for len(data) > 0 {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				line = security.SecureAppend(line, data...)
+				break
+			}
+			line = security.SecureAppend(line, data[:i]...)
+			if err := endLine(); err != nil {
+				return nil, err
+			}
+			data = data[i+1:]
+		}
+		if len(line) > MaxOutputSize {
+			return nil, fmt.Errorf("failed to read output: line exceeds %d bytes", MaxOutputSize)
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read output: %w", readErr)
+		}
+	}
+	if err := endLine(); err != nil {
+		return nil, err
 	}
 
-	// Join lines and create secure string
-	output := strings.Join(lines, "\n")
-	secureOutput, err := security.NewSecureStringFromString(output)
+	secureOutput, err := security.NewSecureString(output)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create secure string: %w", err)
 	}
@@ -326,7 +451,7 @@ func (e *Executor) captureOutput(reader io.Reader) (*security.SecureString, erro
 func (e *Executor) SetEnvironment(env []string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.env = append(getMinimalEnv(), env...)
+	e.env = append(append(getMinimalEnv(), proxy.Env(e.manager.proxyURL)...), env...)
 }
 
 // SetWorkingDirectory sets the working directory for CLI execution.
@@ -347,6 +472,7 @@ func getMinimalEnv() []string {
 		"TEMP",
 		"TMP",
 	}
+	essential = append(essential, proxy.EnvVars...)
 
 	var env []string
 	for _, key := range essential {
@@ -396,6 +522,8 @@ func (e *Executor) isSafeFlag(flag string) bool {
 		"--encoding",
 		"--no-color",
 		"--raw",
+		"--otp",
+		"--out-file",
 	}
 
 	flagName := strings.Split(flag, "=")[0]
@@ -5,6 +5,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -227,6 +229,8 @@ func TestExecutorIsSafeFlag(t *testing.T) {
 		{"--encoding", true},
 		{"--no-color", true},
 		{"--raw", true},
+		{"--otp", true},
+		{"--out-file", true},
 		{"--vault=test", true}, // Flag with value
 		{"--dangerous", false},
 		{"--exec", false},
@@ -315,6 +319,81 @@ func TestExecutorExecuteWithMockCommand(t *testing.T) {
 	}
 }
 
+// newExecDeniedManager returns a manager whose binary exists but lacks execute
+// permission, which is how a noexec mount presents itself to exec.
+func newExecDeniedManager(t *testing.T, fallbackDir string) *Manager {
+	t.Helper()
+	if runtime.GOOS == windowsOS {
+		t.Skip("exec permission bits are not enforced on Windows")
+	}
+
+	tempDir := t.TempDir()
+	manager, err := NewManager(&Config{
+		CacheDir:        filepath.Join(tempDir, "cache"),
+		Version:         "2.29.0",
+		ExpectedSHA:     "test-sha",
+		TestMode:        true,
+		FallbackExecDir: fallbackDir,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+
+	binary := manager.GetBinaryPath()
+	if err := os.MkdirAll(filepath.Dir(binary), 0700); err != nil {
+		t.Fatalf("failed to create binary dir: %v", err)
+	}
+	script := "#!/bin/sh\necho 'relocated output'\nexit 0\n"
+	if err := os.WriteFile(binary, []byte(script), 0600); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	return manager
+}
+
+func TestExecutorExecute_ExecDeniedFallsBackToExecDir(t *testing.T) {
+	fallbackDir := filepath.Join(t.TempDir(), "exec")
+	manager := newExecDeniedManager(t, fallbackDir)
+	originalPath := manager.GetBinaryPath()
+
+	executor := NewExecutor(manager, DefaultTimeout)
+	result, err := executor.Execute(context.Background(), []string{"--version"}, nil)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	defer result.Destroy()
+
+	if !strings.Contains(result.Stdout.String(), "relocated output") {
+		t.Errorf("Execute() stdout = %q, want relocated binary output", result.Stdout.String())
+	}
+
+	newPath := manager.GetBinaryPath()
+	if newPath == originalPath {
+		t.Fatalf("binary path was not relocated from %s", originalPath)
+	}
+	if !strings.HasPrefix(newPath, fallbackDir) {
+		t.Errorf("relocated binary %s is not under fallback dir %s", newPath, fallbackDir)
+	}
+}
+
+func TestExecutorExecute_ExecDeniedWithoutFallbackGivesGuidance(t *testing.T) {
+	manager := newExecDeniedManager(t, "")
+
+	executor := NewExecutor(manager, DefaultTimeout)
+	_, err := executor.Execute(context.Background(), []string{"--version"}, nil)
+	if err == nil {
+		t.Fatal("Execute() expected error for non-executable binary")
+	}
+	if !errors.Is(err, ErrExecDenied) {
+		t.Fatalf("expected ErrExecDenied, got %v", err)
+	}
+	for _, want := range []string{"noexec", "exec_fallback_dir", "OP_EXEC_FALLBACK_DIR"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err.Error(), want)
+		}
+	}
+}
+
 func TestExecutorExecuteWithTimeout(t *testing.T) {
 	// Create a mock binary that sleeps
 	tempDir := t.TempDir()
@@ -372,6 +451,80 @@ func TestExecutorExecuteWithTimeout(t *testing.T) {
 	}
 }
 
+func TestExecutorExecuteTimeoutKinds(t *testing.T) {
+	tempDir := t.TempDir()
+
+	mockBinary := filepath.Join(tempDir, "mock-sleep")
+	scriptContent := "#!/bin/sh\nexec sleep 10\n"
+	if runtime.GOOS == windowsOS {
+		mockBinary += exeExtension
+		scriptContent = "@echo off\nping 127.0.0.1 -n 10 >nul\n"
+	}
+	// #nosec G306 -- executable binary requires 0700 permissions
+	if err := os.WriteFile(mockBinary, []byte(scriptContent), 0700); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	manager, err := NewManager(&Config{CacheDir: tempDir, Version: "2.29.0", ExpectedSHA: "test-sha"})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetBinaryPath(mockBinary)
+	manager.MarkBinaryValid()
+	executor := NewExecutor(manager, DefaultTimeout)
+
+	tests := []struct {
+		name          string
+		parentTimeout time.Duration
+		opts          *ExecutionOptions
+		wantCode      apperrors.ErrorCode
+		wantMessage   string
+	}{
+		{
+			name:        "connect phase",
+			opts:        &ExecutionOptions{Timeout: 100 * time.Millisecond, Connect: true},
+			wantCode:    apperrors.ErrCodeCLIConnectTimeout,
+			wantMessage: "100ms connection timeout",
+		},
+		{
+			name:        "operation",
+			opts:        &ExecutionOptions{Timeout: 100 * time.Millisecond},
+			wantCode:    apperrors.ErrCodeCLITimeout,
+			wantMessage: "100ms operation timeout",
+		},
+		{
+			name:          "total operation",
+			parentTimeout: 100 * time.Millisecond,
+			opts:          &ExecutionOptions{Timeout: 10 * time.Second, Connect: true},
+			wantCode:      apperrors.ErrCodeCLITimeout,
+			wantMessage:   "total operation timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.parentTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.parentTimeout)
+				defer cancel()
+			}
+
+			_, err := executor.Execute(ctx, []string{"test"}, tt.opts)
+			if !apperrors.IsErrorCode(err, tt.wantCode) {
+				t.Fatalf("Execute() error = %v, want code %s", err, tt.wantCode)
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) || !strings.Contains(err.Error(), "elapsed") {
+				t.Errorf("Execute() error %q should mention %q and the elapsed time", err, tt.wantMessage)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Execute() error should wrap context.DeadlineExceeded")
+			}
+		})
+	}
+}
+
 func TestExecutorExecuteWithInput(t *testing.T) {
 	// Create a mock binary that reads stdin
 	tempDir := t.TempDir()
@@ -624,3 +777,34 @@ func TestCaptureOutputLargeOutput(t *testing.T) {
 		t.Errorf("captureOutput() should fail with 'too many lines' error, got: %v", err)
 	}
 }
+
+func TestCaptureOutputLines(t *testing.T) {
+	manager, err := NewManager(&Config{
+		CacheDir:    t.TempDir(),
+		Version:     "2.29.0",
+		ExpectedSHA: "test-sha",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+
+	executor := NewExecutor(manager, DefaultTimeout)
+
+	// A line longer than a read is kept whole; blank lines and carriage
+	// returns are dropped
+	long := strings.Repeat("x", 100*1024)
+	result, err := executor.captureOutput(strings.NewReader("first\r\n\n" + long + "\nlast"))
+	if err != nil {
+		t.Fatalf("captureOutput() failed: %v", err)
+	}
+	defer func() { _ = result.Destroy() }()
+	if got, want := result.String(), "first\n"+long+"\nlast"; got != want {
+		t.Errorf("captureOutput() = %d bytes, want %d bytes", len(got), len(want))
+	}
+
+	_, err = executor.captureOutput(strings.NewReader(strings.Repeat("x", MaxOutputSize+1)))
+	if err == nil || !strings.Contains(err.Error(), "line exceeds") {
+		t.Errorf("captureOutput() should fail for a line over the limit, got: %v", err)
+	}
+}
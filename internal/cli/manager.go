@@ -6,18 +6,28 @@ package cli
 
 import (
 	"archive/zip"
+	"bufio"
+	"bytes"
 	"context"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
-
+	"sync"
 	"time"
+
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/metrics"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/proxy"
 )
 
 const (
@@ -27,12 +37,24 @@ const (
 	// DefaultDownloadTimeout is the default download timeout
 	DefaultDownloadTimeout = 5 * time.Minute
 
+	// DefaultDownloadMaxAttempts is the default number of CLI download attempts
+	DefaultDownloadMaxAttempts = 3
+
+	// DefaultRetryTimeout caps the total time spent retrying a CLI download
+	DefaultRetryTimeout = 30 * time.Second
+
+	// defaultRetryBaseDelay is the backoff before the first download retry
+	defaultRetryBaseDelay = 1 * time.Second
+
 	// BaseDownloadURL is the 1Password CLI download base URL
 	BaseDownloadURL = "https://cache.agilebits.com/dist/1P/op2"
 
 	// CacheDir is the cache directory for CLI binaries
 	CacheDir = ".op-cache"
 
+	// versionCheckTimeout bounds the post-install `op --version` self-check
+	versionCheckTimeout = 10 * time.Second
+
 	// MaxOutputSize is the maximum CLI output size (10MB)
 	MaxOutputSize = 10 * 1024 * 1024
 
@@ -40,6 +62,66 @@ const (
 	windowsAMD64 = "windows_amd64"
 )
 
+// ErrInsecureDownloadURL indicates a download base URL that is not https
+// while insecure downloads have not been allowed.
+var ErrInsecureDownloadURL = errors.New("CLI download base URL must use https")
+
+// ErrOfflineCLIMissing indicates offline mode found no CLI binary to use.
+var ErrOfflineCLIMissing = errors.New("offline mode requires pre-installed CLI")
+
+// ErrCLIVerificationFailed indicates the CLI binary does not match the
+// checksum recorded in the versions DB.
+var ErrCLIVerificationFailed = errors.New("CLI verification failed")
+
+// ErrInstallDirNotWritable indicates the CLI install directory cannot be
+// written, so the CLI cannot be downloaded into it.
+var ErrInstallDirNotWritable = errors.New("CLI install directory is not writable")
+
+// ErrExecDenied indicates the operating system refused to execute the CLI binary.
+var ErrExecDenied = errors.New("1Password CLI binary could not be executed")
+
+// ErrUnexpectedResponse indicates the download server returned something other
+// than a CLI archive, typically an HTML page from a proxy or captive portal.
+var ErrUnexpectedResponse = errors.New("unexpected response from CLI download server, possible proxy/captive portal")
+
+// ErrDownloadTimeout indicates the CLI download, including its retries, did
+// not finish within the configured download timeout.
+var ErrDownloadTimeout = errors.New("1Password CLI download timed out")
+
+// zipMagic is the signature at the start of every non-empty zip archive.
+var zipMagic = []byte("PK\x03\x04")
+
+// DownloadError reports that the CLI could not be downloaded and verified
+// within the configured number of attempts.
+type DownloadError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("failed to download 1Password CLI after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// httpStatusError reports a non-200 response from the download server.
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("download failed with status %d", e.StatusCode)
+}
+
+// How EnsureCLI obtained the binary, as reported by Source
+const (
+	SourceDownloaded   = "downloaded"
+	SourceCached       = "cached"
+	SourcePreinstalled = "preinstalled"
+)
+
 // PlatformInfo contains information about the platform and CLI version
 type PlatformInfo struct {
 	Version  string
@@ -51,26 +133,73 @@ type PlatformInfo struct {
 // Manager handles 1Password CLI lifecycle and execution.
 type Manager struct {
 	cacheDir         string
+	installDir       string // Configured install directory; empty when CacheDir is used
 	timeout          time.Duration
 	downloadURL      string
 	httpClient       *http.Client
 	version          string
 	expectedSHA      string
+	expectedSHA512   string // Preferred over expectedSHA when set
 	binaryPath       string
+	binaryCacheDir   string // Persistent cache of verified binaries; empty disables it
 	testMode         bool
 	disableStderrOut bool // Control stderr output
+	fallbackExecDir  string
+	offline          bool
+	proxyURL         string // Explicit proxy override; empty uses the environment
+	maxAttempts      int
+	retryTimeout     time.Duration
+	downloadTimeout  time.Duration
+	retryBaseDelay   time.Duration
+	logger           *logger.Logger
+	metrics          metrics.Metrics
+	source           string // Set by EnsureCLI; empty until it succeeds
+	allowedVersions  []string
+	mu               sync.RWMutex
+
+	// versionMu guards versionChecked, set once the installed binary has
+	// reported the requested version
+	versionMu      sync.Mutex
+	versionChecked bool
 }
 
 // Config holds configuration for the CLI manager.
 type Config struct {
 	CacheDir         string
+	InstallDir       string // Directory the CLI is installed into instead of CacheDir
 	Timeout          time.Duration
-	DownloadTimeout  time.Duration
+	DownloadTimeout  time.Duration // Upper bound on the whole download, including retries
 	Version          string
 	ExpectedSHA      string
+	ExpectedSHA512   string // Verified instead of ExpectedSHA when set
 	TestMode         bool
-	DownloadURL      string // Custom download URL for the 1Password CLI binary
-	DisableStderrOut bool   // Disable direct stderr output (for library usage)
+	DownloadURL      string          // Custom download URL for the 1Password CLI binary
+	DownloadBaseURL  string          // Mirror replacing BaseDownloadURL; the archive path is appended
+	AllowInsecure    bool            // Allow an http DownloadBaseURL
+	Offline          bool            // Never download; use a pre-installed op found on PATH
+	BinaryPath       string          // Pre-installed op used in offline mode instead of searching PATH
+	ProxyURL         string          // Proxy overriding HTTPS_PROXY/HTTP_PROXY; NO_PROXY still applies
+	DisableStderrOut bool            // Disable direct stderr output (for library usage)
+	FallbackExecDir  string          // Alternate install directory used when CacheDir is mounted noexec
+	MaxAttempts      int             // Download attempts before giving up (default 3)
+	RetryTimeout     time.Duration   // Upper bound on total time spent retrying downloads
+	Logger           *logger.Logger  // Optional logger for download diagnostics
+	Metrics          metrics.Metrics // Optional hook receiving downloaded archive sizes
+
+	// BinaryCacheDir keeps verified binaries across runs, keyed by version,
+	// platform and checksum. Empty uses DefaultBinaryCacheDir, except in
+	// test mode, which has no persistent cache unless a directory is given.
+	BinaryCacheDir     string
+	DisableBinaryCache bool // Always download, ignoring and never filling the binary cache
+
+	// SkipChecksumVerification never verifies the binary, for platforms the
+	// versions DB has no checksum for. The CLI is then trusted blindly
+	SkipChecksumVerification bool
+
+	// AllowedVersions restricts the CLI versions the manager accepts,
+	// whether requested, detected or reported by the binary. Empty allows
+	// every version in the versions DB.
+	AllowedVersions []string
 }
 
 // DefaultConfig returns a default configuration.
@@ -84,6 +213,8 @@ func DefaultConfig() *Config {
 		CacheDir:         CacheDir,
 		Timeout:          DefaultTimeout,
 		DownloadTimeout:  DefaultDownloadTimeout,
+		MaxAttempts:      DefaultDownloadMaxAttempts,
+		RetryTimeout:     DefaultRetryTimeout,
 		Version:          DefaultCLIVersion, // Latest stable version
 		ExpectedSHA:      "",                // Will be set based on platform
 		DisableStderrOut: inGitHubActions,   // Disable stderr output in GitHub Actions by default
@@ -96,23 +227,73 @@ func NewManager(cfg *Config) (*Manager, error) {
 		cfg = DefaultConfig()
 	}
 
+	binaryName := "op"
+	if runtime.GOOS == windowsOS {
+		binaryName = "op.exe"
+	}
+
+	// Offline runners use the configured CLI, or else the one installed on
+	// PATH; a missing binary is reported by EnsureCLI rather than here
+	offlinePath := ""
+	if cfg.Offline && cfg.BinaryPath != "" {
+		abs, err := filepath.Abs(cfg.BinaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CLI binary path: %w", err)
+		}
+		offlinePath = abs
+	} else if cfg.Offline {
+		if path, err := exec.LookPath(binaryName); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				offlinePath = abs
+			}
+		}
+	}
+
+	// Without a pinned version an offline runner uses whichever version is
+	// installed, and verifies the binary against that version's checksums
+	detectedFrom := ""
+	if offlinePath != "" && (cfg.Version == "" || cfg.Version == "latest") {
+		if _, err := os.Stat(offlinePath); err == nil {
+			detected, err := DetectCLIVersion(offlinePath)
+			if err != nil {
+				return nil, err
+			}
+			if _, _, err := ExpectedChecksumsFromDB(detected); errors.Is(err, ErrUnsupportedVersion) {
+				return nil, fmt.Errorf("%w: %s reports version %s, which the versions database does not list",
+					ErrUnsupportedVersion, offlinePath, detected)
+			}
+			cfg.Version = detected
+			detectedFrom = offlinePath
+		}
+	}
+
 	// Resolve "latest" to actual version and normalize any leading 'v'
-	if cfg.Version == "latest" {
+	if cfg.Version == "" || cfg.Version == "latest" {
 		cfg.Version = DefaultCLIVersion // Use the default latest stable version
 	}
 	cfg.Version = strings.TrimPrefix(cfg.Version, "v")
+	if err := checkAllowedVersion(cfg.Version, cfg.AllowedVersions, detectedFrom); err != nil {
+		return nil, err
+	}
 
-	// Set platform-specific expected SHA
-	if cfg.ExpectedSHA == "" {
+	// Set platform-specific expected checksums; with verification skipped
+	// there is nothing to verify against
+	if cfg.SkipChecksumVerification {
+		cfg.ExpectedSHA, cfg.ExpectedSHA512 = "", ""
+	} else if cfg.ExpectedSHA == "" && cfg.ExpectedSHA512 == "" {
 		var err error
-		cfg.ExpectedSHA, err = getExpectedSHA(cfg.Version)
+		cfg.ExpectedSHA, cfg.ExpectedSHA512, err = getExpectedChecksums(cfg.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get expected SHA: %w", err)
 		}
 	}
 
-	// Create cache directory
-	cacheDir, err := filepath.Abs(cfg.CacheDir)
+	// Create cache directory, or the install directory replacing it
+	dir := cfg.CacheDir
+	if cfg.InstallDir != "" {
+		dir = cfg.InstallDir
+	}
+	cacheDir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve cache directory: %w", err)
 	}
@@ -120,20 +301,38 @@ func NewManager(cfg *Config) (*Manager, error) {
 	if err := os.MkdirAll(cacheDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
+	installDir := ""
+	if cfg.InstallDir != "" {
+		installDir = cacheDir
+	}
 
-	// Create HTTP client with timeout
+	// Create HTTP client with timeout, honoring the proxy environment.
+	// downloadWithRetry also bounds the whole download, retries included
+	proxyFunc, err := proxy.Func(cfg.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
 	client := &http.Client{
 		Timeout: cfg.DownloadTimeout,
 		Transport: &http.Transport{
+			Proxy:             proxyFunc,
 			DisableKeepAlives: true,
 		},
 	}
 
-	// Use custom download URL if provided, otherwise build the default URL
+	// Use custom download URL if provided, otherwise build the URL from
+	// the mirror or default base
+	baseURL := BaseDownloadURL
+	if cfg.DownloadBaseURL != "" {
+		if err := ValidateDownloadBaseURL(cfg.DownloadBaseURL, cfg.AllowInsecure); err != nil {
+			return nil, err
+		}
+		baseURL = strings.TrimRight(cfg.DownloadBaseURL, "/")
+	}
 	downloadURL := cfg.DownloadURL
 	if downloadURL == "" {
 		downloadURL = fmt.Sprintf("%s/pkg/v%s/op_%s_%s_v%s.zip",
-			BaseDownloadURL,
+			baseURL,
 			cfg.Version,
 			runtime.GOOS,
 			runtime.GOARCH,
@@ -141,39 +340,436 @@ func NewManager(cfg *Config) (*Manager, error) {
 		)
 	}
 
-	binaryName := "op"
-	if runtime.GOOS == windowsOS {
-		binaryName = "op.exe"
+	binaryPath := filepath.Join(cacheDir, fmt.Sprintf("op-%s", cfg.Version), binaryName)
+	if cfg.Offline {
+		binaryPath = offlinePath
 	}
 
-	binaryPath := filepath.Join(cacheDir, fmt.Sprintf("op-%s", cfg.Version), binaryName)
+	binaryCacheDir := ""
+	if !cfg.DisableBinaryCache && !cfg.Offline {
+		binaryCacheDir = cfg.BinaryCacheDir
+		if binaryCacheDir == "" && !cfg.TestMode {
+			// Without a config directory the binary is simply downloaded
+			binaryCacheDir, _ = DefaultBinaryCacheDir()
+		}
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultDownloadMaxAttempts
+	}
+	retryTimeout := cfg.RetryTimeout
+	if retryTimeout <= 0 {
+		retryTimeout = DefaultRetryTimeout
+	}
+	downloadTimeout := cfg.DownloadTimeout
+	if downloadTimeout <= 0 {
+		downloadTimeout = DefaultDownloadTimeout
+	}
 
 	return &Manager{
 		cacheDir:         cacheDir,
+		installDir:       installDir,
 		timeout:          cfg.Timeout,
 		downloadURL:      downloadURL,
 		httpClient:       client,
 		version:          cfg.Version,
 		expectedSHA:      cfg.ExpectedSHA,
+		expectedSHA512:   cfg.ExpectedSHA512,
 		binaryPath:       binaryPath,
+		binaryCacheDir:   binaryCacheDir,
 		testMode:         cfg.TestMode,
 		disableStderrOut: cfg.DisableStderrOut,
+		fallbackExecDir:  cfg.FallbackExecDir,
+		offline:          cfg.Offline,
+		proxyURL:         cfg.ProxyURL,
+		maxAttempts:      maxAttempts,
+		retryTimeout:     retryTimeout,
+		downloadTimeout:  downloadTimeout,
+		retryBaseDelay:   defaultRetryBaseDelay,
+		metrics:          metrics.OrNop(cfg.Metrics),
+		logger:           cfg.Logger,
+		allowedVersions:  cfg.AllowedVersions,
 	}, nil
 }
 
+// ValidateDownloadBaseURL checks that raw is an absolute https URL with a
+// host, such as "https://artifacts.example.com/1password/op2". Plain http is
+// accepted only when allowInsecure is set; the downloaded archive is still
+// verified against the versions DB either way.
+func ValidateDownloadBaseURL(raw string, allowInsecure bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid CLI download base URL: %w", err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid CLI download base URL %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid CLI download base URL %q: query and fragment are not allowed", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if allowInsecure {
+			return nil
+		}
+		return fmt.Errorf("%w: %q (set allow_insecure_download to use http)", ErrInsecureDownloadURL, raw)
+	default:
+		return fmt.Errorf("invalid CLI download base URL %q: scheme must be https", raw)
+	}
+}
+
 // EnsureCLI ensures the 1Password CLI is available and verified.
 func (m *Manager) EnsureCLI(ctx context.Context) error {
-	// Check if binary already exists and is valid
+	if m.offline {
+		if err := m.verifyPreinstalled(); err != nil {
+			return err
+		}
+		return m.finishEnsure(ctx, SourcePreinstalled)
+	}
+
+	// Download and verify CLI unless a valid binary already exists, here
+	// or in the binary cache
+	source := SourceCached
+	if !m.isValidBinary() {
+		if err := m.checkInstallDirWritable(); err != nil {
+			return err
+		}
+		if !m.restoreFromBinaryCache() {
+			if err := m.downloadWithRetry(ctx); err != nil {
+				return err
+			}
+			m.storeInBinaryCache()
+			source = SourceDownloaded
+		}
+	}
+
+	return m.finishEnsure(ctx, source)
+}
+
+// checkInstallDirWritable confirms a configured install directory accepts
+// new files before anything is downloaded into it. The default cache
+// directory is not checked; a failure there surfaces from the download.
+func (m *Manager) checkInstallDirWritable() error {
+	if m.installDir == "" {
+		return nil
+	}
+	m.debug("Installing 1Password CLI into configured directory",
+		"install_dir", m.installDir, "path", m.GetBinaryPath())
+
+	return probeWritable(m.installDir)
+}
+
+// probeWritable creates and removes a file in dir, failing with
+// ErrInstallDirNotWritable when dir does not accept new files.
+func probeWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".op-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInstallDirNotWritable, dir, err)
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+// CheckInstallable reports whether EnsureCLI could provide the CLI, without
+// downloading or running anything: in offline mode the pre-installed binary
+// must exist, otherwise either a verified binary is already in place or the
+// install directory accepts the download.
+func (m *Manager) CheckInstallable() error {
+	if m.offline {
+		binaryPath := m.GetBinaryPath()
+		if binaryPath == "" {
+			return fmt.Errorf("%w: op was not found on PATH", ErrOfflineCLIMissing)
+		}
+		if _, err := os.Stat(binaryPath); err != nil {
+			return fmt.Errorf("%w: %v", ErrOfflineCLIMissing, err)
+		}
+		return nil
+	}
 	if m.isValidBinary() {
 		return nil
 	}
+	return probeWritable(m.cacheDir)
+}
+
+// InstallDir returns the resolved directory the CLI is installed into.
+func (m *Manager) InstallDir() string {
+	return m.cacheDir
+}
+
+// finishEnsure verifies the binary, checks the installed version and
+// records how EnsureCLI obtained the binary.
+func (m *Manager) finishEnsure(ctx context.Context, source string) error {
+	if err := m.verifyInstalled(source); err != nil {
+		return err
+	}
+	if err := m.checkInstalledVersion(ctx); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.source = source
+	return nil
+}
+
+// Source reports whether the binary was downloaded, taken from a cache or
+// found pre-installed, or "" when EnsureCLI has not succeeded.
+func (m *Manager) Source() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.source
+}
+
+// verifyPreinstalled checks the CLI used in offline mode exists. It never
+// downloads anything; finishEnsure verifies its checksum.
+func (m *Manager) verifyPreinstalled() error {
+	binaryPath := m.GetBinaryPath()
+	if binaryPath == "" {
+		return fmt.Errorf("%w: op was not found on PATH", ErrOfflineCLIMissing)
+	}
+	if _, err := os.Stat(binaryPath); err != nil {
+		return fmt.Errorf("%w: %v", ErrOfflineCLIMissing, err)
+	}
+	m.debug("Using pre-installed 1Password CLI", "path", binaryPath)
+	return nil
+}
+
+// verifyInstalled hashes the binary EnsureCLI resolved and compares it with
+// the versions DB, whether it was downloaded, restored from a cache or found
+// pre-installed, so nothing unverified is ever executed. It fails with
+// ErrCLIVerificationFailed on a mismatch.
+func (m *Manager) verifyInstalled(source string) error {
+	if m.testMode || !m.hasChecksum() {
+		return nil
+	}
+	binaryPath := m.GetBinaryPath()
+	if err := m.verifyChecksum(binaryPath); err != nil {
+		if source == SourcePreinstalled {
+			return fmt.Errorf("%w for pre-installed %s: %w", ErrCLIVerificationFailed, binaryPath, err)
+		}
+		return fmt.Errorf("%w for %s: %w", ErrCLIVerificationFailed, binaryPath, err)
+	}
+	m.debug("Verified 1Password CLI checksum", "path", binaryPath, "source", source)
+	return nil
+}
+
+// checkInstalledVersion runs `op --version` once and confirms the binary
+// reports the requested version. A matching checksum already proves which
+// file was downloaded; this catches a stale cached binary or a wrapper that
+// execs some other op, and fails with ErrCodeCLIVersionMismatch.
+func (m *Manager) checkInstalledVersion(ctx context.Context) error {
+	if m.testMode {
+		return nil
+	}
 
-	// Download and verify CLI
-	return m.downloadAndVerify(ctx)
+	m.versionMu.Lock()
+	defer m.versionMu.Unlock()
+	if m.versionChecked {
+		return nil
+	}
+
+	binaryPath := m.GetBinaryPath()
+	reported, err := runVersion(ctx, binaryPath)
+	if err != nil && isExecPermissionError(err) {
+		if relocateErr := m.relocateForExec(binaryPath); relocateErr != nil {
+			return relocateErr
+		}
+		binaryPath = m.GetBinaryPath()
+		reported, err = runVersion(ctx, binaryPath)
+	}
+	if err != nil {
+		return fmt.Errorf("CLI self-check failed: %s --version: %w", binaryPath, err)
+	}
+
+	if err := checkAllowedVersion(reported, m.allowedVersions, binaryPath); err != nil {
+		return err
+	}
+	if NormalizeVersion(reported) != NormalizeVersion(m.version) {
+		return apperrors.New(apperrors.ErrCodeCLIVersionMismatch,
+			fmt.Sprintf("1Password CLI at %s reports version %s, expected %s", binaryPath, reported, m.version)).
+			WithDetails(map[string]interface{}{
+				"binary_path":      binaryPath,
+				"reported_version": reported,
+				"expected_version": m.version,
+			}).
+			WithSuggestions(
+				"Clear the CLI cache directory so the requested version is downloaded again",
+				"Check that nothing replaces or wraps the op binary in the cache directory",
+			)
+	}
+
+	m.versionChecked = true
+	m.debug("CLI version self-check passed", "version", reported)
+	return nil
+}
+
+// checkAllowedVersion fails with ErrCodeCLIVersionNotAllowed when allowed
+// is not empty and does not list version. binaryPath names the binary
+// reporting the version, if any.
+func checkAllowedVersion(version string, allowed []string, binaryPath string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, entry := range allowed {
+		if NormalizeVersion(entry) == NormalizeVersion(version) {
+			return nil
+		}
+	}
+
+	message := fmt.Sprintf("1Password CLI version %s is not in allowed_cli_versions", version)
+	if binaryPath != "" {
+		message = fmt.Sprintf("1Password CLI at %s reports version %s, which is not in allowed_cli_versions",
+			binaryPath, version)
+	}
+	return apperrors.New(apperrors.ErrCodeCLIVersionNotAllowed, message).
+		WithDetails(map[string]interface{}{
+			"binary_path":      binaryPath,
+			"version":          version,
+			"allowed_versions": allowed,
+		}).
+		WithSuggestions(
+			"Install one of the allowed versions on the runner, or set cli_version to one of them",
+			"Add the version to allowed_cli_versions once it has been reviewed",
+		)
+}
+
+// DetectCLIVersion runs `<binaryPath> --version` and returns the semantic
+// version the binary reports, such as "2.31.1". The binary is executed
+// before its checksum can be verified, as the checksum to verify against
+// depends on the version; it runs with a minimal environment and no token.
+func DetectCLIVersion(binaryPath string) (string, error) {
+	reported, err := runVersion(context.Background(), binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect CLI version: %s --version: %w", binaryPath, err)
+	}
+	match := reportedVersion.FindString(reported)
+	if match == "" {
+		return "", fmt.Errorf("%w: %s --version printed %q", ErrInvalidVersion, binaryPath, reported)
+	}
+	if _, err := ParseVersion(match); err != nil {
+		return "", err
+	}
+	return NormalizeVersion(match), nil
+}
+
+// reportedVersion finds the version in `op --version` output.
+var reportedVersion = regexp.MustCompile(`\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?`)
+
+// runVersion returns the trimmed output of `<binaryPath> --version`.
+func runVersion(ctx context.Context, binaryPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
+	defer cancel()
+
+	// #nosec G204 -- binaryPath is the manager's own verified binary
+	cmd := exec.CommandContext(ctx, binaryPath, "--version")
+	cmd.Env = getMinimalEnv()
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// downloadWithRetry runs downloadAndVerify with exponential backoff and jitter.
+// Every attempt downloads and verifies a fresh copy, and the total time spent
+// waiting between attempts never exceeds the retry timeout. The whole download
+// is cancelled once the download timeout passes, failing with
+// ErrDownloadTimeout rather than a generic timeout.
+func (m *Manager) downloadWithRetry(ctx context.Context) (err error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, m.downloadTimeout, ErrDownloadTimeout)
+	defer cancel()
+	defer func() {
+		var downloadErr *DownloadError
+		if errors.As(err, &downloadErr) && errors.Is(context.Cause(ctx), ErrDownloadTimeout) {
+			downloadErr.Err = fmt.Errorf("%w after %s: %v", ErrDownloadTimeout, m.downloadTimeout, downloadErr.Err)
+		}
+	}()
+
+	deadline := time.Now().Add(m.retryTimeout)
+	delay := m.retryBaseDelay
+
+	var lastErr error
+	attempt := 0
+	for attempt < m.maxAttempts {
+		attempt++
+		m.debug("Downloading 1Password CLI", "attempt", attempt, "max_attempts", m.maxAttempts)
+
+		lastErr = m.downloadAndVerify(ctx)
+		if lastErr == nil {
+			return nil
+		}
+		m.debug("CLI download attempt failed", "attempt", attempt, "error", lastErr.Error())
+
+		if attempt == m.maxAttempts || ctx.Err() != nil || !isRetryableDownloadError(lastErr) {
+			break
+		}
+
+		wait := withJitter(delay)
+		if time.Now().Add(wait).After(deadline) {
+			m.debug("CLI download retry timeout reached", "attempt", attempt, "retry_timeout", m.retryTimeout)
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return &DownloadError{Attempts: attempt, Err: ctx.Err()}
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+
+	return &DownloadError{Attempts: attempt, Err: lastErr}
+}
+
+// isRetryableDownloadError reports whether a failed download may succeed on a
+// later attempt. Client errors other than timeouts and rate limits are final.
+func isRetryableDownloadError(err error) bool {
+	// A proxy or portal page will keep being served until the network changes
+	if errors.Is(err, ErrUnexpectedResponse) {
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.StatusCode
+		return code >= http.StatusInternalServerError ||
+			code == http.StatusRequestTimeout ||
+			code == http.StatusTooManyRequests
+	}
+
+	// Network failures, truncated archives and checksum mismatches are transient
+	return true
+}
+
+// withJitter returns a random duration in [d/2, d] so concurrent runners do
+// not retry in lockstep.
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	// #nosec G404 -- jitter does not require cryptographic randomness
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// debug logs a diagnostic message when a logger is configured.
+func (m *Manager) debug(msg string, args ...any) {
+	if m.logger != nil {
+		m.logger.Debug(msg, args...)
+	}
+}
+
+// trace logs a verbose diagnostic message when a logger is configured.
+func (m *Manager) trace(msg string, args ...any) {
+	if m.logger != nil {
+		m.logger.Trace(msg, args...)
+	}
 }
 
 // SetBinaryPath sets the binary path directly (for testing).
 func (m *Manager) SetBinaryPath(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.binaryPath = path
 }
 
@@ -207,13 +803,109 @@ func (m *Manager) MarkBinaryValid() {
 
 // GetBinaryPath returns the path to the verified CLI binary.
 func (m *Manager) GetBinaryPath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.binaryPath
 }
 
+// relocateForExec is called when the kernel refuses to execute the binary,
+// which typically means the cache directory is on a noexec mount. The
+// verified binary is copied into the fallback exec directory and used from
+// there. Without a usable fallback directory ErrExecDenied is returned with
+// guidance on how to resolve the problem.
+func (m *Manager) relocateForExec(failedPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another caller may already have relocated the binary
+	if m.binaryPath != failedPath {
+		return nil
+	}
+
+	if m.fallbackExecDir == "" {
+		return fmt.Errorf("%w: %s is not executable; the directory is likely mounted noexec "+
+			"(common for RUNNER_TEMP on hardened runners). Set exec_fallback_dir "+
+			"(OP_EXEC_FALLBACK_DIR) to a writable directory that allows execution",
+			ErrExecDenied, failedPath)
+	}
+
+	fallbackDir, err := filepath.Abs(m.fallbackExecDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve exec fallback directory: %w", err)
+	}
+
+	target := filepath.Join(fallbackDir, fmt.Sprintf("op-%s", m.version), filepath.Base(failedPath))
+	if target == failedPath {
+		return fmt.Errorf("%w: %s is not executable and the exec fallback directory %s "+
+			"does not allow execution either; choose a directory on a mount without noexec",
+			ErrExecDenied, failedPath, fallbackDir)
+	}
+
+	if err := copyExecutable(failedPath, target); err != nil {
+		return fmt.Errorf("failed to copy CLI into exec fallback directory %s: %w", fallbackDir, err)
+	}
+
+	if !m.testMode && m.hasChecksum() {
+		if err := m.verifyChecksum(target); err != nil {
+			_ = os.Remove(target)
+			return fmt.Errorf("CLI verification failed after relocation: %w", err)
+		}
+	}
+
+	m.binaryPath = target
+	m.printNotice(fmt.Sprintf("1Password CLI could not be executed from %s (noexec mount?); using %s instead",
+		filepath.Dir(failedPath), filepath.Dir(target)))
+
+	return nil
+}
+
+// printNotice reports a non-fatal condition to the user.
+func (m *Manager) printNotice(message string) {
+	if m.disableStderrOut {
+		// In GitHub Actions a workflow command keeps the notice visible in the UI
+		fmt.Printf("::notice::%s\n", message)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Notice: %s\n", message)
+}
+
+// copyExecutable copies src to dst and marks the copy executable.
+func copyExecutable(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+
+	// #nosec G304 -- src is the manager's own binary path
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	// #nosec G302 G304 -- CLI binary needs execute permissions; dst is derived from configuration
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, io.LimitReader(in, MaxOutputSize)); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// #nosec G302 -- CLI binary needs execute permissions
+	return os.Chmod(dst, 0700)
+}
+
 // isValidBinary checks if the cached binary exists and has correct checksum.
 func (m *Manager) isValidBinary() bool {
+	binaryPath := m.GetBinaryPath()
+
 	// Check if file exists
-	if _, err := os.Stat(m.binaryPath); os.IsNotExist(err) {
+	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
 		return false
 	}
 
@@ -223,7 +915,7 @@ func (m *Manager) isValidBinary() bool {
 	}
 
 	// Verify checksum
-	if err := m.verifySHA256(m.binaryPath, m.expectedSHA); err != nil {
+	if err := m.verifyChecksum(binaryPath); err != nil {
 		return false
 	}
 
@@ -242,13 +934,15 @@ func (m *Manager) downloadAndVerify(ctx context.Context) error {
 		_ = os.Remove(tmpFile.Name())
 	}()
 
+	m.debug("Downloading 1Password CLI archive", "url", m.downloadURL)
+
 	// Print download URL for debugging purposes only if stderr output is not disabled
 	if !m.disableStderrOut {
 		fmt.Printf("Downloading 1Password CLI from: %s\n", m.downloadURL)
 	}
 
 	// Download the CLI archive
-	if err := m.downloadFile(ctx, m.downloadURL, tmpFile); err != nil {
+	if err := m.downloadArchive(ctx, m.downloadURL, tmpFile); err != nil {
 		return fmt.Errorf("failed to download CLI: %w", err)
 	}
 
@@ -258,17 +952,20 @@ func (m *Manager) downloadAndVerify(ctx context.Context) error {
 	}
 
 	// Verify the extracted binary
-	if m.expectedSHA != "" {
-		if err := m.verifySHA256(m.binaryPath, m.expectedSHA); err != nil {
+	if m.hasChecksum() {
+		if err := m.verifyChecksum(m.binaryPath); err != nil {
+			// Never leave an unverified binary behind
+			_ = os.Remove(m.binaryPath)
+
 			// Output enhanced error to stderr for debugging only if not disabled
 			if !m.disableStderrOut {
 				fmt.Fprintf(os.Stderr, "CLI verification failed: %v\n", err)
 			}
-			return fmt.Errorf("CLI verification failed: %w", err)
+			return fmt.Errorf("%w: %w", ErrCLIVerificationFailed, err)
 		}
 		// Output success message only if stderr output is not disabled
 		if !m.disableStderrOut {
-			fmt.Printf("Binary checksum verification passed: %s\n", m.expectedSHA)
+			fmt.Printf("Binary checksum verification passed: %s\n", m.checksum())
 		}
 	} else {
 		// Check if we're using a custom download URL without checksum
@@ -288,29 +985,74 @@ func (m *Manager) downloadAndVerify(ctx context.Context) error {
 
 // downloadFile downloads a file from the given URL to the destination.
 func (m *Manager) downloadFile(ctx context.Context, url string, dest *os.File) error {
+	_, err := m.fetch(ctx, url, dest, nil)
+	return err
+}
+
+// downloadArchive downloads the CLI archive, rejecting responses that are not
+// a zip archive before anything is written to dest. The size of each
+// completed download is reported to the metrics hook.
+func (m *Manager) downloadArchive(ctx context.Context, url string, dest *os.File) error {
+	n, err := m.fetch(ctx, url, dest, checkArchiveResponse)
+	if err != nil {
+		return err
+	}
+	m.metrics.ObserveDownloadBytes(n)
+	return nil
+}
+
+// fetch streams url into dest, optionally inspecting the response first, and
+// returns the number of bytes written.
+func (m *Manager) fetch(ctx context.Context, url string, dest *os.File,
+	check func(contentType string, body *bufio.Reader) error) (int64, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+		return 0, fmt.Errorf("failed to download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+		return 0, &httpStatusError{StatusCode: resp.StatusCode}
 	}
 
 	// Limit the response size
-	limitedReader := io.LimitReader(resp.Body, MaxOutputSize)
+	body := bufio.NewReader(io.LimitReader(resp.Body, MaxOutputSize))
+	if check != nil {
+		if err := check(resp.Header.Get("Content-Type"), body); err != nil {
+			return 0, err
+		}
+	}
 
-	_, err = io.Copy(dest, limitedReader)
+	n, err := io.Copy(dest, body)
 	if err != nil {
-		return fmt.Errorf("failed to write download: %w", err)
+		return n, fmt.Errorf("failed to write download: %w", err)
 	}
 
+	return n, nil
+}
+
+// checkArchiveResponse rejects responses that are clearly not a zip archive,
+// so that an HTML error page is reported as such rather than as a checksum
+// mismatch further down the line.
+func checkArchiveResponse(contentType string, body *bufio.Reader) error {
+	head, _ := body.Peek(512)
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" ||
+		strings.HasPrefix(http.DetectContentType(head), "text/html") {
+		return fmt.Errorf("%w: received HTML (Content-Type %q) instead of a zip archive; "+
+			"check proxy settings or network access to the download URL", ErrUnexpectedResponse, contentType)
+	}
+
+	if !bytes.HasPrefix(head, zipMagic) {
+		return fmt.Errorf("%w: response is not a zip archive (Content-Type %q, %d byte(s) inspected)",
+			ErrUnexpectedResponse, contentType, len(head))
+	}
 	return nil
 }
 
@@ -370,21 +1112,51 @@ func (m *Manager) extractBinary(archivePath string) error {
 	return nil
 }
 
-// verifySHA256 verifies the SHA256 checksum of a file.
-func (m *Manager) verifySHA256(filePath, expectedSHA string) error {
-	// #nosec G304 -- filePath is validated by caller
-	file, err := os.Open(filePath)
+// hasChecksum reports whether the binary is verified against a checksum.
+func (m *Manager) hasChecksum() bool {
+	return m.expectedSHA512 != "" || m.expectedSHA != ""
+}
+
+// checksum returns the checksum the binary is verified against.
+func (m *Manager) checksum() string {
+	if m.expectedSHA512 != "" {
+		return m.expectedSHA512
+	}
+	return m.expectedSHA
+}
+
+// verifyChecksum verifies a file against the SHA512 checksum when one is
+// known, falling back to SHA256.
+func (m *Manager) verifyChecksum(filePath string) error {
+	if m.expectedSHA512 != "" {
+		return m.verifySHA512(filePath, m.expectedSHA512)
+	}
+	return m.verifySHA256(filePath, m.expectedSHA)
+}
+
+// verifySHA512 verifies the SHA512 checksum of a file.
+func (m *Manager) verifySHA512(filePath, expectedSHA string) error {
+	actualSHA, err := fileSHA512(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return err
+	}
+
+	if actualSHA != expectedSHA {
+		platformInfo := m.getPlatformInfo()
+		return fmt.Errorf("SHA512 mismatch: expected %s, got %s (CLI version: %s, platform: %s, architecture: %s)",
+			expectedSHA, actualSHA, platformInfo.Version, platformInfo.OS, platformInfo.Arch)
 	}
-	defer func() { _ = file.Close() }()
 
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return fmt.Errorf("failed to hash file: %w", err)
+	return nil
+}
+
+// verifySHA256 verifies the SHA256 checksum of a file.
+func (m *Manager) verifySHA256(filePath, expectedSHA string) error {
+	actualSHA, err := fileSHA256(filePath)
+	if err != nil {
+		return err
 	}
 
-	actualSHA := fmt.Sprintf("%x", hasher.Sum(nil))
 	if actualSHA != expectedSHA {
 		// Get platform information for enhanced error reporting
 		platformInfo := m.getPlatformInfo()
@@ -395,18 +1167,23 @@ func (m *Manager) verifySHA256(filePath, expectedSHA string) error {
 	return nil
 }
 
-// getExpectedSHA returns the expected SHA256 for the given version and platform by
-// consulting the YAML-backed versions database.
-func getExpectedSHA(version string) (string, error) {
-	sha, err := ExpectedSHAFromDB(version)
+// getExpectedChecksums returns the expected SHA256 and SHA512 for the given version
+// and platform by consulting the YAML-backed versions database. Either may be empty.
+func getExpectedChecksums(version string) (string, string, error) {
+	sha256Sum, sha512Sum, err := ExpectedChecksumsFromDB(version)
 	if err != nil {
 		if errors.Is(err, ErrUnsupportedVersion) {
 			// Provide a clear, user-actionable message
-			return "", fmt.Errorf("unsupported 1Password CLI version '%s': %w", version, err)
+			return "", "", fmt.Errorf("unsupported 1Password CLI version '%s': %w", version, err)
 		}
-		return "", err
+		if errors.Is(err, ErrPlatformChecksumMissing) {
+			// The version is known; only this platform's checksum needs adding
+			return "", "", fmt.Errorf("1Password CLI version '%s' is supported but the versions database "+
+				"lists no checksum for this platform; add one to the database: %w", version, err)
+		}
+		return "", "", err
 	}
-	return sha, nil
+	return sha256Sum, sha512Sum, nil
 }
 
 // Cleanup removes the CLI cache directory.
@@ -419,6 +1196,23 @@ func (m *Manager) Version() string {
 	return m.version
 }
 
+// DownloadURL returns the URL the CLI archive is downloaded from.
+func (m *Manager) DownloadURL() string {
+	return m.downloadURL
+}
+
+// ExpectedSHA returns the SHA256 of the extracted binary recorded for this
+// version; it is empty when only a SHA512 is known.
+func (m *Manager) ExpectedSHA() string {
+	return m.expectedSHA
+}
+
+// ExpectedSHA512 returns the SHA512 the extracted binary is verified against,
+// if one is known.
+func (m *Manager) ExpectedSHA512() string {
+	return m.expectedSHA512
+}
+
 // getPlatformInfo returns platform information for enhanced error reporting
 func (m *Manager) getPlatformInfo() PlatformInfo {
 	platform := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
@@ -5,9 +5,11 @@ package cli
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,6 +20,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/metrics"
 )
 
 const (
@@ -90,6 +95,44 @@ func TestNewManager(t *testing.T) {
 	}
 }
 
+func TestNewManager_DownloadBaseURL(t *testing.T) {
+	newManager := func(baseURL string, allowInsecure bool) (*Manager, error) {
+		return NewManager(&Config{
+			CacheDir:        filepath.Join(t.TempDir(), "cache"),
+			Version:         DefaultCLIVersion,
+			ExpectedSHA:     "test-sha",
+			DownloadBaseURL: baseURL,
+			AllowInsecure:   allowInsecure,
+		})
+	}
+
+	manager, err := newManager("https://mirror.example.com/1password/op2/", false)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	want := fmt.Sprintf("https://mirror.example.com/1password/op2/pkg/v%s/op_%s_%s_v%s.zip",
+		DefaultCLIVersion, runtime.GOOS, runtime.GOARCH, DefaultCLIVersion)
+	if manager.DownloadURL() != want {
+		t.Errorf("DownloadURL() = %q, want %q", manager.DownloadURL(), want)
+	}
+	if manager.ExpectedSHA() != "test-sha" {
+		t.Errorf("ExpectedSHA() = %q, checksum must not depend on the mirror", manager.ExpectedSHA())
+	}
+
+	if _, err := newManager("http://mirror.internal/op2", false); !errors.Is(err, ErrInsecureDownloadURL) {
+		t.Errorf("NewManager() with http mirror error = %v, want ErrInsecureDownloadURL", err)
+	}
+	if _, err := newManager("http://mirror.internal/op2", true); err != nil {
+		t.Errorf("NewManager() with allowed http mirror failed: %v", err)
+	}
+
+	for _, baseURL := range []string{"ftp://mirror.internal/op2", "mirror.internal/op2", "https://mirror.internal/op2?token=x"} {
+		if _, err := newManager(baseURL, true); err == nil {
+			t.Errorf("NewManager() with base URL %q should have failed", baseURL)
+		}
+	}
+}
+
 func TestManagerEnsureCLI(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -133,6 +176,803 @@ func TestManagerEnsureCLI(t *testing.T) {
 	}
 }
 
+func TestManagerEnsureCLI_VersionSelfCheck(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("version self-check test uses a shell script binary")
+	}
+
+	newManager := func(t *testing.T, reported string) *Manager {
+		t.Helper()
+		script := []byte("#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo '" + reported + "'; exit 0; fi\nexit 1\n")
+		sum := sha256.Sum256(script)
+
+		manager, err := NewManager(&Config{
+			CacheDir:    filepath.Join(t.TempDir(), "cache"),
+			Version:     DefaultCLIVersion,
+			ExpectedSHA: fmt.Sprintf("%x", sum),
+		})
+		if err != nil {
+			t.Fatalf("NewManager() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = manager.Cleanup() })
+
+		// A cached binary whose checksum matches skips the download
+		if err := os.MkdirAll(filepath.Dir(manager.GetBinaryPath()), 0700); err != nil {
+			t.Fatalf("Failed to create binary directory: %v", err)
+		}
+		// #nosec G306 -- Test binary needs execute permissions
+		if err := os.WriteFile(manager.GetBinaryPath(), script, 0700); err != nil {
+			t.Fatalf("Failed to create test binary: %v", err)
+		}
+		return manager
+	}
+
+	if err := newManager(t, "v"+DefaultCLIVersion).EnsureCLI(context.Background()); err != nil {
+		t.Errorf("EnsureCLI() with matching version failed: %v", err)
+	}
+
+	err := newManager(t, "2.0.0").EnsureCLI(context.Background())
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeCLIVersionMismatch) {
+		t.Fatalf("EnsureCLI() error = %v, want %s", err, apperrors.ErrCodeCLIVersionMismatch)
+	}
+	if !strings.Contains(err.Error(), "reports version 2.0.0, expected "+DefaultCLIVersion) {
+		t.Errorf("EnsureCLI() error = %v, want both versions", err)
+	}
+}
+
+func TestDetectCLIVersion(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("test uses shell script binaries")
+	}
+	pk := currentPlatformKey(t)
+
+	writeScript := func(t *testing.T, output string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "op")
+		// #nosec G306 -- Test binary needs execute permissions
+		if err := os.WriteFile(path, []byte("#!/bin/sh\necho '"+output+"'\n"), 0700); err != nil {
+			t.Fatalf("Failed to create test binary: %v", err)
+		}
+		return path
+	}
+
+	version, err := DetectCLIVersion(writeScript(t, "2.31.1"))
+	if err != nil || version != "2.31.1" {
+		t.Errorf("DetectCLIVersion() = %q, %v, want 2.31.1", version, err)
+	}
+	version, err = DetectCLIVersion(writeScript(t, "op version v2.30.0+build7"))
+	if err != nil || version != "2.30.0" {
+		t.Errorf("DetectCLIVersion() = %q, %v, want 2.30.0", version, err)
+	}
+	if _, err := DetectCLIVersion(writeScript(t, "not a version")); !errors.Is(err, ErrInvalidVersion) {
+		t.Errorf("DetectCLIVersion() error = %v, want ErrInvalidVersion", err)
+	}
+	if _, err := DetectCLIVersion(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("DetectCLIVersion() of a missing binary should fail")
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", pk, strings.Repeat("a", 64))
+	t.Setenv(envVersionsFile, dbPath)
+
+	// Offline without a pinned version uses the installed version's checksums
+	manager, err := NewManager(&Config{
+		CacheDir:   filepath.Join(t.TempDir(), "cache"),
+		Version:    "latest",
+		Offline:    true,
+		BinaryPath: writeScript(t, "2.31.1"),
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	if manager.Version() != "2.31.1" || manager.ExpectedSHA() != strings.Repeat("a", 64) {
+		t.Errorf("Version() = %q, ExpectedSHA() = %q", manager.Version(), manager.ExpectedSHA())
+	}
+
+	// A detected version missing from the DB is unsupported, by name
+	_, err = NewManager(&Config{
+		CacheDir:   filepath.Join(t.TempDir(), "cache"),
+		Offline:    true,
+		BinaryPath: writeScript(t, "2.99.0"),
+	})
+	if !errors.Is(err, ErrUnsupportedVersion) || !strings.Contains(err.Error(), "2.99.0") {
+		t.Errorf("NewManager() error = %v, want ErrUnsupportedVersion naming 2.99.0", err)
+	}
+
+	// A pinned version is not detected
+	_, err = NewManager(&Config{
+		CacheDir:   filepath.Join(t.TempDir(), "cache"),
+		Version:    "2.31.1",
+		Offline:    true,
+		BinaryPath: writeScript(t, "2.99.0"),
+	})
+	if err != nil {
+		t.Errorf("NewManager() with a pinned version error = %v", err)
+	}
+}
+
+func TestNewManager_AllowedVersions(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("test uses shell script binaries")
+	}
+	pk := currentPlatformKey(t)
+
+	// An empty allowlist allows every version in the DB
+	if _, err := NewManager(&Config{
+		CacheDir: filepath.Join(t.TempDir(), "cache"),
+		Version:  DefaultCLIVersion,
+	}); err != nil {
+		t.Errorf("NewManager() without an allowlist error = %v", err)
+	}
+
+	if _, err := NewManager(&Config{
+		CacheDir:        filepath.Join(t.TempDir(), "cache"),
+		Version:         DefaultCLIVersion,
+		AllowedVersions: []string{"2.0.0", "v" + DefaultCLIVersion},
+	}); err != nil {
+		t.Errorf("NewManager() with an allowed version error = %v", err)
+	}
+
+	_, err := NewManager(&Config{
+		CacheDir:        filepath.Join(t.TempDir(), "cache"),
+		Version:         DefaultCLIVersion,
+		AllowedVersions: []string{"2.0.0"},
+	})
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeCLIVersionNotAllowed) {
+		t.Errorf("NewManager() error = %v, want %s", err, apperrors.ErrCodeCLIVersionNotAllowed)
+	}
+
+	// A detected version must be allowed too, even though the DB lists it
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", pk, strings.Repeat("a", 64))
+	t.Setenv(envVersionsFile, dbPath)
+
+	binary := filepath.Join(t.TempDir(), "op")
+	// #nosec G306 -- Test binary needs execute permissions
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho '2.31.1'\n"), 0700); err != nil {
+		t.Fatalf("Failed to create test binary: %v", err)
+	}
+	_, err = NewManager(&Config{
+		CacheDir:        filepath.Join(t.TempDir(), "cache"),
+		Offline:         true,
+		BinaryPath:      binary,
+		AllowedVersions: []string{"2.30.0"},
+	})
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeCLIVersionNotAllowed) ||
+		!strings.Contains(err.Error(), binary+" reports version 2.31.1") {
+		t.Errorf("NewManager() error = %v, want %s naming the binary", err, apperrors.ErrCodeCLIVersionNotAllowed)
+	}
+}
+
+func TestManagerEnsureCLI_ReportedVersionNotAllowed(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("version self-check test uses a shell script binary")
+	}
+
+	script := []byte("#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo '2.0.0'; exit 0; fi\nexit 1\n")
+	sum := sha256.Sum256(script)
+	manager, err := NewManager(&Config{
+		CacheDir:        filepath.Join(t.TempDir(), "cache"),
+		Version:         DefaultCLIVersion,
+		ExpectedSHA:     fmt.Sprintf("%x", sum),
+		AllowedVersions: []string{DefaultCLIVersion},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Cleanup() })
+
+	if err := os.MkdirAll(filepath.Dir(manager.GetBinaryPath()), 0700); err != nil {
+		t.Fatalf("Failed to create binary directory: %v", err)
+	}
+	// #nosec G306 -- Test binary needs execute permissions
+	if err := os.WriteFile(manager.GetBinaryPath(), script, 0700); err != nil {
+		t.Fatalf("Failed to create test binary: %v", err)
+	}
+
+	// The allowlist is checked before the version the manager expects
+	err = manager.EnsureCLI(context.Background())
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeCLIVersionNotAllowed) {
+		t.Errorf("EnsureCLI() error = %v, want %s", err, apperrors.ErrCodeCLIVersionNotAllowed)
+	}
+}
+
+func TestManagerEnsureCLI_Offline(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("offline test uses a shell script binary")
+	}
+
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		downloads++
+		_, _ = w.Write(createTestZipContent(t))
+	}))
+	defer server.Close()
+
+	script := []byte("#!/bin/sh\necho '" + DefaultCLIVersion + "'\n")
+	sum := sha256.Sum256(script)
+
+	newOfflineManager := func(t *testing.T, expectedSHA string) *Manager {
+		t.Helper()
+		manager, err := NewManager(&Config{
+			CacheDir:    filepath.Join(t.TempDir(), "cache"),
+			Version:     DefaultCLIVersion,
+			ExpectedSHA: expectedSHA,
+			Offline:     true,
+		})
+		if err != nil {
+			t.Fatalf("NewManager() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = manager.Cleanup() })
+		manager.SetDownloadURL(server.URL)
+		return manager
+	}
+
+	// No op on PATH
+	t.Setenv("PATH", t.TempDir())
+	missing := newOfflineManager(t, fmt.Sprintf("%x", sum))
+	if err := missing.CheckInstallable(); !errors.Is(err, ErrOfflineCLIMissing) {
+		t.Errorf("CheckInstallable() error = %v, want ErrOfflineCLIMissing", err)
+	}
+	err := missing.EnsureCLI(context.Background())
+	if !errors.Is(err, ErrOfflineCLIMissing) {
+		t.Errorf("EnsureCLI() error = %v, want ErrOfflineCLIMissing", err)
+	}
+
+	// A pre-installed op on PATH is verified and used in place
+	binDir := t.TempDir()
+	// #nosec G306 -- Test binary needs execute permissions
+	if err := os.WriteFile(filepath.Join(binDir, "op"), script, 0700); err != nil {
+		t.Fatalf("Failed to create test binary: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	manager := newOfflineManager(t, fmt.Sprintf("%x", sum))
+	if err := manager.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if manager.GetBinaryPath() != filepath.Join(binDir, "op") {
+		t.Errorf("GetBinaryPath() = %q, want the binary on PATH", manager.GetBinaryPath())
+	}
+
+	// A checksum mismatch fails instead of downloading a replacement
+	err = newOfflineManager(t, strings.Repeat("0", 64)).EnsureCLI(context.Background())
+	if !errors.Is(err, ErrCLIVerificationFailed) || !strings.Contains(err.Error(), "pre-installed") {
+		t.Errorf("EnsureCLI() error = %v, want pre-installed verification failure", err)
+	}
+
+	// Skipping verification runs the binary whatever its checksum, and
+	// needs no versions DB entry for the platform
+	skipped, err := NewManager(&Config{
+		CacheDir:                 filepath.Join(t.TempDir(), "cache"),
+		Version:                  "9.9.9",
+		Offline:                  true,
+		SkipChecksumVerification: true,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() with SkipChecksumVerification failed: %v", err)
+	}
+	t.Cleanup(func() { _ = skipped.Cleanup() })
+	if skipped.ExpectedSHA() != "" || skipped.ExpectedSHA512() != "" {
+		t.Errorf("expected no checksums, got %q and %q", skipped.ExpectedSHA(), skipped.ExpectedSHA512())
+	}
+	if err := skipped.verifyInstalled(SourcePreinstalled); err != nil {
+		t.Errorf("verifyInstalled() error = %v, want nil", err)
+	}
+
+	// A configured binary is used instead of searching PATH
+	configured := filepath.Join(t.TempDir(), "op-custom")
+	// #nosec G306 -- Test binary needs execute permissions
+	if err := os.WriteFile(configured, script, 0700); err != nil {
+		t.Fatalf("Failed to create test binary: %v", err)
+	}
+	manager, err = NewManager(&Config{
+		CacheDir:    filepath.Join(t.TempDir(), "cache"),
+		Version:     DefaultCLIVersion,
+		ExpectedSHA: fmt.Sprintf("%x", sum),
+		Offline:     true,
+		BinaryPath:  configured,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Cleanup() })
+	if err := manager.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() with configured binary failed: %v", err)
+	}
+	if manager.GetBinaryPath() != configured {
+		t.Errorf("GetBinaryPath() = %q, want %q", manager.GetBinaryPath(), configured)
+	}
+
+	// A configured binary that does not exist is not replaced by PATH
+	manager, err = NewManager(&Config{
+		CacheDir:   filepath.Join(t.TempDir(), "cache"),
+		Version:    DefaultCLIVersion,
+		Offline:    true,
+		BinaryPath: filepath.Join(t.TempDir(), "missing-op"),
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Cleanup() })
+	if err := manager.EnsureCLI(context.Background()); !errors.Is(err, ErrOfflineCLIMissing) {
+		t.Errorf("EnsureCLI() error = %v, want ErrOfflineCLIMissing", err)
+	}
+
+	if downloads != 0 {
+		t.Errorf("offline mode downloaded the CLI %d time(s)", downloads)
+	}
+}
+
+// newRetryTestManager returns a manager pointed at url with a short backoff.
+func newRetryTestManager(t *testing.T, url string, maxAttempts int) *Manager {
+	t.Helper()
+
+	manager, err := NewManager(&Config{
+		CacheDir:        filepath.Join(t.TempDir(), "cache"),
+		Timeout:         30 * time.Second,
+		DownloadTimeout: 30 * time.Second,
+		Version:         DefaultCLIVersion,
+		TestMode:        true,
+		ExpectedSHA:     calculateTestSHA(t),
+		MaxAttempts:     maxAttempts,
+		RetryTimeout:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Cleanup() })
+
+	manager.SetDownloadURL(url)
+	manager.retryBaseDelay = 10 * time.Millisecond
+	return manager
+}
+
+func TestManagerEnsureCLI_BinaryCache(t *testing.T) {
+	archive := createTestZipContent(t)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	binaryCacheDir := filepath.Join(t.TempDir(), "bin")
+	newManager := func(disable bool) *Manager {
+		manager, err := NewManager(&Config{
+			CacheDir:           filepath.Join(t.TempDir(), "cache"),
+			DownloadTimeout:    30 * time.Second,
+			Version:            DefaultCLIVersion,
+			TestMode:           true,
+			ExpectedSHA:        calculateTestSHA(t),
+			BinaryCacheDir:     binaryCacheDir,
+			DisableBinaryCache: disable,
+		})
+		if err != nil {
+			t.Fatalf("NewManager() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = manager.Cleanup() })
+		manager.SetDownloadURL(server.URL)
+		return manager
+	}
+
+	first := newManager(false)
+	entry, parent := first.binaryCacheEntry()
+	if entry == "" {
+		t.Fatal("binary cache entry is empty with a checksum configured")
+	}
+
+	// An entry for a checksum the DB no longer lists is replaced
+	stale := filepath.Join(parent, "0123456789abcdef")
+	if err := os.MkdirAll(stale, 0700); err != nil {
+		t.Fatalf("Failed to create stale entry: %v", err)
+	}
+
+	if err := first.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1", requests)
+	}
+	if got := first.Source(); got != SourceDownloaded {
+		t.Errorf("Source() = %q, want %q", got, SourceDownloaded)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale cache entry was not removed: %v", err)
+	}
+
+	// A later run with an empty cache directory reuses the cached binary
+	second := newManager(false)
+	if err := second.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("cached binary was downloaded again: requests = %d", requests)
+	}
+	if got := second.Source(); got != SourceCached {
+		t.Errorf("Source() = %q, want %q", got, SourceCached)
+	}
+	if err := second.verifyChecksum(second.GetBinaryPath()); err != nil {
+		t.Errorf("restored binary fails verification: %v", err)
+	}
+
+	// A cached binary that no longer matches the checksum is downloaded again
+	cached := filepath.Join(entry, filepath.Base(second.GetBinaryPath()))
+	if err := os.WriteFile(cached, []byte("tampered"), 0600); err != nil {
+		t.Fatalf("Failed to tamper with cached binary: %v", err)
+	}
+	third := newManager(false)
+	if err := third.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want a fresh download after a checksum mismatch", requests)
+	}
+	if err := third.verifyChecksum(cached); err != nil {
+		t.Errorf("cache entry was not replaced: %v", err)
+	}
+
+	// With the cache disabled the binary is always downloaded
+	if err := newManager(true).EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want a download with the cache disabled", requests)
+	}
+}
+
+func TestManagerEnsureCLI_InstallDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(createTestZipContent(t))
+	}))
+	defer server.Close()
+
+	installDir := filepath.Join(t.TempDir(), "tools", "op")
+	manager, err := NewManager(&Config{
+		CacheDir:           filepath.Join(t.TempDir(), "cache"),
+		InstallDir:         installDir,
+		DownloadTimeout:    30 * time.Second,
+		Version:            DefaultCLIVersion,
+		TestMode:           true,
+		ExpectedSHA:        calculateTestSHA(t),
+		DisableBinaryCache: true,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup() }()
+	manager.SetDownloadURL(server.URL)
+
+	info, err := os.Stat(installDir)
+	if err != nil {
+		t.Fatalf("install directory was not created: %v", err)
+	}
+	if runtime.GOOS != windowsOS && info.Mode().Perm() != 0700 {
+		t.Errorf("install directory mode = %o, want 700", info.Mode().Perm())
+	}
+	if manager.InstallDir() != installDir {
+		t.Errorf("InstallDir() = %q, want %q", manager.InstallDir(), installDir)
+	}
+
+	if err := manager.CheckInstallable(); err != nil {
+		t.Errorf("CheckInstallable() error = %v", err)
+	}
+
+	if err := manager.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if !strings.HasPrefix(manager.GetBinaryPath(), installDir+string(filepath.Separator)) {
+		t.Errorf("GetBinaryPath() = %q, want a path under %q", manager.GetBinaryPath(), installDir)
+	}
+	if _, err := os.Stat(manager.GetBinaryPath()); err != nil {
+		t.Errorf("binary was not installed: %v", err)
+	}
+}
+
+func TestManagerEnsureCLI_InstallDirNotWritable(t *testing.T) {
+	if runtime.GOOS == windowsOS || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for this user")
+	}
+
+	installDir := t.TempDir()
+	manager, err := NewManager(&Config{
+		CacheDir:           filepath.Join(t.TempDir(), "cache"),
+		InstallDir:         installDir,
+		Version:            DefaultCLIVersion,
+		ExpectedSHA:        "test-sha",
+		DisableBinaryCache: true,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	if err := os.Chmod(installDir, 0500); err != nil {
+		t.Fatalf("Failed to make install directory read-only: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(installDir, 0700) })
+
+	// The directory is rejected before any download is attempted
+	manager.SetDownloadURL("http://127.0.0.1:0/unreachable")
+	if err := manager.EnsureCLI(context.Background()); !errors.Is(err, ErrInstallDirNotWritable) {
+		t.Errorf("EnsureCLI() error = %v, want ErrInstallDirNotWritable", err)
+	}
+}
+
+func TestManagerEnsureCLI_RetriesTransientFailures(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			// A truncated archive must fail extraction and be retried
+			_, _ = w.Write(createTestZipContent(t)[:32])
+		default:
+			_, _ = w.Write(createTestZipContent(t))
+		}
+	}))
+	defer server.Close()
+
+	manager := newRetryTestManager(t, server.URL, 3)
+	if err := manager.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 download attempts, got %d", requests)
+	}
+	if err := manager.verifySHA256(manager.GetBinaryPath(), manager.expectedSHA); err != nil {
+		t.Errorf("installed binary failed verification: %v", err)
+	}
+}
+
+func TestManagerEnsureCLI_Proxy(t *testing.T) {
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(key, "")
+	}
+
+	var proxied []string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write(createTestZipContent(t))
+	}))
+	defer proxyServer.Close()
+
+	const target = "http://downloads.example.invalid/op.zip"
+	newProxyManager := func(t *testing.T, proxyURL string) *Manager {
+		t.Helper()
+		manager, err := NewManager(&Config{
+			CacheDir:        filepath.Join(t.TempDir(), "cache"),
+			DownloadTimeout: 30 * time.Second,
+			Version:         DefaultCLIVersion,
+			TestMode:        true,
+			ExpectedSHA:     calculateTestSHA(t),
+			MaxAttempts:     1,
+			ProxyURL:        proxyURL,
+		})
+		if err != nil {
+			t.Fatalf("NewManager() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = manager.Cleanup() })
+		manager.SetDownloadURL(target)
+		return manager
+	}
+
+	// The proxy_url override
+	if err := newProxyManager(t, proxyServer.URL).EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() through proxy_url failed: %v", err)
+	}
+
+	// The standard environment variable
+	t.Setenv("HTTP_PROXY", proxyServer.URL)
+	if err := newProxyManager(t, "").EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() through HTTP_PROXY failed: %v", err)
+	}
+
+	if len(proxied) != 2 || proxied[0] != target || proxied[1] != target {
+		t.Errorf("proxied requests = %v, want two for %s", proxied, target)
+	}
+
+	// NO_PROXY bypasses the proxy, so the unresolvable host fails
+	t.Setenv("NO_PROXY", "example.invalid")
+	if err := newProxyManager(t, proxyServer.URL).EnsureCLI(context.Background()); err == nil {
+		t.Error("EnsureCLI() succeeded for a NO_PROXY host, want a direct connection failure")
+	}
+	if len(proxied) != 2 {
+		t.Errorf("NO_PROXY host was proxied: %v", proxied)
+	}
+
+	if _, err := NewManager(&Config{CacheDir: t.TempDir(), ProxyURL: "ftp://proxy"}); err == nil {
+		t.Error("NewManager() accepted an ftp proxy URL")
+	}
+}
+
+// downloadRecorder records the archive sizes reported to the metrics hook.
+type downloadRecorder struct {
+	metrics.Nop
+	sizes []int64
+}
+
+func (r *downloadRecorder) ObserveDownloadBytes(n int64) {
+	r.sizes = append(r.sizes, n)
+}
+
+func TestManagerEnsureCLI_ReportsDownloadBytes(t *testing.T) {
+	archive := createTestZipContent(t)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			_, _ = w.Write(archive[:32])
+		default:
+			_, _ = w.Write(archive)
+		}
+	}))
+	defer server.Close()
+
+	recorder := &downloadRecorder{}
+	manager := newRetryTestManager(t, server.URL, 3)
+	manager.metrics = recorder
+	if err := manager.EnsureCLI(context.Background()); err != nil {
+		t.Fatalf("EnsureCLI() failed: %v", err)
+	}
+
+	// The failed request transfers nothing and is not reported
+	want := []int64{32, int64(len(archive))}
+	if fmt.Sprint(recorder.sizes) != fmt.Sprint(want) {
+		t.Errorf("reported download sizes = %v, want %v", recorder.sizes, want)
+	}
+}
+
+func TestManagerEnsureCLI_ReportsAttemptsAfterExhaustion(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	manager := newRetryTestManager(t, server.URL, 2)
+	err := manager.EnsureCLI(context.Background())
+
+	downloadErr, ok := err.(*DownloadError)
+	if !ok {
+		t.Fatalf("EnsureCLI() error = %v (%T), want *DownloadError", err, err)
+	}
+	if downloadErr.Attempts != 2 || requests != 2 {
+		t.Errorf("attempts = %d, requests = %d, want 2 each", downloadErr.Attempts, requests)
+	}
+	if !strings.Contains(err.Error(), "after 2 attempt(s)") || !strings.Contains(err.Error(), "status 502") {
+		t.Errorf("error should include attempt count and cause, got: %v", err)
+	}
+}
+
+func TestManagerEnsureCLI_DoesNotRetryClientErrors(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	manager := newRetryTestManager(t, server.URL, 3)
+	err := manager.EnsureCLI(context.Background())
+
+	downloadErr, ok := err.(*DownloadError)
+	if !ok {
+		t.Fatalf("EnsureCLI() error = %v (%T), want *DownloadError", err, err)
+	}
+	if downloadErr.Attempts != 1 || requests != 1 {
+		t.Errorf("404 should not be retried: attempts = %d, requests = %d", downloadErr.Attempts, requests)
+	}
+}
+
+func TestManagerEnsureCLI_StopsAtRetryTimeout(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	manager := newRetryTestManager(t, server.URL, 10)
+	manager.retryBaseDelay = time.Second
+	manager.retryTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err := manager.EnsureCLI(context.Background())
+	if err == nil {
+		t.Fatal("EnsureCLI() expected error")
+	}
+	if requests != 1 {
+		t.Errorf("backoff beyond retry timeout should stop retries, got %d requests", requests)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EnsureCLI() took %v, should give up before sleeping past the retry timeout", elapsed)
+	}
+}
+
+func TestManagerEnsureCLI_RejectsHTMLResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{name: "html content type", contentType: "text/html; charset=utf-8"},
+		{name: "mislabelled html", contentType: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte("<!DOCTYPE html><html><body>Sign in to the network</body></html>"))
+			}))
+			defer server.Close()
+
+			manager := newRetryTestManager(t, server.URL, 3)
+			err := manager.EnsureCLI(context.Background())
+			if !errors.Is(err, ErrUnexpectedResponse) {
+				t.Fatalf("EnsureCLI() error = %v, want ErrUnexpectedResponse", err)
+			}
+			if !strings.Contains(err.Error(), "possible proxy/captive portal") {
+				t.Errorf("error should point at a proxy or captive portal: %v", err)
+			}
+			if strings.Contains(err.Error(), "SHA mismatch") {
+				t.Errorf("error should not be reported as a checksum mismatch: %v", err)
+			}
+			if requests != 1 {
+				t.Errorf("portal responses should not be retried, got %d requests", requests)
+			}
+			if _, statErr := os.Stat(manager.GetBinaryPath()); !os.IsNotExist(statErr) {
+				t.Errorf("no binary should be written for an HTML response")
+			}
+		})
+	}
+}
+
+func TestCheckArchiveResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantErr     bool
+	}{
+		{name: "zip archive", contentType: "application/zip", body: createTestZipContent(t)},
+		{name: "zip without content type", contentType: "", body: createTestZipContent(t)},
+		{name: "html page", contentType: "text/html", body: []byte("<html></html>"), wantErr: true},
+		{name: "sniffed html", contentType: "", body: []byte("<!doctype html><title>x</title>"), wantErr: true},
+		{name: "json error", contentType: "application/json", body: []byte(`{"error":"denied"}`), wantErr: true},
+		{name: "empty body", contentType: "application/zip", body: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkArchiveResponse(tt.contentType, bufio.NewReader(bytes.NewReader(tt.body)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkArchiveResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnexpectedResponse) {
+				t.Errorf("checkArchiveResponse() error = %v, want ErrUnexpectedResponse", err)
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	base := 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		got := withJitter(base)
+		if got < base/2 || got > base {
+			t.Fatalf("withJitter(%v) = %v, want within [%v, %v]", base, got, base/2, base)
+		}
+	}
+}
+
 func TestManagerIsValidBinary(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -292,17 +1132,17 @@ func TestGetPlatformInfo(t *testing.T) {
 func TestGetExpectedSHA(t *testing.T) {
 	version := DefaultCLIVersion
 
-	sha, err := getExpectedSHA(version)
+	sha, _, err := getExpectedChecksums(version)
 	if err != nil {
-		t.Errorf("getExpectedSHA() failed: %v", err)
+		t.Errorf("getExpectedChecksums() failed: %v", err)
 	}
 
 	if sha == "" {
-		t.Error("getExpectedSHA() returned empty SHA")
+		t.Error("getExpectedChecksums() returned empty SHA")
 	}
 
 	if len(sha) != 64 {
-		t.Errorf("getExpectedSHA() returned SHA with wrong length: got %d, want 64", len(sha))
+		t.Errorf("getExpectedChecksums() returned SHA with wrong length: got %d, want 64", len(sha))
 	}
 
 	// Test with unsupported platform (by temporarily changing runtime values)
@@ -570,3 +1410,31 @@ func TestManagerConcurrentAccess(t *testing.T) {
 		}
 	}
 }
+
+func TestManagerEnsureCLI_DownloadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	manager := newRetryTestManager(t, server.URL, 3)
+	manager.downloadTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err := manager.EnsureCLI(context.Background())
+
+	var downloadErr *DownloadError
+	if !errors.As(err, &downloadErr) {
+		t.Fatalf("EnsureCLI() error = %v (%T), want *DownloadError", err, err)
+	}
+	if !errors.Is(err, ErrDownloadTimeout) {
+		t.Errorf("EnsureCLI() error = %v, want ErrDownloadTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EnsureCLI() took %v, should stop at the download timeout", elapsed)
+	}
+}
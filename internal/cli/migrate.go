@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Env var that, set to "true", rewrites a versions DB file loaded at an
+// older schema with the migrated contents. The file is left untouched when
+// its signature is verified, since the rewrite would invalidate it.
+const envMigrateVersionsFile = "OP_SECRETS_ACTION_MIGRATE_VERSIONS_FILE"
+
+// migration upgrades db by one schema version, from the key it is
+// registered under in migrations. It must not modify maps shared with the
+// DB it was copied from.
+type migration func(db *VersionsDB) error
+
+// migrations holds the upgrade from each schema version to the next.
+var migrations = map[int]migration{
+	1: migrateV1ToV2,
+}
+
+// migrateV1ToV2 drops released dates, which schema version 1 ignored, so a
+// value left in an old file never takes effect by being upgraded.
+func migrateV1ToV2(db *VersionsDB) error {
+	versions := make(map[string]PlatformChecksums, len(db.Versions))
+	for ver, pcs := range db.Versions {
+		pcs.Released = ""
+		versions[ver] = pcs
+	}
+	db.Versions = versions
+	return nil
+}
+
+// Migrate upgrades db from schema version from to schema version to, one
+// registered migration at a time. The DB must be at schema from, and to may
+// not be newer than SchemaVersion. Migrating to the same version is a no-op.
+func (db *VersionsDB) Migrate(from, to int) error {
+	if db == nil {
+		return errors.New("versions DB is nil")
+	}
+	if db.SchemaVersion != from {
+		return fmt.Errorf("cannot migrate versions DB from schema %d: it is at schema %d", from, db.SchemaVersion)
+	}
+	if from < minSchemaVersion || to > SchemaVersion || from > to {
+		return fmt.Errorf("cannot migrate versions DB from schema %d to %d (supported: %d to %d)",
+			from, to, minSchemaVersion, SchemaVersion)
+	}
+
+	for v := from; v < to; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return fmt.Errorf("no migration registered from versions DB schema %d to %d", v, v+1)
+		}
+		if err := migrate(db); err != nil {
+			return fmt.Errorf("failed to migrate versions DB from schema %d to %d: %w", v, v+1, err)
+		}
+		db.SchemaVersion = v + 1
+	}
+	return nil
+}
+
+// rewriteMigratedDB writes a DB migrated on load back to path when
+// envMigrateVersionsFile is enabled. A failed rewrite does not fail the
+// load, as the migrated DB is already in memory.
+func rewriteMigratedDB(db *VersionsDB, path string) {
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv(envMigrateVersionsFile)), "true") {
+		return
+	}
+	if key, err := trustedVersionsKey(); err != nil || key != nil {
+		return
+	}
+	_ = db.SaveToPath(path)
+}
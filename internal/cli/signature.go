@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+// Detached signatures of the versions database. When a trusted Ed25519
+// public key is configured via OP_SECRETS_ACTION_VERSIONS_PUBKEY, the
+// database is only loaded if a signature beside it verifies:
+// - <file>.minisig, as written by `minisign -S -l` (legacy, non-prehashed)
+// - <file>.sig, a base64 Ed25519 signature over the file's bytes
+// The key is either a minisign public key (the base64 line of a .pub file)
+// or a base64 raw 32-byte Ed25519 key. Without a key nothing is verified.
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Env var holding the trusted public key of the versions database
+const envVersionsPublicKey = "OP_SECRETS_ACTION_VERSIONS_PUBKEY"
+
+// Minisign algorithm identifiers
+const (
+	minisignAlgorithm         = "Ed" // Signature over the message itself
+	minisignPrehashAlgorithm  = "ED" // Signature over its BLAKE2b-512 hash
+	minisignKeyIDLength       = 8
+	minisignTrustedCommentTag = "trusted comment: "
+)
+
+// ErrSignatureInvalid indicates the versions database signature is missing
+// or does not verify against the trusted public key.
+var ErrSignatureInvalid = errors.New("versions DB signature verification failed")
+
+// versionsPublicKey is a trusted key; keyID is set for minisign keys.
+type versionsPublicKey struct {
+	key   ed25519.PublicKey
+	keyID []byte
+}
+
+// trustedVersionsKey returns the configured public key, or nil when
+// signature verification is not enabled.
+func trustedVersionsKey() (*versionsPublicKey, error) {
+	raw := strings.TrimSpace(os.Getenv(envVersionsPublicKey))
+	if raw == "" {
+		return nil, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: not base64: %w", envVersionsPublicKey, err)
+	}
+
+	switch {
+	case len(decoded) == ed25519.PublicKeySize:
+		return &versionsPublicKey{key: ed25519.PublicKey(decoded)}, nil
+	case len(decoded) == 2+minisignKeyIDLength+ed25519.PublicKeySize && string(decoded[:2]) == minisignAlgorithm:
+		return &versionsPublicKey{
+			keyID: decoded[2 : 2+minisignKeyIDLength],
+			key:   ed25519.PublicKey(decoded[2+minisignKeyIDLength:]),
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid %s: expected a minisign or raw Ed25519 public key", envVersionsPublicKey)
+	}
+}
+
+// verifyDBSignature verifies content, read from path, against the detached
+// signature beside it when a trusted key is configured. A missing signature
+// is an error then, so removing it cannot bypass verification.
+func verifyDBSignature(path string, content []byte) error {
+	key, err := trustedVersionsKey()
+	if err != nil || key == nil {
+		return err
+	}
+
+	// #nosec G304 -- the signature sits beside the versions DB being loaded
+	if sig, err := os.ReadFile(path + ".minisig"); err == nil {
+		if err := key.verifyMinisign(content, sig); err != nil {
+			return fmt.Errorf("%w: %s.minisig: %v", ErrSignatureInvalid, path, err)
+		}
+		return nil
+	}
+
+	// #nosec G304 -- the signature sits beside the versions DB being loaded
+	sig, err := os.ReadFile(path + ".sig")
+	if err != nil {
+		return fmt.Errorf("%w: no %s.minisig or %s.sig found while %s is set",
+			ErrSignatureInvalid, path, path, envVersionsPublicKey)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: %s.sig is not a base64 Ed25519 signature", ErrSignatureInvalid, path)
+	}
+	if !ed25519.Verify(key.key, content, signature) {
+		return fmt.Errorf("%w: %s.sig does not match the trusted key", ErrSignatureInvalid, path)
+	}
+	return nil
+}
+
+// verifyMinisign verifies a minisign signature file over content, including
+// the global signature binding its trusted comment.
+func (k *versionsPublicKey) verifyMinisign(content, sigFile []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sigFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], minisignTrustedCommentTag) {
+		return errors.New("malformed minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+minisignKeyIDLength+ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+	switch string(sig[:2]) {
+	case minisignAlgorithm:
+	case minisignPrehashAlgorithm:
+		return errors.New("prehashed signatures are not supported; sign with `minisign -S -l`")
+	default:
+		return errors.New("unknown minisign signature algorithm")
+	}
+	keyID, signature := sig[2:2+minisignKeyIDLength], sig[2+minisignKeyIDLength:]
+	if k.keyID != nil && !bytes.Equal(keyID, k.keyID) {
+		return errors.New("signed with a different key")
+	}
+	if !ed25519.Verify(k.key, content, signature) {
+		return errors.New("signature does not match the trusted key")
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], minisignTrustedCommentTag)
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("malformed minisign global signature")
+	}
+	if !ed25519.Verify(k.key, append(append([]byte{}, signature...), trustedComment...), globalSig) {
+		return errors.New("trusted comment does not match the signature")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package cli
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// vaultCache remembers which vault a name resolved to, so that records read
+// from the same vault list vaults once per run rather than once per record.
+// Concurrent lookups of one name wait for the first to finish. Entries
+// expire after ttl, and an entry is dropped as soon as a vault listing
+// resolves its name differently. All methods are safe to call on a nil
+// cache, which caches nothing.
+type vaultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   Clock
+	entries map[string]*vaultCacheEntry
+}
+
+// vaultCacheEntry is a resolved vault, or a lookup still in progress while
+// ready is open.
+type vaultCacheEntry struct {
+	ready   chan struct{}
+	vault   *VaultInfo
+	err     error
+	expires time.Time
+}
+
+// newVaultCache creates a cache whose entries live for ttl as measured by
+// clock; a nil clock uses SystemClock.
+func newVaultCache(ttl time.Duration, clock Clock) *vaultCache {
+	return &vaultCache{
+		ttl:     ttl,
+		clock:   clockOrSystem(clock),
+		entries: make(map[string]*vaultCacheEntry),
+	}
+}
+
+// resolve returns the cached vault for identifier, calling load when there
+// is no live entry. It reports whether the vault came from the cache. Failed
+// loads are not cached.
+func (c *vaultCache) resolve(ctx context.Context, identifier string,
+	load func() (*VaultInfo, error)) (*VaultInfo, bool, error) {
+	if c == nil {
+		vault, err := load()
+		return vault, false, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[identifier]
+	if ok && entry.vault != nil && !c.clock.Now().Before(entry.expires) {
+		delete(c.entries, identifier)
+		ok = false
+	}
+
+	if ok {
+		c.mu.Unlock()
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+
+		c.mu.Lock()
+		if entry.err != nil {
+			c.mu.Unlock()
+			return nil, false, entry.err
+		}
+		if entry.vault == nil {
+			c.mu.Unlock()
+			vault, err := load()
+			return vault, false, err
+		}
+		vault := *entry.vault
+		c.mu.Unlock()
+		return &vault, true, nil
+	}
+
+	entry = &vaultCacheEntry{ready: make(chan struct{})}
+	c.entries[identifier] = entry
+	c.mu.Unlock()
+
+	vault, err := load()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(entry.ready)
+
+	if err != nil {
+		entry.err = err
+	} else if vault != nil {
+		cached := *vault
+		entry.vault = &cached
+		entry.expires = c.clock.Now().Add(c.ttl)
+	}
+	if entry.vault == nil && c.entries[identifier] == entry {
+		delete(c.entries, identifier)
+	}
+	return vault, false, err
+}
+
+// reconcile drops every entry that resolve would no longer answer the same
+// way given a fresh vault listing, such as a vault renamed or replaced by
+// another of the same name since it was cached.
+func (c *vaultCache) reconcile(vaults []VaultInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for identifier, entry := range c.entries {
+		if entry.vault == nil {
+			continue
+		}
+		current, err := findVault(vaults, identifier)
+		if err != nil || current.ID != entry.vault.ID {
+			delete(c.entries, identifier)
+		}
+	}
+}
+
+// size returns the number of cached vaults.
+func (c *vaultCache) size() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -13,7 +13,12 @@ package cli
 // - Otherwise, uses: $XDG_CONFIG_HOME/1password-secrets/action/1password-cli-versions.yaml
 //   or ~/.config/1password-secrets/action/1password-cli-versions.yaml on non-Windows
 //   or %APPDATA%\1password-secrets\action\1password-cli-versions.yaml on Windows
+// - The 1password-secrets/action subdir may be replaced via OP_SECRETS_ACTION_CONFIG_SUBDIR
+//   (relative, no ".." segments) to namespace databases per repository or environment
 // - If the file is absent, a bundled database for 2.31.1 is installed automatically
+// - With OP_SECRETS_ACTION_VERSIONS_PUBKEY set, a detached signature beside
+//   the file must verify before it is parsed (see signature.go)
+// - A db_checksum, when present, must match the versions map (see dbchecksum.go)
 // - The schema is validated on load; failures produce a helpful error
 //
 // Usage (typical integration from manager.go):
@@ -21,59 +26,112 @@ package cli
 //   if err != nil { /* unsupported version or validation error */ }
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 )
 
-// SchemaVersion is the current schema for the YAML database.
-const SchemaVersion = 1
+// SchemaVersion is the current schema for the YAML database. Version 2
+// adds the optional per-version released date; version 1 files still load,
+// with every released date treated as empty.
+const SchemaVersion = 2
+
+// generatedAtSkew is how far in the future generated_at may lie, allowing
+// for clock differences between the machine writing the DB and the runner.
+const generatedAtSkew = time.Hour
+
+// minSchemaVersion is the oldest schema that still loads.
+const minSchemaVersion = 1
+
+// releasedDateLayouts are the accepted formats of a released date.
+var releasedDateLayouts = []string{time.RFC3339, time.DateOnly}
 
 // Default file name for the versions database
 const defaultVersionsFilename = "1password-cli-versions.yaml"
 
+// Default directory of the binary cache under the config subdir
+const defaultBinaryCacheSubdir = "bin"
+
 // Env var to override the versions file path
 const envVersionsFile = "OP_SECRETS_ACTION_VERSIONS_FILE"
 
+// Env var to override the subdir under the config root
+const envConfigSubdir = "OP_SECRETS_ACTION_CONFIG_SUBDIR"
+
 // Default subdir under config root
 var defaultSubdir = filepath.Join("1password-secrets", "action")
 
 // Architecture and OS constants to avoid goconst warnings
 const (
-	amd64Architecture = "amd64"
-	arm64Architecture = "arm64"
-	linuxOS           = "linux"
-	darwinOS          = "darwin"
-	windowsOS         = "windows"
+	amd64Architecture   = "amd64"
+	arm64Architecture   = "arm64"
+	ppc64leArchitecture = "ppc64le"
+	s390xArchitecture   = "s390x"
+	linuxOS             = "linux"
+	darwinOS            = "darwin"
+	windowsOS           = "windows"
 )
 
-// VersionsDB defines the schema of the YAML versions database.
+// VersionsDB defines the schema of the versions database, which may be
+// stored as YAML, JSON or TOML (see dbFormat).
 type VersionsDB struct {
 	// SchemaVersion is an integer allowing future evolution of the schema.
-	SchemaVersion int `yaml:"schema_version"`
+	SchemaVersion int `yaml:"schema_version" json:"schema_version" toml:"schema_version"`
 
-	// GeneratedAt is informational and not required for operation.
-	GeneratedAt string `yaml:"generated_at,omitempty"`
+	// GeneratedAt is informational and not required for operation, but
+	// when set it must be an RFC3339 timestamp that is not in the future.
+	GeneratedAt string `yaml:"generated_at,omitempty" json:"generated_at,omitempty" toml:"generated_at,omitempty"`
+
+	// DBChecksum is the optional SHA256 of the canonical versions map (see
+	// dbchecksum.go); when set it must match on load.
+	DBChecksum string `yaml:"db_checksum,omitempty" json:"db_checksum,omitempty" toml:"db_checksum,omitempty"`
 
 	// Versions maps a semantic version (e.g., "2.31.1") to platform checksums.
-	Versions map[string]PlatformChecksums `yaml:"versions"`
+	Versions map[string]PlatformChecksums `yaml:"versions" json:"versions" toml:"versions"`
 }
 
-// PlatformChecksums holds per-platform SHA256 checksums for the CLI binary of a given version.
-// At least one platform should be provided. Unknown keys are ignored by YAML.
+// PlatformChecksums holds per-platform SHA256 checksums for the CLI binary of a given version,
+// with optional SHA512 checksums that are preferred when present.
+// At least one platform should be provided. Unknown keys are ignored.
 type PlatformChecksums struct {
-	LinuxAMD64   string `yaml:"linux_amd64,omitempty"`
-	LinuxARM64   string `yaml:"linux_arm64,omitempty"`
-	DarwinAMD64  string `yaml:"darwin_amd64,omitempty"`
-	DarwinARM64  string `yaml:"darwin_arm64,omitempty"`
-	WindowsAMD64 string `yaml:"windows_amd64,omitempty"`
+	LinuxAMD64   string `yaml:"linux_amd64,omitempty" json:"linux_amd64,omitempty" toml:"linux_amd64,omitempty"`
+	LinuxARM64   string `yaml:"linux_arm64,omitempty" json:"linux_arm64,omitempty" toml:"linux_arm64,omitempty"`
+	DarwinAMD64  string `yaml:"darwin_amd64,omitempty" json:"darwin_amd64,omitempty" toml:"darwin_amd64,omitempty"`
+	DarwinARM64  string `yaml:"darwin_arm64,omitempty" json:"darwin_arm64,omitempty" toml:"darwin_arm64,omitempty"`
+	WindowsAMD64 string `yaml:"windows_amd64,omitempty" json:"windows_amd64,omitempty" toml:"windows_amd64,omitempty"`
+
+	// IBM Power and IBM Z runners. The bundled DB has no checksums for
+	// them; supply them in an override file
+	LinuxPPC64LE string `yaml:"linux_ppc64le,omitempty" json:"linux_ppc64le,omitempty" toml:"linux_ppc64le,omitempty"`
+	LinuxS390X   string `yaml:"linux_s390x,omitempty" json:"linux_s390x,omitempty" toml:"linux_s390x,omitempty"`
+
+	LinuxAMD64SHA512   string `yaml:"linux_amd64_sha512,omitempty" json:"linux_amd64_sha512,omitempty" toml:"linux_amd64_sha512,omitempty"`
+	LinuxARM64SHA512   string `yaml:"linux_arm64_sha512,omitempty" json:"linux_arm64_sha512,omitempty" toml:"linux_arm64_sha512,omitempty"`
+	DarwinAMD64SHA512  string `yaml:"darwin_amd64_sha512,omitempty" json:"darwin_amd64_sha512,omitempty" toml:"darwin_amd64_sha512,omitempty"`
+	DarwinARM64SHA512  string `yaml:"darwin_arm64_sha512,omitempty" json:"darwin_arm64_sha512,omitempty" toml:"darwin_arm64_sha512,omitempty"`
+	WindowsAMD64SHA512 string `yaml:"windows_amd64_sha512,omitempty" json:"windows_amd64_sha512,omitempty" toml:"windows_amd64_sha512,omitempty"`
+	LinuxPPC64LESHA512 string `yaml:"linux_ppc64le_sha512,omitempty" json:"linux_ppc64le_sha512,omitempty" toml:"linux_ppc64le_sha512,omitempty"`
+	LinuxS390XSHA512   string `yaml:"linux_s390x_sha512,omitempty" json:"linux_s390x_sha512,omitempty" toml:"linux_s390x_sha512,omitempty"`
+
+	// Released is the release date of the version, either RFC3339 or
+	// date-only ("2025-06-30"). It is honored from schema version 2.
+	Released string `yaml:"released,omitempty" json:"released,omitempty" toml:"released,omitempty"`
 }
 
 // ValidationError aggregates schema validation errors.
@@ -92,40 +150,92 @@ var (
 	// ErrUnsupportedVersion indicates the requested CLI version is not present in the DB.
 	ErrUnsupportedVersion = errors.New("unsupported 1Password CLI version")
 
+	// ErrPlatformChecksumMissing indicates the version is in the DB but has no
+	// checksum for the current platform.
+	ErrPlatformChecksumMissing = errors.New("no checksum for platform")
+
+	// ErrChecksumMismatch indicates a file does not match the checksum recorded in the DB.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrInvalidConfigSubdir indicates the config subdir override would escape the config root.
+	ErrInvalidConfigSubdir = errors.New("invalid config subdirectory")
+
+	// ErrInvalidVersion indicates a version string is not a semantic version.
+	ErrInvalidVersion = errors.New("invalid version")
+
 	// regex to validate semantic versions like 2.31.1 (no leading 'v')
 	semverLike = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
 
+	// regex to validate pre-release and build metadata identifiers
+	semverIdentifiers = regexp.MustCompile(`^[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
+
 	// regex to validate lowercase hex-encoded SHA256 values
 	hexSHA256 = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+	// regex to validate lowercase hex-encoded SHA512 values
+	hexSHA512 = regexp.MustCompile(`^[a-f0-9]{128}$`)
 )
 
 // Validate performs schema validation for the versions DB.
 func (db *VersionsDB) Validate() error {
 	var errs []string
 
-	if db.SchemaVersion != SchemaVersion {
+	if db.SchemaVersion < minSchemaVersion || db.SchemaVersion > SchemaVersion {
 		errs = append(errs,
-			fmt.Sprintf("unexpected schema_version=%d (expected %d)", db.SchemaVersion, SchemaVersion))
+			fmt.Sprintf("unexpected schema_version=%d (expected %d to %d)", db.SchemaVersion, minSchemaVersion, SchemaVersion))
+	}
+
+	if db.GeneratedAt != "" {
+		generated, err := time.Parse(time.RFC3339, strings.TrimSpace(db.GeneratedAt))
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("invalid generated_at %q (expected an RFC3339 timestamp)", db.GeneratedAt))
+		case generated.After(time.Now().Add(generatedAtSkew)):
+			errs = append(errs, fmt.Sprintf("generated_at %q is in the future", db.GeneratedAt))
+		}
+	}
+
+	if db.DBChecksum != "" && !hexSHA256.MatchString(db.DBChecksum) {
+		errs = append(errs, fmt.Sprintf("invalid db_checksum %q (must be 64 lowercase hex chars)", db.DBChecksum))
 	}
 
 	if len(db.Versions) == 0 {
 		errs = append(errs, "versions map is empty")
 	} else {
 		for ver, pcs := range db.Versions {
-			norm := NormalizeVersion(ver)
-			if !semverLike.MatchString(norm) {
+			// Keys must be plain releases: pre-release builds are not
+			// published with stable checksums, and build metadata would
+			// never match a normalized lookup.
+			parsed, err := ParseVersion(ver)
+			switch {
+			case err != nil:
 				errs = append(errs, fmt.Sprintf("invalid version key '%s' (expected semantic version like 2.31.1)", ver))
+			case parsed.Prerelease != "":
+				errs = append(errs, fmt.Sprintf("invalid version key '%s': pre-release versions are not supported", ver))
+			case parsed.Build != "":
+				errs = append(errs, fmt.Sprintf("invalid version key '%s': remove build metadata (use %s)", ver, parsed.Release()))
 			}
 			// Validate checksums if present
 			checkPairs := []struct {
 				name  string
 				value string
+				regex *regexp.Regexp
+				chars int
 			}{
-				{"linux_amd64", pcs.LinuxAMD64},
-				{"linux_arm64", pcs.LinuxARM64},
-				{"darwin_amd64", pcs.DarwinAMD64},
-				{"darwin_arm64", pcs.DarwinARM64},
-				{"windows_amd64", pcs.WindowsAMD64},
+				{"linux_amd64", pcs.LinuxAMD64, hexSHA256, 64},
+				{"linux_arm64", pcs.LinuxARM64, hexSHA256, 64},
+				{"darwin_amd64", pcs.DarwinAMD64, hexSHA256, 64},
+				{"darwin_arm64", pcs.DarwinARM64, hexSHA256, 64},
+				{"windows_amd64", pcs.WindowsAMD64, hexSHA256, 64},
+				{"linux_ppc64le", pcs.LinuxPPC64LE, hexSHA256, 64},
+				{"linux_s390x", pcs.LinuxS390X, hexSHA256, 64},
+				{"linux_amd64_sha512", pcs.LinuxAMD64SHA512, hexSHA512, 128},
+				{"linux_arm64_sha512", pcs.LinuxARM64SHA512, hexSHA512, 128},
+				{"darwin_amd64_sha512", pcs.DarwinAMD64SHA512, hexSHA512, 128},
+				{"darwin_arm64_sha512", pcs.DarwinARM64SHA512, hexSHA512, 128},
+				{"windows_amd64_sha512", pcs.WindowsAMD64SHA512, hexSHA512, 128},
+				{"linux_ppc64le_sha512", pcs.LinuxPPC64LESHA512, hexSHA512, 128},
+				{"linux_s390x_sha512", pcs.LinuxS390XSHA512, hexSHA512, 128},
 			}
 			atLeastOne := false
 			for _, p := range checkPairs {
@@ -133,13 +243,20 @@ func (db *VersionsDB) Validate() error {
 					continue
 				}
 				atLeastOne = true
-				if !hexSHA256.MatchString(p.value) {
-					errs = append(errs, fmt.Sprintf("version %s: invalid %s checksum (must be 64 hex chars)", ver, p.name))
+				if !p.regex.MatchString(p.value) {
+					errs = append(errs, fmt.Sprintf("version %s: invalid %s checksum (must be %d hex chars)",
+						ver, p.name, p.chars))
 				}
 			}
 			if !atLeastOne {
 				errs = append(errs, fmt.Sprintf("version %s: no platform checksums provided", ver))
 			}
+			if db.SchemaVersion >= 2 && pcs.Released != "" {
+				if _, err := parseReleased(pcs.Released); err != nil {
+					errs = append(errs, fmt.Sprintf("version %s: invalid released date %q (expected RFC3339 or YYYY-MM-DD)",
+						ver, pcs.Released))
+				}
+			}
 		}
 	}
 
@@ -149,13 +266,165 @@ func (db *VersionsDB) Validate() error {
 	return nil
 }
 
-// NormalizeVersion strips a leading 'v' if present, e.g., "v2.31.1" -> "2.31.1".
+// parseReleased parses a released date in any of releasedDateLayouts.
+func parseReleased(value string) (time.Time, error) {
+	var err error
+	for _, layout := range releasedDateLayouts {
+		var released time.Time
+		if released, err = time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return released, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// ReleaseDate returns the release date recorded for version. It reports
+// false when the version is unknown, has no valid date, or the DB uses
+// schema version 1, which predates released dates.
+func (db *VersionsDB) ReleaseDate(version string) (time.Time, bool) {
+	if db == nil || db.SchemaVersion < 2 {
+		return time.Time{}, false
+	}
+	pcs, ok := db.Versions[NormalizeVersion(version)]
+	if !ok || pcs.Released == "" {
+		return time.Time{}, false
+	}
+	released, err := parseReleased(pcs.Released)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return released, true
+}
+
+// VersionAge returns how long ago version was released according to the
+// versions DB, for warning about old pinned versions. It reports false when
+// the DB cannot be loaded or records no release date for the version.
+func VersionAge(version string) (time.Duration, bool) {
+	db, _, err := LoadOrInstallDB()
+	if err != nil {
+		return 0, false
+	}
+	released, ok := db.ReleaseDate(version)
+	if !ok {
+		return 0, false
+	}
+	return time.Since(released), true
+}
+
+// NormalizeVersion strips a leading 'v' and any build metadata, e.g.,
+// "v2.31.1" -> "2.31.1" and "2.31.1+build5" -> "2.31.1". Build metadata does
+// not identify a different release, so it never takes part in lookups.
+// Pre-release tags are kept: "2.31.1-beta" is a different binary from 2.31.1.
 func NormalizeVersion(v string) string {
-	return strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string // e.g., "beta.1" for 2.31.1-beta.1; empty for releases
+	Build      string // e.g., "build5" for 2.31.1+build5
+}
+
+// Release returns the major.minor.patch portion, e.g., "2.31.1".
+func (v Version) Release() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or +1 as v precedes, equals or follows other in
+// semantic version precedence. A pre-release precedes its release, and
+// build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	// Identifiers are compared in turn: numerically when both are numeric,
+	// otherwise lexically, with numeric ones preceding alphanumeric ones
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(a[i])
+		bn, bErr := strconv.Atoi(b[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			return sign(an - bn)
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			return strings.Compare(a[i], b[i])
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// sign returns -1, 0 or +1 for the sign of d.
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ParseVersion parses a semantic version such as "2.31.1", "v2.31.1-beta.1"
+// or "2.31.1+build5" into its components.
+func ParseVersion(s string) (Version, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	var v Version
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !semverIdentifiers.MatchString(v.Build) {
+			return Version{}, fmt.Errorf("%w: %q has malformed build metadata", ErrInvalidVersion, s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.Prerelease = rest[i+1:]
+		rest = rest[:i]
+		if !semverIdentifiers.MatchString(v.Prerelease) {
+			return Version{}, fmt.Errorf("%w: %q has a malformed pre-release tag", ErrInvalidVersion, s)
+		}
+	}
+	if !semverLike.MatchString(rest) {
+		return Version{}, fmt.Errorf("%w: %q (expected semantic version like 2.31.1)", ErrInvalidVersion, s)
+	}
+
+	if _, err := fmt.Sscanf(rest, "%d.%d.%d", &v.Major, &v.Minor, &v.Patch); err != nil {
+		return Version{}, fmt.Errorf("%w: %q: %v", ErrInvalidVersion, s, err)
+	}
+	return v, nil
 }
 
 // ComputePlatformKey returns the platform key used in the versions DB given GOOS/GOARCH.
 // Example outputs: "linux_amd64", "darwin_arm64", "windows_amd64".
+// linux_ppc64le and linux_s390x are computed even though the bundled DB has
+// no checksums for them, so that an override file can supply them.
 func ComputePlatformKey(goos, goarch string) (string, error) {
 	switch goos {
 	case linuxOS:
@@ -164,6 +433,10 @@ func ComputePlatformKey(goos, goarch string) (string, error) {
 			return "linux_amd64", nil
 		case arm64Architecture:
 			return "linux_arm64", nil
+		case ppc64leArchitecture:
+			return "linux_ppc64le", nil
+		case s390xArchitecture:
+			return "linux_s390x", nil
 		}
 	case darwinOS:
 		switch goarch {
@@ -202,30 +475,180 @@ func (db *VersionsDB) GetExpectedSHA(version, platformKey string) (string, bool)
 		return pcs.DarwinARM64, pcs.DarwinARM64 != ""
 	case "windows_amd64":
 		return pcs.WindowsAMD64, pcs.WindowsAMD64 != ""
+	case "linux_ppc64le":
+		return pcs.LinuxPPC64LE, pcs.LinuxPPC64LE != ""
+	case "linux_s390x":
+		return pcs.LinuxS390X, pcs.LinuxS390X != ""
 	default:
 		return "", false
 	}
 }
 
+// GetExpectedSHA512 returns the expected SHA512 for a given version and platform key.
+func (db *VersionsDB) GetExpectedSHA512(version, platformKey string) (string, bool) {
+	if db == nil {
+		return "", false
+	}
+	pcs, ok := db.Versions[NormalizeVersion(version)]
+	if !ok {
+		return "", false
+	}
+	var sha string
+	switch platformKey {
+	case "linux_amd64":
+		sha = pcs.LinuxAMD64SHA512
+	case "linux_arm64":
+		sha = pcs.LinuxARM64SHA512
+	case "darwin_amd64":
+		sha = pcs.DarwinAMD64SHA512
+	case "darwin_arm64":
+		sha = pcs.DarwinARM64SHA512
+	case "windows_amd64":
+		sha = pcs.WindowsAMD64SHA512
+	case "linux_ppc64le":
+		sha = pcs.LinuxPPC64LESHA512
+	case "linux_s390x":
+		sha = pcs.LinuxS390XSHA512
+	}
+	return sha, sha != ""
+}
+
+// platformKeys lists every platform key ComputePlatformKey can return.
+var platformKeys = []string{
+	"darwin_amd64", "darwin_arm64",
+	"linux_amd64", "linux_arm64", "linux_ppc64le", "linux_s390x",
+	"windows_amd64",
+}
+
+// PlatformsForVersion returns the sorted platform keys that have a SHA256 or
+// SHA512 checksum for version, or nil when the DB does not know the version.
+func (db *VersionsDB) PlatformsForVersion(version string) []string {
+	if db == nil {
+		return nil
+	}
+	if _, ok := db.Versions[NormalizeVersion(version)]; !ok {
+		return nil
+	}
+	var platforms []string
+	for _, pk := range platformKeys {
+		_, has256 := db.GetExpectedSHA(version, pk)
+		_, has512 := db.GetExpectedSHA512(version, pk)
+		if has256 || has512 {
+			platforms = append(platforms, pk)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
 // ExpectedSHAFromDB resolves the expected SHA256 for the provided version using the
 // current runtime platform. It loads the DB from the environment-configured path
 // or the default path, installing the bundled DB if missing.
 func ExpectedSHAFromDB(version string) (string, error) {
-	db, path, err := LoadOrInstallDB()
+	sha, _, err := ExpectedChecksumsFromDB(version)
 	if err != nil {
 		return "", err
 	}
-	_ = path // reserved for future diagnostics if needed
+	if sha == "" {
+		pk, _ := ComputePlatformKey(runtime.GOOS, runtime.GOARCH)
+		return "", fmt.Errorf("%w: version %s has no %s SHA256 checksum",
+			ErrPlatformChecksumMissing, NormalizeVersion(version), pk)
+	}
+	return sha, nil
+}
+
+// ExpectedChecksumsFromDB resolves the expected SHA256 and SHA512 for the
+// provided version on the current runtime platform. Either may be empty, but
+// not both.
+func ExpectedChecksumsFromDB(version string) (sha256Sum, sha512Sum string, err error) {
+	db, _, err := LoadOrInstallDB()
+	if err != nil {
+		return "", "", err
+	}
 
 	pk, err := ComputePlatformKey(runtime.GOOS, runtime.GOARCH)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	sha, ok := db.GetExpectedSHA(version, pk)
-	if !ok || strings.TrimSpace(sha) == "" {
-		return "", fmt.Errorf("%w: %s", ErrUnsupportedVersion, NormalizeVersion(version))
+
+	sha256Sum, _ = db.GetExpectedSHA(version, pk)
+	sha512Sum, _ = db.GetExpectedSHA512(version, pk)
+	sha256Sum = strings.TrimSpace(sha256Sum)
+	sha512Sum = strings.TrimSpace(sha512Sum)
+	if sha256Sum == "" && sha512Sum == "" {
+		if _, known := db.Versions[NormalizeVersion(version)]; known {
+			return "", "", fmt.Errorf("%w: version %s has no %s checksum",
+				ErrPlatformChecksumMissing, NormalizeVersion(version), pk)
+		}
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedVersion, NormalizeVersion(version))
 	}
-	return sha, nil
+	return sha256Sum, sha512Sum, nil
+}
+
+// VerifyFileChecksum hashes the file at path and compares it with the checksum
+// recorded in the versions DB for version on the current platform, preferring
+// SHA512 over SHA256 when both are recorded. The file is
+// streamed so large binaries are never held in memory. Unknown versions return
+// an error wrapping ErrUnsupportedVersion, a version without a checksum for
+// this platform wraps ErrPlatformChecksumMissing, and a mismatch wraps
+// ErrChecksumMismatch.
+func VerifyFileChecksum(path, version string) error {
+	expectedSHA256, expectedSHA512, err := ExpectedChecksumsFromDB(version)
+	if err != nil {
+		return err
+	}
+
+	expected, actual := expectedSHA256, ""
+	if expectedSHA512 != "" {
+		expected = expectedSHA512
+		actual, err = fileSHA512(path)
+	} else {
+		actual, err = fileSHA256(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%w for %s (CLI version %s): expected %s, got %s",
+			ErrChecksumMismatch, path, NormalizeVersion(version), expected, truncateDigest(actual))
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	return fileDigest(path, sha256.New())
+}
+
+// fileSHA512 returns the hex-encoded SHA512 of the file at path.
+func fileSHA512(path string) (string, error) {
+	return fileDigest(path, sha512.New())
+}
+
+// fileDigest streams the file at path through hasher.
+func fileDigest(path string, hasher hash.Hash) (string, error) {
+	// #nosec G304 -- path is supplied by the caller verifying its own download
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s for checksum: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// truncateDigest shortens a digest for log output; the expected value is
+// always reported in full so it can be compared against the DB.
+func truncateDigest(digest string) string {
+	const keep = 16
+	if len(digest) <= keep {
+		return digest
+	}
+	return digest[:keep] + "..."
 }
 
 // LoadOrInstallDB loads the versions DB from the configured path or installs the
@@ -264,7 +687,73 @@ func LoadOrInstallDB() (*VersionsDB, string, error) {
 	return db, defaultPath, nil
 }
 
-// loadDBFromPath reads and validates the versions DB from a file path.
+// ExportEffectiveDB returns the versions DB in effect, as loaded by
+// LoadOrInstallDB, as YAML for checking which checksums a run will verify
+// against. Comments record the file it was loaded from and, per version,
+// whether the entry matches the bundled DB, differs from it, or was added,
+// followed by any bundled versions the file leaves out. The versions DB
+// holds only checksums and dates, so no token or secret can appear.
+func ExportEffectiveDB() ([]byte, error) {
+	db, path, err := LoadOrInstallDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var bundled VersionsDB
+	if err := yaml.Unmarshal([]byte(bundledVersionsYAML(SystemClock.Now())), &bundled); err != nil {
+		return nil, fmt.Errorf("bundled versions DB is invalid YAML: %w", err)
+	}
+
+	var root yaml.Node
+	if err := root.Encode(db); err != nil {
+		return nil, fmt.Errorf("failed to encode versions DB: %w", err)
+	}
+	root.HeadComment = "Effective 1Password CLI versions DB\nsource: " + path
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "versions" {
+			continue
+		}
+		entries := root.Content[i+1].Content
+		for j := 0; j+1 < len(entries); j += 2 {
+			ver := entries[j].Value
+			origin := "added by " + path
+			if want, ok := bundled.Versions[ver]; ok {
+				got := db.Versions[ver]
+				got.Released, want.Released = "", ""
+				if got == want {
+					origin = "matches bundled"
+				} else {
+					origin = "overrides bundled"
+				}
+			}
+			entries[j].LineComment = origin
+		}
+	}
+
+	var missing []string
+	for ver := range bundled.Versions {
+		if _, ok := db.Versions[ver]; !ok {
+			missing = append(missing, ver)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		root.FootComment = "bundled versions not in effect: " + strings.Join(missing, ", ")
+	}
+
+	content, err := yaml.Marshal(&root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode versions DB: %w", err)
+	}
+	return content, nil
+}
+
+// loadDBFromPath reads and validates the versions DB from a file path,
+// verifying its detached signature first when a trusted key is configured
+// and its db_checksum when it has one. The file is parsed as YAML, JSON or
+// TOML according to its extension. A DB at an older schema is migrated to
+// SchemaVersion before validation.
 func loadDBFromPath(path string) (*VersionsDB, error) {
 	// #nosec G304 -- path is determined from a trusted environment variable or default config directory
 	content, err := os.ReadFile(path)
@@ -272,17 +761,71 @@ func loadDBFromPath(path string) (*VersionsDB, error) {
 		return nil, fmt.Errorf("failed to read versions DB at %s: %w", path, err)
 	}
 
+	if err := verifyDBSignature(path, content); err != nil {
+		return nil, err
+	}
+
 	var db VersionsDB
-	if err := yaml.Unmarshal(content, &db); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML versions DB at %s: %w", path, err)
+	format := dbFormat(path)
+	if err := unmarshalDB(format, content, &db); err != nil {
+		return nil, invalidDBError(path, fmt.Errorf("failed to parse %s versions DB at %s: %w",
+			strings.ToUpper(format), path, err))
+	}
+
+	// The checksum covers the versions as written, before any migration
+	if hexSHA256.MatchString(db.DBChecksum) {
+		if err := db.VerifyChecksum(); err != nil {
+			return nil, invalidDBError(path, err)
+		}
+	}
+
+	migrated := false
+	if db.SchemaVersion >= minSchemaVersion && db.SchemaVersion < SchemaVersion {
+		if err := db.Migrate(db.SchemaVersion, SchemaVersion); err != nil {
+			return nil, invalidDBError(path, err)
+		}
+		if db.DBChecksum != "" {
+			// Keep the verified checksum in step with the migrated versions
+			if err := db.SetChecksum(); err != nil {
+				return nil, invalidDBError(path, err)
+			}
+		}
+		migrated = true
 	}
 
 	if err := db.Validate(); err != nil {
-		return nil, err
+		return nil, invalidDBError(path, err)
+	}
+	if migrated {
+		rewriteMigratedDB(&db, path)
 	}
 	return &db, nil
 }
 
+// invalidDBError reports a versions DB that cannot be parsed or fails
+// validation as ErrCodeVersionsDBInvalid, naming the file and each
+// validation message, so that it is not mistaken for a failed download.
+func invalidDBError(path string, err error) error {
+	details := map[string]interface{}{"path": path}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		details["validation_errors"] = validationErr.Errors
+	}
+
+	restore := "Delete the file to have the bundled versions DB reinstalled"
+	if strings.TrimSpace(os.Getenv(envVersionsFile)) != "" {
+		restore = fmt.Sprintf("Unset %s to use the bundled versions DB", envVersionsFile)
+	}
+
+	return apperrors.Wrap(apperrors.ErrCodeVersionsDBInvalid,
+		fmt.Sprintf("Versions DB at %s is invalid", path), err).
+		WithDetails(details).
+		WithSuggestions(
+			fmt.Sprintf("Fix the problems reported for %s", path),
+			restore,
+		)
+}
+
 // DefaultConfigDir determines the OS-appropriate base configuration directory.
 func DefaultConfigDir() (string, error) {
 	// Windows: %APPDATA%
@@ -313,12 +856,71 @@ func DefaultDBPath() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(cfgRoot, defaultSubdir, defaultVersionsFilename), nil
+	subdir, err := configSubdir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cfgRoot, subdir, defaultVersionsFilename), nil
+}
+
+// DefaultBinaryCacheDir returns the default directory of the persistent CLI
+// binary cache, next to the versions database.
+func DefaultBinaryCacheDir() (string, error) {
+	cfgRoot, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	subdir, err := configSubdir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cfgRoot, subdir, defaultBinaryCacheSubdir), nil
+}
+
+// configSubdir returns the subdir under the config root, honoring the
+// OP_SECRETS_ACTION_CONFIG_SUBDIR override. The override must be a relative
+// path that stays inside the config root.
+func configSubdir() (string, error) {
+	raw := strings.TrimSpace(os.Getenv(envConfigSubdir))
+	if raw == "" {
+		return defaultSubdir, nil
+	}
+
+	// Reject rooted paths on every platform, including "\\foo" and "C:foo" on Windows
+	if filepath.IsAbs(raw) || filepath.VolumeName(raw) != "" ||
+		strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, `\`) {
+		return "", fmt.Errorf("%w: %s=%q must be a relative path", ErrInvalidConfigSubdir, envConfigSubdir, raw)
+	}
+
+	// Check segments before cleaning so "a/../b" is rejected rather than normalized
+	for _, segment := range strings.FieldsFunc(raw, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %s=%q must not contain '..'", ErrInvalidConfigSubdir, envConfigSubdir, raw)
+		}
+	}
+
+	cleaned := filepath.Clean(raw)
+	if cleaned == "." {
+		return "", fmt.Errorf("%w: %s=%q must name a subdirectory", ErrInvalidConfigSubdir, envConfigSubdir, raw)
+	}
+	return cleaned, nil
+}
+
+// WriteOptions configures writing the versions DB.
+type WriteOptions struct {
+	// Clock sets generated_at; nil uses SystemClock
+	Clock Clock
 }
 
 // WriteBundledDBIfMissing writes the bundled DB to the default path if it does not exist.
 // It creates parent directories with 0700 and writes the file with 0600 permissions.
 func WriteBundledDBIfMissing() error {
+	return WriteBundledDBIfMissingWithOptions(WriteOptions{})
+}
+
+// WriteBundledDBIfMissingWithOptions is WriteBundledDBIfMissing with the
+// given options.
+func WriteBundledDBIfMissingWithOptions(opts WriteOptions) error {
 	path, err := DefaultDBPath()
 	if err != nil {
 		return err
@@ -338,8 +940,9 @@ func WriteBundledDBIfMissing() error {
 	}
 
 	// Validate bundled YAML before writing (defensive)
+	content := bundledVersionsYAML(clockOrSystem(opts.Clock).Now())
 	var db VersionsDB
-	if err := yaml.Unmarshal([]byte(bundledVersionsYAML), &db); err != nil {
+	if err := yaml.Unmarshal([]byte(content), &db); err != nil {
 		return fmt.Errorf("bundled versions DB is invalid YAML: %w", err)
 	}
 	if err := db.Validate(); err != nil {
@@ -347,18 +950,24 @@ func WriteBundledDBIfMissing() error {
 	}
 
 	// 0600 for file
-	if writeErr := os.WriteFile(path, []byte(bundledVersionsYAML), 0o600); writeErr != nil {
+	if writeErr := os.WriteFile(path, []byte(content), 0o600); writeErr != nil {
 		return fmt.Errorf("failed to write versions DB to %s: %w", path, writeErr)
 	}
 	return nil
 }
 
-// bundledVersionsYAML contains the default, built-in YAML database that will be installed
-// automatically if no user-provided database exists at the default path.
+// bundledVersionsYAML returns the default, built-in YAML database that will be installed
+// automatically if no user-provided database exists at the default path, generated at now.
+func bundledVersionsYAML(now time.Time) string {
+	return strings.TrimSpace(fmt.Sprintf(bundledVersionsTemplate, SchemaVersion, now.UTC().Format(time.RFC3339)))
+}
+
+// bundledVersionsTemplate is the bundled database, formatted with the schema
+// version and generated_at by bundledVersionsYAML.
 //
 // The checksums below correspond to 1Password CLI v2.31.1, verified against official sources.
 // Last verified: 2025-07-28
-var bundledVersionsYAML = strings.TrimSpace(fmt.Sprintf(`
+const bundledVersionsTemplate = `
 schema_version: %d
 generated_at: %q
 versions:
@@ -368,10 +977,11 @@ versions:
     darwin_amd64: "019f37e33a6d4f7824cda14eee5e24c2947d58d94ed7dd3b3fc3cbcd644647df"
     darwin_arm64: "71d38ddee25d34a9159b81d8c16844c3869defd7cc1563cc8f216a20439ceba4"
     windows_amd64: "9e54520aa136ecd6bc7082ec719b68f00bd23cb575c6e787d62f34cc44895bbb"
-`, SchemaVersion, time.Now().UTC().Format(time.RFC3339)))
+`
 
 // ExtendDB allows programmatic extension of an already loaded DB with a new version entry,
-// performing validation of the added checksums. This does not persist changes to disk.
+// performing validation of the added checksums. This does not persist changes to disk;
+// use SaveToPath for that.
 func (db *VersionsDB) ExtendDB(version string, checksums PlatformChecksums) error {
 	if db.Versions == nil {
 		db.Versions = make(map[string]PlatformChecksums)
@@ -389,3 +999,127 @@ func (db *VersionsDB) ExtendDB(version string, checksums PlatformChecksums) erro
 	db.Versions[nv] = checksums
 	return nil
 }
+
+// Prune removes all but the newest keep versions from the DB and returns
+// the removed versions, oldest first, for logging. DefaultCLIVersion and
+// any protected version, such as the one a workflow pins, are always kept
+// and do not count towards keep. Entries whose key is not a semantic
+// version are left alone. Like ExtendDB, it does not persist the change.
+func (db *VersionsDB) Prune(keep int, protect ...string) []string {
+	if db == nil || len(db.Versions) == 0 {
+		return nil
+	}
+
+	protected := map[string]bool{NormalizeVersion(DefaultCLIVersion): true}
+	for _, v := range protect {
+		protected[NormalizeVersion(v)] = true
+	}
+
+	type entry struct {
+		key     string
+		version Version
+	}
+	var candidates []entry
+	for key := range db.Versions {
+		if protected[NormalizeVersion(key)] {
+			continue
+		}
+		parsed, err := ParseVersion(key)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, entry{key: key, version: parsed})
+	}
+
+	// Newest first
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].version.Compare(candidates[j].version) > 0
+	})
+	if keep < 0 {
+		keep = 0
+	}
+	if keep >= len(candidates) {
+		return nil
+	}
+
+	pruned := make([]string, 0, len(candidates)-keep)
+	for i := len(candidates) - 1; i >= keep; i-- {
+		delete(db.Versions, candidates[i].key)
+		pruned = append(pruned, candidates[i].key)
+	}
+	return pruned
+}
+
+// SaveToPath validates the DB and writes it to path in the format its
+// extension selects (see dbFormat), setting generated_at to the current
+// time. The file is written with 0600 permissions to a temporary file in
+// the same directory and renamed into place, so readers never observe a
+// partially written DB. An invalid DB is
+// never written. A DB of an older schema is migrated and written as
+// SchemaVersion. A DB with a db_checksum, set by SetChecksum or loaded from
+// the file, is written with the checksum of its current versions.
+func (db *VersionsDB) SaveToPath(path string) error {
+	return db.SaveToPathWithOptions(path, WriteOptions{})
+}
+
+// SaveToPathWithOptions is SaveToPath with the given options.
+func (db *VersionsDB) SaveToPathWithOptions(path string, opts WriteOptions) error {
+	if db == nil {
+		return errors.New("versions DB is nil")
+	}
+	if err := db.Validate(); err != nil {
+		return err
+	}
+
+	out := *db
+	if err := out.Migrate(out.SchemaVersion, SchemaVersion); err != nil {
+		return err
+	}
+	out.GeneratedAt = clockOrSystem(opts.Clock).Now().UTC().Format(time.RFC3339)
+	if out.DBChecksum != "" {
+		if err := out.SetChecksum(); err != nil {
+			return err
+		}
+	}
+	content, err := marshalDB(dbFormat(path), &out)
+	if err != nil {
+		return fmt.Errorf("failed to encode versions DB: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary versions DB in %s: %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set permissions on %s: %w", tmpPath, err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write versions DB to %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync versions DB to %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to move versions DB into place at %s: %w", path, err)
+	}
+
+	db.SchemaVersion = out.SchemaVersion
+	db.Versions = out.Versions
+	db.GeneratedAt = out.GeneratedAt
+	db.DBChecksum = out.DBChecksum
+	return nil
+}
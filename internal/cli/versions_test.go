@@ -4,12 +4,24 @@ package cli
 // SPDX-FileCopyrightText: 2025 The Linux Foundation
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 )
 
 // helper to compute the current platform key or skip the test if unsupported
@@ -69,7 +81,7 @@ func TestExpectedSHAFromDB_WithEnvOverride_SupportedAndPrefixedVersion(t *testin
 	}
 }
 
-func TestManager_getExpectedSHA_UnsupportedVersion_WrapsErrUnsupportedVersion(t *testing.T) {
+func TestManager_getExpectedChecksums_UnsupportedVersion_WrapsErrUnsupportedVersion(t *testing.T) {
 	pk := currentPlatformKey(t)
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "1password-cli-versions.yaml")
@@ -79,7 +91,7 @@ func TestManager_getExpectedSHA_UnsupportedVersion_WrapsErrUnsupportedVersion(t
 	t.Setenv(envVersionsFile, dbPath)
 
 	// Call the manager helper which wraps the error
-	_, err := getExpectedSHA("9.9.9")
+	_, _, err := getExpectedChecksums("9.9.9")
 	if err == nil {
 		t.Fatal("expected error for unsupported version, got nil")
 	}
@@ -91,6 +103,43 @@ func TestManager_getExpectedSHA_UnsupportedVersion_WrapsErrUnsupportedVersion(t
 	}
 }
 
+func TestExpectedSHAFromDB_VersionMissingPlatformChecksum(t *testing.T) {
+	pk := currentPlatformKey(t)
+	otherKey := "linux_amd64"
+	if pk == otherKey {
+		otherKey = "darwin_arm64"
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", otherKey, strings.Repeat("d", 64))
+	t.Setenv(envVersionsFile, dbPath)
+
+	_, err := ExpectedSHAFromDB("v2.31.1")
+	if !errors.Is(err, ErrPlatformChecksumMissing) {
+		t.Fatalf("expected ErrPlatformChecksumMissing, got %v", err)
+	}
+	if errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("a known version must not be reported as unsupported: %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "2.31.1") || !strings.Contains(msg, pk) {
+		t.Errorf("error should name the version and platform, got: %v", err)
+	}
+
+	_, _, err = getExpectedChecksums("2.31.1")
+	if !errors.Is(err, ErrPlatformChecksumMissing) {
+		t.Fatalf("getExpectedChecksums should wrap ErrPlatformChecksumMissing, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "add one to the database") {
+		t.Errorf("error should suggest adding the checksum, got: %v", err)
+	}
+
+	// An absent version is still unsupported
+	_, err = ExpectedSHAFromDB("9.9.9")
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion for unknown version, got %v", err)
+	}
+}
+
 func TestLoadOrInstallDB_AutoInstallsBundledDB_UsesTempConfigDir(t *testing.T) {
 	// Ensure no env override is present
 	t.Setenv(envVersionsFile, "")
@@ -161,6 +210,22 @@ func TestValidationError_OnInvalidChecksumSchema(t *testing.T) {
 	if len(ve.Errors) == 0 {
 		t.Fatalf("ValidationError should contain details, got empty list")
 	}
+
+	// The failure names the file and its problems under a dedicated code
+	var actionErr *apperrors.ActionableError
+	if !errors.As(err, &actionErr) || actionErr.Code != apperrors.ErrCodeVersionsDBInvalid {
+		t.Fatalf("expected %s, got %v", apperrors.ErrCodeVersionsDBInvalid, err)
+	}
+	if actionErr.Details["path"] != dbPath {
+		t.Errorf("details path = %v, want %s", actionErr.Details["path"], dbPath)
+	}
+	if got, ok := actionErr.Details["validation_errors"].([]string); !ok || len(got) != len(ve.Errors) {
+		t.Errorf("details validation_errors = %v, want %v", actionErr.Details["validation_errors"], ve.Errors)
+	}
+	if suggestions := actionErr.GetSuggestions(); len(suggestions) == 0 ||
+		!strings.Contains(strings.Join(suggestions, "\n"), envVersionsFile) {
+		t.Errorf("suggestions = %v, want a pointer to %s", suggestions, envVersionsFile)
+	}
 }
 
 func TestNormalizeVersion(t *testing.T) {
@@ -172,6 +237,9 @@ func TestNormalizeVersion(t *testing.T) {
 		{"v2.31.1", "2.31.1"},
 		{"  v2.31.1  ", "2.31.1"},
 		{"   1.2.3   ", "1.2.3"},
+		{"2.31.1+build5", "2.31.1"},
+		{"v2.31.1-beta+build5", "2.31.1-beta"},
+		{"2.31.1-beta", "2.31.1-beta"},
 	}
 	for _, tt := range tests {
 		got := NormalizeVersion(tt.in)
@@ -180,3 +248,1124 @@ func TestNormalizeVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "2.31.1", want: Version{Major: 2, Minor: 31, Patch: 1}},
+		{in: "v2.31.1", want: Version{Major: 2, Minor: 31, Patch: 1}},
+		{in: "2.31.1-beta", want: Version{Major: 2, Minor: 31, Patch: 1, Prerelease: "beta"}},
+		{in: "2.31.1-beta.01", want: Version{Major: 2, Minor: 31, Patch: 1, Prerelease: "beta.01"}},
+		{in: "2.31.1+build5", want: Version{Major: 2, Minor: 31, Patch: 1, Build: "build5"}},
+		{in: "2.31.1-rc.1+build.5", want: Version{Major: 2, Minor: 31, Patch: 1, Prerelease: "rc.1", Build: "build.5"}},
+		{in: "2.31", wantErr: true},
+		{in: "2.31.1-", wantErr: true},
+		{in: "2.31.1+", wantErr: true},
+		{in: "2.31.1-beta..1", wantErr: true},
+		{in: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidVersion) {
+				t.Errorf("ParseVersion(%q) error = %v, want ErrInvalidVersion", tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVersion(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVersionsDB_ValidateRejectsPrereleaseAndBuildKeys(t *testing.T) {
+	sha := strings.Repeat("a", 64)
+	for key, want := range map[string]string{
+		"2.31.1-beta":   "'2.31.1-beta': pre-release versions are not supported",
+		"2.31.1+build5": "'2.31.1+build5': remove build metadata (use 2.31.1)",
+	} {
+		db := &VersionsDB{
+			SchemaVersion: SchemaVersion,
+			Versions:      map[string]PlatformChecksums{key: {LinuxAMD64: sha}},
+		}
+		err := db.Validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() with key %q error = %v, want it to contain %q", key, err, want)
+		}
+	}
+}
+
+func TestDefaultDBPath_ConfigSubdirOverride(t *testing.T) {
+	tmpCfg := t.TempDir()
+	if runtime.GOOS == windowsOS {
+		t.Setenv("APPDATA", tmpCfg)
+	} else {
+		t.Setenv("XDG_CONFIG_HOME", tmpCfg)
+	}
+
+	tests := []struct {
+		name    string
+		subdir  string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset uses default", subdir: "", want: filepath.Join(tmpCfg, defaultSubdir, defaultVersionsFilename)},
+		{name: "single segment", subdir: "repo-a", want: filepath.Join(tmpCfg, "repo-a", defaultVersionsFilename)},
+		{name: "nested segments", subdir: "org/repo-b/staging", want: filepath.Join(tmpCfg, "org", "repo-b", "staging", defaultVersionsFilename)},
+		{name: "absolute path", subdir: "/etc/op", wantErr: true},
+		{name: "backslash rooted", subdir: `\op`, wantErr: true},
+		{name: "parent traversal", subdir: "../outside", wantErr: true},
+		{name: "embedded traversal", subdir: "a/../../b", wantErr: true},
+		{name: "traversal normalizing inside root", subdir: "a/../b", wantErr: true},
+		{name: "current directory only", subdir: ".", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envConfigSubdir, tt.subdir)
+
+			got, err := DefaultDBPath()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConfigSubdir) {
+					t.Fatalf("DefaultDBPath() error = %v, want ErrInvalidConfigSubdir", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DefaultDBPath() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DefaultDBPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOrInstallDB_ConfigSubdirOverride_IsolatesInstalls(t *testing.T) {
+	t.Setenv(envVersionsFile, "")
+
+	tmpCfg := t.TempDir()
+	if runtime.GOOS == windowsOS {
+		t.Setenv("APPDATA", tmpCfg)
+	} else {
+		t.Setenv("XDG_CONFIG_HOME", tmpCfg)
+	}
+
+	t.Setenv(envConfigSubdir, "repo-a")
+	_, pathA, err := LoadOrInstallDB()
+	if err != nil {
+		t.Fatalf("LoadOrInstallDB (repo-a) returned error: %v", err)
+	}
+
+	t.Setenv(envConfigSubdir, "repo-b")
+	_, pathB, err := LoadOrInstallDB()
+	if err != nil {
+		t.Fatalf("LoadOrInstallDB (repo-b) returned error: %v", err)
+	}
+
+	if pathA == pathB {
+		t.Fatalf("installs should be isolated, both used %s", pathA)
+	}
+	for _, p := range []string{pathA, pathB} {
+		if !strings.HasPrefix(p, tmpCfg) {
+			t.Errorf("DB path %s escaped config root %s", p, tmpCfg)
+		}
+		if _, statErr := os.Stat(p); statErr != nil {
+			t.Errorf("bundled DB not installed at %s: %v", p, statErr)
+		}
+	}
+}
+
+func TestVerifyFileChecksum(t *testing.T) {
+	pk := currentPlatformKey(t)
+	tmpDir := t.TempDir()
+
+	binary := filepath.Join(tmpDir, "op")
+	content := []byte("fake op binary")
+	if err := os.WriteFile(binary, content, 0o600); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	goodSHA := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name    string
+		dbSHA   string
+		path    string
+		version string
+		wantErr error
+	}{
+		{name: "matching checksum", dbSHA: goodSHA, path: binary, version: "2.31.1"},
+		{name: "prefixed version", dbSHA: goodSHA, path: binary, version: "v2.31.1"},
+		{name: "mismatch", dbSHA: strings.Repeat("b", 64), path: binary, version: "2.31.1", wantErr: ErrChecksumMismatch},
+		{name: "unknown version", dbSHA: goodSHA, path: binary, version: "9.9.9", wantErr: ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+			writeVersionsYAML(t, dbPath, "2.31.1", pk, tt.dbSHA)
+			t.Setenv(envVersionsFile, dbPath)
+
+			err := VerifyFileChecksum(tt.path, tt.version)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("VerifyFileChecksum() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyFileChecksum() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("mismatch message includes both digests", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		wantSHA := strings.Repeat("c", 64)
+		writeVersionsYAML(t, dbPath, "2.31.1", pk, wantSHA)
+		t.Setenv(envVersionsFile, dbPath)
+
+		err := VerifyFileChecksum(binary, "2.31.1")
+		if err == nil {
+			t.Fatal("VerifyFileChecksum() expected mismatch error")
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, wantSHA) {
+			t.Errorf("error should include expected digest in full: %s", msg)
+		}
+		if !strings.Contains(msg, goodSHA[:16]+"...") || strings.Contains(msg, goodSHA) {
+			t.Errorf("error should include the truncated actual digest: %s", msg)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		writeVersionsYAML(t, dbPath, "2.31.1", pk, goodSHA)
+		t.Setenv(envVersionsFile, dbPath)
+
+		if err := VerifyFileChecksum(filepath.Join(tmpDir, "absent"), "2.31.1"); err == nil {
+			t.Fatal("VerifyFileChecksum() expected error for missing file")
+		}
+	})
+}
+
+func TestVersionsDB_SaveToPath(t *testing.T) {
+	pk := currentPlatformKey(t)
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "nested", "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", pk, strings.Repeat("a", 64))
+
+	db, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("loadDBFromPath() error: %v", err)
+	}
+	if err := db.ExtendDB("v2.32.0", PlatformChecksums{LinuxAMD64: strings.Repeat("e", 64)}); err != nil {
+		t.Fatalf("ExtendDB() error: %v", err)
+	}
+
+	before := time.Now().UTC().Add(-time.Second)
+	if err := db.SaveToPath(dbPath); err != nil {
+		t.Fatalf("SaveToPath() error: %v", err)
+	}
+
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("saved DB missing: %v", err)
+	}
+	if runtime.GOOS != windowsOS && info.Mode().Perm() != 0o600 {
+		t.Errorf("saved DB permissions = %o, want 600", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(dbPath))
+	if err != nil {
+		t.Fatalf("ReadDir() error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	reloaded, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("reloading saved DB: %v", err)
+	}
+	if reloaded.SchemaVersion != SchemaVersion {
+		t.Errorf("schema_version = %d, want %d", reloaded.SchemaVersion, SchemaVersion)
+	}
+	if sha, ok := reloaded.GetExpectedSHA("2.32.0", "linux_amd64"); !ok || sha != strings.Repeat("e", 64) {
+		t.Errorf("extended version not persisted: %q %v", sha, ok)
+	}
+	if _, ok := reloaded.GetExpectedSHA("2.31.1", pk); !ok {
+		t.Error("existing version not persisted")
+	}
+	generated, err := time.Parse(time.RFC3339, reloaded.GeneratedAt)
+	if err != nil || generated.Before(before.Truncate(time.Second)) {
+		t.Errorf("generated_at = %q, want the save time", reloaded.GeneratedAt)
+	}
+	if db.GeneratedAt != reloaded.GeneratedAt {
+		t.Errorf("in-memory generated_at = %q, want %q", db.GeneratedAt, reloaded.GeneratedAt)
+	}
+}
+
+func TestVersionsDB_SaveToPathRefusesInvalidDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	if err := os.WriteFile(dbPath, []byte("original"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	db := &VersionsDB{
+		SchemaVersion: SchemaVersion,
+		Versions:      map[string]PlatformChecksums{"2.31.1": {LinuxAMD64: "not-a-checksum"}},
+	}
+	err := db.SaveToPath(dbPath)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("SaveToPath() error = %v, want ValidationError", err)
+	}
+
+	content, err := os.ReadFile(dbPath)
+	if err != nil || string(content) != "original" {
+		t.Errorf("invalid DB must not overwrite the file, got %q (%v)", content, err)
+	}
+}
+
+// writeVersionsYAMLEntries writes a versions YAML file with one version and
+// the given checksum keys.
+func writeVersionsYAMLEntries(t *testing.T, path, version string, entries map[string]string) {
+	t.Helper()
+	content := strings.Builder{}
+	content.WriteString("schema_version: 1\nversions:\n")
+	content.WriteString("  \"" + NormalizeVersion(version) + "\":\n")
+	for key, value := range entries {
+		content.WriteString("    " + key + ": \"" + value + "\"\n")
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o600); err != nil {
+		t.Fatalf("failed to write versions yaml: %v", err)
+	}
+}
+
+func TestVersionsDB_ValidateSHA512(t *testing.T) {
+	tests := []struct {
+		name      string
+		checksums PlatformChecksums
+		wantErr   string
+	}{
+		{
+			name:      "sha256 only",
+			checksums: PlatformChecksums{LinuxAMD64: strings.Repeat("a", 64)},
+		},
+		{
+			name:      "sha512 only",
+			checksums: PlatformChecksums{LinuxAMD64SHA512: strings.Repeat("a", 128)},
+		},
+		{
+			name: "both",
+			checksums: PlatformChecksums{
+				DarwinARM64:       strings.Repeat("a", 64),
+				DarwinARM64SHA512: strings.Repeat("b", 128),
+			},
+		},
+		{
+			name:      "sha256 in sha512 slot",
+			checksums: PlatformChecksums{WindowsAMD64SHA512: strings.Repeat("a", 64)},
+			wantErr:   "invalid windows_amd64_sha512 checksum (must be 128 hex chars)",
+		},
+		{
+			name:      "sha512 in sha256 slot",
+			checksums: PlatformChecksums{LinuxARM64: strings.Repeat("a", 128)},
+			wantErr:   "invalid linux_arm64 checksum (must be 64 hex chars)",
+		},
+		{
+			name: "ibm platforms",
+			checksums: PlatformChecksums{
+				LinuxPPC64LE:     strings.Repeat("a", 64),
+				LinuxS390XSHA512: strings.Repeat("b", 128),
+			},
+		},
+		{
+			name:      "invalid s390x checksum",
+			checksums: PlatformChecksums{LinuxS390X: "not-hex"},
+			wantErr:   "invalid linux_s390x checksum (must be 64 hex chars)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &VersionsDB{
+				SchemaVersion: SchemaVersion,
+				Versions:      map[string]PlatformChecksums{"2.31.1": tt.checksums},
+			}
+			err := db.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputePlatformKey(t *testing.T) {
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "linux_amd64"},
+		{"linux", "arm64", "linux_arm64"},
+		{"linux", "ppc64le", "linux_ppc64le"},
+		{"linux", "s390x", "linux_s390x"},
+		{"darwin", "arm64", "darwin_arm64"},
+		{"windows", "amd64", "windows_amd64"},
+		{"windows", "arm64", ""},
+		{"linux", "riscv64", ""},
+	}
+	for _, tt := range tests {
+		got, err := ComputePlatformKey(tt.goos, tt.goarch)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ComputePlatformKey(%s, %s) = %q, want an error", tt.goos, tt.goarch, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ComputePlatformKey(%s, %s) = %q, %v, want %q", tt.goos, tt.goarch, got, err, tt.want)
+		}
+	}
+
+	// The new platforms resolve their own checksums, which the bundled DB
+	// leaves empty
+	db := &VersionsDB{Versions: map[string]PlatformChecksums{"2.31.1": {
+		LinuxPPC64LE:       strings.Repeat("a", 64),
+		LinuxS390XSHA512:   strings.Repeat("b", 128),
+		LinuxPPC64LESHA512: strings.Repeat("c", 128),
+	}}}
+	if got, ok := db.GetExpectedSHA("2.31.1", "linux_ppc64le"); !ok || got != strings.Repeat("a", 64) {
+		t.Errorf("GetExpectedSHA(linux_ppc64le) = %q, %v", got, ok)
+	}
+	if got, ok := db.GetExpectedSHA512("2.31.1", "linux_s390x"); !ok || got != strings.Repeat("b", 128) {
+		t.Errorf("GetExpectedSHA512(linux_s390x) = %q, %v", got, ok)
+	}
+	if _, ok := db.GetExpectedSHA("2.31.1", "linux_s390x"); ok {
+		t.Error("GetExpectedSHA(linux_s390x) found a checksum the DB does not have")
+	}
+}
+
+func TestVersionsDB_PlatformsForVersion(t *testing.T) {
+	db := &VersionsDB{Versions: map[string]PlatformChecksums{
+		"2.31.1": {
+			WindowsAMD64:     strings.Repeat("a", 64),
+			LinuxAMD64:       strings.Repeat("b", 64),
+			LinuxS390XSHA512: strings.Repeat("c", 128),
+		},
+		"2.32.0": {},
+	}}
+
+	want := []string{"linux_amd64", "linux_s390x", "windows_amd64"}
+	for _, version := range []string{"2.31.1", "v2.31.1", " 2.31.1+build "} {
+		if got := db.PlatformsForVersion(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("PlatformsForVersion(%q) = %v, want %v", version, got, want)
+		}
+	}
+	if got := db.PlatformsForVersion("2.32.0"); got != nil {
+		t.Errorf("PlatformsForVersion() without checksums = %v, want nil", got)
+	}
+	if got := db.PlatformsForVersion("9.9.9"); got != nil {
+		t.Errorf("PlatformsForVersion() of an unknown version = %v, want nil", got)
+	}
+	var nilDB *VersionsDB
+	if got := nilDB.PlatformsForVersion("2.31.1"); got != nil {
+		t.Errorf("nil DB PlatformsForVersion() = %v, want nil", got)
+	}
+
+	// The bundled DB covers every GitHub-hosted runner platform
+	var bundled VersionsDB
+	if err := yaml.Unmarshal([]byte(bundledVersionsYAML(time.Now())), &bundled); err != nil {
+		t.Fatalf("bundled DB is invalid YAML: %v", err)
+	}
+	want = []string{"darwin_amd64", "darwin_arm64", "linux_amd64", "linux_arm64", "windows_amd64"}
+	if got := bundled.PlatformsForVersion(DefaultCLIVersion); !reflect.DeepEqual(got, want) {
+		t.Errorf("bundled PlatformsForVersion(%s) = %v, want %v", DefaultCLIVersion, got, want)
+	}
+}
+
+func TestVerifyFileChecksum_PrefersSHA512(t *testing.T) {
+	pk := currentPlatformKey(t)
+	binary := filepath.Join(t.TempDir(), "op")
+	content := []byte("fake op binary")
+	if err := os.WriteFile(binary, content, 0o600); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	sum256 := sha256.Sum256(content)
+	sum512 := sha512.Sum512(content)
+	good256, good512 := hex.EncodeToString(sum256[:]), hex.EncodeToString(sum512[:])
+
+	tests := []struct {
+		name    string
+		entries map[string]string
+		wantErr bool
+	}{
+		{name: "sha512 only", entries: map[string]string{pk + "_sha512": good512}},
+		{name: "sha512 preferred", entries: map[string]string{pk: strings.Repeat("b", 64), pk + "_sha512": good512}},
+		{name: "sha512 mismatch", entries: map[string]string{pk: good256, pk + "_sha512": strings.Repeat("b", 128)}, wantErr: true},
+		{name: "sha256 fallback", entries: map[string]string{pk: good256}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+			writeVersionsYAMLEntries(t, dbPath, "2.31.1", tt.entries)
+			t.Setenv(envVersionsFile, dbPath)
+
+			err := VerifyFileChecksum(binary, "2.31.1")
+			if tt.wantErr {
+				if !errors.Is(err, ErrChecksumMismatch) {
+					t.Fatalf("VerifyFileChecksum() error = %v, want ErrChecksumMismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyFileChecksum() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewManager_PrefersSHA512FromDB(t *testing.T) {
+	pk := currentPlatformKey(t)
+	binary := filepath.Join(t.TempDir(), "op")
+	content := []byte("fake op binary")
+	if err := os.WriteFile(binary, content, 0o600); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	sum512 := sha512.Sum512(content)
+	good512 := hex.EncodeToString(sum512[:])
+
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	writeVersionsYAMLEntries(t, dbPath, "2.31.1", map[string]string{
+		pk:             strings.Repeat("b", 64),
+		pk + "_sha512": good512,
+	})
+	t.Setenv(envVersionsFile, dbPath)
+
+	manager, err := NewManager(&Config{
+		CacheDir: filepath.Join(t.TempDir(), "cache"),
+		Version:  "2.31.1",
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error: %v", err)
+	}
+	if manager.ExpectedSHA512() != good512 || manager.ExpectedSHA() != strings.Repeat("b", 64) {
+		t.Fatalf("unexpected checksums: sha256=%q sha512=%q", manager.ExpectedSHA(), manager.ExpectedSHA512())
+	}
+
+	// The wrong SHA256 is ignored because the SHA512 takes precedence
+	if err := manager.verifyChecksum(binary); err != nil {
+		t.Errorf("verifyChecksum() error: %v", err)
+	}
+	if err := os.WriteFile(binary, []byte("tampered"), 0o600); err != nil {
+		t.Fatalf("failed to rewrite binary: %v", err)
+	}
+	if err := manager.verifyChecksum(binary); err == nil || !strings.Contains(err.Error(), "SHA512 mismatch") {
+		t.Errorf("verifyChecksum() error = %v, want SHA512 mismatch", err)
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.31.1", "2.31.1", 0},
+		{"2.31.1", "2.31.10", -1},
+		{"2.32.0", "2.31.9", 1},
+		{"3.0.0", "2.99.99", 1},
+		{"2.31.1-beta", "2.31.1", -1},
+		{"2.31.1-beta.2", "2.31.1-beta.11", -1},
+		{"2.31.1-beta.1", "2.31.1-alpha", 1},
+		{"2.31.1-1", "2.31.1-alpha", -1},
+		{"2.31.1-beta", "2.31.1-beta.1", -1},
+		{"2.31.1+build5", "2.31.1", 0},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", tt.a, err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestVersionsDB_Prune(t *testing.T) {
+	newDB := func() *VersionsDB {
+		db := &VersionsDB{SchemaVersion: SchemaVersion, Versions: map[string]PlatformChecksums{}}
+		for _, v := range []string{"2.9.0", "2.10.0", "2.28.0", DefaultCLIVersion, "2.32.0", "2.33.1"} {
+			db.Versions[v] = PlatformChecksums{}
+		}
+		return db
+	}
+
+	db := newDB()
+	pruned := db.Prune(2)
+	if got := strings.Join(pruned, ","); got != "2.9.0,2.10.0,2.28.0" {
+		t.Errorf("Prune(2) pruned %q, want oldest first 2.9.0,2.10.0,2.28.0", got)
+	}
+	for _, kept := range []string{DefaultCLIVersion, "2.32.0", "2.33.1"} {
+		if _, ok := db.Versions[kept]; !ok {
+			t.Errorf("Prune(2) removed %s", kept)
+		}
+	}
+
+	// A protected version survives even when it is among the oldest
+	db = newDB()
+	pruned = db.Prune(0, "v2.9.0")
+	if _, ok := db.Versions["2.9.0"]; !ok {
+		t.Error("Prune(0) removed the protected version 2.9.0")
+	}
+	if _, ok := db.Versions[DefaultCLIVersion]; !ok {
+		t.Error("Prune(0) removed the default CLI version")
+	}
+	if len(pruned) != 4 || len(db.Versions) != 2 {
+		t.Errorf("Prune(0) pruned %v, left %d versions", pruned, len(db.Versions))
+	}
+
+	// Keeping more versions than exist removes nothing
+	db = newDB()
+	if pruned := db.Prune(10); pruned != nil || len(db.Versions) != 6 {
+		t.Errorf("Prune(10) pruned %v", pruned)
+	}
+}
+
+func TestVersionsDB_ReleasedDates(t *testing.T) {
+	sha := strings.Repeat("a", 64)
+
+	db := &VersionsDB{SchemaVersion: SchemaVersion, Versions: map[string]PlatformChecksums{
+		"2.30.0": {LinuxAMD64: sha, Released: "2024-12-01"},
+		"2.31.1": {LinuxAMD64: sha, Released: "2025-06-30T12:00:00Z"},
+		"2.32.0": {LinuxAMD64: sha},
+	}}
+	if err := db.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	released, ok := db.ReleaseDate("v2.30.0")
+	if !ok || !released.Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ReleaseDate(2.30.0) = %v, %v", released, ok)
+	}
+	released, ok = db.ReleaseDate("2.31.1")
+	if !ok || !released.Equal(time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("ReleaseDate(2.31.1) = %v, %v", released, ok)
+	}
+	if _, ok := db.ReleaseDate("2.32.0"); ok {
+		t.Error("ReleaseDate() reported a date for a version without one")
+	}
+	if _, ok := db.ReleaseDate("9.9.9"); ok {
+		t.Error("ReleaseDate() reported a date for an unknown version")
+	}
+
+	db.Versions["2.33.0"] = PlatformChecksums{LinuxAMD64: sha, Released: "June 2025"}
+	var validationErr *ValidationError
+	if err := db.Validate(); !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "invalid released date") {
+		t.Errorf("Validate() error = %v, want an invalid released date", err)
+	}
+
+	// Schema version 1 predates released dates, which are ignored
+	db.SchemaVersion = 1
+	if err := db.Validate(); err != nil {
+		t.Errorf("Validate() of a schema 1 DB error = %v", err)
+	}
+	if _, ok := db.ReleaseDate("2.30.0"); ok {
+		t.Error("ReleaseDate() reported a date from a schema 1 DB")
+	}
+}
+
+func TestVersionAge(t *testing.T) {
+	pk := currentPlatformKey(t)
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	content := "schema_version: 2\nversions:\n  \"2.31.1\":\n    " + pk + ": \"" + strings.Repeat("a", 64) +
+		"\"\n    released: \"" + time.Now().AddDate(0, 0, -10).UTC().Format(time.DateOnly) + "\"\n"
+	if err := os.WriteFile(dbPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write versions yaml: %v", err)
+	}
+	t.Setenv(envVersionsFile, dbPath)
+
+	age, ok := VersionAge("2.31.1")
+	if !ok || age < 9*24*time.Hour || age > 11*24*time.Hour {
+		t.Errorf("VersionAge() = %v, %v, want about 10 days", age, ok)
+	}
+	if _, ok := VersionAge("2.32.0"); ok {
+		t.Error("VersionAge() reported an age for an unknown version")
+	}
+}
+
+func TestVersionsDB_ValidateGeneratedAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		generatedAt string
+		wantErr     string
+	}{
+		{"empty", "", ""},
+		{"rfc3339", "2025-07-28T00:00:00Z", ""},
+		{"within skew", time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339), ""},
+		{"date only", "2025-07-28", "invalid generated_at"},
+		{"garbage", "yesterday", "invalid generated_at"},
+		{"future", time.Now().AddDate(0, 1, 0).UTC().Format(time.RFC3339), "is in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &VersionsDB{
+				SchemaVersion: SchemaVersion,
+				GeneratedAt:   tt.generatedAt,
+				Versions:      map[string]PlatformChecksums{"2.31.1": {LinuxAMD64: strings.Repeat("a", 64)}},
+			}
+			err := db.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDBFromPath_Signature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyID := []byte("12345678")
+	minisignKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+
+	// minisig builds a minisign signature file over content
+	minisig := func(alg string, id, content []byte, comment string) []byte {
+		sig := ed25519.Sign(priv, content)
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), id...), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+
+	newDB := func(t *testing.T) (string, []byte) {
+		t.Helper()
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		writeVersionsYAML(t, dbPath, "2.31.1", "linux_amd64", strings.Repeat("a", 64))
+		content, err := os.ReadFile(dbPath) // #nosec G304 - test file path is controlled
+		if err != nil {
+			t.Fatalf("failed to read versions yaml: %v", err)
+		}
+		return dbPath, content
+	}
+	write := func(t *testing.T, path string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	t.Run("no key configured skips verification", func(t *testing.T) {
+		t.Setenv(envVersionsPublicKey, "")
+		dbPath, _ := newDB(t)
+		if _, err := loadDBFromPath(dbPath); err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+	})
+
+	t.Run("valid minisig", func(t *testing.T) {
+		t.Setenv(envVersionsPublicKey, minisignKey)
+		dbPath, content := newDB(t)
+		write(t, dbPath+".minisig", minisig("Ed", keyID, content, "timestamp:1700000000"))
+		if _, err := loadDBFromPath(dbPath); err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+	})
+
+	t.Run("valid raw sig", func(t *testing.T) {
+		t.Setenv(envVersionsPublicKey, base64.StdEncoding.EncodeToString(pub))
+		dbPath, content := newDB(t)
+		write(t, dbPath+".sig", []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))+"\n"))
+		if _, err := loadDBFromPath(dbPath); err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+	})
+
+	failures := []struct {
+		name    string
+		key     string
+		prepare func(t *testing.T, dbPath string, content []byte)
+		wantMsg string
+	}{
+		{
+			name:    "missing signature",
+			key:     minisignKey,
+			prepare: func(*testing.T, string, []byte) {},
+			wantMsg: "no ",
+		},
+		{
+			name: "tampered content",
+			key:  minisignKey,
+			prepare: func(t *testing.T, dbPath string, content []byte) {
+				write(t, dbPath+".minisig", minisig("Ed", keyID, content, "timestamp:1700000000"))
+				write(t, dbPath, append(content, []byte("# tampered\n")...))
+			},
+			wantMsg: "does not match",
+		},
+		{
+			name: "tampered trusted comment",
+			key:  minisignKey,
+			prepare: func(t *testing.T, dbPath string, content []byte) {
+				sig := minisig("Ed", keyID, content, "timestamp:1700000000")
+				write(t, dbPath+".minisig", []byte(strings.Replace(string(sig), "1700000000", "1800000000", 1)))
+			},
+			wantMsg: "trusted comment",
+		},
+		{
+			name: "different key id",
+			key:  minisignKey,
+			prepare: func(t *testing.T, dbPath string, content []byte) {
+				write(t, dbPath+".minisig", minisig("Ed", []byte("87654321"), content, "c"))
+			},
+			wantMsg: "different key",
+		},
+		{
+			name: "prehashed signature",
+			key:  minisignKey,
+			prepare: func(t *testing.T, dbPath string, content []byte) {
+				write(t, dbPath+".minisig", minisig("ED", keyID, content, "c"))
+			},
+			wantMsg: "minisign -S -l",
+		},
+		{
+			name: "raw sig from another key",
+			key:  base64.StdEncoding.EncodeToString(pub),
+			prepare: func(t *testing.T, dbPath string, content []byte) {
+				_, other, _ := ed25519.GenerateKey(rand.Reader)
+				write(t, dbPath+".sig", []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(other, content))))
+			},
+			wantMsg: "does not match",
+		},
+	}
+	for _, tc := range failures {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envVersionsPublicKey, tc.key)
+			dbPath, content := newDB(t)
+			tc.prepare(t, dbPath, content)
+			_, err := loadDBFromPath(dbPath)
+			if !errors.Is(err, ErrSignatureInvalid) {
+				t.Fatalf("loadDBFromPath() error = %v, want ErrSignatureInvalid", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantMsg) {
+				t.Errorf("error %q does not mention %q", err, tc.wantMsg)
+			}
+		})
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		t.Setenv(envVersionsPublicKey, "not-a-key")
+		dbPath, _ := newDB(t)
+		if _, err := loadDBFromPath(dbPath); err == nil || !strings.Contains(err.Error(), envVersionsPublicKey) {
+			t.Fatalf("loadDBFromPath() error = %v, want invalid key error", err)
+		}
+	})
+}
+
+func TestVersionsDB_Migrate(t *testing.T) {
+	t.Setenv(envVersionsPublicKey, "")
+	sum := strings.Repeat("a", 64)
+	v1 := "schema_version: 1\nversions:\n  \"2.31.1\":\n    linux_amd64: \"" + sum + "\"\n    released: \"2025-06-30\"\n"
+
+	t.Run("v1 file is migrated on load", func(t *testing.T) {
+		t.Setenv(envMigrateVersionsFile, "")
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		if err := os.WriteFile(dbPath, []byte(v1), 0o600); err != nil {
+			t.Fatalf("failed to write versions yaml: %v", err)
+		}
+
+		db, err := loadDBFromPath(dbPath)
+		if err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+		if db.SchemaVersion != SchemaVersion {
+			t.Errorf("schema_version = %d, want %d", db.SchemaVersion, SchemaVersion)
+		}
+		if got := db.Versions["2.31.1"].Released; got != "" {
+			t.Errorf("released = %q, want it dropped by the migration", got)
+		}
+		if sha, ok := db.GetExpectedSHA("2.31.1", "linux_amd64"); !ok || sha != sum {
+			t.Errorf("GetExpectedSHA() = %q, %v after migration", sha, ok)
+		}
+
+		content, _ := os.ReadFile(dbPath) // #nosec G304 - test file path is controlled
+		if string(content) != v1 {
+			t.Errorf("file rewritten without %s:\n%s", envMigrateVersionsFile, content)
+		}
+	})
+
+	t.Run("v1 file is rewritten when enabled", func(t *testing.T) {
+		t.Setenv(envMigrateVersionsFile, "true")
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		if err := os.WriteFile(dbPath, []byte(v1), 0o600); err != nil {
+			t.Fatalf("failed to write versions yaml: %v", err)
+		}
+		if _, err := loadDBFromPath(dbPath); err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+
+		content, _ := os.ReadFile(dbPath) // #nosec G304 - test file path is controlled
+		if !strings.Contains(string(content), "schema_version: 2") || strings.Contains(string(content), "released") {
+			t.Errorf("file not rewritten at the current schema:\n%s", content)
+		}
+	})
+
+	t.Run("current schema skips migration", func(t *testing.T) {
+		t.Setenv(envMigrateVersionsFile, "true")
+		dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+		current := strings.Replace(v1, "schema_version: 1", "schema_version: 2", 1)
+		if err := os.WriteFile(dbPath, []byte(current), 0o600); err != nil {
+			t.Fatalf("failed to write versions yaml: %v", err)
+		}
+
+		db, err := loadDBFromPath(dbPath)
+		if err != nil {
+			t.Fatalf("loadDBFromPath() error = %v", err)
+		}
+		if got := db.Versions["2.31.1"].Released; got != "2025-06-30" {
+			t.Errorf("released = %q, want it kept", got)
+		}
+		content, _ := os.ReadFile(dbPath) // #nosec G304 - test file path is controlled
+		if string(content) != current {
+			t.Errorf("current-schema file was rewritten:\n%s", content)
+		}
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		db := &VersionsDB{SchemaVersion: 1, Versions: map[string]PlatformChecksums{}}
+		for _, tc := range []struct{ from, to int }{{2, 2}, {0, 2}, {1, SchemaVersion + 1}} {
+			if err := db.Migrate(tc.from, tc.to); err == nil {
+				t.Errorf("Migrate(%d, %d) succeeded on a schema 1 DB", tc.from, tc.to)
+			}
+		}
+		if err := db.Migrate(1, 1); err != nil || db.SchemaVersion != 1 {
+			t.Errorf("Migrate(1, 1) = %v, schema %d", err, db.SchemaVersion)
+		}
+	})
+}
+
+func TestExportEffectiveDB(t *testing.T) {
+	t.Setenv(envVersionsPublicKey, "")
+	dbPath := filepath.Join(t.TempDir(), "1password-cli-versions.yaml")
+	content := "schema_version: 2\nversions:\n" +
+		"  \"2.31.1\":\n    linux_amd64: \"" + strings.Repeat("b", 64) + "\"\n" +
+		"  \"2.32.0\":\n    linux_amd64: \"" + strings.Repeat("c", 64) + "\"\n"
+	if err := os.WriteFile(dbPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write versions yaml: %v", err)
+	}
+	t.Setenv(envVersionsFile, dbPath)
+
+	out, err := ExportEffectiveDB()
+	if err != nil {
+		t.Fatalf("ExportEffectiveDB() error = %v", err)
+	}
+	export := string(out)
+	for _, want := range []string{
+		"# source: " + dbPath,
+		"2.31.1: # overrides bundled",
+		"2.32.0: # added by " + dbPath,
+		strings.Repeat("c", 64),
+	} {
+		if !strings.Contains(export, want) {
+			t.Errorf("export missing %q:\n%s", want, export)
+		}
+	}
+
+	// The export is itself a loadable DB
+	var reparsed VersionsDB
+	if err := yaml.Unmarshal(out, &reparsed); err != nil {
+		t.Fatalf("export is not valid YAML: %v", err)
+	}
+	if err := reparsed.Validate(); err != nil {
+		t.Errorf("export fails validation: %v", err)
+	}
+	if len(reparsed.Versions) != 2 {
+		t.Errorf("export has %d versions, want 2", len(reparsed.Versions))
+	}
+}
+
+func TestVersionsDB_DBChecksum(t *testing.T) {
+	pk := currentPlatformKey(t)
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "1password-cli-versions.yaml")
+	writeVersionsYAML(t, dbPath, "2.31.1", pk, strings.Repeat("a", 64))
+
+	// A file without db_checksum loads as before
+	db, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("loadDBFromPath() error: %v", err)
+	}
+	if db.DBChecksum != "" {
+		t.Errorf("DBChecksum = %q, want empty", db.DBChecksum)
+	}
+
+	// The checksum ignores comments, quoting and key order
+	restyled := filepath.Join(dir, "restyled.yaml")
+	yamlContent := "versions:\n  # pinned\n  2.31.1: {" + pk + ": " + strings.Repeat("a", 64) + "}\nschema_version: 1\n"
+	if err := os.WriteFile(restyled, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	other, err := loadDBFromPath(restyled)
+	if err != nil {
+		t.Fatalf("loadDBFromPath() error: %v", err)
+	}
+	want, err := other.ComputeChecksum()
+	if err != nil {
+		t.Fatalf("ComputeChecksum() error: %v", err)
+	}
+	if got, _ := db.ComputeChecksum(); got != want {
+		t.Errorf("ComputeChecksum() = %s, want %s", got, want)
+	}
+
+	// SetChecksum opts in, and SaveToPath keeps the checksum current
+	if err := db.SetChecksum(); err != nil {
+		t.Fatalf("SetChecksum() error: %v", err)
+	}
+	if err := db.ExtendDB("2.32.0", PlatformChecksums{LinuxAMD64: strings.Repeat("e", 64)}); err != nil {
+		t.Fatalf("ExtendDB() error: %v", err)
+	}
+	if err := db.SaveToPath(dbPath); err != nil {
+		t.Fatalf("SaveToPath() error: %v", err)
+	}
+	reloaded, err := loadDBFromPath(dbPath)
+	if err != nil {
+		t.Fatalf("reloading saved DB: %v", err)
+	}
+	if reloaded.DBChecksum == "" || reloaded.DBChecksum != db.DBChecksum {
+		t.Errorf("DBChecksum = %q, want %q", reloaded.DBChecksum, db.DBChecksum)
+	}
+
+	// An edit that does not update db_checksum fails the load
+	content, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	tampered := strings.Replace(string(content), strings.Repeat("e", 64), strings.Repeat("f", 64), 1)
+	if err := os.WriteFile(dbPath, []byte(tampered), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	_, err = loadDBFromPath(dbPath)
+	if !errors.Is(err, ErrDBChecksumMismatch) {
+		t.Fatalf("loadDBFromPath() error = %v, want ErrDBChecksumMismatch", err)
+	}
+	if !apperrors.IsErrorCode(err, apperrors.ErrCodeVersionsDBInvalid) {
+		t.Errorf("loadDBFromPath() error = %v, want %s", err, apperrors.ErrCodeVersionsDBInvalid)
+	}
+
+	// A malformed db_checksum fails validation
+	malformed := &VersionsDB{SchemaVersion: SchemaVersion, DBChecksum: "abc", Versions: reloaded.Versions}
+	if err := malformed.Validate(); err == nil || !strings.Contains(err.Error(), "db_checksum") {
+		t.Errorf("Validate() error = %v, want a db_checksum error", err)
+	}
+}
+
+func TestLoadDBFromPath_Formats(t *testing.T) {
+	pk := currentPlatformKey(t)
+	dir := t.TempDir()
+	sha := strings.Repeat("a", 64)
+	sha512 := strings.Repeat("b", 128)
+
+	files := map[string]string{
+		"versions.yaml": "schema_version: 2\nversions:\n  \"2.31.1\":\n    " + pk + ": " + sha +
+			"\n    " + pk + "_sha512: " + sha512 + "\n    released: \"2025-06-30\"\n",
+		"versions.yml": "schema_version: 2\nversions:\n  2.31.1: {" + pk + ": " + sha +
+			", " + pk + "_sha512: " + sha512 + ", released: \"2025-06-30\"}\n",
+		"versions.json": `{"schema_version": 2, "versions": {"2.31.1": {"` + pk + `": "` + sha +
+			`", "` + pk + `_sha512": "` + sha512 + `", "released": "2025-06-30", "unknown": "ignored"}}}`,
+		"versions.toml": "schema_version = 2\n\n[versions.\"2.31.1\"]\n" + pk + " = \"" + sha +
+			"\"\n" + pk + "_sha512 = \"" + sha512 + "\"\nreleased = \"2025-06-30\"\n",
+	}
+
+	var want *VersionsDB
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		db, err := loadDBFromPath(path)
+		if err != nil {
+			t.Fatalf("loadDBFromPath(%s) error: %v", name, err)
+		}
+		if got, ok := db.GetExpectedSHA("v2.31.1", pk); !ok || got != sha {
+			t.Errorf("%s: GetExpectedSHA() = %q, %v", name, got, ok)
+		}
+		if got, ok := db.GetExpectedSHA512("2.31.1", pk); !ok || got != sha512 {
+			t.Errorf("%s: GetExpectedSHA512() = %q, %v", name, got, ok)
+		}
+		if want == nil {
+			want = db
+		} else if !reflect.DeepEqual(db, want) {
+			t.Errorf("%s loaded as %+v, want %+v", name, db, want)
+		}
+	}
+
+	// Saving keeps the format of the file, and the checksum does not
+	// depend on it
+	if err := want.SetChecksum(); err != nil {
+		t.Fatalf("SetChecksum() error: %v", err)
+	}
+	for _, name := range []string{"saved.json", "saved.toml", "saved.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := want.SaveToPath(path); err != nil {
+			t.Fatalf("SaveToPath(%s) error: %v", name, err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error: %v", err)
+		}
+		var probe VersionsDB
+		if err := unmarshalDB(dbFormat(path), content, &probe); err != nil {
+			t.Errorf("%s is not %s: %v", name, dbFormat(path), err)
+		}
+		reloaded, err := loadDBFromPath(path)
+		if err != nil {
+			t.Fatalf("reloading %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(reloaded.Versions, want.Versions) || reloaded.DBChecksum != want.DBChecksum {
+			t.Errorf("%s reloaded as %+v, want %+v", name, reloaded, want)
+		}
+	}
+
+	// Parse errors name the format
+	broken := filepath.Join(dir, "broken.toml")
+	if err := os.WriteFile(broken, []byte("schema_version = [\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	_, err := loadDBFromPath(broken)
+	if err == nil || !strings.Contains(err.Error(), "TOML") ||
+		!apperrors.IsErrorCode(err, apperrors.ErrCodeVersionsDBInvalid) {
+		t.Errorf("loadDBFromPath() error = %v, want an invalid TOML DB", err)
+	}
+}
@@ -8,13 +8,17 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/proxy"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
 	"gopkg.in/yaml.v3"
 )
@@ -25,6 +29,18 @@ const (
 	trueString        = "true"
 )
 
+// RecordRequest is a single secret to retrieve, parsed from the record input.
+// All requests of a run share one authenticated CLI session.
+type RecordRequest struct {
+	OutputName string
+	Vault      string // Empty selects the default vault input; AnyVault searches all vaults
+	Item       string
+	Section    string // Section holding Field; empty when the field is not qualified
+	Field      string
+	Transforms []string
+	Optional   bool // A missing field yields an empty output with a warning
+}
+
 // Config holds all configuration for the 1Password secrets action
 type Config struct {
 	// Core inputs
@@ -34,12 +50,36 @@ type Config struct {
 	Record              string `json:"record" yaml:"record"`
 	ReturnType          string `json:"return_type" yaml:"return_type"`
 
+	// OutputNamePolicy names records listed without an output name after
+	// their field: snake_case (default), upper_snake_case or sanitized
+	OutputNamePolicy string `json:"output_name_policy" yaml:"output_name_policy"`
+
+	// RecordInterpolation expands ${VAR} references in Record from the
+	// environment: "env" expands undefined variables to nothing, "strict"
+	// fails on them; empty leaves Record as written
+	RecordInterpolation string `json:"record_interpolation" yaml:"record_interpolation"`
+
+	// TokenFile names a file holding the service account token, as an
+	// alternative to passing the token inline; the two are mutually exclusive
+	TokenFile string `json:"token_file" yaml:"token_file"`
+
+	// TokenSource "stdin" reads the token from standard input, for local
+	// runs only; empty takes it from token or token_file
+	TokenSource string `json:"token_source" yaml:"token_source"`
+
+	// VaultPriority settles an item title found in several vaults when
+	// vault is AnyVault; the first listed vault holding the item wins
+	VaultPriority []string `json:"vault_priority,omitempty" yaml:"vault_priority,omitempty"`
+
 	// Parsed record data
-	Records map[string]string `json:"records" yaml:"records"`
+	Records        map[string]string   `json:"records" yaml:"records"`
+	Transforms     map[string][]string `json:"transforms,omitempty" yaml:"transforms,omitempty"`
+	RecordRequests []RecordRequest     `json:"-" yaml:"-"`
 
 	// Operational settings
 	Debug      bool   `json:"debug" yaml:"debug"`
 	LogLevel   string `json:"log_level" yaml:"log_level"`
+	LogFormat  string `json:"log_format" yaml:"log_format"`
 	Profile    string `json:"profile" yaml:"profile"`
 	ConfigFile string `json:"config_file" yaml:"config_file"`
 
@@ -48,14 +88,117 @@ type Config struct {
 	RetryTimeout   int `json:"retry_timeout" yaml:"retry_timeout"`
 	ConnectTimeout int `json:"connect_timeout" yaml:"connect_timeout"`
 
+	// DownloadTimeout bounds the 1Password CLI download, including retries,
+	// separately from the operation timeout; 0 uses the CLI manager default
+	DownloadTimeout int `json:"download_timeout" yaml:"download_timeout"`
+
+	// RecordTimeout bounds the fetch of each record in seconds; 0 derives
+	// it from Timeout (see GetTimeout)
+	RecordTimeout int `json:"record_timeout" yaml:"record_timeout"`
+
 	// Performance settings
 	MaxConcurrency int  `json:"max_concurrency" yaml:"max_concurrency"`
-	CacheEnabled   bool `json:"cache_enabled" yaml:"cache_enabled"`
-	CacheTTL       int  `json:"cache_ttl" yaml:"cache_ttl"`
+	FailFast       bool `json:"fail_fast" yaml:"fail_fast"`
+
+	// BatchMode decides what a failed record does to the rest of the batch;
+	// empty is BatchModeAtomic, or BatchModeFailFast with FailFast set
+	BatchMode     string `json:"batch_mode" yaml:"batch_mode"`
+	CacheEnabled  bool   `json:"cache_enabled" yaml:"cache_enabled"`
+	CacheTTL      int    `json:"cache_ttl" yaml:"cache_ttl"`
+	CacheNegative bool   `json:"cache_negative" yaml:"cache_negative"`
+
+	// BatchRead reads plain fields with one op inject process rather than
+	// one op read per field
+	BatchRead bool `json:"batch_read" yaml:"batch_read"`
 
 	// CLI settings
-	CLIVersion string `json:"cli_version" yaml:"cli_version"`
-	CLIPath    string `json:"cli_path" yaml:"cli_path"`
+	CLIVersion      string `json:"cli_version" yaml:"cli_version"`
+	CLIPath         string `json:"cli_path" yaml:"cli_path"`
+	ExecFallbackDir string `json:"exec_fallback_dir" yaml:"exec_fallback_dir"`
+
+	// AllowedCLIVersions refuses any CLI version it does not list, whether
+	// requested or found on the runner. Empty allows every version in the
+	// versions DB
+	AllowedCLIVersions []string `json:"allowed_cli_versions,omitempty" yaml:"allowed_cli_versions,omitempty"`
+
+	// CLIInstallDir is where the CLI is downloaded and installed instead of
+	// the .op-cache directory, for runners that only allow writes to
+	// allowlisted locations. It is created with mode 0700 when missing.
+	CLIInstallDir string `json:"cli_install_dir" yaml:"cli_install_dir"`
+
+	// CLIDownloadBaseURL replaces the 1Password download host, e.g. with an
+	// internal mirror; downloads are still verified against the versions DB.
+	// It must be https unless AllowInsecureDownload is set.
+	CLIDownloadBaseURL    string `json:"cli_download_base_url" yaml:"cli_download_base_url"`
+	AllowInsecureDownload bool   `json:"allow_insecure_download" yaml:"allow_insecure_download"`
+
+	// ProxyURL overrides HTTPS_PROXY and HTTP_PROXY for CLI downloads,
+	// Connect calls and the op CLI; NO_PROXY still applies
+	ProxyURL string `json:"proxy_url" yaml:"proxy_url"`
+
+	// Offline never downloads the CLI; a pre-installed op on PATH is used
+	// and still verified against the versions DB
+	Offline bool `json:"offline" yaml:"offline"`
+
+	// DisableBinaryCache always downloads the CLI instead of reusing a
+	// verified binary cached by an earlier run on the same runner
+	DisableBinaryCache bool `json:"disable_binary_cache" yaml:"disable_binary_cache"`
+
+	// SkipChecksumVerification runs the CLI without verifying it against
+	// the versions DB, for platforms the DB has no checksum for
+	SkipChecksumVerification bool `json:"skip_checksum_verification" yaml:"skip_checksum_verification"`
+
+	// 1Password Connect settings; when both are set secrets are read from
+	// the Connect server instead of through the CLI
+	ConnectHost  string `json:"connect_host" yaml:"connect_host"`
+	ConnectToken string `json:"connect_token" yaml:"connect_token"`
+
+	// SecretsDir is where return_type "file" writes secrets; defaults to a
+	// directory under the GitHub workspace and must not lie outside it
+	SecretsDir string `json:"secrets_dir" yaml:"secrets_dir"`
+
+	// CleanupFiles removes secret files on exit even after a successful run
+	CleanupFiles bool `json:"cleanup_files" yaml:"cleanup_files"`
+
+	// JSONEnv also exports the return_type "json" document to GITHUB_ENV
+	JSONEnv bool `json:"json_env" yaml:"json_env"`
+
+	// VerifyOutputs re-reads GITHUB_OUTPUT and GITHUB_ENV after writing and
+	// fails the run if any value does not read back exactly
+	VerifyOutputs bool `json:"verify_outputs" yaml:"verify_outputs"`
+
+	// DryRun validates the configuration, installs the CLI, authenticates
+	// and resolves the vault, then reports the records it would fetch
+	// without reading any secret values
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+
+	// SummaryPath, when set, receives a JSON audit summary of the secrets
+	// the run resolved, without their values
+	SummaryPath string `json:"summary_path" yaml:"summary_path"`
+
+	// WriteStepSummary appends a table of the requested outputs and their
+	// status to GITHUB_STEP_SUMMARY; values are always redacted. Unset
+	// leaves it off; see WriteStepSummaryEnabled.
+	WriteStepSummary *bool `json:"write_step_summary,omitempty" yaml:"write_step_summary,omitempty"`
+
+	// StepSummary appends the CLI version and source, the number of secrets
+	// fetched and phase durations to GITHUB_STEP_SUMMARY. Unset defaults to
+	// on when running in GitHub Actions; see StepSummaryEnabled.
+	StepSummary *bool `json:"step_summary,omitempty" yaml:"step_summary,omitempty"`
+
+	// OutputSchemaVersion selects which metadata outputs are emitted
+	OutputSchemaVersion int `json:"output_schema_version" yaml:"output_schema_version"`
+
+	// DownloadMaxAttempts bounds CLI download attempts (0 uses the default);
+	// RetryTimeout caps the total time spent retrying
+	DownloadMaxAttempts int `json:"download_max_attempts" yaml:"download_max_attempts"`
+
+	// RetryMaxAttempts bounds attempts to authenticate and fetch secrets when
+	// a failure is transient; RetryBaseDelay is the backoff in seconds before
+	// the first retry, doubling with jitter up to RetryTimeout. 0 uses the
+	// default for either
+	RetryMaxAttempts int `json:"retry_max_attempts" yaml:"retry_max_attempts"`
+	RetryBaseDelay   int `json:"retry_base_delay" yaml:"retry_base_delay"`
 
 	// GitHub Actions specific
 	GitHubWorkspace string `json:"github_workspace" yaml:"github_workspace"`
@@ -66,13 +209,53 @@ type Config struct {
 	ConfigSource string            `json:"-" yaml:"-"`
 	LoadTime     time.Time         `json:"-" yaml:"-"`
 	Profiles     map[string]Config `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// Warnings lists non-fatal problems found while loading, for the caller to log
+	Warnings []string `json:"-" yaml:"-"`
+
+	// inputProblems lists inputs that could not be loaded, for Validate to
+	// report
+	inputProblems []error
 }
 
+// AnyVault as the vault searches every vault the token can access
+const AnyVault = validation.AnyVault
+
 // ReturnType constants
 const (
 	ReturnTypeOutput = "output"
 	ReturnTypeEnv    = "env"
 	ReturnTypeBoth   = "both"
+	ReturnTypeFile   = "file"
+	ReturnTypeJSON   = "json"
+)
+
+// LogFormat constants
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Batch modes
+const (
+	// BatchModeAtomic fetches every record and fails the run, setting no
+	// outputs, if any of them failed
+	BatchModeAtomic = "atomic"
+	// BatchModeFailFast is BatchModeAtomic, but stops at the first failure
+	BatchModeFailFast = "fail-fast"
+	// BatchModeBestEffort sets the outputs of the records that succeeded and
+	// then fails the run naming the records that did not
+	BatchModeBestEffort = "best-effort"
+)
+
+// minRecordTimeout is the shortest record timeout derived from Timeout.
+const minRecordTimeout = 30 * time.Second
+
+// Output schema versions. Each version only ever adds outputs, so consumers
+// can branch on output_schema_version across action upgrades.
+const (
+	DefaultOutputSchemaVersion = 1
+	LatestOutputSchemaVersion  = 2
 )
 
 // Profile constants
@@ -111,21 +294,27 @@ type LoadOptions struct {
 func LoadWithOptions(opts LoadOptions) (*Config, error) {
 	config := &Config{
 		// Set defaults
-		ReturnType:     ReturnTypeOutput,
-		Debug:          false,
-		LogLevel:       "info",
-		Profile:        ProfileDefault,
-		Timeout:        300, // 5 minutes
-		RetryTimeout:   30,  // 30 seconds
-		ConnectTimeout: 10,  // 10 seconds
-		MaxConcurrency: 5,   // 5 concurrent operations
-		CacheEnabled:   false,
-		CacheTTL:       300, // 5 minutes
-		CLIVersion:     "latest",
-		Records:        make(map[string]string),
-		Profiles:       make(map[string]Config),
-		LoadTime:       time.Now(),
-		ConfigSource:   "defaults",
+		ReturnType:          ReturnTypeOutput,
+		OutputNamePolicy:    validation.OutputNameSnakeCase,
+		Debug:               false,
+		LogLevel:            "info",
+		LogFormat:           LogFormatText,
+		Profile:             ProfileDefault,
+		Timeout:             300, // 5 minutes
+		RetryTimeout:        30,  // 30 seconds
+		ConnectTimeout:      10,  // 10 seconds
+		DownloadTimeout:     300, // 5 minutes
+		MaxConcurrency:      5,   // 5 concurrent operations
+		CacheEnabled:        false,
+		CacheTTL:            300, // 5 minutes
+		CLIVersion:          "latest",
+		DownloadMaxAttempts: 3,
+		RetryMaxAttempts:    3,
+		RetryBaseDelay:      1,
+		Records:             make(map[string]string),
+		Profiles:            make(map[string]Config),
+		LoadTime:            time.Now(),
+		ConfigSource:        "defaults",
 	}
 
 	// Load from configuration file first (if not disabled)
@@ -156,6 +345,11 @@ func LoadWithOptions(opts LoadOptions) (*Config, error) {
 		return config, nil
 	}
 
+	// Read the token and expand the record before validating them
+	if err := config.ResolveInputs(); err != nil {
+		return nil, fmt.Errorf("failed to resolve configuration inputs: %w", err)
+	}
+
 	// Validate configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -169,6 +363,20 @@ func LoadWithOptions(opts LoadOptions) (*Config, error) {
 	return config, nil
 }
 
+// ResolveInputs reads the token from token_file or standard input and
+// expands variables in the record, see interpolateRecord. LoadWithOptions
+// calls it before validating; a configuration built by hand must call it
+// once before Validate if it uses any of these settings.
+func (c *Config) ResolveInputs() error {
+	if err := c.loadTokenFile(); err != nil {
+		return err
+	}
+	if err := c.loadTokenStdin(); err != nil {
+		return err
+	}
+	return c.interpolateRecord()
+}
+
 // loadFromEnvironment loads configuration from environment variables
 func (c *Config) loadFromEnvironment() {
 	c.loadTokenFromEnvironment()
@@ -197,10 +405,25 @@ func (c *Config) loadCoreInputsFromEnvironment() {
 		c.Token = token
 		c.ConfigSource = sourceEnvironment
 	}
+	if tokenFile := getEnvOrInput("INPUT_TOKEN_FILE", "OP_TOKEN_FILE"); tokenFile != "" {
+		c.TokenFile = tokenFile
+	}
+	if tokenSource := getEnvOrInput("INPUT_TOKEN_SOURCE", "OP_TOKEN_SOURCE"); tokenSource != "" {
+		c.TokenSource = strings.ToLower(strings.TrimSpace(tokenSource))
+	}
+	if connectHost := getEnvOrInput("INPUT_CONNECT_HOST", "OP_CONNECT_HOST"); connectHost != "" {
+		c.ConnectHost = connectHost
+	}
+	if connectToken := getEnvOrInput("INPUT_CONNECT_TOKEN", "OP_CONNECT_TOKEN"); connectToken != "" {
+		c.ConnectToken = connectToken
+	}
 	if vault := getEnvOrInput("INPUT_VAULT", "OP_VAULT"); vault != "" {
 		c.Vault = vault
 		c.ConfigSource = sourceEnvironment
 	}
+	if priority := getEnvOrInput("INPUT_VAULT_PRIORITY", "OP_VAULT_PRIORITY"); priority != "" {
+		c.VaultPriority = splitList(priority)
+	}
 	if record := getEnvOrInput("INPUT_RECORD", "OP_RECORD"); record != "" {
 		c.Record = record
 		c.ConfigSource = sourceEnvironment
@@ -209,6 +432,41 @@ func (c *Config) loadCoreInputsFromEnvironment() {
 		c.ReturnType = returnType
 		c.ConfigSource = "environment"
 	}
+	if policy := getEnvOrInput("INPUT_OUTPUT_NAME_POLICY", "OP_OUTPUT_NAME_POLICY"); policy != "" {
+		c.OutputNamePolicy = strings.ToLower(policy)
+	}
+	if interpolation := getEnvOrInput("INPUT_RECORD_INTERPOLATION", "OP_RECORD_INTERPOLATION"); interpolation != "" {
+		c.RecordInterpolation = strings.ToLower(strings.TrimSpace(interpolation))
+	}
+	if secretsDir := getEnvOrInput("INPUT_SECRETS_DIR", "OP_SECRETS_DIR"); secretsDir != "" {
+		c.SecretsDir = secretsDir
+	}
+	if cleanupFiles := getEnvOrInput("INPUT_CLEANUP_FILES", "OP_CLEANUP_FILES"); cleanupFiles == trueString {
+		c.CleanupFiles = true
+	}
+	if jsonEnv := getEnvOrInput("INPUT_JSON_ENV", "OP_JSON_ENV"); jsonEnv == trueString {
+		c.JSONEnv = true
+	}
+	if verify := getEnvOrInput("INPUT_VERIFY_OUTPUTS", "OP_VERIFY_OUTPUTS"); verify == trueString {
+		c.VerifyOutputs = true
+	}
+	if dryRun := getEnvOrInput("INPUT_DRY_RUN", "OP_DRY_RUN"); dryRun == trueString {
+		c.DryRun = true
+	}
+	if summaryPath := getEnvOrInput("INPUT_SUMMARY_PATH", "OP_SUMMARY_PATH"); summaryPath != "" {
+		c.SummaryPath = summaryPath
+	}
+	if stepSummary := getEnvOrInput("INPUT_WRITE_STEP_SUMMARY", "OP_WRITE_STEP_SUMMARY"); stepSummary != "" {
+		enabled := stepSummary == trueString
+		c.WriteStepSummary = &enabled
+	}
+	if stepSummary := getEnvOrInput("INPUT_STEP_SUMMARY", "OP_STEP_SUMMARY"); stepSummary != "" {
+		enabled := stepSummary == trueString
+		c.StepSummary = &enabled
+	}
+	if schema := getEnvOrInput("INPUT_OUTPUT_SCHEMA_VERSION", "OP_OUTPUT_SCHEMA_VERSION"); schema != "" {
+		c.loadInt(&c.OutputSchemaVersion, "output_schema_version", schema, 1)
+	}
 }
 
 // loadProfileConfigFromEnvironment loads profile and configuration settings
@@ -229,43 +487,54 @@ func (c *Config) loadOperationalSettingsFromEnvironment() {
 		c.ConfigSource = sourceEnvironment
 	}
 	if logLevel := getEnvOrInput("INPUT_LOG_LEVEL", "OP_LOG_LEVEL"); logLevel != "" {
-		c.LogLevel = logLevel
+		c.LogLevel = strings.ToLower(logLevel)
+	}
+	if logFormat := getEnvOrInput("INPUT_LOG_FORMAT", "OP_LOG_FORMAT"); logFormat != "" {
+		c.LogFormat = strings.ToLower(logFormat)
 	}
 }
 
 // loadTimeoutSettingsFromEnvironment loads timeout-related settings
 func (c *Config) loadTimeoutSettingsFromEnvironment() {
 	if timeout := getEnvOrInput("INPUT_TIMEOUT", "OP_TIMEOUT"); timeout != "" {
-		if val, err := strconv.Atoi(timeout); err == nil && val > 0 {
-			c.Timeout = val
-		}
+		c.loadInt(&c.Timeout, "timeout", timeout, 1)
 	}
 	if retryTimeout := getEnvOrInput("INPUT_RETRY_TIMEOUT", "OP_RETRY_TIMEOUT"); retryTimeout != "" {
-		if val, err := strconv.Atoi(retryTimeout); err == nil && val > 0 {
-			c.RetryTimeout = val
-		}
+		c.loadInt(&c.RetryTimeout, "retry_timeout", retryTimeout, 1)
 	}
 	if connectTimeout := getEnvOrInput("INPUT_CONNECT_TIMEOUT", "OP_CONNECT_TIMEOUT"); connectTimeout != "" {
-		if val, err := strconv.Atoi(connectTimeout); err == nil && val > 0 {
-			c.ConnectTimeout = val
-		}
+		c.loadInt(&c.ConnectTimeout, "connect_timeout", connectTimeout, 1)
+	}
+	if recordTimeout := getEnvOrInput("INPUT_RECORD_TIMEOUT", "OP_RECORD_TIMEOUT"); recordTimeout != "" {
+		c.loadInt(&c.RecordTimeout, "record_timeout", recordTimeout, 1)
+	}
+	if downloadTimeout := getEnvOrInput("INPUT_DOWNLOAD_TIMEOUT", "OP_DOWNLOAD_TIMEOUT"); downloadTimeout != "" {
+		c.loadInt(&c.DownloadTimeout, "download_timeout", downloadTimeout, 1)
 	}
 }
 
 // loadPerformanceSettingsFromEnvironment loads performance-related settings
 func (c *Config) loadPerformanceSettingsFromEnvironment() {
 	if maxConcurrency := getEnvOrInput("INPUT_MAX_CONCURRENCY", "OP_MAX_CONCURRENCY"); maxConcurrency != "" {
-		if val, err := strconv.Atoi(maxConcurrency); err == nil && val > 0 {
-			c.MaxConcurrency = val
-		}
+		c.loadInt(&c.MaxConcurrency, "max_concurrency", maxConcurrency, 1)
+	}
+	if failFast := getEnvOrInput("INPUT_FAIL_FAST", "OP_FAIL_FAST"); failFast == trueString {
+		c.FailFast = true
+	}
+	if batchMode := getEnvOrInput("INPUT_BATCH_MODE", "OP_BATCH_MODE"); batchMode != "" {
+		c.BatchMode = strings.ToLower(strings.TrimSpace(batchMode))
 	}
 	if cacheEnabled := getEnvOrInput("INPUT_CACHE_ENABLED", "OP_CACHE_ENABLED"); cacheEnabled == "true" {
 		c.CacheEnabled = true
 	}
+	if cacheNegative := getEnvOrInput("INPUT_CACHE_NEGATIVE", "OP_CACHE_NEGATIVE"); cacheNegative == "true" {
+		c.CacheNegative = true
+	}
+	if batchRead := getEnvOrInput("INPUT_BATCH_READ", "OP_BATCH_READ"); batchRead == trueString {
+		c.BatchRead = true
+	}
 	if cacheTTL := getEnvOrInput("INPUT_CACHE_TTL", "OP_CACHE_TTL"); cacheTTL != "" {
-		if val, err := strconv.Atoi(cacheTTL); err == nil && val > 0 {
-			c.CacheTTL = val
-		}
+		c.loadInt(&c.CacheTTL, "cache_ttl", cacheTTL, 0)
 	}
 }
 
@@ -274,9 +543,58 @@ func (c *Config) loadCLISettingsFromEnvironment() {
 	if cliVersion := getEnvOrInput("INPUT_CLI_VERSION", "OP_CLI_VERSION"); cliVersion != "" {
 		c.CLIVersion = cliVersion
 	}
+	if allowed := getEnvOrInput("INPUT_ALLOWED_CLI_VERSIONS", "OP_ALLOWED_CLI_VERSIONS"); allowed != "" {
+		c.AllowedCLIVersions = splitList(allowed)
+	}
 	if cliPath := getEnvOrInput("INPUT_CLI_PATH", "OP_CLI_PATH"); cliPath != "" {
 		c.CLIPath = cliPath
 	}
+	if execFallbackDir := getEnvOrInput("INPUT_EXEC_FALLBACK_DIR", "OP_EXEC_FALLBACK_DIR"); execFallbackDir != "" {
+		c.ExecFallbackDir = execFallbackDir
+	}
+	if installDir := getEnvOrInput("INPUT_CLI_INSTALL_DIR", "OP_CLI_INSTALL_DIR"); installDir != "" {
+		c.CLIInstallDir = installDir
+	}
+	if baseURL := getEnvOrInput("INPUT_CLI_DOWNLOAD_BASE_URL", "OP_CLI_DOWNLOAD_BASE_URL"); baseURL != "" {
+		c.CLIDownloadBaseURL = baseURL
+	}
+	if insecure := getEnvOrInput("INPUT_ALLOW_INSECURE_DOWNLOAD", "OP_ALLOW_INSECURE_DOWNLOAD"); insecure == trueString {
+		c.AllowInsecureDownload = true
+	}
+	if proxyURL := getEnvOrInput("INPUT_PROXY_URL", "OP_PROXY_URL"); proxyURL != "" {
+		c.ProxyURL = proxyURL
+	}
+	if offline := getEnvOrInput("INPUT_OFFLINE", "OP_OFFLINE", "OP_SECRETS_ACTION_OFFLINE"); offline == trueString {
+		c.Offline = true
+	}
+	if disable := getEnvOrInput("INPUT_DISABLE_BINARY_CACHE", "OP_DISABLE_BINARY_CACHE"); disable == trueString {
+		c.DisableBinaryCache = true
+	}
+	if skip := getEnvOrInput("INPUT_SKIP_CHECKSUM_VERIFICATION", "OP_SKIP_CHECKSUM_VERIFICATION"); skip == trueString {
+		c.SkipChecksumVerification = true
+	}
+	if attempts := getEnvOrInput("INPUT_DOWNLOAD_MAX_ATTEMPTS", "OP_DOWNLOAD_MAX_ATTEMPTS"); attempts != "" {
+		c.loadInt(&c.DownloadMaxAttempts, "download_max_attempts", attempts, 1)
+	}
+	if attempts := getEnvOrInput("INPUT_RETRY_MAX_ATTEMPTS", "OP_RETRY_MAX_ATTEMPTS"); attempts != "" {
+		c.loadInt(&c.RetryMaxAttempts, "retry_max_attempts", attempts, 1)
+	}
+	if delay := getEnvOrInput("INPUT_RETRY_BASE_DELAY", "OP_RETRY_BASE_DELAY"); delay != "" {
+		c.loadInt(&c.RetryBaseDelay, "retry_base_delay", delay, 1)
+	}
+}
+
+// loadInt sets *field to value parsed as a whole number of at least
+// minimum. Anything else leaves *field unchanged and is reported by
+// Validate, rather than silently falling back to the default.
+func (c *Config) loadInt(field *int, name, value string, minimum int) {
+	val, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || val < minimum {
+		c.inputProblems = append(c.inputProblems,
+			fmt.Errorf("invalid %s %q: must be a whole number of at least %d", name, value, minimum))
+		return
+	}
+	*field = val
 }
 
 // loadGitHubEnvironment loads GitHub Actions environment variables
@@ -345,7 +663,10 @@ func (c *Config) Save(configPath string) error {
 	// Create a sanitized copy for saving (no secrets)
 	saveConfig := *c
 	saveConfig.Token = ""                        // Never save tokens
+	saveConfig.ConnectToken = ""                 // Never save tokens
 	saveConfig.Records = make(map[string]string) // Don't save parsed records
+	saveConfig.Transforms = nil
+	saveConfig.RecordRequests = nil
 
 	// Marshal to YAML
 	data, err := yaml.Marshal(&saveConfig)
@@ -435,6 +756,17 @@ func (c *Config) GetTimeout(operation string) time.Duration {
 		return time.Duration(c.ConnectTimeout) * time.Second
 	case "retry":
 		return time.Duration(c.RetryTimeout) * time.Second
+	case "download":
+		return time.Duration(c.DownloadTimeout) * time.Second
+	case "record":
+		if c.RecordTimeout > 0 {
+			return time.Duration(c.RecordTimeout) * time.Second
+		}
+		// A quarter of the operation timeout leaves time for the other
+		// records and for retries, but never less than minRecordTimeout
+		// unless the operation timeout itself is shorter
+		total := time.Duration(c.Timeout) * time.Second
+		return min(total, max(total/4, minRecordTimeout))
 	default:
 		return time.Duration(c.Timeout) * time.Second
 	}
@@ -517,6 +849,10 @@ func (c *Config) applyFinalDefaults() {
 		c.Profile = ProfileDefault
 	}
 
+	// The log level may come from a file or profile in any case; validation
+	// only inspects it
+	c.LogLevel = strings.ToLower(strings.TrimSpace(c.LogLevel))
+
 	// GitHub Actions debug mode (only if not explicitly set by profile)
 	if os.Getenv("DEBUG") == "true" || os.Getenv("RUNNER_DEBUG") == "1" {
 		if c.Profile == ProfileDefault || c.Profile == ProfileDevelopment {
@@ -538,6 +874,18 @@ func (c *Config) mergeConfig(other *Config) {
 	if other.Token != "" {
 		c.Token = other.Token
 	}
+	if other.TokenFile != "" {
+		c.TokenFile = other.TokenFile
+	}
+	if other.TokenSource != "" {
+		c.TokenSource = other.TokenSource
+	}
+	if other.ConnectHost != "" {
+		c.ConnectHost = other.ConnectHost
+	}
+	if other.ConnectToken != "" {
+		c.ConnectToken = other.ConnectToken
+	}
 	if other.Vault != "" {
 		c.Vault = other.Vault
 	}
@@ -547,15 +895,60 @@ func (c *Config) mergeConfig(other *Config) {
 	if other.ReturnType != "" {
 		c.ReturnType = other.ReturnType
 	}
+	if other.OutputNamePolicy != "" {
+		c.OutputNamePolicy = other.OutputNamePolicy
+	}
+	if other.RecordInterpolation != "" {
+		c.RecordInterpolation = other.RecordInterpolation
+	}
 	if other.LogLevel != "" {
 		c.LogLevel = other.LogLevel
 	}
+	if other.LogFormat != "" {
+		c.LogFormat = other.LogFormat
+	}
 	if other.CLIVersion != "" {
 		c.CLIVersion = other.CLIVersion
 	}
 	if other.CLIPath != "" {
 		c.CLIPath = other.CLIPath
 	}
+	if other.ExecFallbackDir != "" {
+		c.ExecFallbackDir = other.ExecFallbackDir
+	}
+	if other.CLIInstallDir != "" {
+		c.CLIInstallDir = other.CLIInstallDir
+	}
+	if other.CLIDownloadBaseURL != "" {
+		c.CLIDownloadBaseURL = other.CLIDownloadBaseURL
+	}
+	if other.ProxyURL != "" {
+		c.ProxyURL = other.ProxyURL
+	}
+	if other.SecretsDir != "" {
+		c.SecretsDir = other.SecretsDir
+	}
+	if other.SummaryPath != "" {
+		c.SummaryPath = other.SummaryPath
+	}
+	if len(other.VaultPriority) > 0 {
+		c.VaultPriority = other.VaultPriority
+	}
+	if len(other.AllowedCLIVersions) > 0 {
+		c.AllowedCLIVersions = other.AllowedCLIVersions
+	}
+	if other.OutputSchemaVersion != 0 {
+		c.OutputSchemaVersion = other.OutputSchemaVersion
+	}
+	if other.DownloadMaxAttempts != 0 {
+		c.DownloadMaxAttempts = other.DownloadMaxAttempts
+	}
+	if other.RetryMaxAttempts != 0 {
+		c.RetryMaxAttempts = other.RetryMaxAttempts
+	}
+	if other.RetryBaseDelay != 0 {
+		c.RetryBaseDelay = other.RetryBaseDelay
+	}
 
 	// Merge timeout settings
 	if other.Timeout != 0 {
@@ -567,6 +960,15 @@ func (c *Config) mergeConfig(other *Config) {
 	if other.ConnectTimeout != 0 {
 		c.ConnectTimeout = other.ConnectTimeout
 	}
+	if other.DownloadTimeout != 0 {
+		c.DownloadTimeout = other.DownloadTimeout
+	}
+	if other.RecordTimeout != 0 {
+		c.RecordTimeout = other.RecordTimeout
+	}
+	if other.BatchMode != "" {
+		c.BatchMode = other.BatchMode
+	}
 
 	// Merge performance settings
 	if other.MaxConcurrency != 0 {
@@ -576,9 +978,66 @@ func (c *Config) mergeConfig(other *Config) {
 		c.CacheTTL = other.CacheTTL
 	}
 
-	// Merge boolean settings (profile can override)
+	// Merge boolean settings the profiles define (profile can override)
 	c.Debug = other.Debug
 	c.CacheEnabled = other.CacheEnabled
+
+	// Other boolean inputs cannot be told apart from unset in a profile, so
+	// a profile may turn them on but never off; otherwise applying a profile
+	// would silently drop offline, cleanup_files and the like
+	c.CacheNegative = c.CacheNegative || other.CacheNegative
+	c.BatchRead = c.BatchRead || other.BatchRead
+	c.CleanupFiles = c.CleanupFiles || other.CleanupFiles
+	c.JSONEnv = c.JSONEnv || other.JSONEnv
+	c.VerifyOutputs = c.VerifyOutputs || other.VerifyOutputs
+	c.FailFast = c.FailFast || other.FailFast
+	c.AllowInsecureDownload = c.AllowInsecureDownload || other.AllowInsecureDownload
+	c.Offline = c.Offline || other.Offline
+	c.DisableBinaryCache = c.DisableBinaryCache || other.DisableBinaryCache
+	c.SkipChecksumVerification = c.SkipChecksumVerification || other.SkipChecksumVerification
+
+	// A profile may turn dry run on but never off, so it cannot make a run
+	// read secrets that was asked not to
+	if other.DryRun {
+		c.DryRun = true
+	}
+
+	// The step summaries are tracked as set or unset, so a file or profile
+	// can turn either off as well as on
+	if other.WriteStepSummary != nil {
+		c.WriteStepSummary = other.WriteStepSummary
+	}
+	if other.StepSummary != nil {
+		c.StepSummary = other.StepSummary
+	}
+}
+
+// WriteStepSummaryEnabled reports whether the table of requested outputs is
+// appended to the step summary. It is off unless write_step_summary is set.
+func (c *Config) WriteStepSummaryEnabled() bool {
+	return c.WriteStepSummary != nil && *c.WriteStepSummary
+}
+
+// StepSummaryEnabled reports whether the run's metrics are appended to the
+// step summary. Unless step_summary is set, it is on when running in GitHub
+// Actions.
+func (c *Config) StepSummaryEnabled() bool {
+	if c.StepSummary != nil {
+		return *c.StepSummary
+	}
+	return os.Getenv("GITHUB_ACTIONS") == trueString
+}
+
+// splitList splits a comma- or newline-separated input into trimmed,
+// non-empty entries.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
 }
 
 // getEnvOrInput returns the first non-empty value from the given environment variables
@@ -646,39 +1105,125 @@ func getBuiltinProfile(profileName string) *Config {
 	return profiles[profileName]
 }
 
-// Validate performs comprehensive validation of the configuration
+// Validate performs comprehensive validation of the configuration. A
+// single problem is returned as is; several are returned together as a
+// *ValidationError, see ValidationErrors.
 func (c *Config) Validate() error {
+	problems := c.ValidationErrors()
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return problems[0]
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// ValidationError lists every problem ValidationErrors found.
+type ValidationError struct {
+	Problems []error
+}
+
+// Error joins the problems into one line.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Error()
+	}
+	return fmt.Sprintf("%d configuration problems: %s", len(e.Problems), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the problems, for errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// ValidationErrors checks every setting and returns all problems found,
+// rather than stopping at the first. It returns nil for a valid
+// configuration. It only inspects the configuration: the token and record
+// are checked as ResolveInputs left them.
+func (c *Config) ValidationErrors() []error {
 	v, err := validation.NewValidator()
 	if err != nil {
-		return fmt.Errorf("failed to initialize validator: %w", err)
+		return []error{fmt.Errorf("failed to initialize validator: %w", err)}
 	}
 
-	// Validate core inputs via central validator
-	if err := v.ValidateToken(c.Token); err != nil {
-		return err
-	}
-	if err := v.ValidateVault(c.Vault); err != nil {
-		return err
+	problems := append([]error(nil), c.inputProblems...)
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
 	}
-	if err := v.ValidateReturnType(c.ReturnType); err != nil {
-		return err
+
+	// Validate core inputs via central validator. A Connect server takes
+	// the place of the service account token.
+	if c.UsesConnect() {
+		check(c.validateConnect())
+	} else {
+		check(v.ValidateToken(c.Token))
+	}
+	check(c.validateTokenSource())
+	// The vault input is only a default, needed unless every record names
+	// its own vault
+	check(c.validateRecordInterpolation())
+	if c.Vault != "" || !c.recordsNameVaults(v) {
+		check(v.ValidateVault(c.Vault))
+	}
+	for _, vault := range c.VaultPriority {
+		if vault == AnyVault {
+			check(fmt.Errorf("vault_priority must list vault names or IDs"))
+			continue
+		}
+		check(v.ValidateVault(vault))
 	}
+	check(v.ValidateReturnType(c.ReturnType))
 
 	// Retain existing non-duplicate validations
-	if err := c.validateProfile(); err != nil {
-		return err
+	check(c.validateProfile())
+	problems = append(problems, c.validateTimeoutSettings()...)
+	problems = append(problems, c.validatePerformanceSettings()...)
+	check(c.validateLogLevel())
+	check(c.validateLogFormat())
+	check(c.validateBatchMode())
+	check(c.validateOutputNamePolicy())
+	check(c.validateCLIVersion())
+	check(c.validateCLIDownloadBaseURL())
+	if err := proxy.Validate(c.ProxyURL); err != nil {
+		check(fmt.Errorf("invalid proxy_url: %w", err))
+	}
+	return problems
+}
+
+// validateCLIDownloadBaseURL validates the CLI download mirror URL
+func (c *Config) validateCLIDownloadBaseURL() error {
+	if c.CLIDownloadBaseURL == "" {
+		return nil
 	}
-	if err := c.validateTimeoutSettings(); err != nil {
-		return err
+	u, err := url.Parse(c.CLIDownloadBaseURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid cli_download_base_url: must be an absolute URL")
 	}
-	if err := c.validatePerformanceSettings(); err != nil {
-		return err
+	if u.Scheme == "https" || (u.Scheme == "http" && c.AllowInsecureDownload) {
+		return nil
 	}
-	if err := c.validateLogLevel(); err != nil {
-		return err
+	if u.Scheme == "http" {
+		return fmt.Errorf("invalid cli_download_base_url: http requires allow_insecure_download")
 	}
-	if err := c.validateCLIVersion(); err != nil {
-		return err
+	return fmt.Errorf("invalid cli_download_base_url: scheme must be https")
+}
+
+// UsesConnect reports whether secrets are read from a 1Password Connect server
+func (c *Config) UsesConnect() bool {
+	return c.ConnectHost != "" || c.ConnectToken != ""
+}
+
+// validateConnect validates the Connect server settings
+func (c *Config) validateConnect() error {
+	if c.ConnectHost == "" || c.ConnectToken == "" {
+		return fmt.Errorf("connect_host and connect_token must be set together")
+	}
+	if !strings.HasPrefix(c.ConnectHost, "https://") && !strings.HasPrefix(c.ConnectHost, "http://") {
+		return fmt.Errorf("connect_host must be an http(s) URL")
 	}
 	return nil
 }
@@ -697,50 +1242,128 @@ func (c *Config) validateProfile() error {
 	return fmt.Errorf("invalid profile: must be one of %v", validProfiles)
 }
 
-// validateTimeoutSettings validates timeout-related settings
-func (c *Config) validateTimeoutSettings() error {
+// validateTimeoutSettings validates timeout-related settings, returning a
+// problem for each setting out of range
+func (c *Config) validateTimeoutSettings() []error {
+	var problems []error
 	if c.Timeout <= 0 || c.Timeout > 3600 {
-		return fmt.Errorf("timeout must be between 1 and 3600 seconds")
+		problems = append(problems, fmt.Errorf("timeout must be between 1 and 3600 seconds"))
 	}
 	if c.RetryTimeout <= 0 || c.RetryTimeout > 300 {
-		return fmt.Errorf("retry_timeout must be between 1 and 300 seconds")
+		problems = append(problems, fmt.Errorf("retry_timeout must be between 1 and 300 seconds"))
 	}
 	if c.ConnectTimeout <= 0 || c.ConnectTimeout > 60 {
-		return fmt.Errorf("connect_timeout must be between 1 and 60 seconds")
+		problems = append(problems, fmt.Errorf("connect_timeout must be between 1 and 60 seconds"))
 	}
-	return nil
+	if c.DownloadTimeout < 0 || c.DownloadTimeout > 3600 {
+		problems = append(problems, fmt.Errorf("download_timeout must be between 1 and 3600 seconds"))
+	}
+	if c.RecordTimeout < 0 || c.RecordTimeout > c.Timeout {
+		problems = append(problems, fmt.Errorf("record_timeout must be between 1 second and timeout (%d seconds)", c.Timeout))
+	}
+	// 0 leaves the attempts and delay below at their defaults; an explicit
+	// input below 1 is rejected as it is loaded
+	if c.DownloadMaxAttempts < 0 || c.DownloadMaxAttempts > 10 {
+		problems = append(problems, fmt.Errorf("download_max_attempts must be between 1 and 10, or 0 for the default"))
+	}
+	if c.RetryMaxAttempts < 0 || c.RetryMaxAttempts > 10 {
+		problems = append(problems, fmt.Errorf("retry_max_attempts must be between 1 and 10, or 0 for the default"))
+	}
+	if c.RetryBaseDelay < 0 || c.RetryBaseDelay > 60 {
+		problems = append(problems, fmt.Errorf("retry_base_delay must be between 1 and 60 seconds, or 0 for the default"))
+	}
+	return problems
 }
 
-// validatePerformanceSettings validates performance-related settings
-func (c *Config) validatePerformanceSettings() error {
+// validatePerformanceSettings validates performance-related settings,
+// returning a problem for each setting out of range
+func (c *Config) validatePerformanceSettings() []error {
+	var problems []error
 	if c.MaxConcurrency <= 0 || c.MaxConcurrency > 20 {
-		return fmt.Errorf("max_concurrency must be between 1 and 20")
+		problems = append(problems, fmt.Errorf("max_concurrency must be between 1 and 20"))
 	}
 	if c.CacheTTL < 0 || c.CacheTTL > 3600 {
-		return fmt.Errorf("cache_ttl must be between 0 and 3600 seconds")
+		problems = append(problems, fmt.Errorf("cache_ttl must be between 0 and 3600 seconds"))
 	}
-	return nil
+	// 0 leaves the schema version at the default; an explicit input below 1
+	// is rejected as it is loaded
+	if c.OutputSchemaVersion < 0 || c.OutputSchemaVersion > LatestOutputSchemaVersion {
+		problems = append(problems, fmt.Errorf("output_schema_version must be between 1 and %d, or 0 for the default",
+			LatestOutputSchemaVersion))
+	}
+	return problems
 }
 
-// validateLogLevel validates the log level setting
+// validateLogLevel validates the log level setting against the levels the
+// logger knows, in any case
 func (c *Config) validateLogLevel() error {
-	validLogLevels := []string{"trace", "debug", "info", "warn", "error", "fatal"}
-	for _, level := range validLogLevels {
-		if c.LogLevel == level {
-			return nil
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid log_level: %w", err)
+	}
+	return nil
+}
+
+// validateLogFormat validates the log format setting
+func (c *Config) validateLogFormat() error {
+	switch c.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid log_format: must be one of [%s %s]", LogFormatText, LogFormatJSON)
+}
+
+// validateBatchMode validates the batch mode setting
+func (c *Config) validateBatchMode() error {
+	switch c.BatchMode {
+	case "", BatchModeAtomic, BatchModeFailFast:
+		return nil
+	case BatchModeBestEffort:
+		if c.FailFast {
+			return fmt.Errorf("batch_mode %s cannot be combined with fail_fast", BatchModeBestEffort)
 		}
+		return nil
+	}
+	return fmt.Errorf("invalid batch_mode: must be one of [%s %s %s]",
+		BatchModeAtomic, BatchModeFailFast, BatchModeBestEffort)
+}
+
+// EffectiveBatchMode returns the batch mode in effect, resolving an empty
+// BatchMode and the fail_fast shorthand.
+func (c *Config) EffectiveBatchMode() string {
+	switch {
+	case c.BatchMode == BatchModeBestEffort, c.BatchMode == BatchModeFailFast:
+		return c.BatchMode
+	case c.FailFast:
+		return BatchModeFailFast
+	default:
+		return BatchModeAtomic
 	}
-	return fmt.Errorf("invalid log_level: must be one of %v", validLogLevels)
 }
 
-// validateCLIVersion validates the CLI version format
+// validateOutputNamePolicy validates the output name policy setting
+func (c *Config) validateOutputNamePolicy() error {
+	if c.OutputNamePolicy == "" || validation.IsOutputNamePolicy(c.OutputNamePolicy) {
+		return nil
+	}
+	return fmt.Errorf("invalid output_name_policy: must be one of %v", validation.OutputNamePolicies)
+}
+
+// cliVersionPattern matches a semver-like CLI version, with optional
+// pre-release tag and build metadata
+var cliVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// validateCLIVersion validates the CLI version format and the allowlist
 func (c *Config) validateCLIVersion() error {
 	if c.CLIVersion != "" && c.CLIVersion != "latest" {
-		// Simple version validation (should be semver-like)
-		if !regexp.MustCompile(`^v?\d+\.\d+\.\d+(-\w+)?$`).MatchString(c.CLIVersion) {
+		if !cliVersionPattern.MatchString(c.CLIVersion) {
 			return fmt.Errorf("invalid cli_version format: must be semver (e.g., v2.18.0) or 'latest'")
 		}
 	}
+	for _, version := range c.AllowedCLIVersions {
+		if !cliVersionPattern.MatchString(version) {
+			return fmt.Errorf("invalid allowed_cli_versions entry %q: must be semver (e.g., v2.18.0)", version)
+		}
+	}
 	return nil
 }
 
@@ -756,10 +1379,17 @@ func (c *Config) parseRecords() error {
 		return fmt.Errorf("failed to initialize validator: %w", err)
 	}
 
+	if c.OutputNamePolicy != "" {
+		if err := v.SetOutputNamePolicy(c.OutputNamePolicy); err != nil {
+			return err
+		}
+	}
+
 	spec, err := v.ParseRecord(record)
 	if err != nil {
 		return err
 	}
+	c.Warnings = append(c.Warnings, spec.Warnings...)
 
 	switch spec.Type {
 	case validation.RecordTypeSingle:
@@ -769,22 +1399,89 @@ func (c *Config) parseRecords() error {
 		c.Records = map[string]string{
 			"value": fmt.Sprintf("%s/%s", spec.Single.SecretName, spec.Single.FieldName),
 		}
-		return nil
+		c.RecordRequests = []RecordRequest{newRecordRequest("value", spec.Single)}
+		return c.validateAttachmentRecords()
 	case validation.RecordTypeMultiple:
 		if len(spec.Multi) == 0 {
 			return fmt.Errorf("no records specified")
 		}
 		recs := make(map[string]string, len(spec.Multi))
+		transforms := make(map[string][]string)
+		requests := make([]RecordRequest, 0, len(spec.Multi))
 		for k, sr := range spec.Multi {
 			recs[k] = fmt.Sprintf("%s/%s", sr.SecretName, sr.FieldName)
+			if len(sr.Transforms) > 0 {
+				transforms[k] = sr.Transforms
+			}
+			requests = append(requests, newRecordRequest(k, sr))
 		}
+		sort.Slice(requests, func(i, j int) bool {
+			return requests[i].OutputName < requests[j].OutputName
+		})
 		c.Records = recs
-		return nil
+		c.RecordRequests = requests
+		if len(transforms) > 0 {
+			c.Transforms = transforms
+		}
+		return c.validateAttachmentRecords()
 	default:
 		return fmt.Errorf("unknown record specification type")
 	}
 }
 
+// recordsNameVaults reports whether every record of the record input names
+// its vault, as in "vault/item/field". A record that cannot be parsed counts
+// as naming none; parseRecords reports it.
+func (c *Config) recordsNameVaults(v *validation.Validator) bool {
+	if c.OutputNamePolicy != "" {
+		if err := v.SetOutputNamePolicy(c.OutputNamePolicy); err != nil {
+			return false
+		}
+	}
+	spec, err := v.ParseRecord(strings.TrimSpace(c.Record))
+	if err != nil {
+		return false
+	}
+	if spec.Type == validation.RecordTypeSingle {
+		return spec.Single != nil && spec.Single.VaultRef != ""
+	}
+	for _, record := range spec.Multi {
+		if record.VaultRef == "" {
+			return false
+		}
+	}
+	return len(spec.Multi) > 0
+}
+
+// validateAttachmentRecords checks that file attachments and documents are
+// only requested with return_type "file", the one return type that can deliver binary
+// content intact.
+func (c *Config) validateAttachmentRecords() error {
+	if c.ReturnType == ReturnTypeFile {
+		return nil
+	}
+	for _, request := range c.RecordRequests {
+		if validation.IsFileField(request.Field) {
+			return fmt.Errorf("record '%s' requests an attachment or document, which requires return_type '%s'",
+				request.OutputName, ReturnTypeFile)
+		}
+	}
+	return nil
+}
+
+// newRecordRequest converts a parsed record into a RecordRequest
+func newRecordRequest(outputName string, record *validation.SingleRecord) RecordRequest {
+	return RecordRequest{
+		OutputName: outputName,
+		Vault:      record.VaultRef,
+		Item:       record.SecretName,
+		Section:    record.Section,
+		Field:      record.FieldName,
+		Transforms: record.Transforms,
+		Optional:   record.Optional,
+	}
+}
+
 // IsSingleRecord returns true if this is a single record configuration
 func (c *Config) IsSingleRecord() bool {
 	return len(c.Records) == 1 && c.Records["value"] != ""
@@ -810,28 +1507,57 @@ func GetRecordPath(recordPath string) (secretName, fieldName string, err error)
 // SanitizeForLogging returns a version of the config safe for logging
 func (c *Config) SanitizeForLogging() map[string]interface{} {
 	return map[string]interface{}{
-		"vault":            "[REDACTED]",
-		"return_type":      c.ReturnType,
-		"profile":          c.Profile,
-		"debug":            c.Debug,
-		"log_level":        c.LogLevel,
-		"timeout":          c.Timeout,
-		"retry_timeout":    c.RetryTimeout,
-		"connect_timeout":  c.ConnectTimeout,
-		"max_concurrency":  c.MaxConcurrency,
-		"cache_enabled":    c.CacheEnabled,
-		"cache_ttl":        c.CacheTTL,
-		"cli_version":      c.CLIVersion,
-		"record_count":     len(c.Records),
-		"is_single":        c.IsSingleRecord(),
-		"has_token":        c.Token != "",
-		"has_cli_path":     c.CLIPath != "",
-		"config_source":    c.ConfigSource,
-		"config_file":      c.ConfigFile != "",
-		"load_time":        c.LoadTime.Format(time.RFC3339),
-		"github_env":       c.GitHubEnv != "",
-		"github_output":    c.GitHubOutput != "",
-		"github_workspace": c.GitHubWorkspace != "",
+		"vault":              "[REDACTED]",
+		"return_type":        c.ReturnType,
+		"output_names":       c.OutputNamePolicy,
+		"interpolation":      c.RecordInterpolation,
+		"profile":            c.Profile,
+		"debug":              c.Debug,
+		"log_level":          c.LogLevel,
+		"log_format":         c.LogFormat,
+		"timeout":            c.Timeout,
+		"retry_timeout":      c.RetryTimeout,
+		"retry_attempts":     c.RetryMaxAttempts,
+		"retry_base_delay":   c.RetryBaseDelay,
+		"connect_timeout":    c.ConnectTimeout,
+		"download_timeout":   c.DownloadTimeout,
+		"record_timeout":     c.RecordTimeout,
+		"batch_mode":         c.EffectiveBatchMode(),
+		"max_concurrency":    c.MaxConcurrency,
+		"fail_fast":          c.FailFast,
+		"cache_enabled":      c.CacheEnabled,
+		"cache_ttl":          c.CacheTTL,
+		"cache_negative":     c.CacheNegative,
+		"batch_read":         c.BatchRead,
+		"vault_priority":     len(c.VaultPriority),
+		"cleanup_files":      c.CleanupFiles,
+		"json_env":           c.JSONEnv,
+		"verify_outputs":     c.VerifyOutputs,
+		"dry_run":            c.DryRun,
+		"summary_path":       c.SummaryPath,
+		"write_step_summary": c.WriteStepSummaryEnabled(),
+		"step_summary":       c.StepSummaryEnabled(),
+		"cli_version":        c.CLIVersion,
+		"allowed_versions":   c.AllowedCLIVersions,
+		"cli_download_base":  c.CLIDownloadBaseURL != "",
+		"insecure_download":  c.AllowInsecureDownload,
+		"offline":            c.Offline,
+		"binary_cache":       !c.DisableBinaryCache,
+		"skip_checksum":      c.SkipChecksumVerification,
+		"proxy_url":          proxy.Redact(c.ProxyURL),
+		"record_count":       len(c.Records),
+		"is_single":          c.IsSingleRecord(),
+		"has_token":          c.Token != "",
+		"has_token_file":     c.TokenFile != "",
+		"token_source":       c.TokenSource,
+		"uses_connect":       c.UsesConnect(),
+		"has_cli_path":       c.CLIPath != "",
+		"config_source":      c.ConfigSource,
+		"config_file":        c.ConfigFile != "",
+		"load_time":          c.LoadTime.Format(time.RFC3339),
+		"github_env":         c.GitHubEnv != "",
+		"github_output":      c.GitHubOutput != "",
+		"github_workspace":   c.GitHubWorkspace != "",
 	}
 }
 
@@ -842,13 +1568,27 @@ func (c *Config) ValidateGitHubEnvironment() error {
 	}
 
 	// Check for required GitHub Actions files when setting outputs or env vars
-	if (c.ReturnType == ReturnTypeOutput || c.ReturnType == ReturnTypeBoth) && c.GitHubOutput == "" {
+	if c.NeedsGitHubOutput() && c.GitHubOutput == "" {
 		return fmt.Errorf("GITHUB_OUTPUT not available for setting outputs")
 	}
 
-	if (c.ReturnType == ReturnTypeEnv || c.ReturnType == ReturnTypeBoth) && c.GitHubEnv == "" {
+	if c.NeedsGitHubEnv() && c.GitHubEnv == "" {
 		return fmt.Errorf("GITHUB_ENV not available for setting environment variables")
 	}
 
 	return nil
 }
+
+// NeedsGitHubOutput reports whether the return type writes step outputs to
+// the GITHUB_OUTPUT file.
+func (c *Config) NeedsGitHubOutput() bool {
+	return c.ReturnType == ReturnTypeOutput || c.ReturnType == ReturnTypeBoth ||
+		c.ReturnType == ReturnTypeFile || c.ReturnType == ReturnTypeJSON
+}
+
+// NeedsGitHubEnv reports whether the return type writes environment
+// variables to the GITHUB_ENV file.
+func (c *Config) NeedsGitHubEnv() bool {
+	return c.ReturnType == ReturnTypeEnv || c.ReturnType == ReturnTypeBoth ||
+		(c.ReturnType == ReturnTypeJSON && c.JSONEnv)
+}
@@ -4,12 +4,17 @@
 package config
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/testdata"
 )
 
@@ -246,6 +251,36 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "trace log level in any case",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          "test-vault",
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "TRACE",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown log level",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          "test-vault",
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "verbose",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty token",
 			config: &Config{
@@ -268,6 +303,65 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "any vault with priority",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          AnyVault,
+				VaultPriority:  []string{"Production", "6n4qm2onchsinyyeuxmcfbo7ne"},
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "every record names its vault",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Record:         `{"db": "prod/database/password", "api": "op://ci/api/key"}`,
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "record without vault and no default",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Record:         `{"db": "prod/database/password", "api": "api/key"}`,
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "any vault listed in priority",
+			config: &Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          AnyVault,
+				VaultPriority:  []string{AnyVault},
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				MaxConcurrency: 5,
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty record",
 			config: &Config{
@@ -346,6 +440,53 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidationErrors(t *testing.T) {
+	cfg := &Config{
+		Token:          testdata.GetValidDummyToken(),
+		Vault:          "test-vault",
+		Record:         "secret/field",
+		ReturnType:     "stdout",
+		LogLevel:       "verbose",
+		Timeout:        0,
+		RetryTimeout:   30,
+		ConnectTimeout: 10,
+		MaxConcurrency: 0,
+		CacheTTL:       -1,
+		CLIVersion:     "latest",
+	}
+
+	problems := cfg.ValidationErrors()
+	want := []string{"return type", "timeout must be", "max_concurrency", "cache_ttl", "log_level"}
+	if len(problems) != len(want) {
+		t.Fatalf("ValidationErrors() = %v, want %d problems", problems, len(want))
+	}
+	for i, problem := range problems {
+		if !strings.Contains(strings.ToLower(problem.Error()), want[i]) {
+			t.Errorf("problem %d = %q, want it to mention %q", i, problem, want[i])
+		}
+	}
+
+	// Validate reports them all together
+	err := cfg.Validate()
+	var validationErr *ValidationError
+	if !stderrors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	if len(validationErr.Problems) != len(want) || !strings.HasPrefix(err.Error(), "5 configuration problems: ") {
+		t.Errorf("Validate() error = %q", err)
+	}
+
+	// A single problem is returned as is
+	cfg.ReturnType, cfg.LogLevel, cfg.Timeout, cfg.MaxConcurrency, cfg.CacheTTL = ReturnTypeOutput, "info", 300, 5, 0
+	if problems := cfg.ValidationErrors(); len(problems) != 0 {
+		t.Fatalf("ValidationErrors() = %v, want none", problems)
+	}
+	cfg.MaxConcurrency = 50
+	if err := cfg.Validate(); err == nil || stderrors.As(err, &validationErr) {
+		t.Errorf("Validate() error = %v, want the single problem itself", err)
+	}
+}
+
 func TestParseRecords(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -433,6 +574,155 @@ func TestParseRecords(t *testing.T) {
 	}
 }
 
+func TestParseRecordsWithTransforms(t *testing.T) {
+	config := &Config{
+		Record: `{"key": {"ref": "keys/signing", "transforms": ["base64decode", "minlength=32"]}, "plain": "api/key"}`,
+	}
+
+	if err := config.parseRecords(); err != nil {
+		t.Fatalf("parseRecords() error = %v", err)
+	}
+
+	if config.Records["key"] != "keys/signing" {
+		t.Errorf("Records[key] = %q, want %q", config.Records["key"], "keys/signing")
+	}
+	if got := config.Transforms["key"]; len(got) != 2 || got[0] != "base64decode" || got[1] != "minlength=32" {
+		t.Errorf("Transforms[key] = %v, want [base64decode minlength=32]", got)
+	}
+	if _, ok := config.Transforms["plain"]; ok {
+		t.Errorf("plain record should not have transforms")
+	}
+}
+
+func TestParseRecordsRecordRequests(t *testing.T) {
+	config := &Config{
+		Record: "db_password: prod/database/password\napi_key: api/key\nsigning_key: staging:keys/signing\n",
+	}
+
+	if err := config.parseRecords(); err != nil {
+		t.Fatalf("parseRecords() error = %v", err)
+	}
+
+	want := []RecordRequest{
+		{OutputName: "api_key", Item: "api", Field: "key"},
+		{OutputName: "db_password", Vault: "prod", Item: "database", Field: "password"},
+		{OutputName: "signing_key", Vault: "staging", Item: "keys", Field: "signing"},
+	}
+	if !reflect.DeepEqual(config.RecordRequests, want) {
+		t.Errorf("RecordRequests = %+v, want %+v", config.RecordRequests, want)
+	}
+	if config.Records["db_password"] != "database/password" {
+		t.Errorf("Records[db_password] = %q, want %q", config.Records["db_password"], "database/password")
+	}
+}
+
+func TestParseRecordsSection(t *testing.T) {
+	for _, record := range []string{
+		"replica: op://prod/database/replica/password\n",
+		"replica: prod/database/replica/password\n",
+	} {
+		config := &Config{Record: record}
+		if err := config.parseRecords(); err != nil {
+			t.Fatalf("parseRecords(%q) error = %v", record, err)
+		}
+		want := RecordRequest{OutputName: "replica", Vault: "prod", Item: "database", Section: "replica", Field: "password"}
+		if len(config.RecordRequests) != 1 || !reflect.DeepEqual(config.RecordRequests[0], want) {
+			t.Errorf("RecordRequests = %+v, want %+v", config.RecordRequests, want)
+		}
+	}
+}
+
+func TestParseRecordsAttachments(t *testing.T) {
+	config := &Config{
+		Record:     "signing_key: prod/release/file:signing.key\n",
+		ReturnType: ReturnTypeFile,
+	}
+	if err := config.parseRecords(); err != nil {
+		t.Fatalf("parseRecords() error = %v", err)
+	}
+	want := RecordRequest{OutputName: "signing_key", Vault: "prod", Item: "release", Field: "file:signing.key"}
+	if len(config.RecordRequests) != 1 || !reflect.DeepEqual(config.RecordRequests[0], want) {
+		t.Errorf("RecordRequests = %+v, want %+v", config.RecordRequests, want)
+	}
+
+	config = &Config{Record: "release/file:signing.key", ReturnType: ReturnTypeOutput}
+	err := config.parseRecords()
+	if err == nil || !strings.Contains(err.Error(), "requires return_type 'file'") {
+		t.Errorf("parseRecords() error = %v, want attachment return type error", err)
+	}
+
+	config = &Config{Record: "keystore/@document", ReturnType: ReturnTypeEnv}
+	err = config.parseRecords()
+	if err == nil || !strings.Contains(err.Error(), "requires return_type 'file'") {
+		t.Errorf("parseRecords() error = %v, want document return type error", err)
+	}
+}
+
+func TestParseRecordsOutputNamePolicy(t *testing.T) {
+	config := &Config{
+		Record:           "- op://Production/Stripe/API Key\n- database/password\n",
+		OutputNamePolicy: "upper_snake_case",
+	}
+	if err := config.parseRecords(); err != nil {
+		t.Fatalf("parseRecords() error = %v", err)
+	}
+	want := map[string]string{"API_KEY": "Stripe/API Key", "PASSWORD": "database/password"}
+	if !reflect.DeepEqual(config.Records, want) {
+		t.Errorf("Records = %v, want %v", config.Records, want)
+	}
+
+	config = &Config{Record: `["db/password", "api/password"]`}
+	err := config.parseRecords()
+	if err == nil || !strings.Contains(err.Error(), `both become output "password"`) {
+		t.Errorf("parseRecords() error = %v, want output name collision", err)
+	}
+}
+
+func TestParseRecordsOutputNameChecks(t *testing.T) {
+	config := &Config{Record: "always: ci/deploy/token\ndb: ci/database/password"}
+	if err := config.parseRecords(); err != nil {
+		t.Fatalf("parseRecords() error = %v", err)
+	}
+	if len(config.Warnings) != 1 || !strings.Contains(config.Warnings[0], "always()") {
+		t.Errorf("Warnings = %v, want one warning about always()", config.Warnings)
+	}
+
+	// Every invalid name is reported in one error
+	config = &Config{Record: `{"env": "ci/a/b", "1st": "ci/c/d", "ok": "ci/e/f"}`}
+	err := config.parseRecords()
+	if !errors.IsErrorCode(err, errors.ErrCodeInvalidRecord) {
+		t.Fatalf("parseRecords() error = %v, want %s", err, errors.ErrCodeInvalidRecord)
+	}
+	for _, name := range []string{`"env"`, `"1st"`} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("parseRecords() error = %v, want it to name %s", err, name)
+		}
+	}
+}
+
+func TestParseRecordsDuplicateOutputNames(t *testing.T) {
+	records := []string{
+		"db: prod/database/password\ndb: prod/database/username",
+		`{"db": "database/password", "db": "database/username"}`,
+	}
+
+	for _, record := range records {
+		config := &Config{Record: record}
+		err := config.parseRecords()
+		if err == nil || !strings.Contains(err.Error(), `Duplicate output name "db"`) {
+			t.Errorf("parseRecords(%q) error = %v, want duplicate output name error", record, err)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" Production, Staging\n\n6n4qm2onchsinyyeuxmcfbo7ne ,")
+	want := []string{"Production", "Staging", "6n4qm2onchsinyyeuxmcfbo7ne"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitList() = %v, want %v", got, want)
+	}
+}
+
 func TestGetRecordPath(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -839,6 +1129,40 @@ func TestConfigProfiles(t *testing.T) {
 	}
 }
 
+func TestConfigProfileKeepsBooleanInputs(t *testing.T) {
+	t.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
+	t.Setenv("INPUT_VAULT", "test-vault")
+	t.Setenv("INPUT_RECORD", "secret/field")
+	t.Setenv("INPUT_PROFILE", ProfileProduction)
+	for _, key := range []string{"INPUT_OFFLINE", "INPUT_CLEANUP_FILES", "INPUT_VERIFY_OUTPUTS",
+		"INPUT_DISABLE_BINARY_CACHE", "INPUT_FAIL_FAST", "INPUT_JSON_ENV", "INPUT_BATCH_READ"} {
+		t.Setenv(key, trueString)
+	}
+
+	config, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+
+	if config.LogLevel != "warn" || config.MaxConcurrency != 3 {
+		t.Errorf("production profile not applied: %+v", config.SanitizeForLogging())
+	}
+	settings := map[string]bool{
+		"offline":              config.Offline,
+		"cleanup_files":        config.CleanupFiles,
+		"verify_outputs":       config.VerifyOutputs,
+		"disable_binary_cache": config.DisableBinaryCache,
+		"fail_fast":            config.FailFast,
+		"json_env":             config.JSONEnv,
+		"batch_read":           config.BatchRead,
+	}
+	for name, value := range settings {
+		if !value {
+			t.Errorf("%s was reset by the profile", name)
+		}
+	}
+}
+
 func TestConfigTimeouts(t *testing.T) {
 	config := &Config{
 		Timeout:        300,
@@ -867,6 +1191,293 @@ func TestConfigTimeouts(t *testing.T) {
 	}
 }
 
+func TestConfigAttemptInputs(t *testing.T) {
+	t.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
+	t.Setenv("INPUT_VAULT", "test-vault")
+	t.Setenv("INPUT_RECORD", "secret/field")
+	t.Setenv("INPUT_PROFILE", "")
+
+	t.Setenv("INPUT_DOWNLOAD_MAX_ATTEMPTS", "5")
+	t.Setenv("INPUT_RETRY_MAX_ATTEMPTS", " 2 ")
+	t.Setenv("INPUT_RETRY_BASE_DELAY", "4")
+	config, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if config.DownloadMaxAttempts != 5 || config.RetryMaxAttempts != 2 || config.RetryBaseDelay != 4 {
+		t.Errorf("attempts = %d/%d/%d, want 5/2/4",
+			config.DownloadMaxAttempts, config.RetryMaxAttempts, config.RetryBaseDelay)
+	}
+
+	// Inputs that are not a whole number of at least 1 are reported, not
+	// replaced by the default
+	t.Setenv("INPUT_DOWNLOAD_MAX_ATTEMPTS", "three")
+	t.Setenv("INPUT_RETRY_MAX_ATTEMPTS", "0")
+	t.Setenv("INPUT_RETRY_BASE_DELAY", "-1")
+	_, err = LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err == nil {
+		t.Fatal("LoadWithOptions() accepted invalid attempt inputs")
+	}
+	for _, want := range []string{`download_max_attempts "three"`, `retry_max_attempts "0"`, `retry_base_delay "-1"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadWithOptions() error = %v, want it to name %s", err, want)
+		}
+	}
+
+	// Out of range values are checked against the documented bounds
+	config = &Config{DownloadMaxAttempts: 11, RetryMaxAttempts: -1, RetryBaseDelay: 61, Timeout: 300,
+		RetryTimeout: 30, ConnectTimeout: 10}
+	if problems := config.validateTimeoutSettings(); len(problems) != 3 {
+		t.Errorf("validateTimeoutSettings() = %v, want 3 problems", problems)
+	}
+	config = &Config{Timeout: 300, RetryTimeout: 30, ConnectTimeout: 10}
+	if problems := config.validateTimeoutSettings(); len(problems) != 0 {
+		t.Errorf("validateTimeoutSettings() = %v, want the defaults (0) accepted", problems)
+	}
+}
+
+func TestConfigNumericInputs(t *testing.T) {
+	t.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
+	t.Setenv("INPUT_VAULT", "test-vault")
+	t.Setenv("INPUT_RECORD", "secret/field")
+	t.Setenv("INPUT_PROFILE", "")
+
+	inputs := map[string]string{
+		"INPUT_TIMEOUT":          "600",
+		"INPUT_RETRY_TIMEOUT":    "60",
+		"INPUT_CONNECT_TIMEOUT":  "20",
+		"INPUT_RECORD_TIMEOUT":   "30",
+		"INPUT_DOWNLOAD_TIMEOUT": "120",
+		"INPUT_MAX_CONCURRENCY":  "8",
+		"INPUT_CACHE_TTL":        "0",
+	}
+	for name, value := range inputs {
+		t.Setenv(name, value)
+	}
+	config, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	got := []int{config.Timeout, config.RetryTimeout, config.ConnectTimeout, config.RecordTimeout,
+		config.DownloadTimeout, config.MaxConcurrency, config.CacheTTL}
+	want := []int{600, 60, 20, 30, 120, 8, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("numeric inputs = %v, want %v", got, want)
+			break
+		}
+	}
+
+	// Garbage is reported for every input rather than ignored
+	for name := range inputs {
+		t.Setenv(name, "soon")
+	}
+	t.Setenv("INPUT_CACHE_TTL", "-5")
+	_, err = LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err == nil {
+		t.Fatal("LoadWithOptions() accepted invalid numeric inputs")
+	}
+	for _, want := range []string{`invalid timeout "soon"`, `retry_timeout "soon"`, `connect_timeout "soon"`,
+		`record_timeout "soon"`, `download_timeout "soon"`, `max_concurrency "soon"`, `cache_ttl "-5"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadWithOptions() error = %v, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestConfigOutputSchemaVersionInput(t *testing.T) {
+	t.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
+	t.Setenv("INPUT_VAULT", "test-vault")
+	t.Setenv("INPUT_RECORD", "secret/field")
+	t.Setenv("INPUT_PROFILE", "")
+
+	// Unset leaves the default to the consumers of the config
+	t.Setenv("INPUT_OUTPUT_SCHEMA_VERSION", "")
+	config, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if config.OutputSchemaVersion != 0 {
+		t.Errorf("OutputSchemaVersion = %d, want 0 (default)", config.OutputSchemaVersion)
+	}
+
+	t.Setenv("INPUT_OUTPUT_SCHEMA_VERSION", "2")
+	config, err = LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if config.OutputSchemaVersion != 2 {
+		t.Errorf("OutputSchemaVersion = %d, want 2", config.OutputSchemaVersion)
+	}
+
+	for _, value := range []string{"v2", "0", "-1"} {
+		t.Setenv("INPUT_OUTPUT_SCHEMA_VERSION", value)
+		_, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("output_schema_version %q", value)) {
+			t.Errorf("LoadWithOptions() with output_schema_version %q error = %v, want it reported", value, err)
+		}
+	}
+}
+
+func TestConfigLogLevelNormalized(t *testing.T) {
+	t.Setenv("INPUT_LOG_LEVEL", "")
+	t.Setenv("INPUT_PROFILE", "")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("log_level: \" WARN \"\n"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	// The loader normalizes the level
+	config, err := LoadWithOptions(LoadOptions{ConfigFile: configFile, ValidateOnly: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+	if config.LogLevel != "warn" {
+		t.Errorf("LogLevel = %q, want %q", config.LogLevel, "warn")
+	}
+
+	// Validation accepts any case but leaves the config as it is
+	config = &Config{LogLevel: "TRACE"}
+	if err := config.validateLogLevel(); err != nil {
+		t.Errorf("validateLogLevel() error = %v", err)
+	}
+	if config.LogLevel != "TRACE" {
+		t.Errorf("validateLogLevel() changed LogLevel to %q", config.LogLevel)
+	}
+}
+
+func TestConfigRecordTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeout       int
+		recordTimeout int
+		expected      time.Duration
+	}{
+		{"quarter of timeout", 300, 0, 75 * time.Second},
+		{"at least the minimum", 60, 0, 30 * time.Second},
+		{"never beyond timeout", 10, 0, 10 * time.Second},
+		{"explicit", 300, 20, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Timeout: tt.timeout, RecordTimeout: tt.recordTimeout}
+			if got := config.GetTimeout("record"); got != tt.expected {
+				t.Errorf("GetTimeout(record) = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigBatchMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchMode string
+		failFast  bool
+		effective string
+		wantErr   bool
+	}{
+		{"default", "", false, BatchModeAtomic, false},
+		{"fail_fast shorthand", "", true, BatchModeFailFast, false},
+		{"atomic with fail_fast", BatchModeAtomic, true, BatchModeFailFast, false},
+		{"best effort", BatchModeBestEffort, false, BatchModeBestEffort, false},
+		{"best effort with fail_fast", BatchModeBestEffort, true, BatchModeBestEffort, true},
+		{"unknown", "partial", false, BatchModeAtomic, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{BatchMode: tt.batchMode, FailFast: tt.failFast}
+			if err := config.validateBatchMode(); (err != nil) != tt.wantErr {
+				t.Errorf("validateBatchMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := config.EffectiveBatchMode(); got != tt.effective {
+				t.Errorf("EffectiveBatchMode() = %q, want %q", got, tt.effective)
+			}
+		})
+	}
+}
+
+func TestConfigStepSummary(t *testing.T) {
+	tests := []struct {
+		name          string
+		githubActions string
+		file          string
+		input         string
+		want          bool
+	}{
+		{"on by default in Actions", "true", "", "", true},
+		{"off by default elsewhere", "", "", "", false},
+		{"disabled in Actions", "true", "", "false", false},
+		{"enabled elsewhere", "", "", "true", true},
+		{"disabled by the file", "true", "step_summary: false\n", "", false},
+		{"input overrides the file", "true", "step_summary: false\n", "true", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", tt.githubActions)
+			t.Setenv("INPUT_STEP_SUMMARY", tt.input)
+			t.Setenv("INPUT_PROFILE", "")
+
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.file), 0600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			config, err := LoadWithOptions(LoadOptions{ConfigFile: configFile, ValidateOnly: true})
+			if err != nil {
+				t.Fatalf("LoadWithOptions() error = %v", err)
+			}
+			if got := config.StepSummaryEnabled(); got != tt.want {
+				t.Errorf("StepSummaryEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigWriteStepSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		input string
+		want  bool
+	}{
+		{"off by default", "", "", false},
+		{"enabled by the input", "", "true", true},
+		{"enabled by the file", "write_step_summary: true\n", "", true},
+		{"input overrides the file", "write_step_summary: true\n", "false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INPUT_WRITE_STEP_SUMMARY", tt.input)
+			t.Setenv("INPUT_PROFILE", "")
+
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.file), 0600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			config, err := LoadWithOptions(LoadOptions{ConfigFile: configFile, ValidateOnly: true})
+			if err != nil {
+				t.Fatalf("LoadWithOptions() error = %v", err)
+			}
+			if got := config.WriteStepSummaryEnabled(); got != tt.want {
+				t.Errorf("WriteStepSummaryEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A profile can turn the summaries off as well as on
+	on, off := true, false
+	config := &Config{WriteStepSummary: &on}
+	config.mergeConfig(&Config{WriteStepSummary: &off, StepSummary: &off})
+	if config.WriteStepSummaryEnabled() || config.StepSummaryEnabled() {
+		t.Error("mergeConfig() did not turn the step summaries off")
+	}
+}
+
 func TestConfigRefresh(t *testing.T) {
 	// Create initial config
 	_ = os.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
@@ -950,6 +1561,96 @@ func TestValidateEnhanced(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_concurrency must be between 1 and 20",
 		},
+		{
+			name: "unsupported output schema version",
+			config: Config{
+				Token:               testdata.GetValidDummyToken(),
+				Vault:               "test-vault",
+				Record:              "secret/field",
+				ReturnType:          ReturnTypeOutput,
+				LogLevel:            "info",
+				Timeout:             300,
+				RetryTimeout:        30,
+				ConnectTimeout:      10,
+				MaxConcurrency:      5,
+				CLIVersion:          "latest",
+				OutputSchemaVersion: LatestOutputSchemaVersion + 1,
+			},
+			wantErr: true,
+			errMsg:  "output_schema_version must be between 1 and 2",
+		},
+		{
+			name: "invalid log format",
+			config: Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          "test-vault",
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				LogFormat:      "xml",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+				CLIVersion:     "latest",
+			},
+			wantErr: true,
+			errMsg:  "invalid log_format",
+		},
+		{
+			name: "http download mirror without allow_insecure_download",
+			config: Config{
+				Token:              testdata.GetValidDummyToken(),
+				Vault:              "test-vault",
+				Record:             "secret/field",
+				ReturnType:         ReturnTypeOutput,
+				LogLevel:           "info",
+				Timeout:            300,
+				RetryTimeout:       30,
+				ConnectTimeout:     10,
+				MaxConcurrency:     5,
+				CLIVersion:         "latest",
+				CLIDownloadBaseURL: "http://mirror.internal/op2",
+			},
+			wantErr: true,
+			errMsg:  "http requires allow_insecure_download",
+		},
+		{
+			name: "proxy url without scheme",
+			config: Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          "test-vault",
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+				CLIVersion:     "latest",
+				ProxyURL:       "proxy.corp.example:3128",
+			},
+			wantErr: true,
+			errMsg:  "invalid proxy_url",
+		},
+		{
+			name: "invalid output name policy",
+			config: Config{
+				Token:            testdata.GetValidDummyToken(),
+				Vault:            "test-vault",
+				Record:           "secret/field",
+				ReturnType:       ReturnTypeOutput,
+				OutputNamePolicy: "kebab-case",
+				LogLevel:         "info",
+				Timeout:          300,
+				RetryTimeout:     30,
+				ConnectTimeout:   10,
+				MaxConcurrency:   5,
+				CLIVersion:       "latest",
+			},
+			wantErr: true,
+			errMsg:  "invalid output_name_policy",
+		},
 		{
 			name: "invalid CLI version",
 			config: Config{
@@ -967,6 +1668,24 @@ func TestValidateEnhanced(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid cli_version format",
 		},
+		{
+			name: "invalid allowed CLI version",
+			config: Config{
+				Token:              testdata.GetValidDummyToken(),
+				Vault:              "test-vault",
+				Record:             "secret/field",
+				ReturnType:         ReturnTypeOutput,
+				LogLevel:           "info",
+				Timeout:            300,
+				RetryTimeout:       30,
+				ConnectTimeout:     10,
+				MaxConcurrency:     5,
+				CLIVersion:         "latest",
+				AllowedCLIVersions: []string{"2.31.1", "latest"},
+			},
+			wantErr: true,
+			errMsg:  "invalid allowed_cli_versions entry",
+		},
 		{
 			name: "valid semver CLI version",
 			config: Config{
@@ -983,6 +1702,22 @@ func TestValidateEnhanced(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "cli version with pre-release and build metadata",
+			config: Config{
+				Token:          testdata.GetValidDummyToken(),
+				Vault:          "test-vault",
+				Record:         "secret/field",
+				ReturnType:     ReturnTypeOutput,
+				LogLevel:       "info",
+				Timeout:        300,
+				RetryTimeout:   30,
+				ConnectTimeout: 10,
+				MaxConcurrency: 5,
+				CLIVersion:     "2.31.1-beta.01+build5",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1103,3 +1838,285 @@ func TestListProfiles(t *testing.T) {
 		}
 	}
 }
+
+// tokenFileConfig returns an otherwise valid config reading its token from path.
+func tokenFileConfig(path string) *Config {
+	return &Config{
+		TokenFile:      path,
+		Vault:          "test-vault",
+		Record:         "secret/field",
+		ReturnType:     ReturnTypeOutput,
+		LogLevel:       "info",
+		Timeout:        300,
+		RetryTimeout:   30,
+		ConnectTimeout: 10,
+		MaxConcurrency: 5,
+		CLIVersion:     "latest",
+	}
+}
+
+// resolveAndValidate resolves the inputs of config and validates it, as
+// LoadWithOptions does.
+func resolveAndValidate(config *Config) error {
+	if err := config.ResolveInputs(); err != nil {
+		return err
+	}
+	return config.Validate()
+}
+
+func TestValidate_TokenFile(t *testing.T) {
+	token := testdata.GetValidDummyToken()
+	path := t.TempDir() + "/op-token"
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	// Validation alone reads nothing
+	config := tokenFileConfig(path)
+	if err := config.Validate(); err == nil || config.Token != "" {
+		t.Fatalf("Validate() read token_file: error = %v", err)
+	}
+
+	if err := resolveAndValidate(config); err != nil {
+		t.Fatalf("resolveAndValidate() error = %v", err)
+	}
+	if config.Token != token {
+		t.Errorf("Expected token read from file with trailing newline trimmed")
+	}
+	if len(config.Warnings) != 0 {
+		t.Errorf("Expected no warnings for a 0600 token file, got %v", config.Warnings)
+	}
+}
+
+func TestValidate_TokenFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	valid := dir + "/valid"
+	empty := dir + "/empty"
+	if err := os.WriteFile(valid, []byte(testdata.GetValidDummyToken()), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		inlineToken bool
+		errContains string
+	}{
+		{name: "token and token_file", path: valid, inlineToken: true, errContains: "mutually exclusive"},
+		{name: "missing file", path: dir + "/missing", errContains: "failed to read token_file"},
+		{name: "empty file", path: empty, errContains: "is empty"},
+		{name: "directory", path: dir, errContains: "not a regular file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tokenFileConfig(tt.path)
+			if tt.inlineToken {
+				config.Token = testdata.GetValidDummyToken()
+			}
+			err := resolveAndValidate(config)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("resolveAndValidate() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestValidate_TokenFilePermissionWarning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permission bits are not enforced on Windows")
+	}
+
+	path := t.TempDir() + "/op-token"
+	if err := os.WriteFile(path, []byte(testdata.GetValidDummyToken()), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatalf("Failed to chmod token file: %v", err)
+	}
+
+	config := tokenFileConfig(path)
+	if err := resolveAndValidate(config); err != nil {
+		t.Fatalf("resolveAndValidate() error = %v", err)
+	}
+	if len(config.Warnings) != 1 || !strings.Contains(config.Warnings[0], "0644") {
+		t.Errorf("Expected a permissions warning, got %v", config.Warnings)
+	}
+}
+
+// useTokenStdin makes token_source stdin read input from a pipe, or wait on
+// an open pipe when input is nil, with a short timeout.
+func useTokenStdin(t *testing.T, input *string) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	t.Cleanup(func() { _ = reader.Close(); _ = writer.Close() })
+	if input != nil {
+		if _, err := writer.WriteString(*input); err != nil {
+			t.Fatalf("Failed to write pipe: %v", err)
+		}
+		_ = writer.Close()
+	}
+
+	oldStdin, oldTimeout := tokenStdin, stdinTokenTimeout
+	tokenStdin, stdinTokenTimeout = reader, 50*time.Millisecond
+	t.Cleanup(func() { tokenStdin, stdinTokenTimeout = oldStdin, oldTimeout })
+}
+
+func TestValidate_TokenStdin(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	token := testdata.GetValidDummyToken()
+	input := "  " + token + "\nsecond line\n"
+	useTokenStdin(t, &input)
+
+	// Validation alone leaves standard input unread
+	config := tokenFileConfig("")
+	config.TokenSource = TokenSourceStdin
+	if err := config.Validate(); err == nil || config.Token != "" {
+		t.Fatalf("Validate() read standard input: error = %v", err)
+	}
+
+	if err := resolveAndValidate(config); err != nil {
+		t.Fatalf("resolveAndValidate() error = %v", err)
+	}
+	if config.Token != token {
+		t.Errorf("Expected the first line of standard input, trimmed")
+	}
+}
+
+func TestValidate_TokenStdinErrors(t *testing.T) {
+	empty := "\n"
+	valid := testdata.GetValidDummyToken()
+
+	tests := []struct {
+		name          string
+		source        string
+		input         *string
+		githubActions bool
+		inlineToken   bool
+		errContains   string
+	}{
+		{name: "unknown source", source: "keychain", input: &valid, errContains: "invalid token_source"},
+		{name: "github actions", source: TokenSourceStdin, input: &valid, githubActions: true, errContains: "local runs only"},
+		{name: "with inline token", source: TokenSourceStdin, input: &valid, inlineToken: true, errContains: "cannot be combined"},
+		{name: "empty input", source: TokenSourceStdin, input: &empty, errContains: "no token received"},
+		{name: "no input", source: TokenSourceStdin, errContains: "no token received within"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.githubActions {
+				t.Setenv("GITHUB_ACTIONS", "true")
+			} else {
+				t.Setenv("GITHUB_ACTIONS", "")
+			}
+			useTokenStdin(t, tt.input)
+
+			config := tokenFileConfig("")
+			config.TokenSource = tt.source
+			if tt.inlineToken {
+				config.Token = valid
+			}
+			err := resolveAndValidate(config)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("resolveAndValidate() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestInterpolateRecord(t *testing.T) {
+	t.Setenv("OP_TEST_SERVICE", "payments")
+	t.Setenv("OP_TEST_PATH", "Production/Stripe")
+	t.Setenv("OP_TEST_EMPTY", "")
+
+	tests := []struct {
+		name          string
+		mode          string
+		record        string
+		want          string
+		wantWarning   bool
+		wantErrSubstr string
+	}{
+		{name: "off", mode: "", record: "${OP_TEST_SERVICE}/password", want: "${OP_TEST_SERVICE}/password"},
+		{name: "expands", mode: RecordInterpolationEnv, record: "${OP_TEST_SERVICE}-db/password", want: "payments-db/password"},
+		{name: "escaped", mode: RecordInterpolationStrict, record: "$${OP_TEST_SERVICE}/password", want: "${OP_TEST_SERVICE}/password"},
+		{name: "set but empty", mode: RecordInterpolationStrict, record: "db${OP_TEST_EMPTY}/password", want: "db/password"},
+		{name: "unbraced left alone", mode: RecordInterpolationStrict, record: "$OP_TEST_SERVICE/password", want: "$OP_TEST_SERVICE/password"},
+		{name: "undefined expands to nothing", mode: RecordInterpolationEnv, record: "db${OP_TEST_UNSET}/password", want: "db/password", wantWarning: true},
+		{name: "undefined in strict mode", mode: RecordInterpolationStrict, record: "${OP_TEST_UNSET_B}/${OP_TEST_UNSET_A}", wantErrSubstr: "OP_TEST_UNSET_A, OP_TEST_UNSET_B"},
+		{name: "unknown mode", mode: "shell", record: "db/password", wantErrSubstr: "invalid record_interpolation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Record: tt.record, RecordInterpolation: tt.mode}
+			_ = config.Validate()
+			if config.Record != tt.record {
+				t.Fatalf("Validate() expanded the record to %q", config.Record)
+			}
+
+			err := config.validateRecordInterpolation()
+			if err == nil {
+				err = config.interpolateRecord()
+			}
+			if tt.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+					t.Fatalf("interpolateRecord() error = %v, want error containing %q", err, tt.wantErrSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolateRecord() error = %v", err)
+			}
+			if config.Record != tt.want {
+				t.Errorf("Record = %q, want %q", config.Record, tt.want)
+			}
+			if got := len(config.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("Warnings = %v, want warning %v", config.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestLoad_RecordInterpolation(t *testing.T) {
+	t.Setenv("INPUT_TOKEN", testdata.GetValidDummyToken())
+	t.Setenv("INPUT_VAULT", "")
+	t.Setenv("INPUT_RECORD", "stripe_key: ${OP_TEST_ITEM}/credential\ndb: op://Shared/${OP_TEST_DB}/password")
+	t.Setenv("INPUT_RECORD_INTERPOLATION", RecordInterpolationStrict)
+	t.Setenv("OP_TEST_ITEM", "Production/Stripe")
+	t.Setenv("OP_TEST_DB", "Main%20Database")
+
+	config, err := LoadWithOptions(LoadOptions{IgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+
+	// The vault input is not needed, as both expanded records name vaults
+	want := map[string]RecordRequest{
+		"stripe_key": {Vault: "Production", Item: "Stripe", Field: "credential"},
+		"db":         {Vault: "Shared", Item: "Main Database", Field: "password"},
+	}
+	if len(config.RecordRequests) != len(want) {
+		t.Fatalf("RecordRequests = %+v, want %d records", config.RecordRequests, len(want))
+	}
+	for _, request := range config.RecordRequests {
+		w := want[request.OutputName]
+		if request.Vault != w.Vault || request.Item != w.Item || request.Field != w.Field {
+			t.Errorf("record %s = %s/%s/%s, want %s/%s/%s", request.OutputName,
+				request.Vault, request.Item, request.Field, w.Vault, w.Item, w.Field)
+		}
+	}
+
+	t.Setenv("OP_TEST_DB", "")
+	_ = os.Unsetenv("OP_TEST_DB")
+	if _, err := LoadWithOptions(LoadOptions{IgnoreFiles: true}); err == nil ||
+		!strings.Contains(err.Error(), "OP_TEST_DB") {
+		t.Errorf("LoadWithOptions() error = %v, want undefined OP_TEST_DB", err)
+	}
+}
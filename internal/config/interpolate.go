@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Record interpolation modes
+const (
+	// RecordInterpolationEnv expands ${VAR} references in the record from
+	// the environment; an undefined variable expands to nothing, with a
+	// warning
+	RecordInterpolationEnv = "env"
+	// RecordInterpolationStrict is RecordInterpolationEnv, but an undefined
+	// variable is an error
+	RecordInterpolationStrict = "strict"
+)
+
+// recordVariablePattern matches a ${VAR} reference, or the $${VAR} escape
+// for a literal ${VAR}.
+var recordVariablePattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolateRecord expands ${VAR} references in Record from the process
+// environment when RecordInterpolation is set, so that records can name
+// items computed by the workflow. Expansion is plain text substitution
+// before the record is parsed, so a slash in a value separates segments of
+// the reference as if it were written out. $${VAR} is left as a literal
+// ${VAR}.
+func (c *Config) interpolateRecord() error {
+	if c.RecordInterpolation != RecordInterpolationEnv && c.RecordInterpolation != RecordInterpolationStrict {
+		return nil
+	}
+
+	record, undefined := expandRecordVariables(c.Record)
+	if len(undefined) > 0 {
+		if c.RecordInterpolation == RecordInterpolationStrict {
+			return fmt.Errorf("record references undefined environment variables: %s",
+				strings.Join(undefined, ", "))
+		}
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"record references undefined environment variables, expanded to empty: %s",
+			strings.Join(undefined, ", ")))
+	}
+
+	c.Record = record
+	return nil
+}
+
+// validateRecordInterpolation validates the record interpolation setting
+func (c *Config) validateRecordInterpolation() error {
+	switch c.RecordInterpolation {
+	case "", RecordInterpolationEnv, RecordInterpolationStrict:
+		return nil
+	}
+	return fmt.Errorf("invalid record_interpolation: must be empty, %s or %s",
+		RecordInterpolationEnv, RecordInterpolationStrict)
+}
+
+// expandRecordVariables expands the ${VAR} references of record and
+// returns the sorted names of those not set in the environment.
+func expandRecordVariables(record string) (string, []string) {
+	seen := make(map[string]bool)
+	var undefined []string
+	expanded := recordVariablePattern.ReplaceAllStringFunc(record, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		name := match[2 : len(match)-1]
+		value, ok := os.LookupEnv(name)
+		if !ok && !seen[name] {
+			seen[name] = true
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	sort.Strings(undefined)
+	return expanded, undefined
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// maxTokenFileSize bounds how much of token_file is read; a service account
+// token is well under 1KB.
+const maxTokenFileSize = 64 * 1024
+
+// loadTokenFile reads the service account token from TokenFile, so that the
+// token never has to appear in the environment or a process listing. A
+// trailing newline is trimmed. Setting both token and token_file is an error.
+// Permissions broader than owner-only are recorded in Warnings.
+func (c *Config) loadTokenFile() error {
+	if c.TokenFile == "" {
+		return nil
+	}
+	if c.Token != "" {
+		return fmt.Errorf("token and token_file are mutually exclusive; set only one")
+	}
+
+	info, err := os.Stat(c.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read token_file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("token_file %s is not a regular file", c.TokenFile)
+	}
+	// Windows does not report POSIX permission bits
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"token_file %s has permissions %04o; restrict it to the current user with chmod 600",
+			c.TokenFile, info.Mode().Perm()))
+	}
+
+	// #nosec G304 -- token_file is an explicit user-supplied path to their own token
+	file, err := os.Open(c.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read token_file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxTokenFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read token_file: %w", err)
+	}
+	if len(data) > maxTokenFileSize {
+		return fmt.Errorf("token_file %s exceeds %d bytes", c.TokenFile, maxTokenFileSize)
+	}
+
+	token := strings.TrimRight(string(data), "\r\n")
+	for i := range data {
+		data[i] = 0
+	}
+	if strings.TrimSpace(token) == "" {
+		return fmt.Errorf("token_file %s is empty", c.TokenFile)
+	}
+
+	c.Token = token
+	return nil
+}
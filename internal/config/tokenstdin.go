@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TokenSourceStdin reads the service account token from standard input.
+const TokenSourceStdin = "stdin"
+
+// stdinTokenTimeout bounds the wait for a token on standard input, so that a
+// run nobody feeds does not hang.
+var stdinTokenTimeout = 30 * time.Second
+
+// tokenStdin and tokenPrompt are where token_source "stdin" reads the token
+// and prompts for it; tests replace them.
+var (
+	tokenStdin            = os.Stdin
+	tokenPrompt io.Writer = os.Stderr
+)
+
+// loadTokenStdin reads the service account token from standard input when
+// TokenSource is TokenSourceStdin, for running the binary locally without
+// the token in the environment. Only the first line is read, trimmed of
+// surrounding whitespace. It refuses to run under GitHub Actions, where
+// nothing feeds standard input, and gives up after stdinTokenTimeout. A
+// terminal is prompted first rather than read silently.
+func (c *Config) loadTokenStdin() error {
+	if c.TokenSource != TokenSourceStdin {
+		return nil
+	}
+	if os.Getenv("GITHUB_ACTIONS") == trueString {
+		return fmt.Errorf("token_source %s is for local runs only; pass the token with the token input under GitHub Actions",
+			TokenSourceStdin)
+	}
+	if c.Token != "" || c.TokenFile != "" {
+		return fmt.Errorf("token_source %s cannot be combined with token or token_file", TokenSourceStdin)
+	}
+
+	if info, err := tokenStdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		_, _ = fmt.Fprint(tokenPrompt, "1Password service account token: ")
+	}
+
+	token, err := readTokenLine(tokenStdin, stdinTokenTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to read token from standard input: %w", err)
+	}
+
+	c.Token = token
+	return nil
+}
+
+// validateTokenSource validates the token source setting
+func (c *Config) validateTokenSource() error {
+	switch c.TokenSource {
+	case "", TokenSourceStdin:
+		return nil
+	}
+	return fmt.Errorf("invalid token_source: must be empty or %s", TokenSourceStdin)
+}
+
+// readTokenLine reads the first line of r, trimmed, waiting at most
+// timeout. A read still blocked on timeout is abandoned.
+func readTokenLine(r io.Reader, timeout time.Duration) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(io.LimitReader(r, maxTokenFileSize)).ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		done <- result{line: line, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return "", res.err
+		}
+		token := strings.TrimSpace(res.line)
+		if token == "" {
+			return "", fmt.Errorf("no token received")
+		}
+		return token, nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("no token received within %s", timeout)
+	}
+}
@@ -0,0 +1,641 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+// Package connect provides a secret backend that reads from a 1Password
+// Connect server over its REST API, for runners that cannot download or run
+// the 1Password CLI.
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/proxy"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// DefaultTimeout bounds each request to the Connect server.
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseSize caps how much of a Connect response is read; a larger
+// response is an error.
+const maxResponseSize = 10 * 1024 * 1024
+
+// idPattern matches 1Password vault and item IDs.
+var idPattern = regexp.MustCompile(`^[a-z0-9]{26}$`)
+
+// Client reads secrets from a 1Password Connect server.
+type Client struct {
+	host       string
+	token      *security.SecureString
+	httpClient *http.Client
+	mu         sync.Mutex
+	vaults     []VaultInfo // Cached vault list, loaded on first use
+}
+
+// Config holds configuration for the Connect client.
+type Config struct {
+	Host       string                 // Base URL of the Connect server
+	Token      *security.SecureString // Connect access token
+	Timeout    time.Duration          // Per-request timeout (default 30s)
+	HTTPClient *http.Client           // Optional client, e.g. for tests
+	ProxyURL   string                 // Proxy overriding HTTPS_PROXY/HTTP_PROXY; NO_PROXY still applies
+}
+
+// VaultInfo describes a vault visible to the Connect token.
+type VaultInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// item is the subset of a Connect item used to resolve fields.
+type item struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Category string    `json:"category"`
+	Sections []section `json:"sections"`
+	Fields   []field   `json:"fields"`
+}
+
+// section is a named group of fields within a Connect item.
+type section struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// field is a single field of a Connect item. For fields of type OTP the
+// server returns the current one-time password in TOTP.
+type field struct {
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Label   string      `json:"label"`
+	Value   string      `json:"value"`
+	TOTP    string      `json:"totp"`
+	Section *sectionRef `json:"section,omitempty"`
+}
+
+// sectionRef links a field to the section holding it.
+type sectionRef struct {
+	ID string `json:"id"`
+}
+
+// file is a file attached to a Connect item.
+type file struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// NewClient creates a Connect client.
+func NewClient(cfg *Config) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("connect config is required")
+	}
+	if cfg.Token == nil || cfg.Token.IsEmpty() {
+		return nil, fmt.Errorf("connect token is required")
+	}
+
+	host, err := url.Parse(strings.TrimSpace(cfg.Host))
+	if err != nil || host.Host == "" || (host.Scheme != "http" && host.Scheme != "https") {
+		return nil, fmt.Errorf("connect host must be an http(s) URL")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		proxyFunc, err := proxy.Func(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = proxyFunc
+		httpClient = &http.Client{Timeout: timeout, Transport: transport}
+	}
+
+	return &Client{
+		host:       strings.TrimSuffix(host.String(), "/"),
+		token:      cfg.Token,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Authenticate verifies that the Connect server accepts the token.
+func (c *Client) Authenticate(ctx context.Context) error {
+	_, err := c.listVaults(ctx)
+	return err
+}
+
+// ResolveVault finds a vault by ID or name. An identifier in the vault ID
+// format is matched by ID only. A name shared by several vaults is an error
+// listing their IDs.
+func (c *Client) ResolveVault(ctx context.Context, identifier string) (*VaultInfo, error) {
+	vaults, err := c.listVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range vaults {
+		if vaults[i].ID == identifier {
+			return &vaults[i], nil
+		}
+	}
+
+	if !idPattern.MatchString(identifier) {
+		var matches []int
+		for i := range vaults {
+			if vaults[i].Name == identifier {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 1 {
+			return &vaults[matches[0]], nil
+		}
+		if len(matches) > 1 {
+			ids := make([]string, 0, len(matches))
+			for _, i := range matches {
+				ids = append(ids, vaults[i].ID)
+			}
+			return nil, errors.NewVaultAmbiguousError(identifier, ids)
+		}
+	}
+
+	return nil, errors.NewAuthenticationError(
+		errors.ErrCodeVaultNotFound,
+		fmt.Sprintf("vault '%s' not found on Connect server", identifier),
+		nil,
+	)
+}
+
+// Resolve implements secrets.SecretResolver.
+func (c *Client) Resolve(ctx context.Context, ref secrets.SecretRef) ([]byte, error) {
+	vault, err := c.ResolveVault(ctx, ref.Vault)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := c.getItem(ctx, vault, ref.Item)
+	if err != nil {
+		return nil, err
+	}
+	defer it.zero()
+
+	if name, ok := validation.AttachmentName(ref.Field); ok {
+		return c.getAttachment(ctx, vault, it, name)
+	}
+	if validation.IsDocumentField(ref.Field) {
+		return c.getDocument(ctx, vault, it)
+	}
+
+	if validation.IsOTPField(ref.Field) {
+		if f := it.findOTPField(); f != nil {
+			return []byte(f.TOTP), nil
+		}
+		return nil, errors.NewSecretError(
+			errors.ErrCodeFieldNotFound,
+			fmt.Sprintf("item '%s' has no one-time password", ref.Item),
+			nil,
+		)
+	}
+
+	f, err := it.findField(ref.Section, ref.Field)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		return []byte(f.Value), nil
+	}
+
+	message := fmt.Sprintf("field '%s' not found in item '%s'", ref.Field, ref.Item)
+	if ref.Section != "" {
+		message = fmt.Sprintf("field '%s' not found in section '%s' of item '%s'", ref.Field, ref.Section, ref.Item)
+	}
+	return nil, errors.NewSecretError(errors.ErrCodeFieldNotFound, message, nil)
+}
+
+// ListFields implements secrets.FieldLister.
+func (c *Client) ListFields(ctx context.Context, ref secrets.SecretRef) ([]secrets.ItemField, error) {
+	vault, err := c.ResolveVault(ctx, ref.Vault)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := c.getItem(ctx, vault, ref.Item)
+	if err != nil {
+		return nil, err
+	}
+	defer it.zero()
+
+	fields := make([]secrets.ItemField, 0, len(it.Fields))
+	for _, f := range it.Fields {
+		fields = append(fields, secrets.ItemField{ID: f.ID, Label: f.Label})
+	}
+	return fields, nil
+}
+
+// LocateItem implements secrets.ItemLocator by searching every vault visible
+// to the token for items with the given title or ID.
+func (c *Client) LocateItem(ctx context.Context, identifier string) ([]secrets.ItemMatch, error) {
+	vaults, err := c.listVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []secrets.ItemMatch
+	for _, vault := range vaults {
+		itemsPath := "/v1/vaults/" + url.PathEscape(vault.ID) + "/items"
+
+		var found []item
+		query := url.Values{"filter": {fmt.Sprintf("title eq %q", identifier)}}
+		if err := c.get(ctx, itemsPath, query, "item", &found); err != nil {
+			return nil, err
+		}
+		if len(found) == 0 && idPattern.MatchString(identifier) {
+			var it item
+			err := c.get(ctx, itemsPath+"/"+url.PathEscape(identifier), nil, "item", &it)
+			if err == nil {
+				it.zero()
+				found = append(found, it)
+			} else if !isNotFound(err) {
+				return nil, err
+			}
+		}
+
+		for _, it := range found {
+			matches = append(matches, secrets.ItemMatch{VaultID: vault.ID, VaultName: vault.Name, ItemID: it.ID})
+		}
+	}
+	return matches, nil
+}
+
+// Destroy releases the client's token.
+func (c *Client) Destroy() error {
+	if c.token != nil {
+		return c.token.Destroy()
+	}
+	return nil
+}
+
+// listVaults returns the vaults visible to the token, caching the result.
+func (c *Client) listVaults(ctx context.Context) ([]VaultInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.vaults != nil {
+		return c.vaults, nil
+	}
+
+	var vaults []VaultInfo
+	if err := c.get(ctx, "/v1/vaults", nil, "vault", &vaults); err != nil {
+		return nil, err
+	}
+	if vaults == nil {
+		vaults = []VaultInfo{}
+	}
+	c.vaults = vaults
+	return vaults, nil
+}
+
+// getItem finds an item by title, or by ID when no title matches.
+func (c *Client) getItem(ctx context.Context, vault *VaultInfo, identifier string) (*item, error) {
+	itemsPath := "/v1/vaults/" + url.PathEscape(vault.ID) + "/items"
+
+	var matches []item
+	query := url.Values{"filter": {fmt.Sprintf("title eq %q", identifier)}}
+	if err := c.get(ctx, itemsPath, query, "item", &matches); err != nil {
+		return nil, err
+	}
+
+	itemID := ""
+	switch {
+	case len(matches) == 1:
+		itemID = matches[0].ID
+	case len(matches) > 1:
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretNotFound,
+			fmt.Sprintf("item title '%s' matches %d items in vault '%s'; reference the item by ID",
+				identifier, len(matches), vault.Name),
+			nil,
+		)
+	case idPattern.MatchString(identifier):
+		itemID = identifier
+	default:
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretNotFound,
+			fmt.Sprintf("item '%s' not found in vault '%s'", identifier, vault.Name),
+			nil,
+		)
+	}
+
+	var it item
+	if err := c.get(ctx, itemsPath+"/"+url.PathEscape(itemID), nil, "item", &it); err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
+
+// getAttachment downloads the file attached to an item under name.
+func (c *Client) getAttachment(ctx context.Context, vault *VaultInfo, it *item, name string) ([]byte, error) {
+	filesPath := "/v1/vaults/" + url.PathEscape(vault.ID) + "/items/" + url.PathEscape(it.ID) + "/files"
+
+	var files []file
+	if err := c.get(ctx, filesPath, nil, "item", &files); err != nil {
+		return nil, err
+	}
+
+	found := findFile(files, name)
+	if found == nil {
+		names := make([]string, 0, len(files))
+		for _, f := range files {
+			names = append(names, f.Name)
+		}
+		return nil, errors.NewAttachmentNotFoundError(it.Title, name, names)
+	}
+
+	return c.fetch(ctx, filesPath+"/"+url.PathEscape(found.ID)+"/content", nil, "file", "application/octet-stream")
+}
+
+// getDocument downloads the file of a Document item, which Connect lists as
+// the item's only file.
+func (c *Client) getDocument(ctx context.Context, vault *VaultInfo, it *item) ([]byte, error) {
+	if !strings.EqualFold(it.Category, "DOCUMENT") {
+		return nil, fmt.Errorf("item '%s' is a %s item, not a document; "+
+			"read its attached files with vault/item/file:<name>", it.Title, it.Category)
+	}
+
+	filesPath := "/v1/vaults/" + url.PathEscape(vault.ID) + "/items/" + url.PathEscape(it.ID) + "/files"
+
+	var files []file
+	if err := c.get(ctx, filesPath, nil, "item", &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("document '%s' has no file", it.Title)
+	}
+
+	return c.fetch(ctx, filesPath+"/"+url.PathEscape(files[0].ID)+"/content", nil, "file", "application/octet-stream")
+}
+
+// findFile returns the file with the given name, matched exactly and then
+// case-insensitively.
+func findFile(files []file, name string) *file {
+	for i := range files {
+		if files[i].Name == name {
+			return &files[i]
+		}
+	}
+	for i := range files {
+		if strings.EqualFold(files[i].Name, name) {
+			return &files[i]
+		}
+	}
+	return nil
+}
+
+// get performs an authenticated GET and decodes the JSON response into out.
+// kind names the resource for not-found errors.
+func (c *Client) get(ctx context.Context, path string, query url.Values, kind string, out interface{}) error {
+	body, err := c.fetch(ctx, path, query, kind, "application/json")
+	if err != nil {
+		return err
+	}
+	defer security.SecureZero(body)
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return errors.Wrap(errors.ErrCodeAPIError, "invalid response from Connect server", err)
+	}
+	return nil
+}
+
+// fetch performs an authenticated GET and returns the response body, which
+// the caller must zero. kind names the resource for not-found errors.
+func (c *Client) fetch(ctx context.Context, path string, query url.Values, kind, accept string) ([]byte, error) {
+	endpoint := c.host + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCodeConnectionFailed, "failed to build Connect request", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token.String())
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(errors.ErrCodeTimeout, "Connect request timed out", err)
+		}
+		return nil, errors.Wrap(errors.ErrCodeConnectionFailed,
+			"failed to connect to 1Password Connect server", err).
+			WithSuggestions("Check that connect_host is reachable from the runner")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCodeConnectionFailed, "failed to read Connect response", err)
+	}
+	if len(body) > maxResponseSize {
+		// A truncated file must not be delivered as if it were complete
+		security.SecureZero(body)
+		return nil, errors.Wrap(errors.ErrCodeAPIError,
+			fmt.Sprintf("Connect %s response exceeds %d bytes", kind, maxResponseSize), nil)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer security.SecureZero(body)
+		return nil, statusError(resp.StatusCode, kind, apiMessage(body, resp.StatusCode)).
+			WithRetryAfter(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return body, nil
+}
+
+// statusError maps a Connect HTTP status onto the action's error codes.
+func statusError(status int, kind, message string) *errors.ActionableError {
+	cause := fmt.Errorf("connect server returned HTTP %d: %s", status, message)
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return errors.NewAuthenticationError(errors.ErrCodeTokenInvalid,
+			"Connect token was rejected", cause)
+	case status == http.StatusForbidden:
+		return errors.NewAuthenticationError(errors.ErrCodePermissionDenied,
+			"Connect token is not permitted to access this "+kind, cause)
+	case status == http.StatusNotFound && kind == "vault":
+		return errors.NewAuthenticationError(errors.ErrCodeVaultNotFound,
+			"vault not found on Connect server", cause)
+	case status == http.StatusNotFound:
+		return errors.NewSecretError(errors.ErrCodeSecretNotFound,
+			kind+" not found on Connect server", cause)
+	case status == http.StatusTooManyRequests:
+		return errors.Wrap(errors.ErrCodeRateLimited,
+			"Connect server rate limit exceeded", cause)
+	default:
+		return errors.Wrap(errors.ErrCodeAPIError,
+			fmt.Sprintf("Connect server request failed with HTTP %d", status), cause)
+	}
+}
+
+// parseRetryAfter converts a Retry-After header, given either in seconds or
+// as an HTTP date, into a wait. A missing, malformed or past value yields
+// zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+	return 0
+}
+
+// apiMessage extracts the message from a Connect error body.
+func apiMessage(body []byte, status int) string {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return http.StatusText(status)
+}
+
+// isNotFound reports whether err is a Connect not-found error.
+func isNotFound(err error) bool {
+	actionableErr, ok := err.(*errors.ActionableError)
+	return ok && actionableErr.Code == errors.ErrCodeSecretNotFound
+}
+
+// findField returns the field with the given label or ID, searching only the
+// section with the given label or ID when sectionName is set. An ID or exact
+// label match wins over a case-insensitive one. A label matching fields in
+// several sections is ambiguous and reported with their names instead of
+// picking one. It returns nil when no field matches.
+func (it *item) findField(sectionName, name string) (*field, error) {
+	var candidates []*field
+	if sectionName == "" {
+		for i := range it.Fields {
+			candidates = append(candidates, &it.Fields[i])
+		}
+	} else {
+		ids := it.sectionIDs(sectionName)
+		for i := range it.Fields {
+			if f := &it.Fields[i]; f.Section != nil && ids[f.Section.ID] {
+				candidates = append(candidates, f)
+			}
+		}
+	}
+
+	for _, f := range candidates {
+		if f.ID == name {
+			return f, nil
+		}
+	}
+	for _, match := range []func(label string) bool{
+		func(label string) bool { return label == name },
+		func(label string) bool { return strings.EqualFold(label, name) },
+	} {
+		var matches []*field
+		for _, f := range candidates {
+			if match(f.Label) {
+				matches = append(matches, f)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if sections := it.sectionNames(matches); len(sections) > 1 {
+			return nil, errors.NewFieldAmbiguousError(it.Title, name, sections)
+		}
+		return matches[0], nil
+	}
+	return nil, nil
+}
+
+// sectionIDs returns the IDs of the sections with the given label or ID.
+// Exact label matches win over case-insensitive ones.
+func (it *item) sectionIDs(name string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, s := range it.Sections {
+		if s.Label == name || s.ID == name {
+			ids[s.ID] = true
+		}
+	}
+	if len(ids) == 0 {
+		for _, s := range it.Sections {
+			if strings.EqualFold(s.Label, name) {
+				ids[s.ID] = true
+			}
+		}
+	}
+	return ids
+}
+
+// sectionNames returns the distinct names of the sections holding fields,
+// "(no section)" standing for fields outside any section.
+func (it *item) sectionNames(fields []*field) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, f := range fields {
+		name := "(no section)"
+		if f.Section != nil {
+			name = f.Section.ID
+			for _, s := range it.Sections {
+				if s.ID == f.Section.ID && s.Label != "" {
+					name = s.Label
+				}
+			}
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// findOTPField returns the first one-time password field holding a code.
+func (it *item) findOTPField() *field {
+	for i := range it.Fields {
+		if strings.EqualFold(it.Fields[i].Type, "OTP") && it.Fields[i].TOTP != "" {
+			return &it.Fields[i]
+		}
+	}
+	return nil
+}
+
+// zero drops references to the item's field values.
+func (it *item) zero() {
+	for i := range it.Fields {
+		it.Fields[i].Value = ""
+		it.Fields[i].TOTP = ""
+	}
+}
+
+// Ensure Client implements the resolver interface
+var _ secrets.SecretResolver = (*Client)(nil)
+var _ secrets.ItemLocator = (*Client)(nil)
+var _ secrets.FieldLister = (*Client)(nil)
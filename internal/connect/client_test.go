@@ -0,0 +1,456 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+const (
+	testConnectToken = "connect-test-token"
+	testVaultID      = "abcdefghijklmnopqrstuvwxy1"
+	testItemID       = "abcdefghijklmnopqrstuvwxy2"
+)
+
+// newFakeConnect serves a single vault "ci" holding an item "database" with
+// a password field, a host field in its "replica" section, and the attached
+// files signing.key and large.bin, which is larger than Connect responses may
+// be. status, when non-zero, is returned for every request.
+func newFakeConnect(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testConnectToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"message":"Invalid token signature"}`))
+			return
+		}
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "7")
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"status":0,"message":"injected failure"}`))
+			return
+		}
+
+		itemsPath := "/v1/vaults/" + testVaultID + "/items"
+		var body interface{}
+		switch r.URL.Path {
+		case "/v1/vaults":
+			body = []map[string]string{{"id": testVaultID, "name": "ci"}}
+		case itemsPath:
+			body = []map[string]string{}
+			if r.URL.Query().Get("filter") == `title eq "database"` {
+				body = []map[string]string{{"id": testItemID, "title": "database"}}
+			}
+		case itemsPath + "/" + testItemID + "/files":
+			body = []map[string]interface{}{
+				{"id": "filesigning", "name": "signing.key", "size": 7},
+				{"id": "filelarge", "name": "large.bin", "size": maxResponseSize + 1},
+			}
+		case itemsPath + "/" + testItemID + "/files/filesigning/content":
+			_, _ = w.Write([]byte("key\r\n\x00\n"))
+			return
+		case itemsPath + "/" + testItemID + "/files/filelarge/content":
+			_, _ = w.Write(make([]byte, maxResponseSize+1))
+			return
+		case itemsPath + "/" + testItemID:
+			body = map[string]interface{}{
+				"id":       testItemID,
+				"title":    "database",
+				"sections": []map[string]string{{"id": "sec_replica", "label": "Replica"}},
+				"fields": []map[string]interface{}{
+					{"id": "username", "label": "username", "value": "admin"},
+					{"id": "password", "label": "password", "value": "s3cr3t-connect-value"},
+					{"id": "TOTP_abc", "type": "OTP", "label": "one-time password",
+						"value": "otpauth://totp/ci?secret=JBSWY3DPEHPK3PXP", "totp": "042917"},
+					{"id": "replica_host", "label": "host", "value": "replica.db.internal",
+						"section": map[string]string{"id": "sec_replica"}},
+				},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"message":"Not found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T, host, token string) *Client {
+	t.Helper()
+
+	secureToken, err := security.NewSecureStringFromString(token)
+	require.NoError(t, err)
+
+	client, err := NewClient(&Config{Host: host, Token: secureToken})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Destroy() })
+	return client
+}
+
+func TestNewClient_Validation(t *testing.T) {
+	token, err := security.NewSecureStringFromString(testConnectToken)
+	require.NoError(t, err)
+	defer func() { _ = token.Destroy() }()
+
+	_, err = NewClient(nil)
+	assert.Error(t, err)
+
+	_, err = NewClient(&Config{Host: "https://connect.example.com"})
+	assert.Error(t, err, "token is required")
+
+	_, err = NewClient(&Config{Host: "connect.example.com", Token: token})
+	assert.Error(t, err, "host must be a URL")
+
+	client, err := NewClient(&Config{Host: "https://connect.example.com/", Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, "https://connect.example.com", client.host)
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(key, "")
+	}
+
+	// The fake server doubles as a forward proxy for an unresolvable host
+	proxyServer := newFakeConnect(t, 0)
+	token, err := security.NewSecureStringFromString(testConnectToken)
+	require.NoError(t, err)
+	defer func() { _ = token.Destroy() }()
+
+	client, err := NewClient(&Config{Host: "http://connect.example.invalid", Token: token, ProxyURL: proxyServer.URL})
+	require.NoError(t, err)
+	require.NoError(t, client.Authenticate(context.Background()))
+
+	// NO_PROXY sends the request directly, which cannot resolve the host
+	t.Setenv("NO_PROXY", ".example.invalid")
+	client, err = NewClient(&Config{Host: "http://connect.example.invalid", Token: token, ProxyURL: proxyServer.URL})
+	require.NoError(t, err)
+	assert.Error(t, client.Authenticate(context.Background()))
+
+	_, err = NewClient(&Config{Host: "https://connect.example.com", Token: token, ProxyURL: "ftp://proxy"})
+	assert.Error(t, err)
+}
+
+func TestClient_Resolve(t *testing.T) {
+	server := newFakeConnect(t, 0)
+	client := newTestClient(t, server.URL, testConnectToken)
+
+	value, err := client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "password",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t-connect-value", string(value))
+
+	// Vault and item IDs work as well as names, and labels match case-insensitively
+	value, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: testVaultID, Item: testItemID, Field: "Username",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", string(value))
+
+	// The otp keyword returns the current code rather than the TOTP secret
+	value, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "otp",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "042917", string(value))
+
+	// Attachments are returned byte for byte
+	value, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "file:Signing.key",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "key\r\n\x00\n", string(value))
+
+	// A file too large to read whole fails rather than arriving truncated
+	_, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "file:large.bin",
+	})
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeAPIError))
+	assert.Contains(t, err.Error(), "exceeds")
+
+	_, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "file:missing.pem",
+	})
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeAttachmentNotFound))
+	assert.Contains(t, err.Error(), "signing.key")
+
+	// Only Document items answer the document keyword
+	_, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Field: "@document",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a document")
+
+	// A section selects the field of that name within it
+	value, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Section: "replica", Field: "host",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "replica.db.internal", string(value))
+
+	_, err = client.Resolve(context.Background(), secrets.SecretRef{
+		Vault: "ci", Item: "database", Section: "replica", Field: "username",
+	})
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeFieldNotFound))
+	assert.Contains(t, err.Error(), "section 'replica'")
+}
+
+func TestClient_ResolveErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		token    string
+		ref      secrets.SecretRef
+		wantCode errors.ErrorCode
+	}{
+		{
+			name:     "rejected token",
+			token:    "wrong-token",
+			ref:      secrets.SecretRef{Vault: "ci", Item: "database", Field: "password"},
+			wantCode: errors.ErrCodeTokenInvalid,
+		},
+		{
+			name:     "forbidden",
+			status:   http.StatusForbidden,
+			ref:      secrets.SecretRef{Vault: "ci", Item: "database", Field: "password"},
+			wantCode: errors.ErrCodePermissionDenied,
+		},
+		{
+			name:     "rate limited",
+			status:   http.StatusTooManyRequests,
+			ref:      secrets.SecretRef{Vault: "ci", Item: "database", Field: "password"},
+			wantCode: errors.ErrCodeRateLimited,
+		},
+		{
+			name:     "server error",
+			status:   http.StatusBadGateway,
+			ref:      secrets.SecretRef{Vault: "ci", Item: "database", Field: "password"},
+			wantCode: errors.ErrCodeAPIError,
+		},
+		{
+			name:     "unknown vault",
+			ref:      secrets.SecretRef{Vault: "prod", Item: "database", Field: "password"},
+			wantCode: errors.ErrCodeVaultNotFound,
+		},
+		{
+			name:     "unknown item",
+			ref:      secrets.SecretRef{Vault: "ci", Item: "missing", Field: "password"},
+			wantCode: errors.ErrCodeSecretNotFound,
+		},
+		{
+			name:     "unknown field",
+			ref:      secrets.SecretRef{Vault: "ci", Item: "database", Field: "api_key"},
+			wantCode: errors.ErrCodeFieldNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeConnect(t, tt.status)
+			token := tt.token
+			if token == "" {
+				token = testConnectToken
+			}
+			client := newTestClient(t, server.URL, token)
+
+			value, err := client.Resolve(context.Background(), tt.ref)
+			require.Error(t, err)
+			assert.Nil(t, value)
+
+			actionableErr, ok := err.(*errors.ActionableError)
+			require.True(t, ok, "expected ActionableError, got %T", err)
+			assert.Equal(t, tt.wantCode, actionableErr.Code)
+			assert.NotContains(t, err.Error(), testConnectToken)
+		})
+	}
+}
+
+func TestItem_FindField(t *testing.T) {
+	noSections := &item{Title: "api", Fields: []field{
+		{ID: "f1", Label: "token", Value: "plain-token"},
+	}}
+	oneSection := &item{
+		Title:    "database",
+		Sections: []section{{ID: "s1", Label: "Replica"}},
+		Fields: []field{
+			{ID: "f1", Label: "password", Value: "primary"},
+			{ID: "f2", Label: "host", Value: "replica-host", Section: &sectionRef{ID: "s1"}},
+		},
+	}
+	duplicates := &item{
+		Title:    "cluster",
+		Sections: []section{{ID: "s1", Label: "Primary"}, {ID: "s2", Label: "Replica"}},
+		Fields: []field{
+			{ID: "f1", Label: "password", Value: "top-level"},
+			{ID: "f2", Label: "password", Value: "primary", Section: &sectionRef{ID: "s1"}},
+			{ID: "f3", Label: "Password", Value: "replica", Section: &sectionRef{ID: "s2"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		item    *item
+		section string
+		field   string
+		want    string
+		wantErr string
+	}{
+		{name: "no sections", item: noSections, field: "token", want: "plain-token"},
+		{name: "no sections with section", item: noSections, section: "Replica", field: "token"},
+		{name: "field outside section", item: oneSection, field: "password", want: "primary"},
+		{name: "field in section", item: oneSection, field: "host", want: "replica-host"},
+		{name: "section by label", item: oneSection, section: "replica", field: "host", want: "replica-host"},
+		{name: "section by ID", item: oneSection, section: "s1", field: "host", want: "replica-host"},
+		{name: "field not in section", item: oneSection, section: "Replica", field: "password"},
+		{name: "duplicates need a section", item: duplicates, field: "password",
+			wantErr: "field 'password' of item 'cluster' is in 2 sections: (no section), Primary"},
+		{name: "duplicates by ID", item: duplicates, field: "f3", want: "replica"},
+		{name: "duplicates in section", item: duplicates, section: "Replica", field: "password", want: "replica"},
+		{name: "case-insensitive duplicates", item: duplicates, field: "PASSWORD",
+			wantErr: "is in 3 sections: (no section), Primary, Replica"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.item.findField(tt.section, tt.field)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.IsErrorCode(err, errors.ErrCodeFieldAmbiguous))
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, f)
+				return
+			}
+			require.NotNil(t, f)
+			assert.Equal(t, tt.want, f.Value)
+		})
+	}
+}
+
+func TestClient_ListFields(t *testing.T) {
+	server := newFakeConnect(t, 0)
+	client := newTestClient(t, server.URL, testConnectToken)
+
+	fields, err := client.ListFields(context.Background(), secrets.SecretRef{Vault: "ci", Item: "database"})
+	require.NoError(t, err)
+	assert.Equal(t, []secrets.ItemField{
+		{ID: "username", Label: "username"},
+		{ID: "password", Label: "password"},
+		{ID: "TOTP_abc", Label: "one-time password"},
+		{ID: "replica_host", Label: "host"},
+	}, fields)
+
+	_, err = client.ListFields(context.Background(), secrets.SecretRef{Vault: "ci", Item: "missing"})
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeSecretNotFound))
+}
+
+func TestClient_ResolveVault(t *testing.T) {
+	server := newFakeConnect(t, 0)
+	client := newTestClient(t, server.URL, testConnectToken)
+	client.vaults = []VaultInfo{
+		{ID: testVaultID, Name: "ci"},
+		{ID: "abcdefghijklmnopqrstuvwxy4", Name: "shared"},
+		{ID: "abcdefghijklmnopqrstuvwxy3", Name: "shared"},
+		// A vault whose name has the ID format is reachable by ID only
+		{ID: "abcdefghijklmnopqrstuvwxy5", Name: testItemID},
+	}
+	ctx := context.Background()
+
+	vault, err := client.ResolveVault(ctx, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, testVaultID, vault.ID)
+
+	vault, err = client.ResolveVault(ctx, "abcdefghijklmnopqrstuvwxy4")
+	require.NoError(t, err)
+	assert.Equal(t, "shared", vault.Name)
+
+	_, err = client.ResolveVault(ctx, "shared")
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeVaultAmbiguous))
+	assert.Contains(t, err.Error(), "abcdefghijklmnopqrstuvwxy3, abcdefghijklmnopqrstuvwxy4")
+	assert.NotContains(t, err.Error(), testVaultID)
+
+	_, err = client.ResolveVault(ctx, testItemID)
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeVaultNotFound))
+}
+
+func TestClient_ConnectionFailure(t *testing.T) {
+	server := newFakeConnect(t, 0)
+	host := server.URL
+	server.Close()
+
+	client := newTestClient(t, host, testConnectToken)
+	err := client.Authenticate(context.Background())
+	require.Error(t, err)
+
+	actionableErr, ok := err.(*errors.ActionableError)
+	require.True(t, ok)
+	assert.Equal(t, errors.ErrCodeConnectionFailed, actionableErr.Code)
+	assert.True(t, strings.Contains(err.Error(), "Connect"))
+}
+
+func TestClient_RateLimitRetryAfter(t *testing.T) {
+	server := newFakeConnect(t, http.StatusTooManyRequests)
+	client := newTestClient(t, server.URL, testConnectToken)
+
+	err := client.Authenticate(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.IsErrorCode(err, errors.ErrCodeRateLimited))
+	assert.Equal(t, 7*time.Second, errors.GetRetryAfter(err))
+
+	// Other failures carry no hint
+	server = newFakeConnect(t, http.StatusBadGateway)
+	client = newTestClient(t, server.URL, testConnectToken)
+	err = client.Authenticate(context.Background())
+	require.Error(t, err)
+	assert.Zero(t, errors.GetRetryAfter(err))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{" 2 ", 2 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), "Retry-After %q", tt.value)
+	}
+}
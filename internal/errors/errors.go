@@ -7,8 +7,11 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 // ErrorCode represents a specific error condition with a unique identifier
@@ -36,6 +39,7 @@ const (
 	ErrCodeVaultAccessDenied ErrorCode = "OP1106"
 	ErrCodeAccountLocked     ErrorCode = "OP1107"
 	ErrCodeQuotaExceeded     ErrorCode = "OP1108"
+	ErrCodeVaultAmbiguous    ErrorCode = "OP1109"
 
 	// CLI and System Errors (1200-1299)
 	ErrCodeCLINotFound           ErrorCode = "OP1201"
@@ -46,6 +50,10 @@ const (
 	ErrCodeSystemError           ErrorCode = "OP1206"
 	ErrCodeMemoryError           ErrorCode = "OP1207"
 	ErrCodeFileSystemError       ErrorCode = "OP1208"
+	ErrCodeCLIConnectTimeout     ErrorCode = "OP1209"
+	ErrCodeCLIVersionMismatch    ErrorCode = "OP1210"
+	ErrCodeVersionsDBInvalid     ErrorCode = "OP1211"
+	ErrCodeCLIVersionNotAllowed  ErrorCode = "OP1212"
 
 	// Secret Retrieval Errors (1300-1399)
 	ErrCodeSecretNotFound         ErrorCode = "OP1301"
@@ -55,6 +63,9 @@ const (
 	ErrCodeSecretParsingFailed    ErrorCode = "OP1305"
 	ErrCodeBatchOperationFailed   ErrorCode = "OP1306"
 	ErrCodeSecretValidationFailed ErrorCode = "OP1307"
+	ErrCodeItemAmbiguous          ErrorCode = "OP1308"
+	ErrCodeAttachmentNotFound     ErrorCode = "OP1309"
+	ErrCodeFieldAmbiguous         ErrorCode = "OP1310"
 
 	// Output and GitHub Actions Errors (1400-1499)
 	ErrCodeOutputFailed           ErrorCode = "OP1401"
@@ -74,6 +85,7 @@ const (
 	ErrCodeInternalError  ErrorCode = "OP1901"
 	ErrCodeUnknownError   ErrorCode = "OP1902"
 	ErrCodePanicRecovered ErrorCode = "OP1903"
+	ErrCodeCanceled       ErrorCode = "OP1904"
 )
 
 // ErrorCategory represents the category of an error
@@ -125,6 +137,10 @@ type ActionableError struct {
 	Cause       error
 	Context     map[string]string
 	Recoverable bool
+
+	// RetryAfter is how long the server asked callers to wait before
+	// retrying, typically from a rate-limit response; zero when unknown
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface
@@ -163,6 +179,12 @@ func (e *ActionableError) IsRecoverable() bool {
 	return e.Recoverable
 }
 
+// IsRetryable reports whether repeating the failed operation can succeed.
+// See retryable for the classification.
+func (e *ActionableError) IsRetryable() bool {
+	return retryable(e.Code)
+}
+
 // GetContext returns error context information
 func (e *ActionableError) GetContext() map[string]string {
 	return e.Context
@@ -227,6 +249,14 @@ func (e *ActionableError) WithContext(key, value string) *ActionableError {
 	return e
 }
 
+// WithRetryAfter records the server's hint for when to retry
+func (e *ActionableError) WithRetryAfter(d time.Duration) *ActionableError {
+	if d > 0 {
+		e.RetryAfter = d
+	}
+	return e
+}
+
 // WithRecoverable sets whether the error is recoverable
 func (e *ActionableError) WithRecoverable(recoverable bool) *ActionableError {
 	e.Recoverable = recoverable
@@ -283,13 +313,32 @@ func isRecoverable(code ErrorCode) bool {
 		ErrCodeCLITimeout, ErrCodeAPIError:
 		return true
 	case ErrCodeTokenInvalid, ErrCodePermissionDenied, ErrCodeVaultNotFound,
-		ErrCodeSecretNotFound, ErrCodeFieldNotFound:
+		ErrCodeSecretNotFound, ErrCodeFieldNotFound, ErrCodeItemAmbiguous,
+		ErrCodeVaultAmbiguous, ErrCodeAttachmentNotFound, ErrCodeCLIVersionMismatch,
+		ErrCodeVersionsDBInvalid, ErrCodeCLIVersionNotAllowed, ErrCodeFieldAmbiguous:
 		return false
 	default:
 		return false
 	}
 }
 
+// retryable classifies error codes for retry loops. Transient failures are
+// retryable: network and connection errors, timeouts of the CLI or an API
+// call, rate limiting, server-side API errors and failed CLI downloads. All
+// other codes are not, as another attempt fails the same way: an invalid or
+// expired token, denied access, missing vaults, items and fields, and
+// configuration, input and validation errors.
+func retryable(code ErrorCode) bool {
+	switch code {
+	case ErrCodeNetworkError, ErrCodeConnectionFailed, ErrCodeTimeout,
+		ErrCodeCLITimeout, ErrCodeCLIConnectTimeout, ErrCodeRateLimited,
+		ErrCodeAPIError, ErrCodeCLIDownloadFailed:
+		return true
+	default:
+		return false
+	}
+}
+
 // Helper functions for creating common errors
 
 // NewConfigurationError creates a configuration-related error
@@ -313,6 +362,68 @@ func NewAuthenticationError(code ErrorCode, message string, cause error) *Action
 	)
 }
 
+// NewVaultAmbiguousError reports a vault name shared by several vaults,
+// listing their IDs so that one can be chosen explicitly.
+func NewVaultAmbiguousError(vault string, ids []string) *ActionableError {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+
+	return Wrap(
+		ErrCodeVaultAmbiguous,
+		fmt.Sprintf("vault name '%s' matches %d vaults: %s", vault, len(sorted), strings.Join(sorted, ", ")),
+		nil,
+	).WithDetails(map[string]interface{}{
+		"vault":     vault,
+		"vault_ids": sorted,
+	}).WithSuggestions(
+		"Set the vault input to one of the listed vault IDs",
+		"Rename one of the vaults so that its name is unique",
+	)
+}
+
+// NewFieldAmbiguousError reports a field label shared by fields in several
+// sections of an item, naming the sections so that one can be chosen. A
+// field outside any section is listed as "(no section)".
+func NewFieldAmbiguousError(item, field string, sections []string) *ActionableError {
+	sorted := append([]string(nil), sections...)
+	sort.Strings(sorted)
+
+	return Wrap(
+		ErrCodeFieldAmbiguous,
+		fmt.Sprintf("field '%s' of item '%s' is in %d sections: %s",
+			field, item, len(sorted), strings.Join(sorted, ", ")),
+		nil,
+	).WithDetails(map[string]interface{}{
+		"item":     item,
+		"field":    field,
+		"sections": sorted,
+	}).WithSuggestions(
+		"Name the section, as in vault/item/section/field or op://vault/item/section/field",
+		"Reference the field by its ID",
+	)
+}
+
+// NewAttachmentNotFoundError reports an attachment missing from an item,
+// listing the names of the files the item does have.
+func NewAttachmentNotFoundError(item, name string, available []string) *ActionableError {
+	sorted := append([]string(nil), available...)
+	sort.Strings(sorted)
+
+	message := fmt.Sprintf("item '%s' has no attachment named '%s'", item, name)
+	if len(sorted) > 0 {
+		message += fmt.Sprintf("; attachments: %s", strings.Join(sorted, ", "))
+	}
+
+	return Wrap(ErrCodeAttachmentNotFound, message, nil).WithDetails(map[string]interface{}{
+		"item":        item,
+		"attachment":  name,
+		"attachments": sorted,
+	}).WithSuggestions(
+		"Check the file name of the attachment in 1Password",
+		"Attachment names are matched exactly, then case-insensitively",
+	)
+}
+
 // NewCLIError creates a CLI-related error
 func NewCLIError(code ErrorCode, message string, cause error) *ActionableError {
 	err := Wrap(code, message, cause)
@@ -377,6 +488,26 @@ func IsRecoverableError(err error) bool {
 	return false
 }
 
+// IsRetryableError reports whether err, or the first ActionableError it
+// wraps, is retryable. Errors without a code are not.
+func IsRetryableError(err error) bool {
+	var actionableErr *ActionableError
+	if stderrors.As(err, &actionableErr) {
+		return actionableErr.IsRetryable()
+	}
+	return false
+}
+
+// GetRetryAfter returns the RetryAfter hint of the first ActionableError
+// err wraps, or zero if there is none.
+func GetRetryAfter(err error) time.Duration {
+	var actionableErr *ActionableError
+	if stderrors.As(err, &actionableErr) {
+		return actionableErr.RetryAfter
+	}
+	return 0
+}
+
 // GetErrorCode extracts the error code from an error
 func GetErrorCode(err error) ErrorCode {
 	if actionableErr, ok := err.(*ActionableError); ok {
@@ -413,8 +544,9 @@ func FormatErrorForUser(err error) string {
 			parts = append(parts, actionableErr.Message)
 		}
 
-		// Add error code for reference
+		// Add error code for reference, and where it is documented
 		parts = append(parts, fmt.Sprintf("Error Code: %s", actionableErr.Code))
+		parts = append(parts, fmt.Sprintf("Help: %s", actionableErr.HelpURL()))
 
 		// Add suggestions if available
 		if len(actionableErr.Suggestions) > 0 {
@@ -441,6 +573,7 @@ func FormatErrorForLog(err error) map[string]interface{} {
 		logData["error_category"] = actionableErr.Category
 		logData["error_severity"] = actionableErr.Severity
 		logData["recoverable"] = actionableErr.Recoverable
+		logData["help_url"] = actionableErr.HelpURL()
 
 		if len(actionableErr.Details) > 0 {
 			logData["details"] = actionableErr.Details
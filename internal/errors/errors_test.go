@@ -5,7 +5,9 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -226,6 +228,24 @@ func TestNewAuthenticationError(t *testing.T) {
 	}
 }
 
+func TestNewVaultAmbiguousError(t *testing.T) {
+	err := NewVaultAmbiguousError("Shared", []string{"vault2", "vault1"})
+
+	if err.Code != ErrCodeVaultAmbiguous || err.Category != CategoryAuthentication {
+		t.Errorf("Expected %s in category %s, got %s in %s",
+			ErrCodeVaultAmbiguous, CategoryAuthentication, err.Code, err.Category)
+	}
+	if err.Message != "vault name 'Shared' matches 2 vaults: vault1, vault2" {
+		t.Errorf("Unexpected message: %s", err.Message)
+	}
+	if ids, ok := err.GetDetails()["vault_ids"].([]string); !ok || len(ids) != 2 || ids[0] != "vault1" {
+		t.Errorf("Expected sorted vault IDs in details, got %v", err.GetDetails())
+	}
+	if err.IsRetryable() {
+		t.Errorf("Ambiguous vault names should not be retryable")
+	}
+}
+
 func TestNewCLIError(t *testing.T) {
 	err := NewCLIError(ErrCodeCLIDownloadFailed, "Failed to download CLI", nil)
 
@@ -499,3 +519,67 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		code     ErrorCode
+		expected bool
+	}{
+		{ErrCodeCLITimeout, true},
+		{ErrCodeCLIDownloadFailed, true},
+		{ErrCodeNetworkError, true},
+		{ErrCodeConnectionFailed, true},
+		{ErrCodeTimeout, true},
+		{ErrCodeRateLimited, true},
+		{ErrCodeAPIError, true},
+		{ErrCodeTokenInvalid, false},
+		{ErrCodeTokenExpired, false},
+		{ErrCodeAuthFailed, false},
+		{ErrCodePermissionDenied, false},
+		{ErrCodeSecretNotFound, false},
+		{ErrCodeCLIVerificationFailed, false},
+		{ErrCodeInvalidConfig, false},
+		{ErrCodeInvalidInput, false},
+		{ErrCodeConfigValidation, false},
+		{ErrCodeSecretValidationFailed, false},
+		{ErrCodeOutputValidationFailed, false},
+	}
+
+	for _, test := range tests {
+		if got := New(test.code, "failure").IsRetryable(); got != test.expected {
+			t.Errorf("Expected IsRetryable %v for code %s, got %v",
+				test.expected, test.code, got)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	wrapped := fmt.Errorf("attempt 1: %w", New(ErrCodeRateLimited, "Rate limited"))
+	if !IsRetryableError(wrapped) {
+		t.Errorf("Expected IsRetryableError to find a wrapped retryable error")
+	}
+
+	tokenErr := Wrap(ErrCodeTokenInvalid, "Invalid token", New(ErrCodeNetworkError, "Network error"))
+	if IsRetryableError(tokenErr) {
+		t.Errorf("Expected the outermost code to decide, got retryable for an invalid token")
+	}
+
+	if IsRetryableError(errors.New("plain error")) || IsRetryableError(nil) {
+		t.Errorf("Expected errors without a code not to be retryable")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := New(ErrCodeRateLimited, "Rate limited").WithRetryAfter(30 * time.Second)
+	if got := GetRetryAfter(fmt.Errorf("request: %w", err)); got != 30*time.Second {
+		t.Errorf("Expected RetryAfter of 30s, got %v", got)
+	}
+
+	// A non-positive hint leaves the error without one
+	if got := New(ErrCodeRateLimited, "Rate limited").WithRetryAfter(-time.Second).RetryAfter; got != 0 {
+		t.Errorf("Expected no RetryAfter for a negative hint, got %v", got)
+	}
+	if got := GetRetryAfter(errors.New("plain error")); got != 0 {
+		t.Errorf("Expected no RetryAfter for a plain error, got %v", got)
+	}
+}
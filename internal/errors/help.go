@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package errors
+
+// Documentation the help URLs of error codes point into
+const (
+	docsURL = "https://github.com/ModeSevenIndustrialSolutions/1password-secrets-action#"
+
+	// TroubleshootingURL is the help URL of codes without a specific section
+	TroubleshootingURL = docsURL + "troubleshooting"
+)
+
+// helpAnchors maps every error code to the README section that explains how
+// to resolve it. A new code needs an entry here; the tests enforce it.
+var helpAnchors = map[ErrorCode]string{
+	// Configuration and Input Errors
+	ErrCodeInvalidConfig:      "inputs",
+	ErrCodeMissingInput:       "inputs",
+	ErrCodeInvalidInput:       "inputs",
+	ErrCodeInvalidToken:       "authentication-failed",
+	ErrCodeInvalidVault:       "vault-specification",
+	ErrCodeInvalidRecord:      "record-format",
+	ErrCodeInvalidReturnType:  "inputs",
+	ErrCodeConfigValidation:   "inputs",
+	ErrCodeEnvironmentMissing: "troubleshooting",
+
+	// Authentication and Authorization Errors
+	ErrCodeAuthFailed:        "authentication-failed",
+	ErrCodeTokenExpired:      "authentication-failed",
+	ErrCodeTokenInvalid:      "authentication-failed",
+	ErrCodePermissionDenied:  "authentication-failed",
+	ErrCodeVaultNotFound:     "vault-not-found",
+	ErrCodeVaultAccessDenied: "vault-not-found",
+	ErrCodeAccountLocked:     "authentication-failed",
+	ErrCodeQuotaExceeded:     "error-handling",
+	ErrCodeVaultAmbiguous:    "vault-specification",
+
+	// CLI and System Errors
+	ErrCodeCLINotFound:           "cli-binary-cannot-execute",
+	ErrCodeCLIDownloadFailed:     "unexpected-response-from-download-server",
+	ErrCodeCLIVerificationFailed: "1password-cli-version-database",
+	ErrCodeCLIExecutionFailed:    "cli-binary-cannot-execute",
+	ErrCodeCLITimeout:            "inputs",
+	ErrCodeSystemError:           "troubleshooting",
+	ErrCodeMemoryError:           "memory-security",
+	ErrCodeFileSystemError:       "troubleshooting",
+	ErrCodeCLIConnectTimeout:     "inputs",
+	ErrCodeCLIVersionMismatch:    "1password-cli-version-database",
+	ErrCodeVersionsDBInvalid:     "1password-cli-version-database",
+	ErrCodeCLIVersionNotAllowed:  "1password-cli-version-database",
+
+	// Secret Retrieval Errors
+	ErrCodeSecretNotFound:         "secret-not-found",
+	ErrCodeSecretAccessDenied:     "vault-not-found",
+	ErrCodeFieldNotFound:          "secret-not-found",
+	ErrCodeSecretEmpty:            "secret-not-found",
+	ErrCodeSecretParsingFailed:    "record-format",
+	ErrCodeBatchOperationFailed:   "error-handling",
+	ErrCodeSecretValidationFailed: "transforms",
+	ErrCodeItemAmbiguous:          "searching-all-vaults",
+	ErrCodeAttachmentNotFound:     "file-attachments",
+	ErrCodeFieldAmbiguous:         "secret-references",
+
+	// Output and GitHub Actions Errors
+	ErrCodeOutputFailed:           "outputs",
+	ErrCodeEnvVarSetFailed:        "outputs",
+	ErrCodeGitHubOutputFailed:     "outputs",
+	ErrCodeMaskingFailed:          "logging-security",
+	ErrCodeOutputValidationFailed: "outputs",
+
+	// Network and API Errors
+	ErrCodeNetworkError:     "proxies",
+	ErrCodeAPIError:         "troubleshooting",
+	ErrCodeRateLimited:      "error-handling",
+	ErrCodeTimeout:          "inputs",
+	ErrCodeConnectionFailed: "proxies",
+
+	// Internal and Unknown Errors
+	ErrCodeInternalError:  "debug-mode",
+	ErrCodeUnknownError:   "debug-mode",
+	ErrCodePanicRecovered: "debug-mode",
+	ErrCodeCanceled:       "error-handling",
+}
+
+// HelpURL returns the documentation section explaining the error's code, or
+// TroubleshootingURL for a code without one.
+func (e *ActionableError) HelpURL() string {
+	if anchor, ok := helpAnchors[e.Code]; ok {
+		return docsURL + anchor
+	}
+	return TroubleshootingURL
+}
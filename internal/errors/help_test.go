@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package errors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// definedCodes returns every ErrorCode constant declared in errors.go.
+func definedCodes(t *testing.T) map[string]ErrorCode {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse errors.go: %v", err)
+	}
+
+	codes := make(map[string]ErrorCode)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "ErrorCode" {
+				continue
+			}
+			for i, name := range value.Names {
+				lit := value.Values[i].(*ast.BasicLit)
+				codes[name.Name] = ErrorCode(strings.Trim(lit.Value, `"`))
+			}
+		}
+	}
+	if len(codes) == 0 {
+		t.Fatal("No ErrorCode constants found in errors.go")
+	}
+	return codes
+}
+
+// readmeAnchors returns the GitHub anchors of the README's headings.
+func readmeAnchors(t *testing.T) map[string]bool {
+	t.Helper()
+
+	data, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatalf("Failed to read README.md: %v", err)
+	}
+
+	drop := regexp.MustCompile(`[^a-z0-9 \-]`)
+	anchors := make(map[string]bool)
+	inCode := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+		}
+		if inCode || !strings.HasPrefix(line, "#") {
+			continue
+		}
+		heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		anchor := drop.ReplaceAllString(strings.ToLower(heading), "")
+		anchors[strings.ReplaceAll(anchor, " ", "-")] = true
+	}
+	return anchors
+}
+
+func TestHelpURL_CoversEveryCode(t *testing.T) {
+	anchors := readmeAnchors(t)
+
+	for name, code := range definedCodes(t) {
+		anchor, ok := helpAnchors[code]
+		if !ok {
+			t.Errorf("%s (%s) has no help anchor", name, code)
+			continue
+		}
+		if !anchors[anchor] {
+			t.Errorf("%s (%s) links to #%s, which is not a README heading", name, code, anchor)
+		}
+		if got := New(code, "test").HelpURL(); got != docsURL+anchor {
+			t.Errorf("%s HelpURL() = %q, want %q", name, got, docsURL+anchor)
+		}
+	}
+}
+
+func TestHelpURL(t *testing.T) {
+	err := New(ErrCodeCLIVerificationFailed, "CLI verification failed")
+	if got := err.HelpURL(); !strings.HasSuffix(got, "#1password-cli-version-database") {
+		t.Errorf("HelpURL() = %q, want the versions database section", got)
+	}
+
+	// A code without a section falls back to troubleshooting
+	if got := New(ErrorCode("OP9999"), "unknown").HelpURL(); got != TroubleshootingURL {
+		t.Errorf("HelpURL() = %q, want %q", got, TroubleshootingURL)
+	}
+
+	if formatted := FormatErrorForUser(err); !strings.Contains(formatted, "Help: "+err.HelpURL()) {
+		t.Errorf("FormatErrorForUser() = %q, want the help URL", formatted)
+	}
+	if logData := FormatErrorForLog(err); logData["help_url"] != err.HelpURL() {
+		t.Errorf("FormatErrorForLog() help_url = %v, want %q", logData["help_url"], err.HelpURL())
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// LevelTrace is below slog.LevelDebug, for verbose diagnostics such as
+// every CLI command run. Trace lines are redacted like any other.
+const LevelTrace = slog.LevelDebug - 4
+
+// LevelNames lists the names ParseLevel accepts, most verbose first.
+var LevelNames = []string{"trace", "debug", "info", "warn", "error"}
+
+// ParseLevel returns the level named by name, ignoring case and
+// surrounding space. Names other than LevelNames are an error.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (expected one of %s)", name, strings.Join(LevelNames, ", "))
+}
+
+// levelAttr names LevelTrace "TRACE" rather than slog's "DEBUG-4".
+func levelAttr(a slog.Attr) slog.Attr {
+	if level, ok := a.Value.Any().(slog.Level); ok && level == LevelTrace {
+		return slog.String(slog.LevelKey, "TRACE")
+	}
+	return a
+}
@@ -7,6 +7,8 @@
 package logger
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -16,6 +18,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 )
 
 // LogContext represents the sensitivity context for a log message
@@ -36,6 +40,9 @@ type Logger struct {
 	logFile  *os.File
 	debugLog *slog.Logger
 	config   Config // Store config for runtime decisions
+	masks    *maskRegistry
+	redactor *redactor
+	level    *slog.LevelVar // Level of logger, changed by SetLevel
 	mu       sync.RWMutex
 }
 
@@ -44,7 +51,7 @@ type Config struct {
 	Level              slog.Level
 	Debug              bool
 	LogFile            string
-	Format             string // "json" or "text"
+	Format             string // FormatText or FormatJSON
 	AddSource          bool
 	DisableFileLogging bool // Disable file logging (useful for CI/CD environments)
 	DisableStderr      bool // Disable direct stderr writes (for library usage)
@@ -52,6 +59,26 @@ type Config struct {
 	SensitiveByDefault bool // Force sensitive context for all logs
 }
 
+// Log formats accepted by Config.Format
+const (
+	// FormatText writes "key=value" lines
+	FormatText = "text"
+	// FormatJSON writes one JSON object per line, for log aggregation
+	FormatJSON = "json"
+)
+
+// formatFromEnvironment returns the format selected by the log_format
+// input, defaulting to FormatText. The logger is created before the action
+// configuration is loaded, so the input is read here directly.
+func formatFromEnvironment() string {
+	for _, name := range []string{"INPUT_LOG_FORMAT", "OP_LOG_FORMAT"} {
+		if strings.EqualFold(strings.TrimSpace(os.Getenv(name)), FormatJSON) {
+			return FormatJSON
+		}
+	}
+	return FormatText
+}
+
 // DefaultConfig returns sensible defaults for logging configuration
 func DefaultConfig() Config {
 	// Auto-detect GitHub Actions and adjust defaults accordingly
@@ -61,7 +88,7 @@ func DefaultConfig() Config {
 		Level:              slog.LevelInfo,
 		Debug:              false,
 		LogFile:            "",
-		Format:             "json",
+		Format:             formatFromEnvironment(),
 		AddSource:          true,
 		DisableFileLogging: inGitHubActions, // Disable file logging in GitHub Actions by default
 		DisableStderr:      false,
@@ -85,6 +112,24 @@ func isGitHubActions() bool {
 
 // New creates a new Logger instance with the provided configuration
 func New() (*Logger, error) {
+	return NewWithConfig(environmentConfig())
+}
+
+// NewWithFormat creates a Logger like New that writes lines in format,
+// FormatText or FormatJSON, regardless of the log_format input
+func NewWithFormat(format string) (*Logger, error) {
+	if format != FormatText && format != FormatJSON {
+		return nil, fmt.Errorf("unknown log format %q (expected %s or %s)", format, FormatText, FormatJSON)
+	}
+
+	config := environmentConfig()
+	config.Format = format
+	return NewWithConfig(config)
+}
+
+// environmentConfig returns DefaultConfig with debug logging enabled when
+// the DEBUG or RUNNER_DEBUG environment variable asks for it
+func environmentConfig() Config {
 	config := DefaultConfig()
 
 	// Check for debug mode from environment
@@ -93,14 +138,18 @@ func New() (*Logger, error) {
 		config.Level = slog.LevelDebug
 	}
 
-	return NewWithConfig(config)
+	return config
 }
 
 // NewWithConfig creates a new Logger with custom configuration
 func NewWithConfig(config Config) (*Logger, error) {
 	l := &Logger{
-		config: config, // Store config for runtime decisions
+		config:   config, // Store config for runtime decisions
+		masks:    &maskRegistry{},
+		redactor: &redactor{},
+		level:    &slog.LevelVar{},
 	}
+	l.level.Set(config.Level)
 
 	// Create log file if specified and not disabled
 	var writers []io.Writer
@@ -139,39 +188,44 @@ func NewWithConfig(config Config) (*Logger, error) {
 	}
 
 	// Create context-aware writer that only scrubs when needed
-	secureOutput := &contextAwareWriter{writer: output}
+	secureOutput := &contextAwareWriter{writer: output, redactor: l.redactor}
 
 	// Configure handler based on format
-	var handler slog.Handler
+	jsonFormat := config.Format == FormatJSON
 	handlerOptions := &slog.HandlerOptions{
-		Level:     config.Level,
+		Level:     l.level,
 		AddSource: config.AddSource,
 		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
 			// Add timestamp in GitHub Actions format
 			if a.Key == slog.TimeKey {
 				return slog.String(slog.TimeKey, a.Value.Time().Format(time.RFC3339))
 			}
+			if a.Key == slog.LevelKey {
+				return levelAttr(a)
+			}
+			if jsonFormat {
+				return scrubAttr(a)
+			}
 			return a
 		},
 	}
 
-	switch config.Format {
-	case "text":
-		handler = slog.NewTextHandler(secureOutput, handlerOptions)
-	default:
-		handler = slog.NewJSONHandler(secureOutput, handlerOptions)
+	newHandler := func(options *slog.HandlerOptions) slog.Handler {
+		if jsonFormat {
+			return slog.NewJSONHandler(secureOutput, options)
+		}
+		return slog.NewTextHandler(secureOutput, options)
 	}
 
 	// Create main logger
-	l.logger = slog.New(handler)
+	l.logger = slog.New(newHandler(handlerOptions))
 
-	// Create debug logger if enabled - use same output as main logger
+	// Create debug logger if enabled - use same output and format as main logger
 	if config.Debug {
-		debugHandler := slog.NewTextHandler(secureOutput, &slog.HandlerOptions{
-			Level:     slog.LevelDebug,
-			AddSource: true,
-		})
-		l.debugLog = slog.New(debugHandler)
+		debugOptions := *handlerOptions
+		debugOptions.Level = slog.LevelDebug
+		debugOptions.AddSource = true
+		l.debugLog = slog.New(newHandler(&debugOptions))
 	}
 
 	return l, nil
@@ -179,8 +233,9 @@ func NewWithConfig(config Config) (*Logger, error) {
 
 // contextAwareWriter wraps an io.Writer to scrub secrets only when context is sensitive
 type contextAwareWriter struct {
-	writer io.Writer
-	mu     sync.Mutex
+	writer   io.Writer
+	redactor *redactor // Registered secret values, replaced in every line
+	mu       sync.Mutex
 }
 
 // Write implements io.Writer and conditionally scrubs based on message context
@@ -189,15 +244,28 @@ func (caw *contextAwareWriter) Write(p []byte) (n int, err error) {
 	defer caw.mu.Unlock()
 
 	message := string(p)
+	redacted := conceal(caw.redactor, message)
 
-	// Only scrub if the message contains specific indicators that it might contain secrets
-	if shouldScrubMessage(message) {
-		scrubbed := scrubKnownSecrets(message)
-		return caw.writer.Write([]byte(scrubbed))
+	// Unchanged lines are written as-is
+	if redacted == message {
+		return caw.writer.Write(p)
 	}
+	if _, err := caw.writer.Write([]byte(redacted)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
 
-	// Otherwise write as-is
-	return caw.writer.Write(p)
+// conceal replaces the values registered with r in message, then scrubs
+// known secret formats when the message suggests it may hold one.
+func conceal(r *redactor, message string) string {
+	redacted := r.redact(message)
+
+	// Only scrub if the message contains specific indicators that it might contain secrets
+	if shouldScrubMessage(redacted) {
+		redacted = scrubKnownSecrets(redacted)
+	}
+	return redacted
 }
 
 // shouldScrubMessage determines if a message needs secret scrubbing based on context
@@ -249,12 +317,7 @@ func scrubKnownSecrets(message string) string {
 			value := parts[2]
 			quote := parts[3]
 
-			scrubbedValue := "[REDACTED]"
-			if len(value) > 8 {
-				scrubbedValue = fmt.Sprintf(redactedFormat, value[:2], value[len(value)-2:])
-			}
-
-			return fmt.Sprintf("%s%s%s", varPart, scrubbedValue, quote)
+			return fmt.Sprintf("%s%s%s", varPart, redactAssignedValue(value), quote)
 		}
 		return match
 	})
@@ -262,6 +325,33 @@ func scrubKnownSecrets(message string) string {
 	return scrubbed
 }
 
+// redactAssignedValue redacts a value assigned to a secret-named variable,
+// keeping its first and last two characters when it is long enough.
+func redactAssignedValue(value string) string {
+	if len(value) > 8 {
+		return fmt.Sprintf(redactedFormat, value[:2], value[len(value)-2:])
+	}
+	return "[REDACTED]"
+}
+
+// sensitiveKeyPattern matches attribute keys that name a secret, the same
+// names whose assignments scrubKnownSecrets redacts in text output.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)^[a-z_]*(?:password|secret|token|key)$`)
+
+// scrubAttr redacts string attributes whose key names a secret. JSON lines
+// hold no "name=value" assignments for the output writer to find, so the
+// values are redacted as attributes instead, by the same rule.
+func scrubAttr(a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindString || !sensitiveKeyPattern.MatchString(a.Key) {
+		return a
+	}
+	value := a.Value.String()
+	if len(value) < 8 {
+		return a
+	}
+	return slog.String(a.Key, redactAssignedValue(value))
+}
+
 // processArgsWithContext converts arguments to proper slog format with context-aware processing
 func (l *Logger) processArgsWithContext(ctx LogContext, args []any) []any {
 	var result []any
@@ -398,6 +488,25 @@ func (l *Logger) DebugSensitive(msg string, args ...any) {
 	l.DebugContext(ContextSensitive, msg, args...)
 }
 
+// Trace logs a trace level message in sensitive context, as trace lines
+// carry command arguments and other detail
+func (l *Logger) Trace(msg string, args ...any) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	l.logger.Log(context.Background(), LevelTrace, msg, l.processArgsWithContext(ContextSensitive, args)...)
+}
+
+// SetLevel changes the level below which messages are dropped, such as to
+// the log_level of the configuration loaded after the logger was created
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// Level returns the level below which messages are dropped
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
 // With returns a new logger with the given attributes
 func (l *Logger) With(args ...any) *Logger {
 	l.mu.RLock()
@@ -408,6 +517,9 @@ func (l *Logger) With(args ...any) *Logger {
 		logFile:  l.logFile,
 		debugLog: l.debugLog,
 		config:   l.config,
+		masks:    l.masks,
+		redactor: l.redactor,
+		level:    l.level,
 	}
 
 	if l.debugLog != nil {
@@ -427,6 +539,9 @@ func (l *Logger) WithGroup(name string) *Logger {
 		logFile:  l.logFile,
 		debugLog: l.debugLog,
 		config:   l.config,
+		masks:    l.masks,
+		redactor: l.redactor,
+		level:    l.level,
 	}
 
 	if l.debugLog != nil {
@@ -464,6 +579,12 @@ func (l *Logger) LogError(err error, message string, context map[string]interfac
 		"error_message": err.Error(),
 	}
 
+	// Include the OP error code so aggregated logs can be filtered by it
+	var actionErr *errors.ActionableError
+	if stderrors.As(err, &actionErr) {
+		logData["error_code"] = string(actionErr.Code)
+	}
+
 	// Add context if provided
 	for k, v := range context {
 		logData[k] = v
@@ -544,11 +665,43 @@ func (l *Logger) GitHubEndGroup() {
 
 // GitHubMask masks a value in GitHub Actions logs
 func (l *Logger) GitHubMask(value string) {
-	if strings.TrimSpace(value) != "" {
-		fmt.Printf("::add-mask::%s\n", value)
+	for _, command := range MaskCommands(value) {
+		fmt.Println(command)
 	}
 }
 
+// MaskCommands returns the ::add-mask:: commands needed to hide value in
+// GitHub Actions logs. GitHub does not match a mask across line boundaries,
+// so each non-empty line of a multi-line value is masked on its own, with
+// trailing whitespace trimmed, followed by the full value escaped as
+// command data.
+func MaskCommands(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	if !strings.ContainsAny(value, "\r\n") {
+		return []string{"::add-mask::" + value}
+	}
+
+	var commands []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		commands = append(commands, "::add-mask::"+line)
+	}
+	return append(commands, "::add-mask::"+escapeCommandData(value))
+}
+
+// escapeCommandData escapes a workflow command value so that it is read
+// back as a single command.
+func escapeCommandData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
 // GitHubSetOutput sets a GitHub Actions output variable
 func (l *Logger) GitHubSetOutput(name, value string) error {
 	if name == "" {
@@ -580,7 +733,9 @@ func (l *Logger) GitHubSetOutput(name, value string) error {
 	return nil
 }
 
-// GitHubSummary writes content to the GitHub Actions step summary
+// GitHubSummary appends content to the GitHub Actions step summary. Earlier
+// steps may have left the file without a trailing newline, in which case one
+// is added first so the content starts on its own line.
 func (l *Logger) GitHubSummary(content string) error {
 	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
 	if summaryFile == "" {
@@ -589,7 +744,7 @@ func (l *Logger) GitHubSummary(content string) error {
 	}
 
 	// #nosec G304 -- summaryFile is from GITHUB_STEP_SUMMARY environment variable, not user input
-	file, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_WRONLY, 0600)
+	file, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_RDWR, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open GITHUB_STEP_SUMMARY file: %w", err)
 	}
@@ -599,6 +754,10 @@ func (l *Logger) GitHubSummary(content string) error {
 		}
 	}()
 
+	if needsNewline(file) {
+		content = "\n" + content
+	}
+
 	_, err = file.WriteString(content)
 	if err != nil {
 		return fmt.Errorf("failed to write to GITHUB_STEP_SUMMARY file: %w", err)
@@ -607,6 +766,20 @@ func (l *Logger) GitHubSummary(content string) error {
 	return nil
 }
 
+// needsNewline reports whether file is non-empty and does not end with a
+// newline.
+func needsNewline(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false
+	}
+	return last[0] != '\n'
+}
+
 // GitHubSummarySection writes a section to the GitHub Actions step summary
 func (l *Logger) GitHubSummarySection(title, content string) error {
 	summary := fmt.Sprintf("## %s\n\n%s\n\n", title, content)
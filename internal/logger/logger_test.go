@@ -5,12 +5,16 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	apperrors "github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
 )
 
 func TestNew(t *testing.T) {
@@ -255,6 +259,60 @@ func TestScrubKnownSecrets(t *testing.T) {
 	}
 }
 
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"trace", LevelTrace, false},
+		{"debug", slog.LevelDebug, false},
+		{" INFO ", slog.LevelInfo, false},
+		{"Warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"fatal", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTraceLevel(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "trace.log")
+	logger, err := NewWithConfig(Config{Level: slog.LevelDebug, LogFile: logFile, Format: FormatJSON, DisableStderr: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Cleanup() }()
+
+	logger.RegisterSecret("correct-horse-battery")
+	logger.Trace("dropped at debug level")
+	logger.SetLevel(LevelTrace)
+	if logger.Level() != LevelTrace {
+		t.Errorf("Level() = %v, want %v", logger.Level(), LevelTrace)
+	}
+	logger.With("component", "cli").Trace("Running command", "args", "read correct-horse-battery")
+
+	content, err := os.ReadFile(logFile) // #nosec G304 - test file path is controlled
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	logContent := string(content)
+	if strings.Contains(logContent, "dropped at debug level") {
+		t.Error("trace message logged below the configured level")
+	}
+	if !strings.Contains(logContent, `"level":"TRACE"`) || !strings.Contains(logContent, "Running command") {
+		t.Errorf("trace message missing or not named TRACE: %s", logContent)
+	}
+	if strings.Contains(logContent, "correct-horse-battery") {
+		t.Errorf("trace message was not redacted: %s", logContent)
+	}
+}
+
 func TestLoggerMethods(t *testing.T) {
 	// Create a logger with a temporary log file
 	tempDir := t.TempDir()
@@ -329,6 +387,35 @@ func TestLoggerWith(t *testing.T) {
 	}
 }
 
+func TestMaskCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "empty", value: "  ", want: nil},
+		{name: "single line", value: "secret", want: []string{"::add-mask::secret"}},
+		{
+			name:  "crlf lines and duplicates",
+			value: "line1\r\n\r\nline1\r\n50%",
+			want: []string{
+				"::add-mask::line1",
+				"::add-mask::50%",
+				"::add-mask::line1%0D%0A%0D%0Aline1%0D%0A50%25",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MaskCommands(tt.value)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MaskCommands(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGitHubActionsMethods(t *testing.T) {
 	// Temporarily redirect stdout
 	oldStdout := os.Stdout
@@ -510,3 +597,142 @@ func BenchmarkContextAwareWriter(b *testing.B) {
 		_, _ = caw.Write(message)
 	}
 }
+
+func TestGitHubSummary_AppendsAfterPriorSteps(t *testing.T) {
+	path := t.TempDir() + "/step_summary"
+	if err := os.WriteFile(path, []byte("## Earlier step"), 0600); err != nil {
+		t.Fatalf("Failed to write step summary: %v", err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	l := newMaskTestLogger(t)
+	if err := l.GitHubSummarySection("Secrets", "| a |"); err != nil {
+		t.Fatalf("GitHubSummarySection() error = %v", err)
+	}
+	if err := l.GitHubSummary("done\n"); err != nil {
+		t.Fatalf("GitHubSummary() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read step summary: %v", err)
+	}
+	want := "## Earlier step\n## Secrets\n\n| a |\n\ndone\n"
+	if string(data) != want {
+		t.Errorf("step summary = %q, want %q", string(data), want)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	logger, err := NewWithConfig(Config{
+		Level:         slog.LevelInfo,
+		LogFile:       logFile,
+		Format:        FormatJSON,
+		DisableStderr: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Cleanup() }()
+
+	secret := "hunter2-correct-horse-battery"
+	logger.Info("Connected", "db_password", secret, "user", "admin", "attempts", 2)
+
+	content, err := os.ReadFile(logFile) // #nosec G304 - test file path is controlled
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(content), &entry); err != nil {
+		t.Fatalf("Log line is not JSON: %v\n%s", err, content)
+	}
+	for _, key := range []string{"time", "level", "msg"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("JSON log line is missing %q: %s", key, content)
+		}
+	}
+	if entry["msg"] != "Connected" || entry["level"] != "INFO" || entry["user"] != "admin" {
+		t.Errorf("Unexpected JSON log line: %s", content)
+	}
+
+	masked, _ := entry["db_password"].(string)
+	if !strings.Contains(masked, "***") || strings.Contains(string(content), secret) {
+		t.Errorf("db_password = %q, want a masked value", masked)
+	}
+}
+
+func TestLogErrorFormats(t *testing.T) {
+	secret := "hunter2-correct-horse-battery"
+	err := apperrors.New(apperrors.ErrCodeSecretNotFound, "Secret not found")
+
+	for _, format := range []string{FormatText, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "test.log")
+			logger, lerr := NewWithConfig(Config{
+				Level:         slog.LevelInfo,
+				LogFile:       logFile,
+				Format:        format,
+				DisableStderr: true,
+			})
+			if lerr != nil {
+				t.Fatalf("Failed to create logger: %v", lerr)
+			}
+			defer func() { _ = logger.Cleanup() }()
+
+			logger.LogError(err, "Retrieval failed", map[string]interface{}{"db_password": secret})
+
+			content, rerr := os.ReadFile(logFile) // #nosec G304 - test file path is controlled
+			if rerr != nil {
+				t.Fatalf("Failed to read log file: %v", rerr)
+			}
+			if strings.Contains(string(content), secret) {
+				t.Errorf("%s log line leaks the secret: %s", format, content)
+			}
+			if !strings.Contains(string(content), string(apperrors.ErrCodeSecretNotFound)) {
+				t.Errorf("%s log line is missing the error code: %s", format, content)
+			}
+
+			if format == FormatJSON {
+				var entry map[string]interface{}
+				if jerr := json.Unmarshal(bytes.TrimSpace(content), &entry); jerr != nil {
+					t.Fatalf("Log line is not JSON: %v\n%s", jerr, content)
+				}
+				if entry["error_code"] != string(apperrors.ErrCodeSecretNotFound) || entry["level"] != "ERROR" {
+					t.Errorf("Unexpected JSON log line: %s", content)
+				}
+			}
+		})
+	}
+}
+
+func TestNewWithFormat(t *testing.T) {
+	if _, err := NewWithFormat("xml"); err == nil {
+		t.Error("NewWithFormat(\"xml\") succeeded, want an error")
+	}
+
+	t.Setenv("OP_LOG_FORMAT", "")
+	t.Setenv("INPUT_LOG_FORMAT", "")
+	logger, err := NewWithFormat(FormatJSON)
+	if err != nil {
+		t.Fatalf("NewWithFormat() failed: %v", err)
+	}
+	defer func() { _ = logger.Cleanup() }()
+	if logger.config.Format != FormatJSON {
+		t.Errorf("Format = %q, want %q", logger.config.Format, FormatJSON)
+	}
+}
+
+func TestFormatFromEnvironment(t *testing.T) {
+	t.Setenv("INPUT_LOG_FORMAT", "")
+	t.Setenv("OP_LOG_FORMAT", "")
+	if got := DefaultConfig().Format; got != FormatText {
+		t.Errorf("default Format = %q, want %q", got, FormatText)
+	}
+
+	t.Setenv("OP_LOG_FORMAT", "JSON")
+	if got := DefaultConfig().Format; got != FormatJSON {
+		t.Errorf("Format with OP_LOG_FORMAT=JSON = %q, want %q", got, FormatJSON)
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// minMaskLength is the shortest value registered as a mask. Shorter values
+// such as "1" or "ok" would hide common substrings throughout the logs.
+const minMaskLength = 6
+
+var numericValue = regexp.MustCompile(`^[0-9]+$`)
+
+// maskRegistry remembers which mask commands have been emitted, by digest,
+// so a value retrieved or transformed several times is registered once.
+// It is shared by every logger derived from the same root.
+type maskRegistry struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]struct{}
+}
+
+// add reports whether command has not been emitted before.
+func (r *maskRegistry) add(command string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[[sha256.Size]byte]struct{})
+	}
+	digest := sha256.Sum256([]byte(command))
+	if _, ok := r.seen[digest]; ok {
+		return false
+	}
+	r.seen[digest] = struct{}{}
+	return true
+}
+
+// IsMaskable reports whether value is safe to register as a GitHub Actions
+// mask. Empty, whitespace-only, very short and purely numeric values are
+// not masked to avoid hiding common substrings.
+func IsMaskable(value string) bool {
+	v := strings.TrimSpace(value)
+	if len(v) < minMaskLength {
+		return false
+	}
+	return !numericValue.MatchString(v)
+}
+
+// MaskVariants returns value together with the encodings it commonly
+// appears in once a later step transforms it: standard and URL-safe base64,
+// with and without padding. Duplicates are removed.
+func MaskVariants(value string) []string {
+	data := []byte(value)
+	variants := []string{value}
+	seen := map[string]bool{value: true}
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		encoded := encoding.EncodeToString(data)
+		if !seen[encoded] {
+			seen[encoded] = true
+			variants = append(variants, encoded)
+		}
+	}
+	return variants
+}
+
+// MaskOneTimeCode registers a one-time password as a GitHub Actions mask.
+// Such codes are short and often numeric, so MaskSecret would skip them, but
+// a live code must not reach the logs. Empty values are ignored.
+func (l *Logger) MaskOneTimeCode(value string) {
+	code := strings.TrimSpace(value)
+	if code == "" {
+		return
+	}
+	for _, command := range MaskCommands(code) {
+		if l.masks != nil && !l.masks.add(command) {
+			continue
+		}
+		fmt.Println(command)
+	}
+}
+
+// MaskSecret registers value and its common encodings as GitHub Actions
+// masks before the value can reach a log line, output or environment
+// variable. Multi-line values are masked line by line, since GitHub only
+// matches a mask within a single line. Values that are not maskable are
+// skipped, and a mask already emitted by this logger is not repeated.
+func (l *Logger) MaskSecret(value string) {
+	if !IsMaskable(value) {
+		return
+	}
+
+	emitted := 0
+	for _, variant := range MaskVariants(value) {
+		for _, command := range MaskCommands(variant) {
+			if l.masks != nil && !l.masks.add(command) {
+				continue
+			}
+			fmt.Println(command)
+			emitted++
+		}
+	}
+	if emitted > 0 {
+		l.Debug("Registered secret masks", "masks", emitted)
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package logger
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	fn()
+
+	_ = w.Close()
+	out, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	return string(out)
+}
+
+func newMaskTestLogger(t *testing.T) *Logger {
+	t.Helper()
+	config := DefaultConfig()
+	config.DisableStderr = true
+	config.DisableFileLogging = true
+	l, err := NewWithConfig(config)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Cleanup() })
+	return l
+}
+
+func TestIsMaskable(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: " \t\n ", want: false},
+		{value: "ok", want: false},
+		{value: "  abc  ", want: false},
+		{value: "1234567890", want: false},
+		{value: "s3cr3t", want: true},
+		{value: "line1\nline2", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsMaskable(tt.value); got != tt.want {
+			t.Errorf("IsMaskable(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaskVariants(t *testing.T) {
+	value := "secret?>"
+	got := MaskVariants(value)
+
+	want := []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.URLEncoding.EncodeToString([]byte(value)),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+	}
+	for _, w := range want {
+		if !containsString(got, w) {
+			t.Errorf("MaskVariants(%q) missing %q: %q", value, w, got)
+		}
+	}
+
+	// Encodings that coincide are listed once
+	seen := make(map[string]bool)
+	for _, v := range MaskVariants("abc") {
+		if seen[v] {
+			t.Errorf("MaskVariants returned duplicate %q", v)
+		}
+		seen[v] = true
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	l := newMaskTestLogger(t)
+	value := "first-line\nsecond-line\n"
+
+	out := captureStdout(t, func() {
+		l.MaskSecret(value)
+		l.MaskSecret("   ")
+		l.MaskSecret("12345678")
+	})
+
+	for _, want := range []string{
+		"::add-mask::first-line\n",
+		"::add-mask::second-line\n",
+		"::add-mask::first-line%0Asecond-line%0A\n",
+		"::add-mask::" + base64.StdEncoding.EncodeToString([]byte(value)) + "\n",
+		"::add-mask::" + base64.RawURLEncoding.EncodeToString([]byte(value)) + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing mask %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "12345678") || strings.Contains(out, "::add-mask::\n") {
+		t.Errorf("unmaskable values must be skipped:\n%s", out)
+	}
+
+	// Masks already emitted, including through derived loggers, are not repeated
+	again := captureStdout(t, func() {
+		l.MaskSecret(value)
+		l.With("key", "value").MaskSecret(value)
+		l.WithGroup("group").MaskSecret(value)
+	})
+	if again != "" {
+		t.Errorf("expected no repeated masks, got:\n%s", again)
+	}
+}
+
+func TestMaskOneTimeCode(t *testing.T) {
+	l := newMaskTestLogger(t)
+
+	out := captureStdout(t, func() {
+		l.MaskOneTimeCode(" 123456\n")
+		l.MaskOneTimeCode("123456")
+		l.MaskOneTimeCode("   ")
+	})
+	if out != "::add-mask::123456\n" {
+		t.Errorf("expected a single mask for the code, got:\n%s", out)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
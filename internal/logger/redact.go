@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package logger
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// redactedValue replaces a registered secret value in log output.
+const redactedValue = "***"
+
+// redactor replaces registered secret values in every log line before it is
+// written, so values stay out of local and file logs that GitHub's masking
+// never sees. It is shared by every logger derived from the same root.
+type redactor struct {
+	mu       sync.Mutex
+	values   map[string]struct{}
+	replacer atomic.Pointer[strings.Replacer] // nil until a value is added
+}
+
+// add registers the forms value can take in a log line and reports whether
+// any of them was new.
+func (r *redactor) add(value string) bool {
+	forms := redactionForms(value)
+	if len(forms) == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = make(map[string]struct{})
+	}
+	added := false
+	for _, form := range forms {
+		if _, ok := r.values[form]; !ok {
+			r.values[form] = struct{}{}
+			added = true
+		}
+	}
+	if !added {
+		return false
+	}
+
+	// Longer values come first so that a secret containing another
+	// registered secret is replaced whole
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, redactedValue)
+	}
+	r.replacer.Store(strings.NewReplacer(pairs...))
+	return true
+}
+
+// redact returns line with every registered value replaced by "***".
+func (r *redactor) redact(line string) string {
+	if r == nil {
+		return line
+	}
+	replacer := r.replacer.Load()
+	if replacer == nil {
+		return line
+	}
+	return replacer.Replace(line)
+}
+
+// redactionForms returns the substrings of value to redact: the value
+// itself, each line of a multi-line value on its own, since a log line may
+// hold just one of them, and the escaped forms the text and JSON handlers
+// write for values with quotes, backslashes or control characters. Forms
+// shorter than minMaskLength are left out to avoid hiding common
+// substrings.
+func redactionForms(value string) []string {
+	candidates := []string{value}
+	if strings.ContainsAny(value, "\r\n") {
+		for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' }) {
+			candidates = append(candidates, strings.TrimSpace(line))
+		}
+	}
+
+	var forms []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		quoted := strconv.Quote(candidate)
+		variants := []string{candidate, quoted[1 : len(quoted)-1]}
+		if encoded, err := json.Marshal(candidate); err == nil {
+			variants = append(variants, string(encoded[1:len(encoded)-1]))
+		}
+		for _, v := range variants {
+			if len(strings.TrimSpace(v)) < minMaskLength || seen[v] {
+				continue
+			}
+			seen[v] = true
+			forms = append(forms, v)
+		}
+	}
+	return forms
+}
+
+// RegisterSecret adds value to the values replaced by "***" in every line
+// this logger, and any logger derived from the same root, writes from now
+// on. Multi-line values are also redacted line by line. Values that are not
+// maskable are ignored, as with MaskSecret.
+func (l *Logger) RegisterSecret(value string) {
+	if !IsMaskable(value) || l.redactor == nil {
+		return
+	}
+	l.redactor.add(value)
+}
+
+// Redact returns text as this logger would write it: registered secret
+// values are replaced by "***" and service account tokens are scrubbed. Use
+// it for text that leaves the process other than through the logger, such
+// as CLI output quoted in an error. A nil logger returns text unchanged.
+func (l *Logger) Redact(text string) string {
+	if l == nil {
+		return text
+	}
+	return conceal(l.redactor, text)
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterSecret(t *testing.T) {
+	secret := "pa\"ss\\word-4242"
+	multiLine := "-----BEGIN KEY-----\nMIIEvQIBADANBgkq\n-----END KEY-----"
+
+	for _, format := range []string{FormatText, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "test.log")
+			logger, err := NewWithConfig(Config{
+				Level:         slog.LevelInfo,
+				LogFile:       logFile,
+				Format:        format,
+				DisableStderr: true,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create logger: %v", err)
+			}
+			defer func() { _ = logger.Cleanup() }()
+
+			logger.RegisterSecret(secret)
+			logger.RegisterSecret(multiLine)
+			logger.RegisterSecret("short") // Too short to redact
+
+			derived := logger.With("component", "test")
+			derived.Info("Fetched "+secret, "value", secret)
+			logger.Info("Partial value", "line", "MIIEvQIBADANBgkq")
+			logger.Info("Whole value", "pem", multiLine)
+			logger.Info("Kept", "word", "short")
+
+			content, err := os.ReadFile(logFile) // #nosec G304 - test file path is controlled
+			if err != nil {
+				t.Fatalf("Failed to read log file: %v", err)
+			}
+			output := string(content)
+			for _, leaked := range []string{"ss\\word-4242", "word-4242", "MIIEvQIBADANBgkq", "BEGIN KEY"} {
+				if strings.Contains(output, leaked) {
+					t.Errorf("%s log leaks %q:\n%s", format, leaked, output)
+				}
+			}
+			if !strings.Contains(output, redactedValue) {
+				t.Errorf("%s log has no redaction marker:\n%s", format, output)
+			}
+			if !strings.Contains(output, "short") {
+				t.Errorf("%s log redacted a value below the minimum length:\n%s", format, output)
+			}
+		})
+	}
+}
+
+func TestRedactorLongestFirst(t *testing.T) {
+	r := &redactor{}
+	r.add("abcdef")
+	r.add("abcdefghij")
+
+	if got := r.redact("x abcdefghij y abcdef z"); got != "x *** y *** z" {
+		t.Errorf("redact() = %q", got)
+	}
+	if got := (*redactor)(nil).redact("abcdef"); got != "abcdef" {
+		t.Errorf("nil redactor changed the line: %q", got)
+	}
+}
+
+func BenchmarkRedact(b *testing.B) {
+	r := &redactor{}
+	for i := 0; i < 50; i++ {
+		r.add(fmt.Sprintf("secret-value-%03d-%s", i, strings.Repeat("x", 24)))
+	}
+	line := `time=2025-01-01T00:00:00Z level=INFO msg="Resolved secret" key=database_password vault=Production duration=12ms`
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = r.redact(line)
+	}
+}
+
+func BenchmarkContextAwareWriterRedaction(b *testing.B) {
+	var buf bytes.Buffer
+	r := &redactor{}
+	for i := 0; i < 50; i++ {
+		r.add(fmt.Sprintf("secret-value-%03d-%s", i, strings.Repeat("x", 24)))
+	}
+	caw := &contextAwareWriter{writer: &buf, redactor: r}
+	message := []byte(`time=2025-01-01T00:00:00Z level=INFO msg="Resolved secret" value=secret-value-007-xxxxxxxxxxxxxxxxxxxxxxxx`)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_, _ = caw.Write(message)
+	}
+}
+
+func TestLoggerRedact(t *testing.T) {
+	logger, err := NewWithConfig(Config{Level: slog.LevelInfo, DisableStderr: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer func() { _ = logger.Cleanup() }()
+
+	logger.RegisterSecret("correct-horse-battery")
+	if got := logger.Redact("[ERROR] bad value correct-horse-battery"); got != "[ERROR] bad value ***" {
+		t.Errorf("Redact() = %q, want the registered value replaced", got)
+	}
+	if got := logger.With("component", "cli").Redact("kept as is"); got != "kept as is" {
+		t.Errorf("Redact() = %q, want the text unchanged", got)
+	}
+
+	var nilLogger *Logger
+	if got := nilLogger.Redact("correct-horse-battery"); got != "correct-horse-battery" {
+		t.Errorf("nil Redact() = %q, want the text unchanged", got)
+	}
+}
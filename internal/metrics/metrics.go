@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+// Package metrics defines the hooks the action reports observability data
+// through. Implementations adapt them to Prometheus, statsd or any other
+// system without the action depending on one.
+package metrics
+
+import "time"
+
+// Metrics receives measurements from a run. Methods are called from
+// concurrent goroutines and must be safe for concurrent use; they should
+// return quickly, as they run inline with secret resolution. No method is
+// ever passed a secret value.
+type Metrics interface {
+	// ObserveResolveDuration reports how long resolving one secret took,
+	// including retries, whether or not it succeeded. ref is the secret
+	// reference in "op://vault/item/field" form.
+	ObserveResolveDuration(ref string, d time.Duration)
+
+	// ObserveDownloadBytes reports the size of a downloaded CLI archive.
+	ObserveDownloadBytes(n int64)
+}
+
+// Nop is a Metrics that discards every measurement.
+type Nop struct{}
+
+// ObserveResolveDuration implements Metrics.
+func (Nop) ObserveResolveDuration(string, time.Duration) {}
+
+// ObserveDownloadBytes implements Metrics.
+func (Nop) ObserveDownloadBytes(int64) {}
+
+// OrNop returns m, or Nop when m is nil.
+func OrNop(m Metrics) Metrics {
+	if m == nil {
+		return Nop{}
+	}
+	return m
+}
@@ -65,6 +65,7 @@ type OperationContext struct {
 	context       map[string]interface{}
 	auditOp       *audit.Operation
 	completed     bool
+	duration      time.Duration // Set once the operation completes or fails
 	mu            sync.Mutex
 }
 
@@ -132,6 +133,7 @@ func (op *OperationContext) CompleteOperation(result map[string]interface{}) {
 	op.completed = true
 
 	duration := time.Since(op.startTime)
+	op.duration = duration
 
 	// Update metrics
 	op.monitor.mu.Lock()
@@ -167,6 +169,7 @@ func (op *OperationContext) FailOperation(err error) {
 	op.completed = true
 
 	duration := time.Since(op.startTime)
+	op.duration = duration
 
 	// Update metrics
 	op.monitor.mu.Lock()
@@ -186,6 +189,18 @@ func (op *OperationContext) FailOperation(err error) {
 	}
 }
 
+// Duration returns how long the operation took, or how long it has been
+// running if it has not completed yet.
+func (op *OperationContext) Duration() time.Duration {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if op.completed {
+		return op.duration
+	}
+	return time.Since(op.startTime)
+}
+
 // AddContext adds additional context to an operation
 func (op *OperationContext) AddContext(key string, value interface{}) {
 	op.mu.Lock()
@@ -253,7 +268,9 @@ func (m *Monitor) HandleError(err error, context string, details map[string]inte
 	// Generate GitHub Actions summary for significant errors
 	if errorSeverity == errors.SeverityHigh || errorSeverity == errors.SeverityCritical {
 		if actionableErr, ok := err.(*errors.ActionableError); ok {
-			if summaryErr := m.logger.GitHubSummaryError(context, err, actionableErr.GetSuggestions()); summaryErr != nil {
+			suggestions := append(append([]string{}, actionableErr.GetSuggestions()...),
+				fmt.Sprintf("See %s", actionableErr.HelpURL()))
+			if summaryErr := m.logger.GitHubSummaryError(context, err, suggestions); summaryErr != nil {
 				m.logger.Error("Failed to write GitHub summary for actionable error", "error", summaryErr)
 			}
 		} else {
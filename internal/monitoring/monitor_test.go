@@ -154,6 +154,16 @@ func TestCompleteOperation(t *testing.T) {
 		t.Errorf("Expected operation to be marked as completed")
 	}
 
+	// The duration is fixed once the operation completes
+	duration := op.Duration()
+	if duration < 10*time.Millisecond {
+		t.Errorf("Duration() = %v, want at least 10ms", duration)
+	}
+	time.Sleep(time.Millisecond)
+	if got := op.Duration(); got != duration {
+		t.Errorf("Duration() changed after completion: %v, then %v", duration, got)
+	}
+
 	// Check metrics
 	metrics := monitor.GetMetrics()
 	if metrics["operations_completed"].(int64) != 1 {
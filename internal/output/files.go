@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+)
+
+// DefaultSecretsDirName is the directory created under the GitHub workspace
+// when return_type is "file" and no secrets_dir is configured.
+const DefaultSecretsDirName = ".1password-secrets"
+
+// SecretFilePath returns the file a secret is written to for return_type
+// "file". The file name is the output name unchanged, so a record key of
+// "db_password" is written to <dir>/db_password.
+func SecretFilePath(dir, name string) string {
+	return filepath.Join(dir, name)
+}
+
+// ResolveSecretsDir returns the absolute directory used for secret files. A
+// relative secrets_dir is taken relative to the workspace, and the directory
+// must not lie outside the workspace.
+func ResolveSecretsDir(cfg *config.Config) (string, error) {
+	if cfg.GitHubWorkspace == "" {
+		return "", fmt.Errorf("no secrets directory: GITHUB_WORKSPACE is not set")
+	}
+
+	workspace, err := filepath.Abs(cfg.GitHubWorkspace)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	dir := cfg.SecretsDir
+	switch {
+	case dir == "":
+		dir = filepath.Join(workspace, DefaultSecretsDirName)
+	case !filepath.IsAbs(dir):
+		dir = filepath.Join(workspace, dir)
+	}
+	dir = filepath.Clean(dir)
+
+	if !withinDir(workspace, dir) {
+		return "", fmt.Errorf("secrets directory %s is outside the workspace %s", dir, workspace)
+	}
+	return dir, nil
+}
+
+// withinDir reports whether target is base or lies beneath it. Symlinks are
+// resolved for the parts of either path that already exist, so a link cannot
+// be used to escape base.
+func withinDir(base, target string) bool {
+	base, target = resolveExisting(base), resolveExisting(target)
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveExisting evaluates symlinks in the longest existing prefix of path
+// and appends the remainder unchanged.
+func resolveExisting(path string) string {
+	rest := ""
+	for current := path; ; current = filepath.Dir(current) {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+	}
+}
+
+// ensureSecretsDir creates dir with 0700 permissions and reports whether it
+// was created by this call. A directory created under the workspace also gets
+// a .gitignore so that secret files cannot be committed by accident.
+func ensureSecretsDir(dir string, ignoreContents bool) (bool, error) {
+	if info, err := os.Stat(dir); err == nil {
+		if !info.IsDir() {
+			return false, fmt.Errorf("secrets directory %s is not a directory", dir)
+		}
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to access secrets directory: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	if ignoreContents {
+		if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0600); err != nil {
+			return true, fmt.Errorf("failed to write secrets directory .gitignore: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// writeSecretFile atomically writes value to path with 0600 permissions.
+func writeSecretFile(path string, value []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".secret-*")
+	if err != nil {
+		return fmt.Errorf("failed to create secret file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if err := tmp.Chmod(0600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set secret file permissions: %w", err)
+	}
+	if _, err := tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write secret file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close secret file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to store secret file: %w", err)
+	}
+	return nil
+}
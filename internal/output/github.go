@@ -247,8 +247,10 @@ func (gh *GitHubActions) MaskValue(value string) error {
 		return nil
 	}
 
-	// Add mask using GitHub Actions command
-	fmt.Printf("::add-mask::%s\n", value)
+	// Add mask using GitHub Actions commands, one per line for multi-line values
+	for _, command := range logger.MaskCommands(value) {
+		fmt.Println(command)
+	}
 
 	// Track masked value
 	gh.masks = append(gh.masks, value)
@@ -259,29 +261,14 @@ func (gh *GitHubActions) MaskValue(value string) error {
 
 // writeToFile writes a name=value pair to a GitHub Actions file
 func (gh *GitHubActions) writeToFile(filePath, name, value string) error {
-	// Handle multiline values using GitHub Actions format
-	if strings.Contains(value, "\n") {
+	// Handle multiline values using GitHub Actions format; a bare carriage
+	// return would also end a name=value line for the runner
+	if strings.ContainsAny(value, "\r\n") {
 		return gh.writeMultilineToFile(filePath, name, value)
 	}
 
-	// Open file for appending
-	// #nosec G304 -- filePath is from GitHub Actions environment variables, not user input
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			gh.logger.Error("Failed to close output file", "file", filePath, "error", closeErr)
-		}
-	}()
-
 	// Write name=value format
-	if _, err := fmt.Fprintf(file, "%s=%s\n", name, value); err != nil {
-		return fmt.Errorf("failed to write to file: %w", err)
-	}
-
-	return nil
+	return gh.appendEntry(filePath, name+"="+value+"\n")
 }
 
 // writeMultilineToFile writes a multiline value using GitHub Actions heredoc format
@@ -289,6 +276,15 @@ func (gh *GitHubActions) writeMultilineToFile(filePath, name, value string) erro
 	// Generate a unique delimiter
 	delimiter := gh.generateDelimiter(value)
 
+	// Heredoc format: name<<delimiter, the value, then the delimiter
+	return gh.appendEntry(filePath, name+"<<"+delimiter+"\n"+value+"\n"+delimiter+"\n")
+}
+
+// appendEntry appends a complete entry to a GitHub Actions file in a single
+// write. If the write fails part way, the file is truncated back to its
+// previous size, so a later entry never lands inside an unterminated
+// heredoc and is read as part of its value.
+func (gh *GitHubActions) appendEntry(filePath, entry string) error {
 	// Open file for appending
 	// #nosec G304 -- filePath is from GitHub Actions environment variables, not user input
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0600)
@@ -297,37 +293,53 @@ func (gh *GitHubActions) writeMultilineToFile(filePath, name, value string) erro
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			gh.logger.Error("Failed to close env file", "file", filePath, "error", closeErr)
+			gh.logger.Error("Failed to close file", "file", filePath, "error", closeErr)
 		}
 	}()
 
-	// Write heredoc format: name<<delimiter
-	if _, err := fmt.Fprintf(file, "%s<<%s\n", name, delimiter); err != nil {
-		return fmt.Errorf("failed to write heredoc start: %w", err)
-	}
-
-	// Write the value
-	if _, err := fmt.Fprintf(file, "%s\n", value); err != nil {
-		return fmt.Errorf("failed to write value: %w", err)
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	// Write the ending delimiter
-	if _, err := fmt.Fprintf(file, "%s\n", delimiter); err != nil {
-		return fmt.Errorf("failed to write heredoc end: %w", err)
+	if _, err := file.WriteString(entry); err != nil {
+		if truncErr := file.Truncate(info.Size()); truncErr != nil {
+			gh.logger.Error("Failed to roll back partial write", "file", filePath, "error", truncErr)
+		}
+		return fmt.Errorf("failed to write to file: %w", err)
 	}
 
 	return nil
 }
 
-// generateDelimiter creates a unique delimiter for heredoc format
-func (gh *GitHubActions) generateDelimiter(_ string) string {
+// maxDelimiterAttempts bounds the random delimiters tried before falling
+// back to a counter.
+const maxDelimiterAttempts = 8
+
+// generateDelimiter creates a heredoc delimiter that does not appear in
+// value, so no line of the value can close the heredoc early
+func (gh *GitHubActions) generateDelimiter(value string) string {
 	// Generate a cryptographically random delimiter to avoid collisions
 	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to static delimiter if randomness unavailable
-		return "EOF"
+	for i := 0; i < maxDelimiterAttempts; i++ {
+		if _, err := rand.Read(b); err != nil {
+			break
+		}
+		if delimiter := "EOF_" + strings.ToUpper(hex.EncodeToString(b)); !strings.Contains(value, delimiter) {
+			return delimiter
+		}
+	}
+	return fallbackDelimiter(value)
+}
+
+// fallbackDelimiter returns the first of "EOF", "EOF_1", "EOF_2", ... that
+// does not appear in value, for when randomness is unavailable.
+func fallbackDelimiter(value string) string {
+	delimiter := "EOF"
+	for n := 1; strings.Contains(value, delimiter); n++ {
+		delimiter = fmt.Sprintf("EOF_%d", n)
 	}
-	return "EOF_" + strings.ToUpper(hex.EncodeToString(b))
+	return delimiter
 }
 
 // stdout fallback removed
@@ -340,11 +352,22 @@ func (gh *GitHubActions) ValidateOutputCapability() error {
 	return nil
 }
 
-// ValidateEnvCapability checks if environment variable operations are supported
+// ValidateEnvCapability checks if environment variable operations are
+// supported and, with ValidateFiles, that GITHUB_ENV is still writable
 func (gh *GitHubActions) ValidateEnvCapability() error {
 	if gh.config.EnvFile == "" {
 		return fmt.Errorf("GITHUB_ENV not available")
 	}
+	if gh.config.ValidateFiles && !gh.config.DryRun {
+		// #nosec G304 -- EnvFile is from GitHub Actions environment variables, not user input
+		file, err := os.OpenFile(gh.config.EnvFile, os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("GITHUB_ENV file is not writable: %w", err)
+		}
+		if closeErr := file.Close(); closeErr != nil {
+			gh.logger.Error("Failed to close file", "file", gh.config.EnvFile, "error", closeErr)
+		}
+	}
 	return nil
 }
 
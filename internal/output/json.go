@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// SecretsJSONOutput is the output holding every secret as one JSON object
+// when return_type is "json"; SecretsJSONEnv is the matching environment
+// variable written when json_env is enabled.
+const (
+	SecretsJSONOutput = "secrets_json"
+	SecretsJSONEnv    = "SECRETS_JSON"
+)
+
+// nestedKeySeparator splits an output name into nested JSON keys, so that
+// "db__password" becomes {"db": {"password": ...}}.
+const nestedKeySeparator = "__"
+
+// secretsJSONValue assembles the secrets into a single JSON document. Every
+// value is masked first, both raw and in its JSON-escaped form, so that no
+// part of the document can reach the logs unmasked. The individual values
+// are destroyed once the document is built.
+func (m *Manager) secretsJSONValue(values []*Value) (*Value, error) {
+	doc := make(map[string]interface{}, len(values))
+	var timestamp int64
+
+	for _, value := range values {
+		raw := value.Value.String()
+		if err := m.maskComponents(value); err != nil {
+			return nil, fmt.Errorf("failed to mask value for '%s': %w", value.Name, err)
+		}
+		for _, form := range []string{raw, jsonEscape(raw)} {
+			if m.isMaskable(form) {
+				if err := m.maskValue(form); err != nil {
+					return nil, fmt.Errorf("failed to mask value for '%s': %w", value.Name, err)
+				}
+			}
+		}
+
+		if err := setNestedKey(doc, jsonKeyPath(value.Name), raw); err != nil {
+			return nil, err
+		}
+		if value.Timestamp > timestamp {
+			timestamp = value.Timestamp
+		}
+	}
+
+	encoded, err := encodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secrets as JSON: %w", err)
+	}
+	secureDoc, err := security.NewSecureString(encoded)
+	security.SecureZero(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secure JSON value: %w", err)
+	}
+
+	for _, value := range values {
+		_ = value.Value.Destroy()
+	}
+
+	return &Value{
+		Name:      SecretsJSONOutput,
+		Value:     secureDoc,
+		Source:    "secret",
+		Timestamp: timestamp,
+	}, nil
+}
+
+// jsonKeyPath splits an output name on nestedKeySeparator. A name with an
+// empty segment, such as "_private" or "a____b", is kept as a single key.
+func jsonKeyPath(name string) []string {
+	parts := strings.Split(name, nestedKeySeparator)
+	for _, part := range parts {
+		if part == "" {
+			return []string{name}
+		}
+	}
+	return parts
+}
+
+// setNestedKey stores value at path in doc, creating intermediate objects.
+func setNestedKey(doc map[string]interface{}, path []string, value string) error {
+	current := doc
+	for i, key := range path[:len(path)-1] {
+		switch next := current[key].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			current[key] = child
+			current = child
+		case map[string]interface{}:
+			current = next
+		default:
+			return fmt.Errorf("JSON key '%s' is both a value and an object",
+				strings.Join(path[:i+1], "."))
+		}
+	}
+
+	leaf := path[len(path)-1]
+	if _, exists := current[leaf]; exists {
+		return fmt.Errorf("JSON key '%s' is both a value and an object", strings.Join(path, "."))
+	}
+	current[leaf] = value
+	return nil
+}
+
+// encodeJSON marshals v on a single line without HTML escaping, so values
+// appear in the document exactly as jsonEscape renders them.
+func encodeJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonEscape returns s as it appears inside a JSON string.
+func jsonEscape(s string) string {
+	encoded, err := encodeJSON(s)
+	if err != nil {
+		return s
+	}
+	return string(encoded[1 : len(encoded)-1])
+}
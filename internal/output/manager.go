@@ -6,14 +6,19 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/secrets"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -28,15 +33,19 @@ type Manager struct {
 	outputs      map[string]*Value
 	envVars      map[string]*Value
 	maskedValues []string
+	files        []string // Secret files written for return_type "file"
+	secretsDir   string
+	createdDir   bool // Whether secretsDir was created by this manager
 }
 
 // Value represents a single output or environment variable value
 type Value struct {
-	Name      string
-	Value     *security.SecureString
-	Masked    bool
-	Source    string // "secret" or "metadata"
-	Timestamp int64
+	Name       string
+	Value      *security.SecureString
+	Components []*security.SecureString // Field values of a templated secret
+	Masked     bool
+	Source     string // "secret", "metadata" or "file"
+	Timestamp  int64
 }
 
 // Config holds configuration for the output manager
@@ -56,6 +65,7 @@ type Config struct {
 type Result struct {
 	OutputsSet    int
 	EnvVarsSet    int
+	FilesWritten  int
 	ValuesMasked  int
 	Errors        []error
 	Success       bool
@@ -156,8 +166,13 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 	// Process successful secrets
 	var pendingOutputs []Operation
 	var pendingEnvVars []Operation
+	var pendingFiles []Operation
+	var jsonValues []*Value
+	var emittedKeys []string
 
-	for key, secretResult := range result.Results {
+	// Results complete in any order; outputs follow the requests
+	for _, key := range result.Keys() {
+		secretResult := result.Results[key]
 		if secretResult.Error != nil {
 			m.logger.Debug("Skipping output for failed secret",
 				"key", key, "error", secretResult.Error)
@@ -166,7 +181,7 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 			continue
 		}
 
-		if secretResult.Value == nil || secretResult.Value.IsEmpty() {
+		if (secretResult.Value == nil || secretResult.Value.IsEmpty()) && !secretResult.Missing {
 			m.logger.Warn("Skipping output for empty secret", "key", key)
 			continue
 		}
@@ -178,20 +193,37 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 			continue
 		}
 
-		// Validate secret value
-		secretValue := secretResult.Value.String()
-		if err := m.validator.ValidateOutputValue(secretValue); err != nil {
-			outputResult.Errors = append(outputResult.Errors,
-				fmt.Errorf("invalid output value for '%s': %w", key, err))
-			continue
+		secretValue := ""
+		if secretResult.Value != nil {
+			secretValue = secretResult.Value.String()
 		}
+		processedValue := secretValue
+
+		// Attachments are written to files byte for byte; they are neither
+		// text values nor safe to place in outputs or the environment
+		if isAttachment(secretResult) {
+			if m.config.ReturnType != config.ReturnTypeFile {
+				outputResult.Errors = append(outputResult.Errors,
+					fmt.Errorf("attachment '%s' requires return_type 'file'", key))
+				continue
+			}
+		} else if !secretResult.Missing {
+			// Validate secret value, unless it is an optional field that
+			// was not found and is delivered empty
+			if err := m.validator.ValidateOutputValue(secretValue); err != nil {
+				outputResult.Errors = append(outputResult.Errors,
+					fmt.Errorf("invalid output value for '%s': %w", key, err))
+				continue
+			}
 
-		// Process the value
-		processedValue, err := m.processOutputValue(secretValue)
-		if err != nil {
-			outputResult.Errors = append(outputResult.Errors,
-				fmt.Errorf("failed to process value for '%s': %w", key, err))
-			continue
+			// Process the value
+			var err error
+			processedValue, err = m.processOutputValue(secretValue)
+			if err != nil {
+				outputResult.Errors = append(outputResult.Errors,
+					fmt.Errorf("failed to process value for '%s': %w", key, err))
+				continue
+			}
 		}
 
 		// Create secure string for the value
@@ -204,10 +236,11 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 
 		// Add to pending operations based on return type
 		outputValue := &Value{
-			Name:      key,
-			Value:     secureValue,
-			Source:    "secret",
-			Timestamp: result.Results[key].Metrics.EndTime.Unix(),
+			Name:       key,
+			Value:      secureValue,
+			Components: secretResult.Components,
+			Source:     "secret",
+			Timestamp:  result.Results[key].Metrics.EndTime.Unix(),
 		}
 
 		switch m.config.ReturnType {
@@ -217,6 +250,7 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 				Name:  key,
 				Value: outputValue,
 			})
+			emittedKeys = append(emittedKeys, key)
 
 		case config.ReturnTypeEnv:
 			pendingEnvVars = append(pendingEnvVars, Operation{
@@ -224,6 +258,18 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 				Name:  key,
 				Value: outputValue,
 			})
+
+		case config.ReturnTypeFile:
+			pendingFiles = append(pendingFiles, Operation{
+				Type:  "file",
+				Name:  key,
+				Value: outputValue,
+			})
+			emittedKeys = append(emittedKeys, key)
+
+		case config.ReturnTypeJSON:
+			jsonValues = append(jsonValues, outputValue)
+			emittedKeys = append(emittedKeys, key)
 		}
 
 		if m.config.ReturnType == config.ReturnTypeBoth {
@@ -235,26 +281,36 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 		}
 	}
 
-	// Add metadata outputs
-	if m.config.ReturnType == config.ReturnTypeOutput ||
-		m.config.ReturnType == config.ReturnTypeBoth {
-
-		secretsCountValue, err := security.NewSecureStringFromString(
-			fmt.Sprintf("%d", result.SuccessCount))
-		if err == nil {
+	// Combine the secrets into a single JSON output
+	if len(jsonValues) > 0 && len(outputResult.Errors) == 0 {
+		sort.Slice(jsonValues, func(i, j int) bool { return jsonValues[i].Name < jsonValues[j].Name })
+		jsonValue, err := m.secretsJSONValue(jsonValues)
+		if err != nil {
+			outputResult.Errors = append(outputResult.Errors, err)
+		} else {
 			pendingOutputs = append(pendingOutputs, Operation{
-				Type: "output",
-				Name: "secrets_count",
-				Value: &Value{
-					Name:      "secrets_count",
-					Value:     secretsCountValue,
-					Source:    "metadata",
-					Timestamp: result.Results[getFirstKey(result.Results)].Metrics.EndTime.Unix(),
-				},
+				Type:  "output",
+				Name:  SecretsJSONOutput,
+				Value: jsonValue,
 			})
+			if m.config.JSONEnv {
+				pendingEnvVars = append(pendingEnvVars, Operation{
+					Type:  "env",
+					Name:  SecretsJSONEnv,
+					Value: jsonValue,
+				})
+			}
 		}
 	}
 
+	// Add metadata outputs
+	if m.config.ReturnType == config.ReturnTypeOutput ||
+		m.config.ReturnType == config.ReturnTypeBoth ||
+		m.config.ReturnType == config.ReturnTypeFile ||
+		m.config.ReturnType == config.ReturnTypeJSON {
+		pendingOutputs = append(pendingOutputs, m.metadataOperations(result, emittedKeys)...)
+	}
+
 	// Execute operations atomically if configured
 	if m.outputConfig.AtomicOperations {
 		// For atomic operations, execute all or none
@@ -263,12 +319,22 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 		}
 	}
 
+	// Write secret files first so their paths are available as outputs
+	if len(pendingFiles) > 0 {
+		if err := m.executeFileOperations(pendingFiles); err != nil {
+			outputResult.Errors = append(outputResult.Errors, err)
+		} else {
+			outputResult.FilesWritten = len(pendingFiles)
+			outputResult.OutputsSet += len(pendingFiles)
+		}
+	}
+
 	// Execute output operations
 	if len(pendingOutputs) > 0 {
 		if err := m.executeOutputOperations(pendingOutputs); err != nil {
 			outputResult.Errors = append(outputResult.Errors, err)
 		} else {
-			outputResult.OutputsSet = len(pendingOutputs)
+			outputResult.OutputsSet += len(pendingOutputs)
 		}
 	}
 
@@ -281,6 +347,15 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 		}
 	}
 
+	// Read the written files back to catch encoding faults
+	if m.config.VerifyOutputs && len(outputResult.Errors) == 0 {
+		if err := m.github.VerifyFiles(); err != nil {
+			outputResult.Errors = append(outputResult.Errors, err)
+			outputResult.ValuesMasked = len(m.maskedValues)
+			return outputResult, fmt.Errorf("output verification failed: %w", err)
+		}
+	}
+
 	// Count masked values
 	outputResult.ValuesMasked = len(m.maskedValues)
 	outputResult.Success = len(outputResult.Errors) == 0
@@ -289,6 +364,7 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 	m.logger.Info("Output processing completed",
 		"outputs_set", outputResult.OutputsSet,
 		"env_vars_set", outputResult.EnvVarsSet,
+		"files_written", outputResult.FilesWritten,
 		"values_masked", outputResult.ValuesMasked,
 		"errors", len(outputResult.Errors),
 		"success", outputResult.Success)
@@ -296,9 +372,68 @@ func (m *Manager) ProcessSecrets(result *secrets.BatchResult) (*Result, error) {
 	return outputResult, nil
 }
 
+// Metadata output names
+const (
+	SecretsCountOutput        = "secrets_count"
+	OutputSchemaVersionOutput = "output_schema_version"
+	SecretsKeysOutput         = "secrets_keys"
+)
+
+// SchemaVersion returns the output schema version this manager emits.
+func (m *Manager) SchemaVersion() int {
+	if m.config.OutputSchemaVersion == 0 {
+		return config.DefaultOutputSchemaVersion
+	}
+	return m.config.OutputSchemaVersion
+}
+
+// metadataOperations builds the metadata outputs for the configured schema:
+//
+//	1: secrets_count, output_schema_version
+//	2: schema 1 plus secrets_keys, a sorted JSON array of the secret output names
+func (m *Manager) metadataOperations(result *secrets.BatchResult, keys []string) []Operation {
+	var timestamp int64
+	if first := firstResult(result); first != nil && first.Metrics != nil {
+		timestamp = first.Metrics.EndTime.Unix()
+	}
+
+	values := [][2]string{
+		{SecretsCountOutput, fmt.Sprintf("%d", result.SuccessCount)},
+		{OutputSchemaVersionOutput, fmt.Sprintf("%d", m.SchemaVersion())},
+	}
+
+	if m.SchemaVersion() >= 2 {
+		sorted := append([]string{}, keys...)
+		sort.Strings(sorted)
+		encoded, err := json.Marshal(sorted)
+		if err == nil {
+			values = append(values, [2]string{SecretsKeysOutput, string(encoded)})
+		}
+	}
+
+	operations := make([]Operation, 0, len(values))
+	for _, nv := range values {
+		secureValue, err := security.NewSecureStringFromString(nv[1])
+		if err != nil {
+			continue
+		}
+		operations = append(operations, Operation{
+			Type: "output",
+			Name: nv[0],
+			Value: &Value{
+				Name:      nv[0],
+				Value:     secureValue,
+				Source:    "metadata",
+				Timestamp: timestamp,
+			},
+		})
+	}
+	return operations
+}
+
 // Operation represents a pending output operation
 type Operation struct {
-	Type  string // "output" or "env"
+	Type  string // "output", "env" or "file"
 	Name  string
 	Value *Value
 }
@@ -311,10 +446,15 @@ func (m *Manager) executeOutputOperations(operations []Operation) error {
 		value := op.Value.Value.String()
 
 		// Mask only real secret values, and avoid masking short/numeric values
-		if op.Value.Source == "secret" && m.isMaskable(value) {
-			if err := m.maskValue(value); err != nil {
+		if op.Value.Source == "secret" {
+			if err := m.maskComponents(op.Value); err != nil {
 				return fmt.Errorf("failed to mask value for output '%s': %w", op.Name, err)
 			}
+			if m.isMaskable(value) {
+				if err := m.maskValue(value); err != nil {
+					return fmt.Errorf("failed to mask value for output '%s': %w", op.Name, err)
+				}
+			}
 		}
 
 		// Set the GitHub Actions output
@@ -338,10 +478,15 @@ func (m *Manager) executeEnvOperations(operations []Operation) error {
 		value := op.Value.Value.String()
 
 		// Mask only real secret values, and avoid masking short/numeric values
-		if op.Value.Source == "secret" && m.isMaskable(value) {
-			if err := m.maskValue(value); err != nil {
+		if op.Value.Source == "secret" {
+			if err := m.maskComponents(op.Value); err != nil {
 				return fmt.Errorf("failed to mask value for env var '%s': %w", op.Name, err)
 			}
+			if m.isMaskable(value) {
+				if err := m.maskValue(value); err != nil {
+					return fmt.Errorf("failed to mask value for env var '%s': %w", op.Name, err)
+				}
+			}
 		}
 
 		// Set the environment variable
@@ -357,6 +502,96 @@ func (m *Manager) executeEnvOperations(operations []Operation) error {
 	return nil
 }
 
+// executeFileOperations writes each secret to its own file in the secrets
+// directory and sets an output holding the file path.
+func (m *Manager) executeFileOperations(operations []Operation) error {
+	m.logger.Debug("Executing file operations", "count", len(operations))
+
+	created, err := ensureSecretsDir(m.secretsDir, m.config.SecretsDir == "")
+	m.createdDir = m.createdDir || created
+	if err != nil {
+		return err
+	}
+
+	for _, op := range operations {
+		// Mask the contents in case a later step prints the file
+		if err := m.maskComponents(op.Value); err != nil {
+			return fmt.Errorf("failed to mask value for file '%s': %w", op.Name, err)
+		}
+		if value := op.Value.Value.String(); m.isMaskable(value) {
+			if err := m.maskValue(value); err != nil {
+				return fmt.Errorf("failed to mask value for file '%s': %w", op.Name, err)
+			}
+		}
+
+		path := SecretFilePath(m.secretsDir, op.Name)
+		data := op.Value.Value.Bytes()
+		err := writeSecretFile(path, data)
+		security.SecureZero(data)
+		if err != nil {
+			return fmt.Errorf("failed to write file for '%s': %w", op.Name, err)
+		}
+		m.files = append(m.files, path)
+
+		// The secret itself is no longer needed in memory
+		_ = op.Value.Value.Destroy()
+
+		if err := m.github.SetOutput(op.Name, path); err != nil {
+			return fmt.Errorf("failed to set output '%s': %w", op.Name, err)
+		}
+
+		pathValue, err := security.NewSecureStringFromString(path)
+		if err != nil {
+			return fmt.Errorf("failed to track output '%s': %w", op.Name, err)
+		}
+		m.outputs[op.Name] = &Value{
+			Name:      op.Name,
+			Value:     pathValue,
+			Source:    "file",
+			Timestamp: op.Value.Timestamp,
+		}
+		m.logger.Debug("Wrote secret file", "name", op.Name, "path", path)
+	}
+
+	return nil
+}
+
+// GetFiles returns the secret files written so far (for testing/debugging)
+func (m *Manager) GetFiles() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]string, len(m.files))
+	copy(result, m.files)
+	return result
+}
+
+// RemoveFiles deletes the secret files written for return_type "file", along
+// with the secrets directory if this manager created it and it is now empty.
+func (m *Manager) RemoveFiles() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for _, path := range m.files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove secret file '%s': %w", path, err))
+		}
+	}
+	m.files = nil
+
+	if m.createdDir {
+		_ = os.Remove(filepath.Join(m.secretsDir, ".gitignore"))
+		_ = os.Remove(m.secretsDir)
+		m.createdDir = false
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("secret file cleanup errors: %v", errs)
+	}
+	return nil
+}
+
 // maskValue adds a GitHub Actions mask for the given value
 func (m *Manager) maskValue(value string) error {
 	if strings.TrimSpace(value) == "" {
@@ -380,22 +615,36 @@ func (m *Manager) maskValue(value string) error {
 	return nil
 }
 
-// isMaskable determines if a value is safe to register as a GitHub Actions mask.
-// We avoid masking very short or purely numeric values to prevent over-masking of common substrings.
-func (m *Manager) isMaskable(value string) bool {
-	v := strings.TrimSpace(value)
-	if v == "" {
-		return false
-	}
-	// Do not mask very short values (e.g., "1", "ok") to avoid broad masking
-	if len(v) < 6 {
-		return false
+// maskComponents masks the individual field values a templated secret was
+// assembled from, so that each part stays hidden when it appears on its own.
+func (m *Manager) maskComponents(value *Value) error {
+	for _, component := range value.Components {
+		if component == nil || component.IsZeroed() {
+			continue
+		}
+		part := component.String()
+		if !m.isMaskable(part) {
+			continue
+		}
+		if err := m.maskValue(part); err != nil {
+			return err
+		}
 	}
-	// Purely numeric values are not masked
-	if regexp.MustCompile(`^[0-9]+$`).MatchString(v) {
+	return nil
+}
+
+// isAttachment reports whether a result holds a file attachment or
+// document.
+func isAttachment(result *secrets.SecretResult) bool {
+	if result.Request == nil {
 		return false
 	}
-	return true
+	return validation.IsFileField(result.Request.FieldName)
+}
+
+// isMaskable determines if a value is safe to register as a GitHub Actions mask.
+func (m *Manager) isMaskable(value string) bool {
+	return logger.IsMaskable(value)
 }
 
 // processOutputValue processes and normalizes an output value
@@ -432,11 +681,36 @@ func (m *Manager) validateOutputCapability() error {
 		if err := m.github.ValidateOutputCapability(); err != nil {
 			return fmt.Errorf("GitHub Actions outputs not available: %w", err)
 		}
+		if m.config.ReturnType == config.ReturnTypeBoth {
+			if err := m.github.ValidateEnvCapability(); err != nil {
+				return fmt.Errorf("GitHub Actions environment variables not available: %w", err)
+			}
+		}
 
 	case config.ReturnTypeEnv:
 		if err := m.github.ValidateEnvCapability(); err != nil {
 			return fmt.Errorf("GitHub Actions environment variables not available: %w", err)
 		}
+
+	case config.ReturnTypeJSON:
+		if err := m.github.ValidateOutputCapability(); err != nil {
+			return fmt.Errorf("GitHub Actions outputs not available: %w", err)
+		}
+		if m.config.JSONEnv {
+			if err := m.github.ValidateEnvCapability(); err != nil {
+				return fmt.Errorf("GitHub Actions environment variables not available: %w", err)
+			}
+		}
+
+	case config.ReturnTypeFile:
+		if err := m.github.ValidateOutputCapability(); err != nil {
+			return fmt.Errorf("GitHub Actions outputs not available: %w", err)
+		}
+		dir, err := ResolveSecretsDir(m.config)
+		if err != nil {
+			return err
+		}
+		m.secretsDir = dir
 	}
 
 	return nil
@@ -525,12 +799,12 @@ func isValidUTF8(s string) bool {
 	return strings.ToValidUTF8(s, "") == s
 }
 
-// getFirstKey returns the first key from a map (for metadata timestamps)
-func getFirstKey(m map[string]*secrets.SecretResult) string {
-	for k := range m {
-		return k
+// firstResult returns the result of the first request (for metadata timestamps)
+func firstResult(result *secrets.BatchResult) *secrets.SecretResult {
+	if keys := result.Keys(); len(keys) > 0 {
+		return result.Results[keys[0]]
 	}
-	return ""
+	return nil
 }
 
 // outputNamePattern validates GitHub Actions output names
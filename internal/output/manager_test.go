@@ -4,6 +4,7 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -123,7 +124,7 @@ func TestProcessSecrets_SingleSecret(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, outputResult)
 	assert.True(t, outputResult.Success)
-	assert.Equal(t, 2, outputResult.OutputsSet) // value + secrets_count
+	assert.Equal(t, 3, outputResult.OutputsSet) // value + secrets_count + output_schema_version
 	assert.Equal(t, 0, outputResult.EnvVarsSet)
 	assert.Equal(t, 1, outputResult.ValuesMasked)
 	assert.Empty(t, outputResult.Errors)
@@ -140,6 +141,46 @@ func TestProcessSecrets_SingleSecret(t *testing.T) {
 	assert.Contains(t, masked, "test-secret-value")
 }
 
+func TestProcessSecrets_TemplateComponentsMasked(t *testing.T) {
+	manager := createTestManager(t, config.ReturnTypeOutput)
+	defer func() { _ = manager.Destroy() }()
+
+	result := &secrets.BatchResult{
+		Results: map[string]*secrets.SecretResult{
+			"database_url": {
+				Request: &secrets.SecretRequest{
+					Key:       "database_url",
+					Vault:     "test-vault",
+					ItemName:  "database",
+					FieldName: "{username}:{password}@{host}",
+				},
+				Value: createTestSecureString(t, "app_user:p4ssw0rd!@db.internal"),
+				Components: []*security.SecureString{
+					createTestSecureString(t, "app_user"),
+					createTestSecureString(t, "p4ssw0rd!"),
+					createTestSecureString(t, "db.internal"),
+				},
+				Metrics: &secrets.RetrievalMetrics{
+					StartTime: time.Now(),
+					EndTime:   time.Now(),
+				},
+			},
+		},
+		SuccessCount: 1,
+	}
+
+	outputResult, err := manager.ProcessSecrets(result)
+	require.NoError(t, err)
+	assert.True(t, outputResult.Success)
+	assert.Equal(t, 4, outputResult.ValuesMasked)
+
+	masked := manager.GetMaskedValues()
+	assert.Contains(t, masked, "app_user")
+	assert.Contains(t, masked, "p4ssw0rd!")
+	assert.Contains(t, masked, "db.internal")
+	assert.Contains(t, masked, "app_user:p4ssw0rd!@db.internal")
+}
+
 func TestProcessSecrets_MultipleSecrets(t *testing.T) {
 	manager := createTestManager(t, config.ReturnTypeOutput)
 	defer func() { _ = manager.Destroy() }()
@@ -191,7 +232,7 @@ func TestProcessSecrets_MultipleSecrets(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, outputResult)
 	assert.True(t, outputResult.Success)
-	assert.Equal(t, 3, outputResult.OutputsSet) // 2 secrets + secrets_count
+	assert.Equal(t, 4, outputResult.OutputsSet) // 2 secrets + secrets_count + output_schema_version
 	assert.Equal(t, 0, outputResult.EnvVarsSet)
 	assert.Equal(t, 2, outputResult.ValuesMasked)
 	assert.Empty(t, outputResult.Errors)
@@ -206,6 +247,39 @@ func TestProcessSecrets_MultipleSecrets(t *testing.T) {
 	assert.Equal(t, "2", outputs["secrets_count"])
 }
 
+func TestProcessSecrets_RequestOrder(t *testing.T) {
+	manager := createTestManager(t, config.ReturnTypeOutput)
+	defer func() { _ = manager.Destroy() }()
+
+	order := []string{"zeta", "alpha", "mid", "beta"}
+	result := &secrets.BatchResult{
+		Results:      make(map[string]*secrets.SecretResult),
+		Order:        order,
+		SuccessCount: len(order),
+	}
+	for _, key := range order {
+		result.Results[key] = &secrets.SecretResult{
+			Request: &secrets.SecretRequest{Key: key, Vault: "test-vault", ItemName: key, FieldName: "password"},
+			Value:   createTestSecureString(t, "value-of-"+key),
+			Metrics: &secrets.RetrievalMetrics{EndTime: time.Now()},
+		}
+	}
+
+	_, err := manager.ProcessSecrets(result)
+	require.NoError(t, err)
+
+	// Outputs are written in request order, not map order
+	data, err := os.ReadFile(manager.config.GitHubOutput)
+	require.NoError(t, err)
+	last := -1
+	for _, key := range order {
+		pos := strings.Index(string(data), "value-of-"+key)
+		require.GreaterOrEqual(t, pos, 0, "output %s missing", key)
+		assert.Greater(t, pos, last, "output %s written out of request order", key)
+		last = pos
+	}
+}
+
 func TestProcessSecrets_EnvironmentVariables(t *testing.T) {
 	manager := createTestManager(t, config.ReturnTypeEnv)
 	defer func() { _ = manager.Destroy() }()
@@ -251,6 +325,43 @@ func TestProcessSecrets_EnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "env-secret-value", envVars["API_KEY"])
 }
 
+func TestProcessSecrets_EnvOnlyMultiline(t *testing.T) {
+	manager := createTestManager(t, config.ReturnTypeEnv)
+	defer func() { _ = manager.Destroy() }()
+
+	values := map[string]string{
+		"CERT":     "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+		"INJECTED": "first\nEOF\nOTHER=injected\nEOF_1",
+	}
+	result := &secrets.BatchResult{
+		Results:      make(map[string]*secrets.SecretResult),
+		SuccessCount: len(values),
+	}
+	for key, value := range values {
+		result.Results[key] = &secrets.SecretResult{
+			Request: &secrets.SecretRequest{Key: key, Vault: "test-vault", ItemName: key, FieldName: "password"},
+			Value:   createTestSecureString(t, value),
+			Metrics: &secrets.RetrievalMetrics{EndTime: time.Now()},
+		}
+	}
+
+	outputResult, err := manager.ProcessSecrets(result)
+	require.NoError(t, err)
+	assert.Equal(t, 0, outputResult.OutputsSet)
+	assert.Equal(t, len(values), outputResult.EnvVarsSet)
+
+	// No step outputs, not even metadata
+	outputs, err := os.ReadFile(manager.config.GitHubOutput)
+	require.NoError(t, err)
+	assert.Empty(t, outputs)
+
+	// Every value reads back unchanged, and no line of a value defines a
+	// variable of its own
+	parsed, err := ParseCommandFile(manager.config.GitHubEnv)
+	require.NoError(t, err)
+	assert.Equal(t, values, parsed)
+}
+
 func TestProcessSecrets_BothOutputsAndEnv(t *testing.T) {
 	manager := createTestManager(t, config.ReturnTypeBoth)
 	defer func() { _ = manager.Destroy() }()
@@ -285,7 +396,7 @@ func TestProcessSecrets_BothOutputsAndEnv(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, outputResult)
 	assert.True(t, outputResult.Success)
-	assert.Equal(t, 2, outputResult.OutputsSet) // shared_secret + secrets_count
+	assert.Equal(t, 3, outputResult.OutputsSet) // shared_secret + secrets_count + output_schema_version
 	assert.Equal(t, 1, outputResult.EnvVarsSet) // shared_secret
 	assert.Equal(t, 1, outputResult.ValuesMasked)
 	assert.Empty(t, outputResult.Errors)
@@ -362,7 +473,7 @@ func TestProcessSecrets_WithErrors(t *testing.T) {
 	assert.NoError(t, err) // Should not error on partial success
 	assert.NotNil(t, outputResult)
 	assert.False(t, outputResult.Success)       // Should be false due to errors
-	assert.Equal(t, 2, outputResult.OutputsSet) // success_secret + secrets_count
+	assert.Equal(t, 3, outputResult.OutputsSet) // success_secret + secrets_count + output_schema_version
 	assert.Equal(t, 0, outputResult.EnvVarsSet)
 	assert.Equal(t, 1, outputResult.ValuesMasked)
 	assert.Len(t, outputResult.Errors, 1) // One error for failed secret
@@ -408,7 +519,7 @@ func TestProcessSecrets_EmptyValues(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, outputResult)
 	assert.True(t, outputResult.Success)
-	assert.Equal(t, 1, outputResult.OutputsSet) // Only secrets_count
+	assert.Equal(t, 2, outputResult.OutputsSet) // Only metadata outputs
 	assert.Equal(t, 0, outputResult.EnvVarsSet)
 	assert.Equal(t, 0, outputResult.ValuesMasked) // secrets_count is metadata and not masked
 
@@ -419,6 +530,42 @@ func TestProcessSecrets_EmptyValues(t *testing.T) {
 	assert.Equal(t, "1", outputs["secrets_count"])
 }
 
+func TestProcessSecrets_MissingOptionalField(t *testing.T) {
+	manager := createTestManager(t, config.ReturnTypeOutput)
+	defer func() { _ = manager.Destroy() }()
+
+	result := &secrets.BatchResult{
+		Results: map[string]*secrets.SecretResult{
+			"optional_secret": {
+				Request: &secrets.SecretRequest{
+					Key:       "optional_secret",
+					Vault:     "test-vault",
+					ItemName:  "item",
+					FieldName: "field",
+				},
+				Value:   createTestSecureString(t, ""),
+				Missing: true,
+				Metrics: &secrets.RetrievalMetrics{
+					StartTime: time.Now(),
+					EndTime:   time.Now(),
+				},
+			},
+		},
+		SuccessCount: 1,
+	}
+
+	outputResult, err := manager.ProcessSecrets(result)
+
+	require.NoError(t, err)
+	assert.True(t, outputResult.Success)
+	assert.Empty(t, outputResult.Errors)
+
+	// The missing field is still delivered, as an empty output
+	outputs := manager.GetOutputs()
+	assert.Contains(t, outputs, "optional_secret")
+	assert.Equal(t, "", outputs["optional_secret"])
+}
+
 func TestProcessSecrets_InvalidOutputNames(t *testing.T) {
 	// Use non-atomic operations to allow partial success
 	cfg := createTestConfig()
@@ -466,7 +613,7 @@ func TestProcessSecrets_InvalidOutputNames(t *testing.T) {
 	assert.NoError(t, err) // Should not error, but validation should fail
 	assert.NotNil(t, outputResult)
 	assert.False(t, outputResult.Success)       // Should fail due to validation error
-	assert.Equal(t, 1, outputResult.OutputsSet) // Only secrets_count
+	assert.Equal(t, 2, outputResult.OutputsSet) // Only metadata outputs
 	assert.Len(t, outputResult.Errors, 1)       // One validation error
 
 	// Verify invalid output was not set
@@ -600,6 +747,180 @@ func TestManagerDestroy(t *testing.T) {
 
 // Helper functions
 
+// createFileTestManager returns a manager for return_type "file" using an
+// isolated workspace, optionally writing to a custom secrets directory.
+func createFileTestManager(t *testing.T, secretsDir string) (*Manager, *config.Config) {
+	workspace := t.TempDir()
+	cfg := createTestConfig()
+	cfg.ReturnType = config.ReturnTypeFile
+	cfg.SecretsDir = secretsDir
+	cfg.GitHubWorkspace = workspace
+	cfg.GitHubOutput = filepath.Join(workspace, "github_output")
+	cfg.GitHubEnv = filepath.Join(workspace, "github_env")
+	require.NoError(t, os.WriteFile(cfg.GitHubOutput, []byte(""), 0600))
+	require.NoError(t, os.WriteFile(cfg.GitHubEnv, []byte(""), 0600))
+
+	manager, err := NewManager(cfg, createTestLogger(t), DefaultConfig())
+	require.NoError(t, err)
+	return manager, cfg
+}
+
+func createFileTestResult(t *testing.T, values map[string]string) *secrets.BatchResult {
+	result := &secrets.BatchResult{Results: map[string]*secrets.SecretResult{}}
+	for key, value := range values {
+		result.Results[key] = &secrets.SecretResult{
+			Request: &secrets.SecretRequest{Key: key, Vault: "test-vault", ItemName: "item", FieldName: "field"},
+			Value:   createTestSecureString(t, value),
+			Metrics: &secrets.RetrievalMetrics{StartTime: time.Now(), EndTime: time.Now()},
+		}
+		result.SuccessCount++
+	}
+	return result
+}
+
+func TestProcessSecrets_FileReturnType(t *testing.T) {
+	manager, cfg := createFileTestManager(t, "")
+	defer func() { _ = manager.Destroy() }()
+
+	result := createFileTestResult(t, map[string]string{
+		"kubeconfig": "apiVersion: v1\nkind: Config",
+		"npmrc":      "//registry.npmjs.org/:_authToken=npm_secret_token",
+	})
+
+	outputResult, err := manager.ProcessSecrets(result)
+	require.NoError(t, err)
+	assert.True(t, outputResult.Success)
+	assert.Equal(t, 2, outputResult.FilesWritten)
+
+	dir := filepath.Join(cfg.GitHubWorkspace, DefaultSecretsDirName)
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	ignore, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "*\n", string(ignore))
+
+	outputs := manager.GetOutputs()
+	for name, want := range map[string]string{
+		"kubeconfig": "apiVersion: v1\nkind: Config",
+		"npmrc":      "//registry.npmjs.org/:_authToken=npm_secret_token",
+	} {
+		path := SecretFilePath(dir, name)
+		assert.Equal(t, path, outputs[name], "output should hold the file path")
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content))
+
+		assert.Contains(t, manager.GetMaskedValues(), want)
+	}
+	assert.Equal(t, "2", outputs["secrets_count"])
+
+	githubOutput, err := os.ReadFile(cfg.GitHubOutput)
+	require.NoError(t, err)
+	assert.Contains(t, string(githubOutput), "kubeconfig="+SecretFilePath(dir, "kubeconfig"))
+	assert.NotContains(t, string(githubOutput), "npm_secret_token")
+
+	require.NoError(t, manager.RemoveFiles())
+	assert.Empty(t, manager.GetFiles())
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "created secrets directory should be removed")
+}
+
+func TestProcessSecrets_Attachment(t *testing.T) {
+	manager, cfg := createFileTestManager(t, "")
+	defer func() { _ = manager.Destroy() }()
+
+	// Binary content with CRLF line endings and surrounding whitespace must
+	// be written byte for byte
+	content := " \x00\x01key\r\n\r\n\xff "
+	result := createFileTestResult(t, map[string]string{"signing_key": content})
+	result.Results["signing_key"].Request.FieldName = "file:signing.key"
+
+	outputResult, err := manager.ProcessSecrets(result)
+	require.NoError(t, err)
+	assert.Equal(t, 1, outputResult.FilesWritten)
+
+	path := SecretFilePath(filepath.Join(cfg.GitHubWorkspace, DefaultSecretsDirName), "signing_key")
+	assert.Equal(t, path, manager.GetOutputs()["signing_key"])
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(written))
+
+	// Other return types cannot carry an attachment
+	manager, cfg = createFileTestManager(t, "")
+	defer func() { _ = manager.Destroy() }()
+	cfg.ReturnType = config.ReturnTypeOutput
+	result = createFileTestResult(t, map[string]string{"signing_key": content})
+	result.Results["signing_key"].Request.FieldName = "file:signing.key"
+	_, err = manager.ProcessSecrets(result)
+	require.Error(t, err)
+}
+
+func TestProcessSecrets_FileReturnTypeCustomDir(t *testing.T) {
+	manager, cfg := createFileTestManager(t, "creds")
+	defer func() { _ = manager.Destroy() }()
+	secretsDir := filepath.Join(cfg.GitHubWorkspace, "creds")
+
+	outputResult, err := manager.ProcessSecrets(createFileTestResult(t, map[string]string{
+		"value": "custom-dir-secret",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, outputResult.FilesWritten)
+
+	path := SecretFilePath(secretsDir, "value")
+	assert.Equal(t, []string{path}, manager.GetFiles())
+	_, err = os.Stat(filepath.Join(secretsDir, ".gitignore"))
+	assert.True(t, os.IsNotExist(err), "custom directories are left as configured")
+
+	require.NoError(t, manager.RemoveFiles())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessSecrets_FileReturnTypeRefusesOutsideWorkspace(t *testing.T) {
+	outside := t.TempDir()
+	for _, secretsDir := range []string{outside, filepath.Join("..", filepath.Base(outside))} {
+		manager, _ := createFileTestManager(t, secretsDir)
+
+		outputResult, _ := manager.ProcessSecrets(createFileTestResult(t, map[string]string{
+			"value": "escaping-secret",
+		}))
+		assert.False(t, outputResult.Success)
+		assert.Zero(t, outputResult.FilesWritten)
+		assert.Empty(t, manager.GetFiles())
+
+		entries, err := os.ReadDir(outside)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "nothing may be written outside the workspace")
+		_ = manager.Destroy()
+	}
+}
+
+func TestProcessSecrets_FileReturnTypeSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	manager, cfg := createFileTestManager(t, "linked")
+	defer func() { _ = manager.Destroy() }()
+	require.NoError(t, os.Symlink(outside, filepath.Join(cfg.GitHubWorkspace, "linked")))
+
+	outputResult, _ := manager.ProcessSecrets(createFileTestResult(t, map[string]string{
+		"value": "escaping-secret",
+	}))
+	assert.False(t, outputResult.Success)
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
 func createTestConfig() *config.Config {
 	tempDir := os.TempDir()
 	return &config.Config{
@@ -622,6 +943,122 @@ func createTestLogger(t *testing.T) *logger.Logger {
 	return log
 }
 
+func TestProcessSecrets_OutputSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaVersion int
+		wantVersion   string
+		wantKeys      bool
+	}{
+		{name: "default", schemaVersion: 0, wantVersion: "1"},
+		{name: "version 1", schemaVersion: 1, wantVersion: "1"},
+		{name: "version 2", schemaVersion: 2, wantVersion: "2", wantKeys: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, cfg := createFileTestManager(t, "")
+			defer func() { _ = manager.Destroy() }()
+			cfg.ReturnType = config.ReturnTypeOutput
+			cfg.OutputSchemaVersion = tt.schemaVersion
+
+			result := createFileTestResult(t, map[string]string{
+				"zeta":  "secret-one",
+				"alpha": "secret-two",
+			})
+
+			outputResult, err := manager.ProcessSecrets(result)
+			require.NoError(t, err)
+			assert.True(t, outputResult.Success)
+
+			outputs := manager.GetOutputs()
+			assert.Equal(t, tt.wantVersion, outputs[OutputSchemaVersionOutput])
+			assert.Equal(t, "2", outputs[SecretsCountOutput])
+
+			written, err := os.ReadFile(cfg.GitHubOutput)
+			require.NoError(t, err)
+			assert.Contains(t, string(written), OutputSchemaVersionOutput)
+
+			if tt.wantKeys {
+				assert.Equal(t, `["alpha","zeta"]`, outputs[SecretsKeysOutput])
+				assert.Equal(t, 5, outputResult.OutputsSet)
+			} else {
+				assert.NotContains(t, outputs, SecretsKeysOutput)
+				assert.NotContains(t, string(written), SecretsKeysOutput)
+				assert.Equal(t, 4, outputResult.OutputsSet)
+			}
+		})
+	}
+}
+
+func TestProcessSecrets_JSONReturnType(t *testing.T) {
+	for _, jsonEnv := range []bool{false, true} {
+		t.Run(fmt.Sprintf("json_env=%t", jsonEnv), func(t *testing.T) {
+			manager, cfg := createFileTestManager(t, "")
+			defer func() { _ = manager.Destroy() }()
+			cfg.ReturnType = config.ReturnTypeJSON
+			cfg.JSONEnv = jsonEnv
+
+			result := createFileTestResult(t, map[string]string{
+				"api_key":      "plain-api-key",
+				"db__username": "app_user",
+				"db__password": "p4ss\"w0rd\nline",
+			})
+
+			outputResult, err := manager.ProcessSecrets(result)
+			require.NoError(t, err)
+			assert.True(t, outputResult.Success)
+			assert.Equal(t, 3, outputResult.OutputsSet) // secrets_json + secrets_count + output_schema_version
+
+			outputs := manager.GetOutputs()
+			assert.NotContains(t, outputs, "api_key")
+			assert.Equal(t, "3", outputs[SecretsCountOutput])
+
+			var doc map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(outputs[SecretsJSONOutput]), &doc))
+			assert.Equal(t, map[string]interface{}{
+				"api_key": "plain-api-key",
+				"db": map[string]interface{}{
+					"username": "app_user",
+					"password": "p4ss\"w0rd\nline",
+				},
+			}, doc)
+
+			masked := manager.GetMaskedValues()
+			assert.Contains(t, masked, "plain-api-key")
+			assert.Contains(t, masked, "app_user")
+			assert.Contains(t, masked, "p4ss\"w0rd\nline")
+			assert.Contains(t, masked, `p4ss\"w0rd\nline`, "JSON-escaped form is masked too")
+			assert.Contains(t, masked, outputs[SecretsJSONOutput])
+
+			envVars := manager.GetEnvVars()
+			if jsonEnv {
+				assert.Equal(t, 1, outputResult.EnvVarsSet)
+				assert.Equal(t, outputs[SecretsJSONOutput], envVars[SecretsJSONEnv])
+			} else {
+				assert.Empty(t, envVars)
+			}
+		})
+	}
+}
+
+func TestProcessSecrets_JSONReturnTypeKeyConflict(t *testing.T) {
+	manager, cfg := createFileTestManager(t, "")
+	defer func() { _ = manager.Destroy() }()
+	cfg.ReturnType = config.ReturnTypeJSON
+
+	result := createFileTestResult(t, map[string]string{
+		"db":           "connection-string",
+		"db__password": "database-password",
+	})
+
+	outputResult, err := manager.ProcessSecrets(result)
+	require.Error(t, err)
+	require.NotEmpty(t, outputResult.Errors)
+	assert.Contains(t, outputResult.Errors[0].Error(), "'db'")
+	assert.NotContains(t, manager.GetOutputs(), SecretsJSONOutput)
+}
+
 func createTestManager(t *testing.T, returnType string) *Manager {
 	cfg := createTestConfig()
 	cfg.ReturnType = returnType
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParseCommandFile reads a GitHub Actions file command such as GITHUB_OUTPUT
+// or GITHUB_ENV the way the runner does: "name=value" lines and
+// "name<<DELIMITER" heredocs closed by a line holding only the delimiter.
+// A later entry for a name replaces an earlier one.
+func ParseCommandFile(filePath string) (map[string]string, error) {
+	// #nosec G304 -- filePath is from GitHub Actions environment variables, not user input
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	values := make(map[string]string)
+	lines := strings.Split(string(data), "\n")
+	// A trailing newline leaves an empty final element that is not a line
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+
+		equalsIndex := strings.Index(line, "=")
+		heredocIndex := strings.Index(line, "<<")
+
+		switch {
+		case equalsIndex > 0 && (heredocIndex < 0 || equalsIndex < heredocIndex):
+			values[line[:equalsIndex]] = line[equalsIndex+1:]
+		case heredocIndex > 0 && (equalsIndex < 0 || heredocIndex < equalsIndex):
+			name := line[:heredocIndex]
+			delimiter := line[heredocIndex+2:]
+			if delimiter == "" {
+				return nil, fmt.Errorf("line %d: empty heredoc delimiter for '%s'", i+1, name)
+			}
+
+			start := i + 1
+			end := -1
+			for j := start; j < len(lines); j++ {
+				if lines[j] == delimiter {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: heredoc for '%s' is not terminated", i+1, name)
+			}
+
+			values[name] = strings.Join(lines[start:end], "\n")
+			i = end
+		default:
+			return nil, fmt.Errorf("line %d: invalid format", i+1)
+		}
+	}
+
+	return values, nil
+}
+
+// VerifyFiles re-reads GITHUB_OUTPUT and GITHUB_ENV and checks that every
+// value set through this instance reads back exactly as written. It catches
+// encoding faults, such as a heredoc delimiter appearing inside a value,
+// before a later step consumes a corrupted file. Error messages name the
+// affected entries but never include their values.
+func (gh *GitHubActions) VerifyFiles() error {
+	gh.mu.RLock()
+	defer gh.mu.RUnlock()
+
+	if gh.config.DryRun {
+		return nil
+	}
+
+	if len(gh.outputs) > 0 {
+		if err := verifyCommandFile(gh.config.OutputFile, gh.outputs); err != nil {
+			return fmt.Errorf("GITHUB_OUTPUT verification failed: %w", err)
+		}
+	}
+	if len(gh.envVars) > 0 {
+		if err := verifyCommandFile(gh.config.EnvFile, gh.envVars); err != nil {
+			return fmt.Errorf("GITHUB_ENV verification failed: %w", err)
+		}
+	}
+
+	gh.logger.Debug("Verified GitHub Actions files",
+		"outputs", len(gh.outputs),
+		"env_vars", len(gh.envVars))
+	return nil
+}
+
+// verifyCommandFile compares the parsed contents of filePath with expected.
+func verifyCommandFile(filePath string, expected map[string]string) error {
+	parsed, err := ParseCommandFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var missing, mismatched []string
+	for name, want := range expected {
+		got, ok := parsed[name]
+		switch {
+		case !ok:
+			missing = append(missing, name)
+		case got != want:
+			mismatched = append(mismatched, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(mismatched)
+
+	switch {
+	case len(missing) > 0:
+		return fmt.Errorf("missing entries: %s", strings.Join(missing, ", "))
+	case len(mismatched) > 0:
+		return fmt.Errorf("entries do not read back as written: %s", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+)
+
+// collidingValues would corrupt GITHUB_OUTPUT if written with a fixed
+// "EOF" heredoc delimiter.
+var collidingValues = map[string]string{
+	"certificate": "-----BEGIN CERTIFICATE-----\nMIIB\nEOF\n-----END CERTIFICATE-----",
+	"script":      "cat <<EOF\necho hello\nEOF",
+	"lookalike":   "first\nEOF_0123456789ABCDEF\nlast=value",
+	"trailing":    "line1\nline2\n",
+	"blank_lines": "\n\nmiddle\n\n",
+	"single":      "plain=value<<EOF",
+}
+
+func TestParseCommandFile(t *testing.T) {
+	path := t.TempDir() + "/github_output"
+	content := "plain=value\n" +
+		"equals=a=b<<c\n" +
+		"multi<<DELIM\nline1\nline2\nDELIM\n" +
+		"empty<<DELIM\nDELIM\n" +
+		"plain=replaced\n"
+	require.NoError(t, WriteTestFile(path, content))
+
+	values, err := ParseCommandFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"plain":  "replaced",
+		"equals": "a=b<<c",
+		"multi":  "line1\nline2",
+		"empty":  "",
+	}, values)
+}
+
+func TestParseCommandFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{
+			name:        "unterminated heredoc",
+			content:     "multi<<DELIM\nline1\n",
+			errContains: "not terminated",
+		},
+		{
+			name:        "delimiter collision",
+			content:     "multi<<EOF\nline1\nEOF\nline3\nEOF\n",
+			errContains: "line 4: invalid format",
+		},
+		{
+			name:        "empty delimiter",
+			content:     "multi<<\nline1\n",
+			errContains: "empty heredoc delimiter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/github_output"
+			require.NoError(t, WriteTestFile(path, tt.content))
+
+			_, err := ParseCommandFile(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestVerifyFiles_CollidingValues(t *testing.T) {
+	github := createTestGitHub(t)
+
+	for name, value := range collidingValues {
+		require.NoError(t, github.SetOutput(name, value))
+		require.NoError(t, github.SetEnv("ENV_"+name, value))
+	}
+
+	require.NoError(t, github.VerifyFiles())
+
+	parsed, err := ParseCommandFile(github.config.OutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, collidingValues, parsed)
+}
+
+func TestVerifyFiles_DetectsCorruption(t *testing.T) {
+	github := createTestGitHub(t)
+	require.NoError(t, github.SetOutput("certificate", collidingValues["certificate"]))
+	require.NoError(t, github.SetOutput("token", "abc123"))
+
+	// Rewrite the file as a fixed "EOF" delimiter would have
+	require.NoError(t, os.WriteFile(github.config.OutputFile,
+		[]byte("certificate<<EOF\n"+collidingValues["certificate"]+"\nEOF\ntoken=abc123\n"), 0600))
+	err := github.VerifyFiles()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GITHUB_OUTPUT")
+	assert.NotContains(t, err.Error(), "MIIB")
+
+	// A missing or altered entry is reported by name only
+	require.NoError(t, os.WriteFile(github.config.OutputFile,
+		[]byte("certificate<<X\nMIIB\nX\n"), 0600))
+	err = github.VerifyFiles()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing entries: token")
+
+	require.NoError(t, os.WriteFile(github.config.OutputFile,
+		[]byte("certificate<<X\nMIIB\nX\ntoken=abc123\n"), 0600))
+	err = github.VerifyFiles()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "do not read back as written: certificate")
+	assert.NotContains(t, err.Error(), "MIIB")
+}
+
+func TestVerifyFiles_DryRun(t *testing.T) {
+	github := createTestGitHub(t)
+	github.config.DryRun = true
+	require.NoError(t, github.SetOutput("certificate", collidingValues["certificate"]))
+	assert.NoError(t, github.VerifyFiles())
+}
+
+func TestProcessSecrets_VerifyOutputs(t *testing.T) {
+	manager, cfg := createFileTestManager(t, "")
+	defer func() { _ = manager.Destroy() }()
+	cfg.ReturnType = config.ReturnTypeBoth
+	cfg.VerifyOutputs = true
+
+	values := map[string]string{
+		"certificate": collidingValues["certificate"],
+		"script":      collidingValues["script"],
+		"lookalike":   collidingValues["lookalike"],
+	}
+	outputResult, err := manager.ProcessSecrets(createFileTestResult(t, values))
+	require.NoError(t, err)
+	assert.True(t, outputResult.Success)
+
+	outputs, err := ParseCommandFile(cfg.GitHubOutput)
+	require.NoError(t, err)
+	envVars, err := ParseCommandFile(cfg.GitHubEnv)
+	require.NoError(t, err)
+	for name, value := range values {
+		assert.Equal(t, value, outputs[name])
+		assert.Equal(t, value, envVars[name])
+	}
+}
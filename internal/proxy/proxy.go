@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+// Package proxy selects the HTTP proxy used for the action's own requests
+// (CLI downloads and 1Password Connect calls) and for the op CLI it runs.
+//
+// By default the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables
+// (or their lower-case forms) are honored. An explicit proxy URL overrides
+// HTTPS_PROXY and HTTP_PROXY; NO_PROXY still applies, so internal hosts such
+// as a Connect server can bypass it.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// EnvVars lists the proxy environment variables passed through to the
+// 1Password CLI.
+var EnvVars = []string{
+	"HTTPS_PROXY", "https_proxy",
+	"HTTP_PROXY", "http_proxy",
+	"NO_PROXY", "no_proxy",
+}
+
+// Validate reports whether raw is usable as an explicit proxy URL. An empty
+// URL is valid and means the environment is used.
+func Validate(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("proxy URL must be an absolute URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	default:
+		return fmt.Errorf("proxy URL scheme must be http, https or socks5")
+	}
+}
+
+// Redact returns raw with any password removed, for logging.
+func Redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// Func returns the proxy function for an http.Transport. With an empty
+// override the environment decides; otherwise every request not excluded by
+// NO_PROXY is sent through override. The environment is read once, when Func
+// is called.
+func Func(override string) (func(*http.Request) (*url.URL, error), error) {
+	if err := Validate(override); err != nil {
+		return nil, err
+	}
+
+	httpsProxy := getEnv("HTTPS_PROXY", "https_proxy")
+	httpProxy := getEnv("HTTP_PROXY", "http_proxy")
+	if override != "" {
+		httpsProxy, httpProxy = override, override
+	}
+	noProxy := parseNoProxy(getEnv("NO_PROXY", "no_proxy"))
+
+	return func(req *http.Request) (*url.URL, error) {
+		raw := httpProxy
+		if req.URL.Scheme == "https" {
+			raw = httpsProxy
+		}
+		if raw == "" || noProxy.matches(req.URL) {
+			return nil, nil
+		}
+		return parseProxy(raw)
+	}, nil
+}
+
+// Env returns environment entries making the 1Password CLI use override,
+// appended after the inherited EnvVars so that they take precedence. It is
+// empty when no override is set.
+func Env(override string) []string {
+	if override == "" {
+		return nil
+	}
+	return []string{
+		"HTTPS_PROXY=" + override, "https_proxy=" + override,
+		"HTTP_PROXY=" + override, "http_proxy=" + override,
+	}
+}
+
+// parseProxy parses a proxy setting, which may omit the scheme.
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		if u, err := url.Parse("http://" + raw); err == nil && u.Host != "" {
+			return u, nil
+		}
+		return nil, fmt.Errorf("invalid proxy address %q", Redact(raw))
+	}
+	return u, nil
+}
+
+// getEnv returns the first non-empty variable in keys.
+func getEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// noProxyList is a parsed NO_PROXY value.
+type noProxyList struct {
+	all      bool
+	domains  []string // Lower-case, without a leading dot
+	networks []*net.IPNet
+}
+
+// parseNoProxy parses a comma-separated NO_PROXY value. Entries are "*",
+// host names (matching the host and its subdomains), IP addresses or CIDR
+// ranges; a port on an entry is ignored.
+func parseNoProxy(value string) noProxyList {
+	var list noProxyList
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.networks = append(list.networks, network)
+			continue
+		}
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+		entry = strings.Trim(entry, "[]")
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list.networks = append(list.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		list.domains = append(list.domains, strings.TrimPrefix(entry, "."))
+	}
+	return list
+}
+
+// matches reports whether requests to u bypass the proxy. Loopback hosts
+// always do, as with http.ProxyFromEnvironment.
+func (l noProxyList) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return true
+		}
+		for _, network := range l.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	if l.all {
+		return true
+	}
+	for _, domain := range l.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// BatchResolver is implemented by backends that can read many fields in a
+// single call. It returns the values of the refs it read; refs missing from
+// the result are resolved one at a time. The caller owns the returned bytes
+// and must zero them when done.
+type BatchResolver interface {
+	ResolveBatch(ctx context.Context, refs []SecretRef) (map[SecretRef][]byte, error)
+}
+
+// prefetchStore holds the values a batch read returned for the current
+// batch, keyed like the secret cache. All methods are safe to call on a nil
+// store, which holds nothing.
+type prefetchStore struct {
+	mu     sync.Mutex
+	values map[string]*security.SecureString
+}
+
+// get returns a copy of the value read for key, or nil if there is none.
+func (p *prefetchStore) get(key string) *security.SecureString {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	value, ok := p.values[key]
+	if !ok {
+		return nil
+	}
+	copied, err := copySecureString(value)
+	if err != nil {
+		return nil
+	}
+	return copied
+}
+
+// size returns the number of values held.
+func (p *prefetchStore) size() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.values)
+}
+
+// destroy zeroes and drops every value.
+func (p *prefetchStore) destroy() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, value := range p.values {
+		_ = value.Destroy()
+		delete(p.values, key)
+	}
+}
+
+// batchable reports whether a request reads a plain field of an item in a
+// named vault, which is all a batch read covers. One-time passwords,
+// attachments, documents, templates and wildcards, and items searched for
+// across vaults, are always resolved one at a time.
+func batchable(request *SecretRequest) bool {
+	field := request.FieldName
+	if _, ok := validation.AttachmentName(field); ok {
+		return false
+	}
+	return request.Vault != config.AnyVault &&
+		!validation.IsFieldTemplate(field) &&
+		!validation.IsWildcardField(field) &&
+		!validation.IsOTPField(field) &&
+		!validation.IsDocumentField(field)
+}
+
+// prefetch reads the fields of requests that a batch read can cover with a
+// single backend call. When the backend cannot batch, or the batch read
+// fails for any reason, it returns nil and every request is resolved one at
+// a time, which also reports which of them failed.
+func (e *Engine) prefetch(ctx context.Context, requests []*SecretRequest) *prefetchStore {
+	batcher, ok := e.resolver.(BatchResolver)
+	if !ok {
+		e.logger.Debug("Secret backend cannot read in batches, reading secrets one at a time")
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var refs []SecretRef
+	for _, request := range requests {
+		if !batchable(request) {
+			continue
+		}
+		ref := SecretRef{
+			Vault:   request.Vault,
+			Item:    request.ItemName,
+			Section: request.Section,
+			Field:   request.FieldName,
+		}
+		key := secretCacheKey(ref)
+		if seen[key] || e.negCache.get(negativeCacheKey(ref.Vault, ref.Item, ref.Section, ref.Field)) != nil {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+	if len(refs) < 2 {
+		return nil
+	}
+
+	if err := e.gate.wait(ctx); err != nil {
+		return nil
+	}
+	values, err := batcher.ResolveBatch(ctx, refs)
+	if err != nil {
+		e.observeRateLimit(err)
+		e.logger.Warn("Batch read failed, reading secrets one at a time",
+			"count", len(refs),
+			"error", e.sanitizeError(err))
+		return nil
+	}
+
+	store := &prefetchStore{values: make(map[string]*security.SecureString, len(values))}
+	for ref, value := range values {
+		secret, secretErr := security.NewSecureString(value)
+		security.SecureZero(value)
+		if secretErr != nil {
+			continue
+		}
+		store.values[secretCacheKey(ref)] = secret
+	}
+	e.logger.Debug("Read secrets in a batch", "count", store.size(), "requested", len(refs))
+	return store
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
+)
+
+// secretCache holds resolved secret values for the current run, so that
+// records referencing the same field are answered with a single backend
+// call. Concurrent lookups of one reference wait for the first to finish.
+// Entries expire after ttl and every value is zeroed by destroy. All methods
+// are safe to call on a nil cache, which caches nothing.
+type secretCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]*secretCacheEntry
+}
+
+// secretCacheEntry is a cached value, or a lookup still in progress while
+// ready is open.
+type secretCacheEntry struct {
+	ready   chan struct{}
+	value   *security.SecureString
+	err     error
+	expires time.Time
+}
+
+// newSecretCache creates a cache whose entries live for ttl.
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*secretCacheEntry),
+	}
+}
+
+// secretCacheKey normalizes a reference into its cache key.
+func secretCacheKey(ref SecretRef) string {
+	if section := strings.TrimSpace(ref.Section); section != "" {
+		return fmt.Sprintf("op://%s/%s/%s/%s",
+			strings.TrimSpace(ref.Vault), strings.TrimSpace(ref.Item), section, strings.TrimSpace(ref.Field))
+	}
+	return fmt.Sprintf("op://%s/%s/%s",
+		strings.TrimSpace(ref.Vault), strings.TrimSpace(ref.Item), strings.TrimSpace(ref.Field))
+}
+
+// fetch returns a copy of the cached value for key, calling load when there
+// is no live entry. It reports whether the value came from the cache. Failed
+// loads are not cached.
+func (c *secretCache) fetch(ctx context.Context, key string,
+	load func() (*security.SecureString, error)) (*security.SecureString, bool, error) {
+	if c == nil {
+		value, err := load()
+		return value, false, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && entry.value != nil && !c.now().Before(entry.expires) {
+		_ = entry.value.Destroy()
+		delete(c.entries, key)
+		ok = false
+	}
+
+	if ok {
+		c.mu.Unlock()
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+
+		c.mu.Lock()
+		if entry.err != nil {
+			c.mu.Unlock()
+			return nil, false, entry.err
+		}
+		if entry.value == nil || entry.value.IsZeroed() {
+			c.mu.Unlock()
+			value, err := load()
+			return value, false, err
+		}
+		defer c.mu.Unlock()
+		value, err := copySecureString(entry.value)
+		return value, err == nil, err
+	}
+
+	entry = &secretCacheEntry{ready: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	value, err := load()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(entry.ready)
+
+	if err == nil && value != nil {
+		if cached, copyErr := copySecureString(value); copyErr == nil {
+			entry.value = cached
+			entry.expires = c.now().Add(c.ttl)
+		}
+	}
+	if entry.value == nil {
+		entry.err = err
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+	}
+	return value, false, err
+}
+
+// size returns the number of cached values.
+func (c *secretCache) size() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// destroy zeroes and drops every cached value.
+func (c *secretCache) destroy() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.value != nil {
+			_ = entry.value.Destroy()
+		}
+		delete(c.entries, key)
+	}
+}
+
+// copySecureString returns an independent copy of s.
+func copySecureString(s *security.SecureString) (*security.SecureString, error) {
+	data := s.Bytes()
+	defer security.SecureZero(data)
+	return security.NewSecureString(data)
+}
@@ -7,10 +7,12 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +23,9 @@ import (
 
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/logger"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/metrics"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/transform"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -28,10 +33,14 @@ import (
 // optimizations including parallel processing and atomic operations.
 type Engine struct {
 	authManager AuthManagerInterface
-	cliClient   CLIClientInterface
+	resolver    SecretResolver
 	logger      *logger.Logger
 	config      *Config
 	metrics     *Metrics
+	hooks       metrics.Metrics // External observability hooks; never nil
+	negCache    *negativeCache  // Nil unless Config.CacheNegative is set
+	secretCache *secretCache    // Nil unless Config.SecretCacheTTL is positive
+	gate        rateLimitGate   // Shared backoff of all workers after a rate limit
 }
 
 // Config holds configuration for the secret retrieval engine.
@@ -49,34 +58,60 @@ type Config struct {
 	AllowEmptyFields bool
 	MaxSecretLength  int
 
+	// MaxAttachmentSize bounds file attachments, which are delivered byte
+	// for byte rather than processed as text fields
+	MaxAttachmentSize int
+
+	// Caching settings
+	CacheNegative  bool          // Remember not-found lookups for the rest of the run
+	SecretCacheTTL time.Duration // Reuse resolved values of the same reference for this long; 0 disables
+
+	// BatchRead reads the plain fields of a batch with one backend call,
+	// where the backend supports it, before resolving the rest one at a time
+	BatchRead bool
+
+	// Item resolution settings
+	VaultPriority []string // Vaults that settle an item title found in several vaults
+
+	// WildcardNames names the outputs of fields selected by a wildcard
+	// record; nil uses validation.SanitizeFieldLabel
+	WildcardNames validation.NameSanitizer
+
 	// Error handling settings
 	AtomicOperations     bool // All succeed or all fail
 	ContinueOnFieldError bool
 	MaxRetries           int
 	RetryDelay           time.Duration
-	FailFast             bool
+	FailFast             bool // Cancel the rest of a batch on the first failure
 
 	// Security settings
 	ScrubSecretsFromLogs bool
 	ZeroSecretsOnError   bool
 	SecureMemoryOnly     bool
+
+	// Metrics receives resolution latencies; nil discards them
+	Metrics metrics.Metrics
 }
 
 // SecretRequest represents a request for a single secret.
 type SecretRequest struct {
-	Key       string // Output key name
-	Vault     string // Vault identifier
-	ItemName  string // Item/secret name
-	FieldName string // Field name within the item
-	Required  bool   // Whether this secret is required
+	Key        string   // Output key name
+	Vault      string   // Vault identifier
+	ItemName   string   // Item/secret name
+	Section    string   // Section holding the field, if qualified
+	FieldName  string   // Field name within the item
+	Required   bool     // Whether this secret is required; a missing field of an optional one resolves empty
+	Transforms []string // Ordered transform pipeline applied to the value
 }
 
 // SecretResult contains the result of a secret retrieval operation.
 type SecretResult struct {
-	Request *SecretRequest
-	Value   *security.SecureString
-	Error   error
-	Metrics *RetrievalMetrics
+	Request    *SecretRequest
+	Value      *security.SecureString
+	Components []*security.SecureString // Field values a templated Value was assembled from
+	Error      error
+	Metrics    *RetrievalMetrics
+	Missing    bool // An optional field was not found and Value is empty
 }
 
 // RetrievalMetrics contains metrics for a single secret retrieval.
@@ -94,6 +129,7 @@ type RetrievalMetrics struct {
 // BatchResult contains the results of a batch secret retrieval operation.
 type BatchResult struct {
 	Results       map[string]*SecretResult // Key -> Result
+	Order         []string                 // Keys of Results in request order
 	SuccessCount  int
 	ErrorCount    int
 	TotalDuration time.Duration
@@ -101,6 +137,52 @@ type BatchResult struct {
 	Errors        []error
 }
 
+// Successful returns a copy of the batch holding only the secrets that were
+// retrieved, for delivering the outputs of a partially failed batch.
+func (r *BatchResult) Successful() *BatchResult {
+	successful := &BatchResult{
+		Results:       make(map[string]*SecretResult, r.SuccessCount),
+		SuccessCount:  r.SuccessCount,
+		TotalDuration: r.TotalDuration,
+		AtomicSuccess: true,
+	}
+	for _, key := range r.Keys() {
+		if result := r.Results[key]; result.Error == nil {
+			successful.Results[key] = result
+			successful.Order = append(successful.Order, key)
+		}
+	}
+	return successful
+}
+
+// Keys returns the keys of Results in request order, so that outputs are
+// written in the same order however the requests completed. A result built
+// without Order lists its keys sorted.
+func (r *BatchResult) Keys() []string {
+	if len(r.Order) == len(r.Results) {
+		return r.Order
+	}
+	keys := make([]string, 0, len(r.Results))
+	for key := range r.Results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FailedKeys returns the keys of the secrets that could not be retrieved,
+// sorted.
+func (r *BatchResult) FailedKeys() []string {
+	var keys []string
+	for key, result := range r.Results {
+		if result.Error != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Metrics tracks engine-level metrics and statistics.
 type Metrics struct {
 	TotalRequests         int64
@@ -112,7 +194,8 @@ type Metrics struct {
 	AtomicFailures        int64
 	FieldValidationErrs   int64
 	UnicodeNormalizations int64
-	SecretsCached         int64
+	SecretCacheHits       int64
+	NegativeCacheHits     int64
 	AverageLatencyMs      int64
 	mu                    sync.RWMutex
 }
@@ -134,19 +217,21 @@ func DefaultConfig() *Config {
 		TrimWhitespace:        true,
 		ValidateUTF8:          true,
 		AllowEmptyFields:      false,
-		MaxSecretLength:       64 * 1024, // 64KB
+		MaxSecretLength:       64 * 1024,   // 64KB
+		MaxAttachmentSize:     1024 * 1024, // 1MB
 		AtomicOperations:      true,
 		ContinueOnFieldError:  false,
 		MaxRetries:            3,
 		RetryDelay:            1 * time.Second,
-		FailFast:              true,
+		FailFast:              false,
 		ScrubSecretsFromLogs:  true,
 		ZeroSecretsOnError:    true,
 		SecureMemoryOnly:      true,
 	}
 }
 
-// NewEngine creates a new secret retrieval engine.
+// NewEngine creates a new secret retrieval engine that reads secrets through
+// the 1Password CLI.
 func NewEngine(authManager AuthManagerInterface, cliClient CLIClientInterface, logger *logger.Logger, config *Config) (*Engine, error) {
 	if authManager == nil {
 		return nil, errors.NewConfigurationError(
@@ -162,6 +247,25 @@ func NewEngine(authManager AuthManagerInterface, cliClient CLIClientInterface, l
 			nil,
 		)
 	}
+
+	engine, err := NewEngineWithResolver(NewCLIResolver(cliClient), logger, config)
+	if err != nil {
+		return nil, err
+	}
+	engine.authManager = authManager
+	return engine, nil
+}
+
+// NewEngineWithResolver creates a secret retrieval engine that reads secrets
+// through the given backend.
+func NewEngineWithResolver(resolver SecretResolver, logger *logger.Logger, config *Config) (*Engine, error) {
+	if resolver == nil {
+		return nil, errors.NewConfigurationError(
+			errors.ErrCodeInvalidConfig,
+			"secret resolver is required",
+			nil,
+		)
+	}
 	if logger == nil {
 		return nil, errors.NewConfigurationError(
 			errors.ErrCodeInvalidConfig,
@@ -181,13 +285,20 @@ func NewEngine(authManager AuthManagerInterface, cliClient CLIClientInterface, l
 		)
 	}
 
-	return &Engine{
-		authManager: authManager,
-		cliClient:   cliClient,
-		logger:      logger,
-		config:      config,
-		metrics:     &Metrics{},
-	}, nil
+	engine := &Engine{
+		resolver: resolver,
+		logger:   logger,
+		config:   config,
+		metrics:  &Metrics{},
+		hooks:    metrics.OrNop(config.Metrics),
+	}
+	if config.CacheNegative {
+		engine.negCache = newNegativeCache()
+	}
+	if config.SecretCacheTTL > 0 {
+		engine.secretCache = newSecretCache(config.SecretCacheTTL)
+	}
+	return engine, nil
 }
 
 // validateEngineConfig validates the engine configuration.
@@ -278,6 +389,35 @@ func ParseRecordsToRequests(cfg *config.Config) ([]*SecretRequest, error) {
 		)
 	}
 
+	// Prefer the structured requests, which carry per-record vaults; the
+	// vault input is only the default for records that name none
+	if len(cfg.RecordRequests) > 0 {
+		requests := make([]*SecretRequest, 0, len(cfg.RecordRequests))
+		for _, rr := range cfg.RecordRequests {
+			vault := rr.Vault
+			if vault == "" {
+				vault = cfg.Vault
+			}
+			if vault == "" {
+				return nil, errors.NewConfigurationError(
+					errors.ErrCodeSecretParsingFailed,
+					fmt.Sprintf("vault is required for record '%s'", rr.OutputName),
+					nil,
+				)
+			}
+			requests = append(requests, &SecretRequest{
+				Key:        rr.OutputName,
+				Vault:      vault,
+				ItemName:   rr.Item,
+				Section:    rr.Section,
+				FieldName:  rr.Field,
+				Required:   !rr.Optional,
+				Transforms: rr.Transforms,
+			})
+		}
+		return requests, nil
+	}
+
 	// Validate that vault is provided
 	if cfg.Vault == "" {
 		return nil, errors.NewConfigurationError(
@@ -297,11 +437,12 @@ func ParseRecordsToRequests(cfg *config.Config) ([]*SecretRequest, error) {
 		}
 
 		request := &SecretRequest{
-			Key:       key,
-			Vault:     cfg.Vault,
-			ItemName:  itemName,
-			FieldName: fieldName,
-			Required:  true, // All secrets are considered required by default
+			Key:        key,
+			Vault:      cfg.Vault,
+			ItemName:   itemName,
+			FieldName:  fieldName,
+			Required:   true, // All secrets are considered required by default
+			Transforms: cfg.Transforms[key],
 		}
 
 		requests = append(requests, request)
@@ -316,6 +457,11 @@ func (e *Engine) RetrieveSecrets(ctx context.Context, requests []*SecretRequest)
 		return nil, fmt.Errorf("no secret requests provided")
 	}
 
+	requests, err := e.ExpandWildcards(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
 	e.logger.Info("Starting batch secret retrieval",
 		"count", len(requests),
 		"atomic", e.config.AtomicOperations)
@@ -333,57 +479,96 @@ func (e *Engine) RetrieveSecrets(ctx context.Context, requests []*SecretRequest)
 		Errors:  make([]error, 0),
 	}
 
-	// Create semaphore for concurrency control
-	semaphore := make(chan struct{}, e.config.MaxConcurrentRequests)
-	var wg sync.WaitGroup
-	var resultMutex sync.Mutex
+	// Read what a single backend call can cover up front; anything left,
+	// and everything after a failed batch read, is resolved one at a time.
+	// The values belong to this call, so concurrent calls never share them
+	var prefetched *prefetchStore
+	if e.config.BatchRead {
+		prefetched = e.prefetch(batchCtx, requests)
+		defer prefetched.destroy()
+	}
 
-	// Process requests in parallel
-	for _, request := range requests {
-		wg.Add(1)
+	// Resolve through a fixed pool of at most MaxConcurrentRequests workers.
+	// Results are stored by request index so that aggregation does not
+	// depend on the order in which requests complete.
+	workers := e.config.MaxConcurrentRequests
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+	results := make([]*SecretResult, len(requests))
+	jobs := make(chan int)
+	order := dispatchOrder(requests)
 
-		go func(req *SecretRequest) {
-			defer wg.Done()
+	// With FailFast the first failure cancels the batch, aborting in-flight
+	// requests and leaving queued ones unstarted
+	var failFastErr error
+	var failOnce sync.Once
 
-			// Acquire semaphore
-			select {
-			case semaphore <- struct{}{}:
-				// Update concurrent tracking
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
 				concurrent := e.metrics.incrementConcurrentRequests()
 				if concurrent > e.metrics.getMaxConcurrentReached() {
 					e.metrics.setMaxConcurrentReached(concurrent)
 				}
-				defer func() {
-					e.metrics.decrementConcurrentRequests()
-					<-semaphore
-				}()
-			case <-batchCtx.Done():
-				resultMutex.Lock()
-				result.Errors = append(result.Errors,
-					fmt.Errorf("request for key '%s' canceled due to timeout", req.Key))
-				resultMutex.Unlock()
-				return
-			}
 
-			// Retrieve the secret
-			secretResult := e.retrieveSingleSecret(batchCtx, req)
+				secretResult := e.retrieveSingleSecret(batchCtx, requests[i], prefetched)
+				e.metrics.decrementConcurrentRequests()
+				results[i] = secretResult
 
-			// Store result
-			resultMutex.Lock()
-			result.Results[req.Key] = secretResult
-			if secretResult.Error != nil {
-				result.ErrorCount++
-				result.Errors = append(result.Errors, secretResult.Error)
-			} else {
-				result.SuccessCount++
+				if secretResult.Error != nil && e.config.FailFast {
+					failOnce.Do(func() {
+						failFastErr = secretResult.Error
+						cancel()
+					})
+				}
 			}
-			resultMutex.Unlock()
-		}(request)
+		}()
 	}
 
-	// Wait for all requests to complete
+dispatch:
+	for _, i := range order {
+		select {
+		case jobs <- i:
+		case <-batchCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
 	wg.Wait()
 
+	// Aggregate in request order
+	for i, req := range requests {
+		secretResult := results[i]
+		if secretResult == nil {
+			if failFastErr != nil {
+				// Skipped after an earlier failure; not an error of its own
+				continue
+			}
+			result.ErrorCount++
+			if ctx.Err() != nil {
+				result.Errors = append(result.Errors,
+					fmt.Errorf("request for key '%s' canceled: %w", req.Key, context.Cause(ctx)))
+			} else {
+				result.Errors = append(result.Errors,
+					fmt.Errorf("request for key '%s' canceled due to timeout: %w", req.Key, context.DeadlineExceeded))
+			}
+			continue
+		}
+
+		result.Results[req.Key] = secretResult
+		result.Order = append(result.Order, req.Key)
+		if secretResult.Error != nil {
+			result.ErrorCount++
+			result.Errors = append(result.Errors, secretResult.Error)
+		} else {
+			result.SuccessCount++
+		}
+	}
+
 	// Calculate total duration
 	result.TotalDuration = time.Since(startTime)
 
@@ -403,13 +588,23 @@ func (e *Engine) RetrieveSecrets(ctx context.Context, requests []*SecretRequest)
 			e.zeroSuccessfulSecrets(result.Results)
 		}
 
-		// If there's only one error, preserve the original ActionableError
+		// Return the failure that stopped a fail-fast batch, or the only
+		// error, preserving the original ActionableError
+		if failFastErr != nil {
+			return result, failFastErr
+		}
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("batch secret retrieval canceled after %d of %d secrets: %w",
+				result.SuccessCount, len(requests), context.Cause(ctx))
+		}
 		if result.ErrorCount == 1 {
-			for _, secretResult := range result.Results {
-				if secretResult.Error != nil {
-					return result, secretResult.Error
-				}
-			}
+			return result, result.Errors[0]
+		}
+
+		// A rate limit on any request makes the batch worth retrying, after
+		// the longest wait any request was asked for
+		if limitErr := batchRateLimitError(result.Errors); limitErr != nil {
+			return result, limitErr
 		}
 
 		return result, fmt.Errorf("atomic batch operation failed: %d errors occurred",
@@ -425,8 +620,27 @@ func (e *Engine) RetrieveSecrets(ctx context.Context, requests []*SecretRequest)
 	return result, nil
 }
 
-// retrieveSingleSecret retrieves a single secret with retry logic.
-func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretRequest) *SecretResult {
+// dispatchOrder returns the indexes of requests grouped by vault, in the
+// order each vault first appears, so that requests to one vault run
+// together. Requests within a vault keep their order.
+func dispatchOrder(requests []*SecretRequest) []int {
+	order := make([]int, len(requests))
+	first := make(map[string]int)
+	for i, req := range requests {
+		order[i] = i
+		if _, seen := first[req.Vault]; !seen {
+			first[req.Vault] = i
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return first[requests[order[a]].Vault] < first[requests[order[b]].Vault]
+	})
+	return order
+}
+
+// retrieveSingleSecret retrieves a single secret with retry logic, using the
+// values prefetched for the batch when they cover it.
+func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretRequest, prefetched *prefetchStore) *SecretResult {
 	startTime := time.Now()
 	metrics := &RetrievalMetrics{
 		StartTime: startTime,
@@ -463,7 +677,8 @@ func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretReques
 
 			select {
 			case <-ctx.Done():
-				result.Error = ctx.Err()
+				result.Error = fmt.Errorf("secret retrieval canceled for key '%s': %w",
+					request.Key, ctx.Err())
 				e.metrics.incrementFailedRequests()
 				return result
 			case <-time.After(e.config.RetryDelay):
@@ -471,7 +686,17 @@ func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretReques
 		}
 
 		// Perform the actual secret retrieval
-		secret, err := e.performSecretRetrieval(ctx, request)
+		secret, components, err := e.performSecretRetrieval(ctx, request, prefetched)
+		if err != nil && !request.Required && errors.IsErrorCode(err, errors.ErrCodeFieldNotFound) {
+			// Only the field may be missing; a missing item still fails
+			e.logger.Warn("Optional field not found, using an empty value",
+				"key", request.Key,
+				"code", errors.ErrCodeFieldNotFound)
+			result.Error = nil
+			result.Value, _ = security.NewSecureString(nil)
+			result.Missing = true
+			break
+		}
 		if err != nil {
 			result.Error = err
 			e.logger.Debug("Secret retrieval attempt failed",
@@ -489,19 +714,29 @@ func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretReques
 		// Success - process and validate the secret
 		processedSecret, err := e.processSecretValue(secret, request)
 		if err != nil {
-			result.Error = fmt.Errorf("secret processing failed for key '%s': %w",
-				request.Key, err)
+			if _, ok := err.(*errors.ActionableError); ok {
+				result.Error = err
+			} else {
+				result.Error = fmt.Errorf("secret processing failed for key '%s': %w",
+					request.Key, err)
+			}
 			// Clean up the original secret
 			if secret != nil {
 				if destroyErr := secret.Destroy(); destroyErr != nil {
 					e.logger.Error("Failed to destroy secret during cleanup", "error", destroyErr)
 				}
 			}
+			e.destroyComponents(components)
 			break
 		}
 
-		// Store the processed secret
+		// Store the processed secret, masked and redacted before it reaches
+		// any output
+		if processedSecret != nil {
+			e.maskSecret(processedSecret, false)
+		}
 		result.Value = processedSecret
+		result.Components = components
 
 		// Destroy the original secret to avoid duplication in memory
 		if secret != nil {
@@ -515,6 +750,9 @@ func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretReques
 	// Update metrics
 	metrics.EndTime = time.Now()
 	metrics.Duration = metrics.EndTime.Sub(metrics.StartTime)
+	e.hooks.ObserveResolveDuration(secretCacheKey(SecretRef{
+		Vault: request.Vault, Item: request.ItemName, Section: request.Section, Field: request.FieldName,
+	}), metrics.Duration)
 
 	if result.Error != nil {
 		e.metrics.incrementFailedRequests()
@@ -529,12 +767,29 @@ func (e *Engine) retrieveSingleSecret(ctx context.Context, request *SecretReques
 }
 
 // performSecretRetrieval performs the actual secret retrieval from 1Password.
-func (e *Engine) performSecretRetrieval(ctx context.Context, request *SecretRequest) (*security.SecureString, error) {
+// For templated field specifications the individual field values are
+// returned alongside the assembled secret so they can be masked as well.
+func (e *Engine) performSecretRetrieval(ctx context.Context, request *SecretRequest, prefetched *prefetchStore) (*security.SecureString, []*security.SecureString, error) {
 	// Validate request
 	if err := e.validateSecretRequest(request); err != nil {
-		return nil, fmt.Errorf("invalid secret request: %w", err)
+		return nil, nil, fmt.Errorf("invalid secret request: %w", err)
 	}
 
+	if validation.IsFieldTemplate(request.FieldName) {
+		return e.performTemplateRetrieval(ctx, request, prefetched)
+	}
+
+	secret, err := e.fetchField(ctx, request, request.FieldName, prefetched)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, nil, nil
+}
+
+// fetchField retrieves a single field of the requested item from 1Password,
+// or from prefetched when the batch read it already.
+func (e *Engine) fetchField(ctx context.Context, request *SecretRequest, fieldName string, prefetched *prefetchStore) (*security.SecureString, error) {
+
 	// Create request-specific timeout
 	reqCtx, cancel := context.WithTimeout(ctx, e.config.RequestTimeout)
 	defer cancel()
@@ -544,11 +799,50 @@ func (e *Engine) performSecretRetrieval(ctx context.Context, request *SecretRequ
 		"key", request.Key,
 		"vault", request.Vault,
 		"item", request.ItemName,
-		"field", request.FieldName)
+		"section", request.Section,
+		"field", fieldName)
 
-	// Retrieve the secret using CLI client
-	secret, err := e.cliClient.GetSecret(reqCtx, request.Vault,
-		request.ItemName, request.FieldName)
+	// Retrieve the secret from the backend, unless it is already known to be missing
+	var secret *security.SecureString
+	cacheKey := negativeCacheKey(request.Vault, request.ItemName, request.Section, fieldName)
+	err := e.negCache.get(cacheKey)
+	if err != nil {
+		e.metrics.incrementNegativeCacheHits()
+		e.logger.Debug("Using cached not-found result", "key", request.Key)
+	} else {
+		ref := SecretRef{
+			Vault:   request.Vault,
+			Item:    request.ItemName,
+			Section: request.Section,
+			Field:   fieldName,
+		}
+		// One-time passwords expire within seconds and are never cached
+		cache := e.secretCache
+		if validation.IsOTPField(fieldName) {
+			cache = nil
+		}
+		var cacheHit bool
+		secret, cacheHit, err = cache.fetch(reqCtx, secretCacheKey(ref), func() (*security.SecureString, error) {
+			if value := prefetched.get(secretCacheKey(ref)); value != nil {
+				return value, nil
+			}
+			if ref.Vault == config.AnyVault {
+				located, locateErr := e.locateItem(reqCtx, ref)
+				if locateErr != nil {
+					return nil, locateErr
+				}
+				return e.resolve(reqCtx, located)
+			}
+			return e.resolve(reqCtx, ref)
+		})
+		if cacheHit {
+			e.metrics.incrementSecretCacheHits()
+			e.logger.Debug("Using cached secret", "key", request.Key)
+		}
+		if err != nil && e.isNotFoundError(err) {
+			e.negCache.put(cacheKey, err)
+		}
+	}
 	if err != nil {
 		// Preserve ActionableError type while adding context
 		if actionableErr, ok := err.(*errors.ActionableError); ok {
@@ -556,11 +850,11 @@ func (e *Engine) performSecretRetrieval(ctx context.Context, request *SecretRequ
 			newErr := errors.Wrap(actionableErr.Code,
 				fmt.Sprintf("failed to retrieve secret for key '%s': %s", request.Key, actionableErr.Message),
 				actionableErr.Cause)
-			// Copy suggestions from original error
+			// Copy suggestions and the retry hint from original error
 			if suggestions := actionableErr.GetSuggestions(); len(suggestions) > 0 {
 				newErr = newErr.WithSuggestions(suggestions...)
 			}
-			return nil, newErr
+			return nil, newErr.WithRetryAfter(actionableErr.RetryAfter)
 		}
 		return nil, fmt.Errorf("failed to retrieve secret for key '%s': %w",
 			request.Key, err)
@@ -573,9 +867,141 @@ func (e *Engine) performSecretRetrieval(ctx context.Context, request *SecretRequ
 		}
 	}
 
+	// Mask the raw value and redact it from the action's own logs before
+	// anything can log it, even if a transform changes it before delivery
+	if secret != nil {
+		e.maskSecret(secret, validation.IsOTPField(fieldName))
+	}
+
 	return secret, nil
 }
 
+// maskSecret registers secret as a GitHub Actions mask and, unless it is a
+// one-time code, with the log redactor. Both must keep the value as a
+// string for the rest of the run to recognize it in later output, so this
+// is the one place a secret deliberately leaves secure memory.
+func (e *Engine) maskSecret(secret *security.SecureString, oneTimeCode bool) {
+	value := secret.String()
+	if oneTimeCode {
+		e.logger.MaskOneTimeCode(value)
+		return
+	}
+	e.logger.MaskSecret(value)
+	e.logger.RegisterSecret(value)
+}
+
+// resolve reads a secret from the backend into secure memory, zeroing the
+// intermediate buffer.
+func (e *Engine) resolve(ctx context.Context, ref SecretRef) (*security.SecureString, error) {
+	if err := e.gate.wait(ctx); err != nil {
+		return nil, err
+	}
+	value, err := e.resolver.Resolve(ctx, ref)
+	if err != nil {
+		e.observeRateLimit(err)
+		return nil, err
+	}
+	defer security.SecureZero(value)
+	return security.NewSecureString(value)
+}
+
+// performTemplateRetrieval retrieves every field referenced by a field
+// template and assembles them into a single secret value.
+func (e *Engine) performTemplateRetrieval(ctx context.Context, request *SecretRequest, prefetched *prefetchStore) (*security.SecureString, []*security.SecureString, error) {
+	tmpl, err := validation.ParseFieldTemplate(request.FieldName)
+	if err != nil {
+		return nil, nil, errors.NewSecretError(
+			errors.ErrCodeSecretParsingFailed,
+			fmt.Sprintf("invalid field template for key '%s'", request.Key),
+			err,
+		)
+	}
+
+	processor := &FieldProcessor{
+		config: e.config,
+		logger: e.logger,
+	}
+
+	components := make([]*security.SecureString, 0, len(tmpl.Fields))
+	values := make(map[string]string, len(tmpl.Fields))
+
+	for _, fieldName := range tmpl.Fields {
+		raw, err := e.fetchField(ctx, request, fieldName, prefetched)
+		if err != nil {
+			e.destroyComponents(components)
+			return nil, nil, templateFieldError(request, fieldName, err)
+		}
+
+		value, err := processor.ProcessField(raw, request)
+		if raw != nil {
+			if destroyErr := raw.Destroy(); destroyErr != nil {
+				e.logger.Error("Failed to destroy template field after processing", "error", destroyErr)
+			}
+		}
+		if err != nil {
+			e.destroyComponents(components)
+			return nil, nil, templateFieldError(request, fieldName, err)
+		}
+
+		components = append(components, value)
+		values[fieldName] = value.String()
+	}
+
+	combined, err := tmpl.Render(values)
+	if err != nil {
+		e.destroyComponents(components)
+		return nil, nil, errors.NewSecretError(
+			errors.ErrCodeFieldNotFound,
+			fmt.Sprintf("failed to assemble field template for key '%s'", request.Key),
+			err,
+		)
+	}
+
+	secret, err := security.NewSecureStringFromString(combined)
+	if err != nil {
+		e.destroyComponents(components)
+		return nil, nil, fmt.Errorf("failed to create secure string for key '%s': %w",
+			request.Key, err)
+	}
+
+	return secret, components, nil
+}
+
+// templateFieldError reports which template field could not be resolved,
+// preserving the error code of the underlying failure when there is one.
+func templateFieldError(request *SecretRequest, fieldName string, err error) error {
+	code := errors.ErrCodeFieldNotFound
+	if actionableErr, ok := err.(*errors.ActionableError); ok {
+		code = actionableErr.Code
+	}
+
+	return errors.NewSecretError(
+		code,
+		fmt.Sprintf("field '%s' referenced by template for key '%s' could not be resolved",
+			fieldName, request.Key),
+		err,
+	).WithDetails(map[string]interface{}{
+		"key":   request.Key,
+		"item":  request.ItemName,
+		"field": fieldName,
+	}).WithSuggestions(
+		fmt.Sprintf("Verify that item '%s' has a field labelled '%s'", request.ItemName, fieldName),
+		"Field references in templates are case-sensitive",
+	)
+}
+
+// destroyComponents destroys the individual field values of a template.
+func (e *Engine) destroyComponents(components []*security.SecureString) {
+	for _, component := range components {
+		if component == nil {
+			continue
+		}
+		if err := component.Destroy(); err != nil {
+			e.logger.Error("Failed to destroy template field", "error", err)
+		}
+	}
+}
+
 // processSecretValue processes and validates a retrieved secret value.
 func (e *Engine) processSecretValue(secret *security.SecureString, request *SecretRequest) (*security.SecureString, error) {
 	if secret == nil {
@@ -587,10 +1013,58 @@ func (e *Engine) processSecretValue(secret *security.SecureString, request *Secr
 		logger: e.logger,
 	}
 
-	return processor.ProcessField(secret, request)
+	var processed *security.SecureString
+	var err error
+	if validation.IsFileField(request.FieldName) {
+		processed, err = processor.ProcessAttachment(secret, request)
+	} else {
+		processed, err = processor.ProcessField(secret, request)
+	}
+	if err != nil || len(request.Transforms) == 0 {
+		return processed, err
+	}
+
+	transformed, err := e.applyTransforms(processed, request)
+	if destroyErr := processed.Destroy(); destroyErr != nil {
+		e.logger.Error("Failed to destroy secret after transforms", "error", destroyErr)
+	}
+	return transformed, err
 }
 
-// ProcessField processes and normalizes a field value.
+// applyTransforms runs the request's transform pipeline over a secret value.
+func (e *Engine) applyTransforms(secret *security.SecureString, request *SecretRequest) (*security.SecureString, error) {
+	pipeline, err := transform.Parse(request.Transforms)
+	if err != nil {
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretParsingFailed,
+			fmt.Sprintf("invalid transform pipeline for key '%s'", request.Key),
+			err,
+		)
+	}
+
+	value, err := pipeline.Apply(secret.Bytes())
+	if err != nil {
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretValidationFailed,
+			fmt.Sprintf("transform pipeline failed for key '%s'", request.Key),
+			err,
+		).WithSuggestions(
+			"Check that the transforms are listed in the order they should run",
+			"Each transform receives the output of the previous one",
+		)
+	}
+
+	e.logger.Debug("Applied transform pipeline",
+		"key", request.Key,
+		"steps", len(pipeline.Steps))
+
+	result, err := security.NewSecureString(value)
+	security.SecureZero(value)
+	return result, err
+}
+
+// ProcessField processes and normalizes a field value. The value is
+// handled as bytes that are zeroed once the result is built.
 func (fp *FieldProcessor) ProcessField(secret *security.SecureString, request *SecretRequest) (*security.SecureString, error) {
 	if secret == nil || secret.IsZeroed() {
 		if !fp.config.AllowEmptyFields {
@@ -600,7 +1074,8 @@ func (fp *FieldProcessor) ProcessField(secret *security.SecureString, request *S
 	}
 
 	// Get the raw value
-	rawValue := secret.String()
+	rawValue := secret.Bytes()
+	defer security.SecureZero(rawValue)
 
 	// Validate length
 	if len(rawValue) > fp.config.MaxSecretLength {
@@ -609,7 +1084,7 @@ func (fp *FieldProcessor) ProcessField(secret *security.SecureString, request *S
 	}
 
 	// Validate UTF-8 if configured
-	if fp.config.ValidateUTF8 && !utf8.ValidString(rawValue) {
+	if fp.config.ValidateUTF8 && !utf8.Valid(rawValue) {
 		return nil, fmt.Errorf("invalid UTF-8 encoding in secret for key '%s'", request.Key)
 	}
 
@@ -617,21 +1092,23 @@ func (fp *FieldProcessor) ProcessField(secret *security.SecureString, request *S
 
 	// Trim whitespace if configured
 	if fp.config.TrimWhitespace {
-		processedValue = strings.TrimSpace(processedValue)
+		processedValue = bytes.TrimSpace(processedValue)
 	}
 
 	// Normalize Unicode if configured
 	if fp.config.NormalizeUnicode {
-		processedValue = fp.normalizeUnicode(processedValue)
+		normalized := fp.normalizeUnicode(processedValue)
+		defer func() { security.SecureZero(normalized[:cap(normalized)]) }()
+		processedValue = normalized
 	}
 
 	// Check for empty result after processing
-	if processedValue == "" && !fp.config.AllowEmptyFields {
+	if len(processedValue) == 0 && !fp.config.AllowEmptyFields {
 		return nil, fmt.Errorf("secret became empty after processing for key '%s'", request.Key)
 	}
 
 	// Create new secure string with processed value
-	result, err := security.NewSecureStringFromString(processedValue)
+	result, err := security.NewSecureString(processedValue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create secure string for key '%s': %w",
 			request.Key, err)
@@ -640,27 +1117,52 @@ func (fp *FieldProcessor) ProcessField(secret *security.SecureString, request *S
 	return result, nil
 }
 
-// normalizeUnicode performs Unicode normalization on the input string.
-func (fp *FieldProcessor) normalizeUnicode(input string) string {
+// ProcessAttachment checks the size of a file attachment and copies it
+// unchanged; trimming or normalizing would corrupt binary content.
+func (fp *FieldProcessor) ProcessAttachment(secret *security.SecureString, request *SecretRequest) (*security.SecureString, error) {
+	if secret == nil || secret.IsZeroed() || secret.IsEmpty() {
+		return nil, fmt.Errorf("empty attachment for key '%s'", request.Key)
+	}
+	if secret.Len() > fp.config.MaxAttachmentSize {
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretValidationFailed,
+			fmt.Sprintf("attachment too large for key '%s': %d bytes (max %d)",
+				request.Key, secret.Len(), fp.config.MaxAttachmentSize),
+			nil,
+		)
+	}
+
+	data := secret.Bytes()
+	defer security.SecureZero(data)
+	result, err := security.NewSecureString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secure string for key '%s': %w",
+			request.Key, err)
+	}
+	return result, nil
+}
+
+// normalizeUnicode performs Unicode normalization on the input, returning
+// a new buffer the caller must zero.
+func (fp *FieldProcessor) normalizeUnicode(input []byte) []byte {
 	// Basic Unicode normalization - remove control characters
-	var result strings.Builder
-	result.Grow(len(input))
-
-	for _, r := range input {
-		// Skip control characters except common whitespace
-		if unicode.IsControl(r) {
-			switch r {
-			case '\t', '\n', '\r':
-				result.WriteRune(r)
-			default:
-				// Skip other control characters
-			}
-		} else {
-			result.WriteRune(r)
+	result := make([]byte, 0, len(input))
+
+	for len(input) > 0 {
+		r, size := utf8.DecodeRune(input)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// Replace invalid encodings, which grows the value
+			result = security.SecureAppend(result, []byte(string(utf8.RuneError))...)
+		case unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r':
+			// Skip control characters except common whitespace
+		default:
+			result = security.SecureAppend(result, input[:size]...)
 		}
+		input = input[size:]
 	}
 
-	return result.String()
+	return result
 }
 
 // validateSecretRequest validates a secret request.
@@ -742,6 +1244,24 @@ func (e *Engine) isRetryableError(err error) bool {
 	return true
 }
 
+// isNotFoundError reports whether err means the item or field definitively
+// does not exist. Transient failures are never treated as not found, even when
+// their message happens to mention it.
+func (e *Engine) isNotFoundError(err error) bool {
+	if err == nil || e.isRetryableError(err) {
+		return false
+	}
+
+	if actionableErr, ok := err.(*errors.ActionableError); ok {
+		switch actionableErr.Code {
+		case errors.ErrCodeSecretNotFound, errors.ErrCodeFieldNotFound:
+			return true
+		}
+	}
+
+	return strings.Contains(strings.ToLower(err.Error()), "not found")
+}
+
 // sanitizeError removes sensitive information from error messages for logging.
 func (e *Engine) sanitizeError(err error) string {
 	if err == nil {
@@ -777,6 +1297,15 @@ func (e *Engine) zeroSuccessfulSecrets(results map[string]*SecretResult) {
 				e.logger.Error("Failed to zero secret on atomic failure",
 					"key", key, "error", err)
 			}
+			for _, component := range result.Components {
+				if component == nil {
+					continue
+				}
+				if err := component.Zero(); err != nil {
+					e.logger.Error("Failed to zero template field on atomic failure",
+						"key", key, "error", err)
+				}
+			}
 		}
 	}
 }
@@ -796,7 +1325,9 @@ func (e *Engine) GetMetrics() map[string]interface{} {
 		"atomic_failures":         e.metrics.AtomicFailures,
 		"field_validation_errors": e.metrics.FieldValidationErrs,
 		"unicode_normalizations":  e.metrics.UnicodeNormalizations,
-		"secrets_cached":          e.metrics.SecretsCached,
+		"secrets_cached":          int64(e.secretCache.size()),
+		"secret_cache_hits":       e.metrics.SecretCacheHits,
+		"negative_cache_hits":     e.metrics.NegativeCacheHits,
 		"average_latency_ms":      e.metrics.AverageLatencyMs,
 	}
 }
@@ -808,6 +1339,9 @@ func (e *Engine) Destroy() error {
 	// Log final metrics
 	e.logger.Info("Secret retrieval engine metrics", e.GetMetrics())
 
+	// Zero cached secret values
+	e.secretCache.destroy()
+
 	return nil
 }
 
@@ -870,3 +1404,15 @@ func (m *Metrics) incrementAtomicFailures() {
 	defer m.mu.Unlock()
 	m.AtomicFailures++
 }
+
+func (m *Metrics) incrementSecretCacheHits() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SecretCacheHits++
+}
+
+func (m *Metrics) incrementNegativeCacheHits() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.NegativeCacheHits++
+}
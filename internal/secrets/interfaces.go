@@ -5,9 +5,12 @@ package secrets
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/auth"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -21,10 +24,196 @@ type AuthManagerInterface interface {
 // CLIClientInterface defines the interface for CLI operations
 type CLIClientInterface interface {
 	GetSecret(ctx context.Context, vault, item, field string) (*security.SecureString, error)
+	GetOTP(ctx context.Context, vault, item string) (*security.SecureString, error)
+	GetAttachment(ctx context.Context, vault, item, name string) (*security.SecureString, error)
+	GetDocument(ctx context.Context, vault, item string) (*security.SecureString, error)
 	ListVaults(ctx context.Context) ([]cli.VaultInfo, error)
 	GetItem(ctx context.Context, vault, item string) (*cli.ItemInfo, error)
+	FindItems(ctx context.Context, item string) ([]cli.ItemInfo, error)
+}
+
+// SecretRef identifies a single field of an item in a vault. Section, when
+// set, names the section of the item holding the field.
+type SecretRef struct {
+	Vault   string
+	Item    string
+	Section string
+	Field   string
+}
+
+// ParseSecretReference parses a 1Password secret reference, either the
+// "op://vault/item/field" form copied from the 1Password apps or the plain
+// "vault/item/field" form. Segments of the op:// form are URL-decoded, and
+// the op:// form may name a section as in "op://vault/item/section/field".
+func ParseSecretReference(s string) (SecretRef, error) {
+	vault, item, section, field, err := validation.SplitSecretReference(s)
+	if err != nil {
+		return SecretRef{}, errors.NewConfigurationError(
+			errors.ErrCodeInvalidRecord,
+			fmt.Sprintf("invalid secret reference: %v", err),
+			err,
+		).WithSuggestions(
+			"Use the form 'op://vault/item/field', 'op://vault/item/section/field' or 'vault/item/field'",
+			"Encode spaces and other special characters in op:// references, e.g. %20",
+		)
+	}
+	return SecretRef{Vault: vault, Item: item, Section: section, Field: field}, nil
+}
+
+// SecretResolver is a backend that reads secret values from 1Password. The
+// caller owns the returned bytes and must zero them when done.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref SecretRef) ([]byte, error)
+}
+
+// ItemMatch is an item found by searching every vault the token can access.
+type ItemMatch struct {
+	VaultID   string
+	VaultName string
+	ItemID    string
+}
+
+// ItemLocator is implemented by backends that can search for an item across
+// all accessible vaults. It is used for records whose vault is AnyVault.
+type ItemLocator interface {
+	LocateItem(ctx context.Context, item string) ([]ItemMatch, error)
+}
+
+// ItemField identifies a field of an item without its value.
+type ItemField struct {
+	ID    string
+	Label string
+}
+
+// FieldLister is implemented by backends that can list the fields of an
+// item. It is used for records whose field is validation.WildcardField; the
+// Field of ref is ignored.
+type FieldLister interface {
+	ListFields(ctx context.Context, ref SecretRef) ([]ItemField, error)
+}
+
+// CLIResolver resolves secrets through the 1Password CLI.
+type CLIResolver struct {
+	client CLIClientInterface
+}
+
+// NewCLIResolver returns a SecretResolver backed by the given CLI client.
+func NewCLIResolver(client CLIClientInterface) *CLIResolver {
+	return &CLIResolver{client: client}
+}
+
+// Resolve implements SecretResolver. The OTPField keyword resolves to the
+// item's current one-time password, an attachment field to the content of
+// the attached file, and the DocumentField keyword to the file of a
+// Document item. A field in a section is read as "section/field",
+// the form op read expects.
+func (r *CLIResolver) Resolve(ctx context.Context, ref SecretRef) ([]byte, error) {
+	var secret *security.SecureString
+	var err error
+	if name, ok := validation.AttachmentName(ref.Field); ok {
+		secret, err = r.client.GetAttachment(ctx, ref.Vault, ref.Item, name)
+	} else if validation.IsDocumentField(ref.Field) {
+		secret, err = r.client.GetDocument(ctx, ref.Vault, ref.Item)
+	} else if validation.IsOTPField(ref.Field) {
+		secret, err = r.client.GetOTP(ctx, ref.Vault, ref.Item)
+	} else {
+		field := ref.Field
+		if ref.Section != "" {
+			field = ref.Section + "/" + field
+		}
+		secret, err = r.client.GetSecret(ctx, ref.Vault, ref.Item, field)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	defer func() { _ = secret.Destroy() }()
+	return secret.Bytes(), nil
+}
+
+// batchReader is implemented by CLI clients that can read many fields with
+// one op process.
+type batchReader interface {
+	ReadBatch(ctx context.Context, refs []cli.ReadRef) ([]*security.SecureString, error)
+}
+
+// ResolveBatch implements BatchResolver, reading every ref whose names op
+// inject accepts with a single op process. Other refs are left out of the
+// result.
+func (r *CLIResolver) ResolveBatch(ctx context.Context, refs []SecretRef) (map[SecretRef][]byte, error) {
+	reader, ok := r.client.(batchReader)
+	if !ok {
+		return nil, nil
+	}
+
+	var batched []SecretRef
+	var reads []cli.ReadRef
+	for _, ref := range refs {
+		field := ref.Field
+		if ref.Section != "" {
+			field = ref.Section + "/" + field
+		}
+		read := cli.ReadRef{Vault: ref.Vault, Item: ref.Item, Field: field}
+		if cli.Injectable(read) {
+			batched = append(batched, ref)
+			reads = append(reads, read)
+		}
+	}
+	if len(reads) == 0 {
+		return nil, nil
+	}
+
+	secrets, err := reader.ReadBatch(ctx, reads)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[SecretRef][]byte, len(secrets))
+	for i, secret := range secrets {
+		values[batched[i]] = secret.Bytes()
+		_ = secret.Destroy()
+	}
+	return values, nil
+}
+
+// LocateItem implements ItemLocator.
+func (r *CLIResolver) LocateItem(ctx context.Context, item string) ([]ItemMatch, error) {
+	items, err := r.client.FindItems(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]ItemMatch, 0, len(items))
+	for _, it := range items {
+		matches = append(matches, ItemMatch{
+			VaultID:   it.Vault.ID,
+			VaultName: it.Vault.Name,
+			ItemID:    it.ID,
+		})
+	}
+	return matches, nil
+}
+
+// ListFields implements FieldLister.
+func (r *CLIResolver) ListFields(ctx context.Context, ref SecretRef) ([]ItemField, error) {
+	item, err := r.client.GetItem(ctx, ref.Vault, ref.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]ItemField, 0, len(item.Fields))
+	for _, f := range item.Fields {
+		fields = append(fields, ItemField{ID: f.ID, Label: f.Label})
+	}
+	return fields, nil
 }
 
 // Ensure concrete types implement interfaces
 var _ AuthManagerInterface = (*auth.Manager)(nil)
 var _ CLIClientInterface = (*cli.Client)(nil)
+var _ SecretResolver = (*CLIResolver)(nil)
+var _ ItemLocator = (*CLIResolver)(nil)
+var _ FieldLister = (*CLIResolver)(nil)
+var _ BatchResolver = (*CLIResolver)(nil)
+var _ batchReader = (*cli.Client)(nil)
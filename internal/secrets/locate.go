@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+)
+
+// locateItem narrows a reference whose vault is config.AnyVault down to a
+// single vault and item. A title found in several vaults is an error unless
+// Config.VaultPriority names a vault that settles it; the action never
+// guesses, because picking the wrong item silently delivers the wrong secret.
+func (e *Engine) locateItem(ctx context.Context, ref SecretRef) (SecretRef, error) {
+	locator, ok := e.resolver.(ItemLocator)
+	if !ok {
+		return ref, errors.NewConfigurationError(
+			errors.ErrCodeInvalidVault,
+			fmt.Sprintf("vault '%s' is not supported by this secret backend", config.AnyVault),
+			nil,
+		)
+	}
+
+	matches, err := locator.LocateItem(ctx, ref.Item)
+	if err != nil {
+		return ref, err
+	}
+
+	match, err := selectItem(ref.Item, matches, e.config.VaultPriority)
+	if err != nil {
+		return ref, err
+	}
+
+	e.logger.DebugSensitive("Located item",
+		"item", ref.Item,
+		"vault_id", match.VaultID,
+		"vault_name", match.VaultName)
+
+	return SecretRef{Vault: match.VaultID, Item: match.ItemID, Field: ref.Field}, nil
+}
+
+// selectItem picks the match for item. A single match is used as is;
+// otherwise the first vault in priority, by ID or name, that holds exactly
+// one match decides.
+func selectItem(item string, matches []ItemMatch, priority []string) (*ItemMatch, error) {
+	switch len(matches) {
+	case 0:
+		return nil, errors.NewSecretError(
+			errors.ErrCodeSecretNotFound,
+			fmt.Sprintf("item '%s' not found in any accessible vault", item),
+			nil,
+		)
+	case 1:
+		return &matches[0], nil
+	}
+
+	for _, vault := range priority {
+		var inVault []ItemMatch
+		for _, match := range matches {
+			if match.VaultID == vault || match.VaultName == vault {
+				inVault = append(inVault, match)
+			}
+		}
+		switch len(inVault) {
+		case 0:
+			continue
+		case 1:
+			return &inVault[0], nil
+		default:
+			return nil, ambiguousItemError(item, inVault)
+		}
+	}
+
+	return nil, ambiguousItemError(item, matches)
+}
+
+// ambiguousItemError lists every vault and item ID that matched.
+func ambiguousItemError(item string, matches []ItemMatch) error {
+	sorted := append([]ItemMatch(nil), matches...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].VaultName != sorted[j].VaultName {
+			return sorted[i].VaultName < sorted[j].VaultName
+		}
+		return sorted[i].ItemID < sorted[j].ItemID
+	})
+
+	found := make([]string, 0, len(sorted))
+	for _, match := range sorted {
+		found = append(found, fmt.Sprintf("vault '%s' (%s) item %s",
+			match.VaultName, match.VaultID, match.ItemID))
+	}
+
+	return errors.Wrap(
+		errors.ErrCodeItemAmbiguous,
+		fmt.Sprintf("item '%s' matches %d items: %s", item, len(sorted), strings.Join(found, ", ")),
+		nil,
+	).WithDetails(map[string]interface{}{
+		"item":    item,
+		"matches": found,
+	}).WithSuggestions(
+		"Set the vault input, or scope the record as vault/item/field",
+		"List the preferred vaults in vault_priority",
+		"Reference the item by its ID",
+	)
+}
@@ -8,11 +8,14 @@ package secrets
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/auth"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/cli"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 )
 
@@ -21,7 +24,12 @@ type MockCLIClient struct {
 	secrets map[string]*security.SecureString
 	errors  map[string]error
 	delays  map[string]time.Duration
+	calls   map[string]int
 	mu      sync.RWMutex
+	callsMu sync.Mutex
+
+	inFlight    int // GetSecret calls currently running
+	maxInFlight int // Most GetSecret calls seen running at once
 }
 
 // NewMockCLIClient creates a new mock CLI client for testing
@@ -30,9 +38,26 @@ func NewMockCLIClient() *MockCLIClient {
 		secrets: make(map[string]*security.SecureString),
 		errors:  make(map[string]error),
 		delays:  make(map[string]time.Duration),
+		calls:   make(map[string]int),
 	}
 }
 
+// CallCount returns how many times GetSecret was called for a field
+func (m *MockCLIClient) CallCount(vault, item, field string) int {
+	m.callsMu.Lock()
+	defer m.callsMu.Unlock()
+
+	return m.calls[fmt.Sprintf("%s/%s/%s", vault, item, field)]
+}
+
+// MaxInFlight returns the most GetSecret calls that ran simultaneously
+func (m *MockCLIClient) MaxInFlight() int {
+	m.callsMu.Lock()
+	defer m.callsMu.Unlock()
+
+	return m.maxInFlight
+}
+
 // SetSecret configures a secret value for testing
 func (m *MockCLIClient) SetSecret(vault, item, field, value string) error {
 	m.mu.Lock()
@@ -72,6 +97,19 @@ func (m *MockCLIClient) GetSecret(ctx context.Context, vault, item, field string
 
 	key := fmt.Sprintf("%s/%s/%s", vault, item, field)
 
+	m.callsMu.Lock()
+	m.calls[key]++
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.callsMu.Unlock()
+	defer func() {
+		m.callsMu.Lock()
+		m.inFlight--
+		m.callsMu.Unlock()
+	}()
+
 	// Check for configured delay
 	if delay, exists := m.delays[key]; exists {
 		select {
@@ -95,6 +133,24 @@ func (m *MockCLIClient) GetSecret(ctx context.Context, vault, item, field string
 	return nil, fmt.Errorf("secret not found: %s", key)
 }
 
+// GetOTP retrieves a one-time password for testing, configured as the
+// secret of the item's OTPField
+func (m *MockCLIClient) GetOTP(ctx context.Context, vault, item string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.OTPField)
+}
+
+// GetAttachment retrieves an attachment for testing, configured as the
+// secret of the item's attachment field
+func (m *MockCLIClient) GetAttachment(ctx context.Context, vault, item, name string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.AttachmentFieldPrefix+name)
+}
+
+// GetDocument retrieves a document for testing, configured as the secret
+// of the item's document field
+func (m *MockCLIClient) GetDocument(ctx context.Context, vault, item string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.DocumentField)
+}
+
 // Destroy cleans up the mock client
 func (m *MockCLIClient) Destroy() error {
 	m.mu.Lock()
@@ -187,8 +243,21 @@ func (m *MockCLIClient) ListVaults(_ context.Context) ([]cli.VaultInfo, error) {
 	}, nil
 }
 
-// GetItem implements the CLIClientInterface
+// GetItem implements the CLIClientInterface. The item's fields are those
+// configured for it with SetSecret, sorted by name.
 func (m *MockCLIClient) GetItem(_ context.Context, vault, item string) (*cli.ItemInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var fields []cli.FieldInfo
+	for key := range m.secrets {
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) == 3 && parts[0] == vault && parts[1] == item {
+			fields = append(fields, cli.FieldInfo{ID: parts[2], Label: parts[2]})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].ID < fields[j].ID })
+
 	return &cli.ItemInfo{
 		ID:    "item-id-123",
 		Title: item,
@@ -200,9 +269,39 @@ func (m *MockCLIClient) GetItem(_ context.Context, vault, item string) (*cli.Ite
 			Name: vault,
 		},
 		Category: "Login",
+		Fields:   fields,
 	}, nil
 }
 
+// FindItems implements the CLIClientInterface. It reports one match for each
+// vault holding a configured secret for item, sorted by vault.
+func (m *MockCLIClient) FindItems(_ context.Context, item string) ([]cli.ItemInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err, exists := m.errors["find:"+item]; exists {
+		return nil, err
+	}
+
+	vaults := make(map[string]bool)
+	for key := range m.secrets {
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) == 3 && parts[1] == item {
+			vaults[parts[0]] = true
+		}
+	}
+
+	matches := make([]cli.ItemInfo, 0, len(vaults))
+	for vault := range vaults {
+		match := cli.ItemInfo{ID: item, Title: item}
+		match.Vault.ID = vault
+		match.Vault.Name = vault
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Vault.ID < matches[j].Vault.ID })
+	return matches, nil
+}
+
 // MockAuthManager implements the auth manager interface for testing
 type MockAuthManager struct {
 	authError error
@@ -632,6 +731,24 @@ func (m *AdvancedMockCLI) GetSecret(ctx context.Context, vault, item, field stri
 	return m.store.GetSecret(ctx, vault, item, field)
 }
 
+// GetOTP retrieves a one-time password, configured as the secret of the
+// item's OTPField
+func (m *AdvancedMockCLI) GetOTP(ctx context.Context, vault, item string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.OTPField)
+}
+
+// GetAttachment retrieves an attachment, configured as the secret of the
+// item's attachment field
+func (m *AdvancedMockCLI) GetAttachment(ctx context.Context, vault, item, name string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.AttachmentFieldPrefix+name)
+}
+
+// GetDocument retrieves a document, configured as the secret of the item's
+// document field
+func (m *AdvancedMockCLI) GetDocument(ctx context.Context, vault, item string) (*security.SecureString, error) {
+	return m.GetSecret(ctx, vault, item, validation.DocumentField)
+}
+
 // SetSecret adds a secret to the store
 func (m *AdvancedMockCLI) SetSecret(vault, item, field, value string) error {
 	return m.store.AddSecret(vault, item, field, value)
@@ -712,6 +829,11 @@ func (m *AdvancedMockCLI) GetItem(_ context.Context, vault, item string) (*cli.I
 	}, nil
 }
 
+// FindItems implements the CLIClientInterface for AdvancedMockCLI
+func (m *AdvancedMockCLI) FindItems(_ context.Context, _ string) ([]cli.ItemInfo, error) {
+	return nil, nil
+}
+
 // Destroy cleans up the advanced mock CLI
 func (m *AdvancedMockCLI) Destroy() error {
 	if m.store != nil {
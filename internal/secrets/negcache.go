@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"sync"
+)
+
+// negativeCache remembers lookups that failed because the item or field does
+// not exist, so repeated references to the same missing value are answered
+// without querying 1Password again. It lives only for the current run and is
+// never persisted. All methods are safe to call on a nil cache.
+type negativeCache struct {
+	mu      sync.RWMutex
+	entries map[string]error
+}
+
+// newNegativeCache creates an empty negative cache.
+func newNegativeCache() *negativeCache {
+	return &negativeCache{entries: make(map[string]error)}
+}
+
+// negativeCacheKey identifies a single field lookup.
+func negativeCacheKey(vault, item, section, field string) string {
+	return vault + "\x00" + item + "\x00" + section + "\x00" + field
+}
+
+// get returns the cached not-found error for key, or nil if there is none.
+func (c *negativeCache) get(key string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[key]
+}
+
+// put records that key was not found.
+func (c *negativeCache) put(key string, err error) {
+	if c == nil || err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = err
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+)
+
+// rateLimitGate holds back every worker of an engine once one of them is
+// rate limited, so that they resume together after the wait instead of
+// each running into the limit in turn. The zero value is open.
+type rateLimitGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// pause closes the gate for d, or for longer if it is already closed
+// beyond that.
+func (g *rateLimitGate) pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+// wait blocks until the gate is open or ctx is done.
+func (g *rateLimitGate) wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		remaining := time.Until(g.until)
+		g.mu.Unlock()
+		if remaining <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimited returns the first ErrCodeRateLimited error in err's chain.
+func rateLimited(err error) (*errors.ActionableError, bool) {
+	var actionableErr *errors.ActionableError
+	if stderrors.As(err, &actionableErr) && actionableErr.Code == errors.ErrCodeRateLimited {
+		return actionableErr, true
+	}
+	return nil, false
+}
+
+// observeRateLimit closes the gate after a rate-limited request, for the
+// wait the backend asked for or, without a hint, for Config.RetryDelay.
+func (e *Engine) observeRateLimit(err error) {
+	limited, ok := rateLimited(err)
+	if !ok {
+		return
+	}
+	wait := limited.RetryAfter
+	if wait <= 0 {
+		wait = e.config.RetryDelay
+	}
+	if wait <= 0 {
+		return
+	}
+	e.gate.pause(wait)
+	e.logger.Warn("Rate limited by 1Password, pausing requests", "wait", wait)
+}
+
+// batchRateLimitError returns an ErrCodeRateLimited error for a batch in
+// which any request was rate limited, carrying the longest wait asked for,
+// or nil if none was.
+func batchRateLimitError(errs []error) error {
+	var (
+		limitedErr error
+		retryAfter time.Duration
+		count      int
+	)
+	for _, err := range errs {
+		limited, ok := rateLimited(err)
+		if !ok {
+			continue
+		}
+		count++
+		if limitedErr == nil {
+			limitedErr = err
+		}
+		if limited.RetryAfter > retryAfter {
+			retryAfter = limited.RetryAfter
+		}
+	}
+	if limitedErr == nil {
+		return nil
+	}
+	return errors.Wrap(errors.ErrCodeRateLimited,
+		fmt.Sprintf("atomic batch operation failed: %d of %d requests were rate limited", count, len(errs)),
+		limitedErr).WithRetryAfter(retryAfter)
+}
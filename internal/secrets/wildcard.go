@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/config"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/validation"
+)
+
+// singleRecordKey is the output name of a single record; a single wildcard
+// record names its outputs after the fields alone.
+const singleRecordKey = "value"
+
+// HasWildcard reports whether any request selects every field of an item.
+func HasWildcard(requests []*SecretRequest) bool {
+	for _, request := range requests {
+		if validation.IsWildcardField(request.FieldName) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandWildcards replaces each request whose field is
+// validation.WildcardField with one request per labeled field of its item.
+// The output of a field is named after its label with Config.WildcardNames,
+// prefixed with the request's own output name and an underscore unless the
+// request is a single record. Fields without a usable label are skipped.
+// Two outputs with the same name are an error rather than one silently
+// replacing the other. Requests without a wildcard are returned unchanged.
+func (e *Engine) ExpandWildcards(ctx context.Context, requests []*SecretRequest) ([]*SecretRequest, error) {
+	if !HasWildcard(requests) {
+		return requests, nil
+	}
+
+	lister, ok := e.resolver.(FieldLister)
+	if !ok {
+		return nil, errors.NewConfigurationError(
+			errors.ErrCodeInvalidRecord,
+			fmt.Sprintf("field '%s' is not supported by this secret backend", validation.WildcardField),
+			nil,
+		)
+	}
+
+	sanitize := e.config.WildcardNames
+	if sanitize == nil {
+		sanitize = validation.SanitizeFieldLabel
+	}
+
+	// Output names already taken, and what took them
+	sources := make(map[string]string, len(requests))
+	for _, request := range requests {
+		if !validation.IsWildcardField(request.FieldName) {
+			sources[request.Key] = fmt.Sprintf("%s/%s", request.ItemName, request.FieldName)
+		}
+	}
+
+	expanded := make([]*SecretRequest, 0, len(requests))
+	for _, request := range requests {
+		if !validation.IsWildcardField(request.FieldName) {
+			expanded = append(expanded, request)
+			continue
+		}
+
+		fields, ref, err := e.listFields(ctx, lister, request)
+		if err != nil {
+			return nil, err
+		}
+
+		var named []*SecretRequest
+		for _, field := range fields {
+			name := sanitize(field.Label)
+			if name == "" {
+				e.logger.Warn("Skipping field without a usable label",
+					"item", request.ItemName, "field_id", field.ID, "label", field.Label)
+				continue
+			}
+			if request.Key != singleRecordKey {
+				name = request.Key + "_" + name
+			}
+
+			source := fmt.Sprintf("%s/%s", request.ItemName, field.Label)
+			if err := validation.ValidateOutputName(name); err != nil {
+				return nil, errors.NewConfigurationError(
+					errors.ErrCodeInvalidRecord,
+					fmt.Sprintf("output %q for %q is invalid: %v", name, source, err),
+					err,
+				).WithSuggestions("List the fields individually with explicit output names")
+			}
+			if previous, exists := sources[name]; exists {
+				return nil, errors.NewConfigurationError(
+					errors.ErrCodeInvalidRecord,
+					fmt.Sprintf("%q and %q both become output %q", previous, source, name),
+					nil,
+				).WithSuggestions(
+					"Rename one of the fields in 1Password",
+					"List the fields individually with explicit output names",
+				)
+			}
+			sources[name] = source
+
+			// Fields are read by ID, which stays unambiguous when labels
+			// repeat across sections
+			fieldName := field.ID
+			if fieldName == "" {
+				fieldName = field.Label
+			}
+			named = append(named, &SecretRequest{
+				Key:        name,
+				Vault:      ref.Vault,
+				ItemName:   ref.Item,
+				FieldName:  fieldName,
+				Required:   request.Required,
+				Transforms: request.Transforms,
+			})
+		}
+
+		if len(named) == 0 {
+			return nil, errors.NewSecretError(
+				errors.ErrCodeFieldNotFound,
+				fmt.Sprintf("item '%s' has no labeled fields", request.ItemName),
+				nil,
+			)
+		}
+
+		sort.Slice(named, func(i, j int) bool { return named[i].Key < named[j].Key })
+		e.logger.Debug("Expanded wildcard record",
+			"item", request.ItemName, "fields", len(named))
+		expanded = append(expanded, named...)
+	}
+
+	return expanded, nil
+}
+
+// listFields lists the fields of a wildcard request's item, locating the
+// item first when its vault is config.AnyVault. The returned reference is
+// the one the fields should be read through.
+func (e *Engine) listFields(ctx context.Context, lister FieldLister, request *SecretRequest) ([]ItemField, SecretRef, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, e.config.RequestTimeout)
+	defer cancel()
+
+	ref := SecretRef{Vault: request.Vault, Item: request.ItemName}
+	if ref.Vault == config.AnyVault {
+		located, err := e.locateItem(reqCtx, ref)
+		if err != nil {
+			return nil, ref, err
+		}
+		ref = located
+	}
+
+	fields, err := lister.ListFields(reqCtx, ref)
+	if err != nil {
+		return nil, ref, fmt.Errorf("failed to list fields of item '%s': %w", request.ItemName, err)
+	}
+	return fields, ref, nil
+}
@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+// Package transform implements ordered post-processing pipelines for secret
+// values. Each step receives the output of the previous step, and pipelines
+// are checked when they are parsed so that orderings where a later step can
+// never apply are rejected before any secret is fetched.
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Step names
+const (
+	StepBase64Decode = "base64decode"
+	StepBase64Encode = "base64encode"
+	StepTrim         = "trim"
+	StepJSON         = "json"
+	StepJSONPath     = "jsonpath"
+	StepMinLength    = "minlength"
+	StepMaxLength    = "maxlength"
+)
+
+// Pipeline limits
+const (
+	MaxSteps = 10
+)
+
+// Kind describes the shape of the value flowing between steps.
+type Kind int
+
+const (
+	// KindText is printable text, the shape of every value fetched from 1Password.
+	KindText Kind = iota
+	// KindBytes is arbitrary decoded data that is not known to be text.
+	KindBytes
+	// KindJSON is a parsed and validated JSON document.
+	KindJSON
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindText:
+		return "text"
+	case KindBytes:
+		return "bytes"
+	case KindJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// Step is a single stage of a pipeline.
+type Step struct {
+	Name string
+	Arg  string
+
+	accepts []Kind
+	output  func(in Kind) Kind
+	apply   func(value []byte, arg string) ([]byte, error)
+}
+
+// Pipeline is an ordered list of steps.
+type Pipeline struct {
+	Steps []*Step
+}
+
+// Parse builds a pipeline from step specifications such as "base64decode",
+// "jsonpath=.database.password" or "minlength=32" and validates the ordering.
+func Parse(specs []string) (*Pipeline, error) {
+	if len(specs) > MaxSteps {
+		return nil, fmt.Errorf("too many transform steps (max %d)", MaxSteps)
+	}
+
+	pipeline := &Pipeline{Steps: make([]*Step, 0, len(specs))}
+	for i, spec := range specs {
+		step, err := newStep(spec)
+		if err != nil {
+			return nil, fmt.Errorf("transform step %d: %w", i+1, err)
+		}
+		pipeline.Steps = append(pipeline.Steps, step)
+	}
+
+	if err := pipeline.Validate(); err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+// Validate checks that every step can accept the output of the step before it.
+func (p *Pipeline) Validate() error {
+	kind := KindText
+	previous := "the secret value"
+
+	for i, step := range p.Steps {
+		if !step.acceptsKind(kind) {
+			return fmt.Errorf("transform step %d (%s) cannot follow %s: it expects %s input but would receive %s",
+				i+1, step.Name, previous, kindList(step.accepts), kind)
+		}
+		kind = step.output(kind)
+		previous = step.Name
+	}
+
+	return nil
+}
+
+// Apply runs the value through every step in order and returns the result.
+// The input value is never modified.
+func (p *Pipeline) Apply(value []byte) ([]byte, error) {
+	current := value
+	kind := KindText
+
+	for i, step := range p.Steps {
+		next, err := step.apply(current, step.Arg)
+		if err != nil {
+			return nil, fmt.Errorf("transform step %d (%s) failed: %w", i+1, step.Name, err)
+		}
+		current = next
+		kind = step.output(kind)
+	}
+
+	if kind == KindBytes && !utf8.Valid(current) {
+		return nil, fmt.Errorf("transformed value is binary data; add a %s step to produce text", StepBase64Encode)
+	}
+
+	return current, nil
+}
+
+// Empty reports whether the pipeline has no steps.
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.Steps) == 0
+}
+
+// newStep parses a single step specification.
+func newStep(spec string) (*Step, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	arg = strings.TrimSpace(arg)
+
+	step := &Step{Name: name, Arg: arg}
+	anyKind := []Kind{KindText, KindBytes, KindJSON}
+	same := func(in Kind) Kind { return in }
+
+	switch name {
+	case StepBase64Decode:
+		step.accepts = []Kind{KindText, KindBytes}
+		step.output = func(Kind) Kind { return KindBytes }
+		step.apply = base64Decode
+	case StepBase64Encode:
+		step.accepts = anyKind
+		step.output = func(Kind) Kind { return KindText }
+		step.apply = base64Encode
+	case StepTrim:
+		step.accepts = []Kind{KindText, KindJSON}
+		step.output = same
+		step.apply = func(value []byte, _ string) ([]byte, error) {
+			return bytes.TrimSpace(value), nil
+		}
+	case StepJSON:
+		step.accepts = []Kind{KindText, KindBytes}
+		step.output = func(Kind) Kind { return KindJSON }
+		step.apply = validateJSON
+	case StepJSONPath:
+		if !hasArg || arg == "" {
+			return nil, fmt.Errorf("%s requires a path, e.g. %s=.database.password", StepJSONPath, StepJSONPath)
+		}
+		step.accepts = []Kind{KindText, KindJSON}
+		step.output = func(Kind) Kind { return KindText }
+		step.apply = extractJSONPath
+	case StepMinLength, StepMaxLength:
+		if _, err := parseLength(arg); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		step.accepts = anyKind
+		step.output = same
+		if name == StepMinLength {
+			step.apply = checkMinLength
+		} else {
+			step.apply = checkMaxLength
+		}
+	case "":
+		return nil, fmt.Errorf("empty transform step")
+	default:
+		return nil, fmt.Errorf("unknown transform %q", name)
+	}
+
+	if hasArg && name != StepJSONPath && name != StepMinLength && name != StepMaxLength {
+		return nil, fmt.Errorf("%s does not take an argument", name)
+	}
+
+	return step, nil
+}
+
+func (s *Step) acceptsKind(kind Kind) bool {
+	for _, k := range s.accepts {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func kindList(kinds []Kind) string {
+	names := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		names = append(names, k.String())
+	}
+	return strings.Join(names, " or ")
+}
+
+func base64Decode(value []byte, _ string) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(value))
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+	for _, enc := range encodings {
+		if decoded, err := enc.DecodeString(trimmed); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("value is not valid base64")
+}
+
+func base64Encode(value []byte, _ string) ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(value)), nil
+}
+
+func validateJSON(value []byte, _ string) ([]byte, error) {
+	if !json.Valid(value) {
+		return nil, fmt.Errorf("value is not valid JSON")
+	}
+	return value, nil
+}
+
+// extractJSONPath resolves a dotted path such as ".database.hosts[0]" and
+// returns strings verbatim and any other value as compact JSON.
+func extractJSONPath(value []byte, path string) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON")
+	}
+
+	segments, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, seg := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[seg]
+			if !ok {
+				return nil, fmt.Errorf("path %q: key %q not found", path, seg)
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("path %q: index %q out of range", path, seg)
+			}
+			current = node[idx]
+		default:
+			return nil, fmt.Errorf("path %q: cannot descend into %q", path, seg)
+		}
+	}
+
+	if s, ok := current.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(current)
+}
+
+func splitJSONPath(path string) ([]string, error) {
+	normalized := strings.ReplaceAll(path, "[", ".")
+	normalized = strings.ReplaceAll(normalized, "]", "")
+	normalized = strings.TrimPrefix(normalized, ".")
+
+	if normalized == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(normalized, ".")
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("path %q contains an empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+func parseLength(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("length must be a non-negative integer, got %q", arg)
+	}
+	return n, nil
+}
+
+func checkMinLength(value []byte, arg string) ([]byte, error) {
+	n, err := parseLength(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) < n {
+		return nil, fmt.Errorf("value is %d bytes, shorter than the minimum of %d", len(value), n)
+	}
+	return value, nil
+}
+
+func checkMaxLength(value []byte, arg string) ([]byte, error) {
+	n, err := parseLength(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) > n {
+		return nil, fmt.Errorf("value is %d bytes, longer than the maximum of %d", len(value), n)
+	}
+	return value, nil
+}
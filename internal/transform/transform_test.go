@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package transform
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_ValidPipelines(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+	}{
+		{name: "empty", specs: nil},
+		{name: "decode then length check", specs: []string{"base64decode", "minlength=32"}},
+		{name: "decode json then extract", specs: []string{"base64decode", "json", "jsonpath=.db.password"}},
+		{name: "extract from text", specs: []string{"jsonpath=.token"}},
+		{name: "double decode", specs: []string{"base64decode", "base64decode"}},
+		{name: "encode binary", specs: []string{"base64decode", "base64encode"}},
+		{name: "case and whitespace", specs: []string{" Base64Decode ", "MaxLength = 64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := Parse(tt.specs)
+			require.NoError(t, err)
+			assert.Len(t, pipeline.Steps, len(tt.specs))
+		})
+	}
+}
+
+func TestParse_RejectsInvalidPipelines(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		wantErr string
+	}{
+		{
+			name:    "jsonpath after binary decode",
+			specs:   []string{"base64decode", "jsonpath=.password"},
+			wantErr: "transform step 2 (jsonpath) cannot follow base64decode",
+		},
+		{
+			name:    "trim after binary decode",
+			specs:   []string{"base64decode", "trim"},
+			wantErr: "cannot follow base64decode",
+		},
+		{
+			name:    "decode a json document",
+			specs:   []string{"json", "base64decode"},
+			wantErr: "cannot follow json",
+		},
+		{
+			name:    "unknown step",
+			specs:   []string{"rot13"},
+			wantErr: "unknown transform",
+		},
+		{
+			name:    "jsonpath without path",
+			specs:   []string{"jsonpath"},
+			wantErr: "requires a path",
+		},
+		{
+			name:    "bad length",
+			specs:   []string{"minlength=abc"},
+			wantErr: "non-negative integer",
+		},
+		{
+			name:    "unexpected argument",
+			specs:   []string{"base64decode=std"},
+			wantErr: "does not take an argument",
+		},
+		{
+			name:    "too many steps",
+			specs:   []string{"trim", "trim", "trim", "trim", "trim", "trim", "trim", "trim", "trim", "trim", "trim"},
+			wantErr: "too many transform steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.specs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestPipeline_Apply(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"db":{"password":"hunter22","ports":[5432]}}`))
+
+	pipeline, err := Parse([]string{"base64decode", "json", "jsonpath=.db.password", "minlength=8"})
+	require.NoError(t, err)
+
+	out, err := pipeline.Apply([]byte(encoded))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter22", string(out))
+
+	portPipeline, err := Parse([]string{"base64decode", "json", "jsonpath=.db.ports[0]"})
+	require.NoError(t, err)
+
+	out, err = portPipeline.Apply([]byte(encoded))
+	require.NoError(t, err)
+	assert.Equal(t, "5432", string(out))
+}
+
+func TestPipeline_ApplyFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "length check sees decoded bytes",
+			specs:   []string{"base64decode", "minlength=32"},
+			input:   base64.StdEncoding.EncodeToString([]byte("short")),
+			wantErr: "shorter than the minimum of 32",
+		},
+		{
+			name:    "not base64",
+			specs:   []string{"base64decode"},
+			input:   "not base64!",
+			wantErr: "not valid base64",
+		},
+		{
+			name:    "missing key",
+			specs:   []string{"jsonpath=.missing"},
+			input:   `{"present":"x"}`,
+			wantErr: `key "missing" not found`,
+		},
+		{
+			name:    "binary result",
+			specs:   []string{"base64decode"},
+			input:   base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00}),
+			wantErr: "add a base64encode step",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := Parse(tt.specs)
+			require.NoError(t, err)
+
+			_, err = pipeline.Apply([]byte(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+)
+
+// Output name policies control how a record listed without an explicit
+// output name is named after its field.
+const (
+	// OutputNameSnakeCase lowercases and joins words with underscores:
+	// "API Key" -> "api_key", "apiToken" -> "api_token"
+	OutputNameSnakeCase = "snake_case"
+
+	// OutputNameUpperSnakeCase is snake_case in upper case, matching
+	// environment variable conventions: "API Key" -> "API_KEY"
+	OutputNameUpperSnakeCase = "upper_snake_case"
+
+	// OutputNameSanitized keeps the field's case and replaces each run of
+	// characters not allowed in output names with one underscore:
+	// "API Key" -> "API_Key"
+	OutputNameSanitized = "sanitized"
+)
+
+// OutputNamePolicies lists the supported output name policies.
+var OutputNamePolicies = []string{OutputNameSnakeCase, OutputNameUpperSnakeCase, OutputNameSanitized}
+
+// IsOutputNamePolicy reports whether policy is a supported output name policy.
+func IsOutputNamePolicy(policy string) bool {
+	for _, p := range OutputNamePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// DeriveOutputName returns the output name policy gives a record's field.
+// Attachments are named after the file, and one-time passwords and
+// documents after their keyword. Templates combine several fields and have no natural name.
+func DeriveOutputName(fieldName, policy string) (string, error) {
+	if IsFieldTemplate(fieldName) {
+		return "", fmt.Errorf("field template %q needs an explicit output name", fieldName)
+	}
+	if name, ok := AttachmentName(fieldName); ok {
+		fieldName = name
+	}
+
+	var name string
+	switch policy {
+	case OutputNameSnakeCase:
+		name = strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+	case OutputNameUpperSnakeCase:
+		name = strings.ToUpper(strings.Join(splitWords(fieldName), "_"))
+	case OutputNameSanitized:
+		name = strings.Join(strings.FieldsFunc(fieldName, func(r rune) bool {
+			return !isOutputNameChar(r)
+		}), "_")
+	default:
+		return "", fmt.Errorf("unknown output name policy %q (expected one of %s)",
+			policy, strings.Join(OutputNamePolicies, ", "))
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("field %q has no characters usable in an output name", fieldName)
+	}
+	return name, nil
+}
+
+// expressionFunctions are the GitHub Actions expression functions, in lower
+// case as expressions ignore case. An output of the same name is allowed
+// but easily confused with the function in "if:" conditions.
+var expressionFunctions = map[string]bool{
+	"always": true, "success": true, "failure": true, "cancelled": true,
+	"contains": true, "startswith": true, "endswith": true, "format": true,
+	"join": true, "tojson": true, "fromjson": true, "hashfiles": true,
+}
+
+// ShadowsExpressionFunction reports whether name, ignoring case, is the
+// name of a GitHub Actions expression function such as always or success.
+func ShadowsExpressionFunction(name string) bool {
+	return expressionFunctions[strings.ToLower(name)]
+}
+
+// ValidateOutputNames checks every name at once. Invalid names are reported
+// together in one configuration error, and names shadowing an expression
+// function are returned as warnings.
+func (v *Validator) ValidateOutputNames(names []string) ([]string, error) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	var warnings, invalid, problems []string
+	for _, name := range sorted {
+		if err := v.validateOutputName(name); err != nil {
+			invalid = append(invalid, name)
+			problems = append(problems, fmt.Sprintf("invalid output name %q: %v", name, err))
+			continue
+		}
+		if ShadowsExpressionFunction(name) {
+			warnings = append(warnings, fmt.Sprintf(
+				"output name %q shadows the %s() expression function; consider renaming it", name, strings.ToLower(name)))
+		}
+	}
+	if len(invalid) == 0 {
+		return warnings, nil
+	}
+
+	return nil, errors.NewConfigurationError(
+		errors.ErrCodeInvalidRecord,
+		"Record specification has invalid output names: "+strings.Join(problems, "; "),
+		nil,
+	).WithDetails(map[string]interface{}{
+		"field":                "record",
+		"invalid_output_names": invalid,
+	}).WithSuggestions(
+		"Start output names with a letter or underscore and use only letters, digits and underscores",
+		"Avoid names reserved by GitHub Actions, such as github, env or steps",
+	)
+}
+
+// isInvalidOutputNames reports whether err is the error of ValidateOutputNames.
+func isInvalidOutputNames(err error) bool {
+	var actionable *errors.ActionableError
+	if !stderrors.As(err, &actionable) {
+		return false
+	}
+	_, ok := actionable.Details["invalid_output_names"]
+	return ok
+}
+
+// NameSanitizer turns the label of a field selected by a wildcard record
+// into an output name. An empty result means the label is unusable.
+type NameSanitizer func(label string) string
+
+// SanitizeFieldLabel is the default NameSanitizer. It lowercases label and
+// replaces each run of characters other than ASCII letters and digits with
+// one underscore, trimming underscores at either end: "API Key" -> "api_key",
+// "Host (primary)" -> "host_primary".
+func SanitizeFieldLabel(label string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return r == '_' || !isOutputNameChar(r)
+	}), "_")
+}
+
+// splitWords splits s into words at characters not allowed in output names
+// and at lower-to-upper case changes, so "apiToken" yields "api", "Token".
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	var prev rune
+	for _, r := range s {
+		if !isOutputNameChar(r) || r == '_' {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			prev = 0
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			words = append(words, string(current))
+			current = nil
+		}
+		current = append(current, r)
+		prev = r
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
+
+// isOutputNameChar reports whether r is allowed in an output name.
+func isOutputNameChar(r rune) bool {
+	return r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
@@ -0,0 +1,263 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeriveOutputName(t *testing.T) {
+	tests := []struct {
+		field  string
+		policy string
+		want   string
+	}{
+		{"API Key", OutputNameSnakeCase, "api_key"},
+		{"apiToken", OutputNameSnakeCase, "api_token"},
+		{"db-password", OutputNameSnakeCase, "db_password"},
+		{"client_secret", OutputNameSnakeCase, "client_secret"},
+		{"OAuth2Token", OutputNameSnakeCase, "oauth2_token"},
+		{"file:signing.key", OutputNameSnakeCase, "signing_key"},
+		{"otp", OutputNameSnakeCase, "otp"},
+		{"@document", OutputNameUpperSnakeCase, "DOCUMENT"},
+		{"API Key", OutputNameUpperSnakeCase, "API_KEY"},
+		{"apiToken", OutputNameUpperSnakeCase, "API_TOKEN"},
+		{"API Key", OutputNameSanitized, "API_Key"},
+		{"apiToken", OutputNameSanitized, "apiToken"},
+		{"  path / to -- key ", OutputNameSanitized, "path_to_key"},
+	}
+
+	for _, tt := range tests {
+		got, err := DeriveOutputName(tt.field, tt.policy)
+		if err != nil {
+			t.Errorf("DeriveOutputName(%q, %s) error: %v", tt.field, tt.policy, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DeriveOutputName(%q, %s) = %q, want %q", tt.field, tt.policy, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ field, policy string }{
+		{"{username}:{password}", OutputNameSnakeCase},
+		{"---", OutputNameSnakeCase},
+		{"ключ", OutputNameSanitized},
+		{"password", "kebab-case"},
+	} {
+		if got, err := DeriveOutputName(tt.field, tt.policy); err == nil {
+			t.Errorf("DeriveOutputName(%q, %s) = %q, want error", tt.field, tt.policy, got)
+		}
+	}
+}
+
+func TestParseRecord_List(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	for _, record := range []string{
+		`["op://Production/Stripe/API Key", "database/password"]`,
+		"- op://Production/Stripe/API Key\n- database/password\n",
+	} {
+		spec, err := validator.ParseRecord(record)
+		if err != nil {
+			t.Fatalf("ParseRecord(%q) error: %v", record, err)
+		}
+		if spec.Type != RecordTypeMultiple || len(spec.Multi) != 2 {
+			t.Fatalf("ParseRecord(%q) = %+v, want two records", record, spec)
+		}
+		if got := spec.Multi["api_key"]; got == nil || got.SecretName != "Stripe" || got.VaultRef != "Production" {
+			t.Errorf("ParseRecord(%q) api_key = %+v", record, got)
+		}
+		if got := spec.Multi["password"]; got == nil || got.SecretName != "database" {
+			t.Errorf("ParseRecord(%q) password = %+v", record, got)
+		}
+	}
+
+	if err := validator.SetOutputNamePolicy(OutputNameUpperSnakeCase); err != nil {
+		t.Fatalf("SetOutputNamePolicy() error: %v", err)
+	}
+	spec, err := validator.ParseRecord("- op://Production/Stripe/API Key")
+	if err != nil {
+		t.Fatalf("ParseRecord() error: %v", err)
+	}
+	if spec.Multi["API_KEY"] == nil {
+		t.Errorf("ParseRecord() outputs = %v, want API_KEY", spec.Multi)
+	}
+
+	if err := validator.SetOutputNamePolicy("kebab-case"); err == nil {
+		t.Error("SetOutputNamePolicy(kebab-case) should have failed")
+	}
+}
+
+func TestParseRecord_ListErrors(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	tests := []struct {
+		record  string
+		wantErr string
+	}{
+		{"- db/password\n- api/password", `"db/password" and "api/password" both become output "password"`},
+		{"- db/API-Key\n- db/api_key", `both become output "api_key"`},
+		{`["db/{user}:{password}"]`, "needs an explicit output name"},
+		{`["db/2fa-secret"]`, "cannot start with a number"},
+		{`["db/env"]`, "is reserved"},
+		{`[]`, "cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		_, err := validator.ParseRecord(tt.record)
+		if err == nil {
+			t.Errorf("ParseRecord(%q) should have failed", tt.record)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseRecord(%q) error = %v, want it to contain %q", tt.record, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseRecord_Assignments(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	spec, err := validator.ParseRecord(
+		"# deploy credentials\nstripe_key = op://Production/Stripe/API Key\n\ndb_password=Production/database/password\n  token =  ci:deploy/token?\n")
+	if err != nil {
+		t.Fatalf("ParseRecord() error: %v", err)
+	}
+	if spec.Type != RecordTypeMultiple || len(spec.Multi) != 3 {
+		t.Fatalf("ParseRecord() = %+v, want three records", spec)
+	}
+	if got := spec.Multi["stripe_key"]; got == nil || got.VaultRef != "Production" || got.FieldName != "API Key" {
+		t.Errorf("stripe_key = %+v", got)
+	}
+	if got := spec.Multi["db_password"]; got == nil || got.VaultRef != "Production" || got.SecretName != "database" {
+		t.Errorf("db_password = %+v", got)
+	}
+	if got := spec.Multi["token"]; got == nil || got.VaultRef != "ci" || !got.Optional {
+		t.Errorf("token = %+v", got)
+	}
+
+	tests := []struct {
+		record  string
+		wantErr string
+	}{
+		{"db = db/password\napi/key", "line 2: missing '='"},
+		{"db = db/password\ndb = api/password", `line 2: duplicate output name "db"`},
+		{"db = ", `missing reference for output "db"`},
+		{"db = db/password\nenv = db/user", `invalid output name "env"`},
+		{"db = not a reference", `invalid secret specification for "db"`},
+	}
+	for _, tt := range tests {
+		_, err := validator.ParseRecord(tt.record)
+		if err == nil {
+			t.Errorf("ParseRecord(%q) should have failed", tt.record)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseRecord(%q) error = %v, want it to contain %q", tt.record, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateOutputNames(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+
+	warnings, err := validator.ValidateOutputNames([]string{"db", "Success", "hashFiles"})
+	if err != nil {
+		t.Fatalf("ValidateOutputNames() error = %v", err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "Success") || !strings.Contains(warnings[1], "hashfiles()") {
+		t.Errorf("ValidateOutputNames() warnings = %v, want Success and hashFiles", warnings)
+	}
+
+	_, err = validator.ValidateOutputNames([]string{"steps", "ok", "bad-name", "9lives"})
+	if err == nil {
+		t.Fatal("ValidateOutputNames() should have failed")
+	}
+	for _, name := range []string{`"9lives"`, `"bad-name"`, `"steps"`} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("ValidateOutputNames() error = %v, want it to name %s", err, name)
+		}
+	}
+	if strings.Contains(err.Error(), `"ok"`) {
+		t.Errorf("ValidateOutputNames() error = %v names a valid output", err)
+	}
+
+	// Records report invalid names rather than failing to parse
+	for _, record := range []string{`{"env": "a/b", "x y": "c/d"}`, "env: a/b\nx y: c/d"} {
+		_, err := validator.ParseRecord(record)
+		if err == nil || !strings.Contains(err.Error(), `"env"`) || !strings.Contains(err.Error(), `"x y"`) {
+			t.Errorf("ParseRecord(%q) error = %v, want both invalid names", record, err)
+		}
+	}
+	spec, err := validator.ParseRecord("always = a/b/c")
+	if err != nil || len(spec.Warnings) != 1 {
+		t.Errorf("ParseRecord() = %+v, %v, want one warning", spec, err)
+	}
+}
+
+func TestSanitizeFieldLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"API Key", "api_key"},
+		{"password", "password"},
+		{"Host (primary)", "host_primary"},
+		{"  db--URL__v2 ", "db_url_v2"},
+		{"apiToken", "apitoken"},
+		{"ключ", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeFieldLabel(tt.label); got != tt.want {
+			t.Errorf("SanitizeFieldLabel(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecord_Wildcard(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	for _, record := range []string{"database/*", "prod:database/*", "op://prod/database/*"} {
+		spec, err := validator.ParseRecord(record)
+		if err != nil {
+			t.Errorf("ParseRecord(%q) error: %v", record, err)
+			continue
+		}
+		if spec.Type != RecordTypeSingle || !IsWildcardField(spec.Single.FieldName) {
+			t.Errorf("ParseRecord(%q) = %+v, want a single wildcard record", record, spec.Single)
+		}
+	}
+
+	spec, err := validator.ParseRecord(`{"db": "prod/database/*", "api": "api/token"}`)
+	if err != nil {
+		t.Fatalf("ParseRecord() with a wildcard entry error: %v", err)
+	}
+	if db := spec.Multi["db"]; db == nil || db.VaultRef != "prod" || !IsWildcardField(db.FieldName) {
+		t.Errorf("db record = %+v, want a wildcard in vault prod", spec.Multi["db"])
+	}
+
+	for _, record := range []string{"- database/*", "database/pass*", "database/*/x", "database/* DROP"} {
+		if _, err := validator.ParseRecord(record); err == nil {
+			t.Errorf("ParseRecord(%q) should have failed", record)
+		}
+	}
+}
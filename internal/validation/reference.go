@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SecretReferencePrefix starts a 1Password secret reference as copied from
+// the 1Password apps, e.g. "op://Production/Database/password".
+const SecretReferencePrefix = "op://"
+
+// OTPField is the field keyword that requests the current one-time password
+// generated from an item's TOTP secret, as in "vault/item/otp", instead of a
+// stored field value. The code changes every 30 seconds.
+const OTPField = "otp"
+
+// AttachmentFieldPrefix marks a field that names a file attached to an item
+// rather than a field, as in "vault/item/file:signing.key". An attachment is
+// written to a file and only return_type "file" can deliver it.
+const AttachmentFieldPrefix = "file:"
+
+// DocumentField is the field keyword that requests the file of a Document
+// item, as in "vault/item/@document". Like an attachment, a document is
+// written to a file and only return_type "file" can deliver it.
+const DocumentField = "@document"
+
+// WildcardField requests every field of an item, as in "vault:item/*" or
+// "op://vault/item/*". Each labeled field becomes its own output, named
+// after its label by SanitizeFieldLabel.
+const WildcardField = "*"
+
+var referenceNameRegex = regexp.MustCompile("^" + ValidVaultChars + "$")
+
+// wildcardFieldRegex matches a WildcardField ending a reference, which the
+// security checks would otherwise take for the start of a SQL comment.
+var wildcardFieldRegex = regexp.MustCompile(`/\*([\s"',\]}]|$)`)
+
+// IsSecretReference reports whether ref uses the op:// secret reference form.
+func IsSecretReference(ref string) bool {
+	return strings.HasPrefix(strings.TrimSpace(ref), SecretReferencePrefix)
+}
+
+// IsOTPField reports whether field is the OTPField keyword.
+func IsOTPField(field string) bool {
+	return strings.EqualFold(strings.TrimSpace(field), OTPField)
+}
+
+// IsDocumentField reports whether field is the DocumentField keyword.
+func IsDocumentField(field string) bool {
+	return strings.EqualFold(strings.TrimSpace(field), DocumentField)
+}
+
+// IsFileField reports whether field resolves to file content, an
+// attachment or a document, which is delivered byte for byte and never
+// masked, validated as text or logged.
+func IsFileField(field string) bool {
+	_, ok := AttachmentName(field)
+	return ok || IsDocumentField(field)
+}
+
+// IsWildcardField reports whether field is the WildcardField keyword.
+func IsWildcardField(field string) bool {
+	return strings.TrimSpace(field) == WildcardField
+}
+
+// AttachmentName returns the file name of a field that uses
+// AttachmentFieldPrefix, and whether field names an attachment at all.
+func AttachmentName(field string) (string, bool) {
+	trimmed := strings.TrimSpace(field)
+	if !strings.HasPrefix(trimmed, AttachmentFieldPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, AttachmentFieldPrefix)), true
+}
+
+// validateAttachmentField checks the file name of an attachment field.
+// Attachment names often contain spaces, so they are checked against the
+// vault character set.
+func validateAttachmentField(field string) error {
+	name, _ := AttachmentName(field)
+	if name == "" {
+		return fmt.Errorf("attachment name cannot be empty")
+	}
+	return validateReferenceName("attachment name", name, MaxFieldLength)
+}
+
+// validateSectionField checks the section of a section-qualified reference.
+// Sections hold fields, so the field cannot be one of the keywords that
+// address the whole item.
+func validateSectionField(section, field string) error {
+	if section == "" {
+		return fmt.Errorf("section name cannot be empty")
+	}
+	if err := validateReferenceName("section name", section, MaxFieldLength); err != nil {
+		return err
+	}
+	if _, ok := AttachmentName(field); ok {
+		return fmt.Errorf("attachments are not held in sections; use vault/item/%s", field)
+	}
+	if IsWildcardField(field) || IsOTPField(field) || IsDocumentField(field) {
+		return fmt.Errorf("a section can only qualify a field name, not %q", field)
+	}
+	return nil
+}
+
+// validateReferenceName checks an item or field name of a secret reference.
+func validateReferenceName(kind, name string, maxLength int) error {
+	if len(name) > maxLength {
+		return fmt.Errorf("%s exceeds maximum length of %d", kind, maxLength)
+	}
+	if !referenceNameRegex.MatchString(name) {
+		return fmt.Errorf("%s contains invalid characters", kind)
+	}
+	return nil
+}
+
+// SplitSecretReference splits "op://vault/item/field" or "vault/item/field"
+// into its segments. Segments of the op:// form are URL-decoded, so that
+// "op://My%20Vault/API%20Keys/credential" names the vault "My Vault". The
+// op:// form may qualify the field with the section holding it, as in
+// "op://vault/item/section/field"; section is empty otherwise. Backslashes
+// pasted from Windows paths are read as slashes and repeated, leading or
+// trailing slashes are ignored. A reference with any other number of
+// segments, or with an empty segment, is rejected.
+func SplitSecretReference(ref string) (vault, item, section, field string, err error) {
+	trimmed := strings.ReplaceAll(strings.TrimSpace(ref), `\`, "/")
+	encoded := strings.HasPrefix(trimmed, SecretReferencePrefix)
+	path := strings.TrimPrefix(trimmed, SecretReferencePrefix)
+
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	names := []string{"vault", "item", "field"}
+	switch {
+	case len(segments) == 0:
+		return "", "", "", "", fmt.Errorf("secret reference cannot be empty")
+	case len(segments) < 3:
+		return "", "", "", "", fmt.Errorf("secret reference %s cannot be empty (expected vault/item/field)",
+			strings.Join(names[len(segments):], " and "))
+	case len(segments) == 3:
+	case len(segments) == 4 && encoded:
+		names = []string{"vault", "item", "section", "field"}
+	case encoded:
+		return "", "", "", "", fmt.Errorf(
+			"secret reference must have 3 or 4 segments (vault/item[/section]/field), got %d",
+			len(segments))
+	default:
+		return "", "", "", "", fmt.Errorf(
+			"secret reference must have 3 segments (vault/item/field), got %d", len(segments))
+	}
+
+	for i, segment := range segments {
+		if encoded {
+			decoded, decodeErr := url.PathUnescape(segment)
+			if decodeErr != nil {
+				return "", "", "", "", fmt.Errorf("secret reference %s is not valid URL encoding: %w",
+					names[i], decodeErr)
+			}
+			segment = decoded
+		}
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return "", "", "", "", fmt.Errorf("secret reference %s cannot be empty", names[i])
+		}
+		segments[i] = segment
+	}
+
+	if len(segments) == 4 {
+		return segments[0], segments[1], segments[2], segments[3], nil
+	}
+	return segments[0], segments[1], "", segments[2], nil
+}
@@ -0,0 +1,322 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSecretReference(t *testing.T) {
+	tests := []struct {
+		name        string
+		ref         string
+		vault       string
+		item        string
+		section     string
+		field       string
+		errContains string
+	}{
+		{
+			name:  "op reference",
+			ref:   "op://Production/Database/password",
+			vault: "Production", item: "Database", field: "password",
+		},
+		{
+			name:  "op reference with encoded spaces",
+			ref:   " op://My%20Vault/API%20Keys/secret%20key ",
+			vault: "My Vault", item: "API Keys", field: "secret key",
+		},
+		{
+			name:  "plain reference",
+			ref:   "Production/Database/password",
+			vault: "Production", item: "Database", field: "password",
+		},
+		{
+			name:  "plain reference is not decoded",
+			ref:   "Production/100%25/password",
+			vault: "Production", item: "100%25", field: "password",
+		},
+		{
+			name:        "too few segments",
+			ref:         "op://Production/Database",
+			errContains: "field cannot be empty",
+		},
+		{
+			name:  "op reference with section",
+			ref:   "op://Production/Database/Replica%20Set/password",
+			vault: "Production", item: "Database", section: "Replica Set", field: "password",
+		},
+		{
+			name:        "plain reference with section",
+			ref:         "Production/Database/replica/password",
+			errContains: "3 segments (vault/item/field), got 4",
+		},
+		{
+			name:        "too many segments",
+			ref:         "op://Production/Database/replica/extra/password",
+			errContains: "3 or 4 segments (vault/item[/section]/field), got 5",
+		},
+		{
+			name:  "duplicate separators are collapsed",
+			ref:   "op://Production/Database//password",
+			vault: "Production", item: "Database", field: "password",
+		},
+		{
+			name:  "trailing slash",
+			ref:   "Production/Database/password/",
+			vault: "Production", item: "Database", field: "password",
+		},
+		{
+			name:  "windows backslashes",
+			ref:   `Production\Database\password`,
+			vault: "Production", item: "Database", field: "password",
+		},
+		{
+			name:  "backslashed op reference",
+			ref:   `op:\\Production\Database\Replica\password`,
+			vault: "Production", item: "Database", section: "Replica", field: "password",
+		},
+		{
+			name:        "collapsed to a missing field",
+			ref:         "op://Production//password",
+			errContains: "field cannot be empty",
+		},
+		{
+			name:        "only separators",
+			ref:         "op://Production///",
+			errContains: "item and field cannot be empty",
+		},
+		{
+			name:        "empty reference",
+			ref:         " / ",
+			errContains: "secret reference cannot be empty",
+		},
+		{
+			name:        "blank item",
+			ref:         "op://Production/%20/password",
+			errContains: "item cannot be empty",
+		},
+		{
+			name:        "invalid encoding",
+			ref:         "op://Production/Data%zzbase/password",
+			errContains: "not valid URL encoding",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault, item, section, field, err := SplitSecretReference(tt.ref)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("SplitSecretReference(%q) error = %v, want containing %q", tt.ref, err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitSecretReference(%q) unexpected error: %v", tt.ref, err)
+			}
+			if vault != tt.vault || item != tt.item || section != tt.section || field != tt.field {
+				t.Errorf("SplitSecretReference(%q) = %q, %q, %q, %q; want %q, %q, %q, %q",
+					tt.ref, vault, item, section, field, tt.vault, tt.item, tt.section, tt.field)
+			}
+		})
+	}
+}
+
+func TestIsOTPField(t *testing.T) {
+	for field, want := range map[string]bool{
+		"otp":      true,
+		" OTP ":    true,
+		"password": false,
+		"otp_code": false,
+		"":         false,
+	} {
+		if got := IsOTPField(field); got != want {
+			t.Errorf("IsOTPField(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
+
+func TestParseRecord_SecretReference(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	single, err := validator.ParseRecord("op://My%20Vault/Database/password")
+	if err != nil {
+		t.Fatalf("ParseRecord() single reference error: %v", err)
+	}
+	if single.Type != RecordTypeSingle || single.Single.VaultRef != "My Vault" ||
+		single.Single.SecretName != "Database" || single.Single.FieldName != "password" {
+		t.Errorf("unexpected single record: %+v", single.Single)
+	}
+
+	multi, err := validator.ParseRecord(`{"db_password": "op://Production/Database/password", "api_key": "api/key"}`)
+	if err != nil {
+		t.Fatalf("ParseRecord() JSON references error: %v", err)
+	}
+	db := multi.Multi["db_password"]
+	if db == nil || db.VaultRef != "Production" || db.SecretName != "Database" || db.FieldName != "password" {
+		t.Errorf("unexpected db_password record: %+v", db)
+	}
+
+	yamlSpec, err := validator.ParseRecord("db_password: op://Production/Database/password\n")
+	if err != nil {
+		t.Fatalf("ParseRecord() YAML reference error: %v", err)
+	}
+	if db := yamlSpec.Multi["db_password"]; db == nil || db.VaultRef != "Production" {
+		t.Errorf("unexpected YAML record: %+v", db)
+	}
+}
+
+func TestParseRecord_Section(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	single, err := validator.ParseRecord("op://Production/Database/Replica%20Set/password")
+	if err != nil {
+		t.Fatalf("ParseRecord() section reference error: %v", err)
+	}
+	if single.Single.VaultRef != "Production" || single.Single.SecretName != "Database" ||
+		single.Single.Section != "Replica Set" || single.Single.FieldName != "password" {
+		t.Errorf("unexpected section record: %+v", single.Single)
+	}
+
+	// Both forms coexist in one record specification
+	multi, err := validator.ParseRecord(`{"primary": "Production/Database/password", "replica": "op://Production/Database/replica/password"}`)
+	if err != nil {
+		t.Fatalf("ParseRecord() mixed references error: %v", err)
+	}
+	if primary := multi.Multi["primary"]; primary == nil || primary.Section != "" {
+		t.Errorf("unexpected primary record: %+v", primary)
+	}
+	if replica := multi.Multi["replica"]; replica == nil || replica.Section != "replica" || replica.FieldName != "password" {
+		t.Errorf("unexpected replica record: %+v", replica)
+	}
+
+	// The plain form takes a section as its third of four parts
+	plain, err := validator.ParseRecord("replica: Production/Database/replica/password\ntoken: CI/Deploy/keys/token?\n")
+	if err != nil {
+		t.Fatalf("ParseRecord() plain section references error: %v", err)
+	}
+	if replica := plain.Multi["replica"]; replica == nil || replica.VaultRef != "Production" ||
+		replica.SecretName != "Database" || replica.Section != "replica" || replica.FieldName != "password" {
+		t.Errorf("unexpected plain replica record: %+v", replica)
+	}
+	if token := plain.Multi["token"]; token == nil || token.Section != "keys" || token.FieldName != "token" || !token.Optional {
+		t.Errorf("unexpected plain token record: %+v", token)
+	}
+
+	for _, record := range []string{
+		`{"replica": "Production/Database//password"}`,
+		`{"replica": "Production/Database/replica/*"}`,
+		`{"replica": "Production/Database/replica/file:key.pem"}`,
+		"op://Production/Database/replica/*",
+		"op://Production/Database/replica/otp",
+		"op://Production/Database/replica/file:key.pem",
+		"op://Production/Database/replica/@document",
+		"op://Production/Database/re;plica/password",
+	} {
+		if _, err := validator.ParseRecord(record); err == nil {
+			t.Errorf("ParseRecord(%q) error = nil, want error", record)
+		}
+	}
+}
+
+func TestParseRecord_Optional(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	single, err := validator.ParseRecord("database/password?")
+	if err != nil {
+		t.Fatalf("ParseRecord() optional record error: %v", err)
+	}
+	if !single.Single.Optional || single.Single.FieldName != "password" {
+		t.Errorf("unexpected optional record: %+v", single.Single)
+	}
+
+	multi, err := validator.ParseRecord(`{"token": "op://CI/Deploy/token?", "user": "CI/Deploy/user"}`)
+	if err != nil {
+		t.Fatalf("ParseRecord() optional references error: %v", err)
+	}
+	if token := multi.Multi["token"]; token == nil || !token.Optional || token.FieldName != "token" {
+		t.Errorf("unexpected token record: %+v", token)
+	}
+	if user := multi.Multi["user"]; user == nil || user.Optional {
+		t.Errorf("unexpected user record: %+v", user)
+	}
+
+	for _, record := range []string{"database/password??", "database?", "database/?"} {
+		if _, err := validator.ParseRecord(record); err == nil {
+			t.Errorf("ParseRecord(%q) error = nil, want error", record)
+		}
+	}
+}
+
+func TestParseRecord_Attachment(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	for _, record := range []string{
+		"signing/file:release key.asc",
+		"Production:signing/file:key.pem",
+		"op://Production/signing/file:release%20key.asc",
+	} {
+		spec, err := validator.ParseRecord(record)
+		if err != nil {
+			t.Errorf("ParseRecord(%q) error: %v", record, err)
+			continue
+		}
+		if name, ok := AttachmentName(spec.Single.FieldName); !ok || name == "" {
+			t.Errorf("ParseRecord(%q) field = %q, want an attachment", record, spec.Single.FieldName)
+		}
+	}
+
+	for _, record := range []string{
+		"signing/file:",
+		"signing/file:../key.pem",
+		"op://Production/signing/file:a%2Fb",
+	} {
+		if _, err := validator.ParseRecord(record); err == nil {
+			t.Errorf("ParseRecord(%q) should have failed", record)
+		}
+	}
+}
+
+func TestParseRecord_Document(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	for _, record := range []string{
+		"keystore/@document",
+		"Production:keystore/@Document",
+		"op://Production/release%20keystore/@document",
+		`{"keystore": "Production/keystore/@document"}`,
+	} {
+		spec, err := validator.ParseRecord(record)
+		if err != nil {
+			t.Errorf("ParseRecord(%q) error: %v", record, err)
+			continue
+		}
+		fields := []*SingleRecord{spec.Single}
+		if spec.Single == nil {
+			fields = []*SingleRecord{spec.Multi["keystore"]}
+		}
+		for _, single := range fields {
+			if single == nil || !IsDocumentField(single.FieldName) || !IsFileField(single.FieldName) {
+				t.Errorf("ParseRecord(%q) field = %q, want a document", record, single.FieldName)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Field template limits
+const (
+	MaxTemplateFields = 16
+)
+
+var templateFieldRegex = regexp.MustCompile("^" + ValidFieldChars + "$")
+
+// FieldTemplate combines several fields of a single item into one value.
+// Field labels are referenced with braces, for example
+// "{username}:{password}@{host}"; any text outside the braces is copied
+// verbatim and acts as the separator between fields.
+type FieldTemplate struct {
+	Raw    string
+	Fields []string // Unique field labels in order of first appearance
+	parts  []templatePart
+}
+
+// templatePart is either a literal string or a field reference.
+type templatePart struct {
+	literal string
+	field   string
+}
+
+// IsFieldTemplate reports whether a field specification uses the template
+// syntax rather than naming a single field.
+func IsFieldTemplate(spec string) bool {
+	return strings.ContainsAny(spec, "{}")
+}
+
+// ParseFieldTemplate parses and validates a field template specification.
+func ParseFieldTemplate(spec string) (*FieldTemplate, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("field template cannot be empty")
+	}
+
+	if len(spec) > MaxFieldLength {
+		return nil, fmt.Errorf("field template exceeds maximum length of %d", MaxFieldLength)
+	}
+
+	tmpl := &FieldTemplate{Raw: spec}
+	seen := make(map[string]bool)
+	rest := spec
+
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		closing := strings.IndexByte(rest, '}')
+
+		if open < 0 {
+			if closing >= 0 {
+				return nil, fmt.Errorf("field template has an unmatched '}'")
+			}
+			tmpl.parts = append(tmpl.parts, templatePart{literal: rest})
+			break
+		}
+		if closing >= 0 && closing < open {
+			return nil, fmt.Errorf("field template has an unmatched '}'")
+		}
+		if open > 0 {
+			tmpl.parts = append(tmpl.parts, templatePart{literal: rest[:open]})
+		}
+
+		rest = rest[open+1:]
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("field template has an unterminated '{'")
+		}
+
+		label := strings.TrimSpace(rest[:end])
+		if label == "" {
+			return nil, fmt.Errorf("field template contains an empty field reference")
+		}
+		if strings.ContainsRune(label, '{') {
+			return nil, fmt.Errorf("field template references cannot be nested")
+		}
+		if !templateFieldRegex.MatchString(label) {
+			return nil, fmt.Errorf("field template reference %q contains invalid characters", label)
+		}
+
+		tmpl.parts = append(tmpl.parts, templatePart{field: label})
+		if !seen[label] {
+			seen[label] = true
+			tmpl.Fields = append(tmpl.Fields, label)
+		}
+		rest = rest[end+1:]
+	}
+
+	if len(tmpl.Fields) == 0 {
+		return nil, fmt.Errorf("field template must reference at least one field")
+	}
+	if len(tmpl.Fields) > MaxTemplateFields {
+		return nil, fmt.Errorf("field template references too many fields (max %d)", MaxTemplateFields)
+	}
+
+	return tmpl, nil
+}
+
+// Render assembles the template using the supplied field values. Every
+// referenced field must be present in values.
+func (t *FieldTemplate) Render(values map[string]string) (string, error) {
+	var b strings.Builder
+
+	for _, part := range t.parts {
+		if part.field == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+
+		value, ok := values[part.field]
+		if !ok {
+			return "", fmt.Errorf("field template value for %q is missing", part.field)
+		}
+		b.WriteString(value)
+	}
+
+	return b.String(), nil
+}
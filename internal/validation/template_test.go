@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2025 The Linux Foundation
+
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		wantFields []string
+		wantErr    string
+	}{
+		{
+			name:       "connection string",
+			spec:       "{username}:{password}@{host}",
+			wantFields: []string{"username", "password", "host"},
+		},
+		{
+			name:       "separator only",
+			spec:       "{first},{second}",
+			wantFields: []string{"first", "second"},
+		},
+		{
+			name:       "repeated field is listed once",
+			spec:       "{user}-{user}",
+			wantFields: []string{"user"},
+		},
+		{
+			name:       "literal prefix and suffix",
+			spec:       "postgres:{host}:5432",
+			wantFields: []string{"host"},
+		},
+		{
+			name:    "no field references",
+			spec:    "plain",
+			wantErr: "at least one field",
+		},
+		{
+			name:    "empty reference",
+			spec:    "{}:{password}",
+			wantErr: "empty field reference",
+		},
+		{
+			name:    "unterminated reference",
+			spec:    "{username",
+			wantErr: "unterminated",
+		},
+		{
+			name:    "unmatched closing brace",
+			spec:    "username}:{password}",
+			wantErr: "unmatched",
+		},
+		{
+			name:    "nested reference",
+			spec:    "{user{name}}",
+			wantErr: "nested",
+		},
+		{
+			name:    "invalid label characters",
+			spec:    "{user name}",
+			wantErr: "invalid characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseFieldTemplate(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tmpl.Fields, tt.wantFields) {
+				t.Errorf("Fields = %v, want %v", tmpl.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestFieldTemplateRender(t *testing.T) {
+	tmpl, err := ParseFieldTemplate("{username}:{password}@{host}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := tmpl.Render(map[string]string{
+		"username": "app",
+		"password": "s3cr3t",
+		"host":     "db.internal",
+	})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if got != "app:s3cr3t@db.internal" {
+		t.Errorf("Render = %q, want %q", got, "app:s3cr3t@db.internal")
+	}
+
+	_, err = tmpl.Render(map[string]string{"username": "app"})
+	if err == nil || !strings.Contains(err.Error(), "password") {
+		t.Errorf("expected missing field error naming password, got %v", err)
+	}
+}
+
+func TestIsFieldTemplate(t *testing.T) {
+	if IsFieldTemplate("password") {
+		t.Error("plain field name should not be a template")
+	}
+	if !IsFieldTemplate("{username}:{password}") {
+		t.Error("braced specification should be a template")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/errors"
+	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/internal/transform"
 	"github.com/ModeSevenIndustrialSolutions/1password-secrets-action/pkg/security"
 	"gopkg.in/yaml.v3"
 )
@@ -47,6 +48,14 @@ const (
 	ValidOutputChars = `[a-zA-Z0-9_]+`
 )
 
+// AnyVault as a vault identifier searches every vault the token can access
+// for the item instead of a single vault.
+const AnyVault = "*"
+
+// OptionalMarker suffixed to a record reference allows its field to be
+// missing from the item; the output is then empty instead of an error.
+const OptionalMarker = "?"
+
 // Validator provides comprehensive input validation and sanitization
 type Validator struct {
 	tokenRegex  *regexp.Regexp
@@ -54,6 +63,9 @@ type Validator struct {
 	secretRegex *regexp.Regexp
 	fieldRegex  *regexp.Regexp
 	outputRegex *regexp.Regexp
+
+	// outputNamePolicy names records listed without an output name
+	outputNamePolicy string
 }
 
 // Error represents a validation failure (deprecated - use errors.ActionableError)
@@ -72,6 +84,10 @@ type RecordSpec struct {
 	Type   RecordType
 	Single *SingleRecord
 	Multi  map[string]*SingleRecord
+
+	// Warnings lists output names that are valid but confusing, such as
+	// names shadowing an expression function
+	Warnings []string
 }
 
 // RecordType indicates the type of record specification
@@ -88,7 +104,10 @@ const (
 type SingleRecord struct {
 	SecretName string
 	FieldName  string
-	VaultRef   string // Optional vault override
+	VaultRef   string   // Optional vault override
+	Section    string   // Optional section holding the field
+	Transforms []string // Optional ordered transform pipeline
+	Optional   bool     // A missing field resolves to an empty value
 }
 
 // NewValidator creates a new input validator
@@ -124,9 +143,22 @@ func NewValidator() (*Validator, error) {
 		secretRegex: secretRegex,
 		fieldRegex:  fieldRegex,
 		outputRegex: outputRegex,
+
+		outputNamePolicy: OutputNameSnakeCase,
 	}, nil
 }
 
+// SetOutputNamePolicy selects how records listed without an explicit output
+// name are named after their field; see DeriveOutputName.
+func (v *Validator) SetOutputNamePolicy(policy string) error {
+	if !IsOutputNamePolicy(policy) {
+		return fmt.Errorf("unknown output name policy %q (expected one of %s)",
+			policy, strings.Join(OutputNamePolicies, ", "))
+	}
+	v.outputNamePolicy = policy
+	return nil
+}
+
 // Enhanced token validation types and constants
 
 // TokenInfo contains information about a validated token.
@@ -446,8 +478,12 @@ func (v *Validator) GetTokenValidationRequirements() map[string]interface{} {
 	}
 }
 
-// ValidateVault validates vault identifier (name or ID)
+// ValidateVault validates vault identifier (name or ID), or AnyVault
 func (v *Validator) ValidateVault(vault string) error {
+	if vault == AnyVault {
+		return nil
+	}
+
 	if vault == "" {
 		return errors.NewConfigurationError(
 			errors.ErrCodeMissingInput,
@@ -546,6 +582,8 @@ func (v *Validator) ValidateReturnType(returnType string) error {
 		"output": true,
 		"env":    true,
 		"both":   true,
+		"file":   true,
+		"json":   true,
 	}
 
 	if !validTypes[returnType] {
@@ -556,12 +594,14 @@ func (v *Validator) ValidateReturnType(returnType string) error {
 		).WithDetails(map[string]interface{}{
 			"field":        "return_type",
 			"value":        returnType,
-			"valid_values": []string{"output", "env", "both"},
-		}).WithUserMessage("The return_type must be one of: output, env, or both").
+			"valid_values": []string{"output", "env", "both", "file", "json"},
+		}).WithUserMessage("The return_type must be one of: output, env, both, file, or json").
 			WithSuggestions(
 				"Use 'output' to set GitHub Actions outputs",
 				"Use 'env' to set environment variables",
 				"Use 'both' to set both outputs and environment variables",
+				"Use 'file' to write each secret to a file and output its path",
+				"Use 'json' to set a single output holding all secrets as a JSON object",
 			)
 	}
 
@@ -602,30 +642,82 @@ func (v *Validator) ParseRecord(record string) (*RecordSpec, error) {
 		}).WithUserMessage("The record specification contains invalid characters")
 	}
 
-	// Check for security attack patterns
-	if err := v.validateSecurityPatterns("record", record); err != nil {
+	// Check for security attack patterns; a wildcard field is not one
+	if err := v.validateSecurityPatterns("record", wildcardFieldRegex.ReplaceAllString(record, "/_${1}")); err != nil {
 		return nil, err
 	}
 
-	// Try to parse as JSON first (starts with { or [)
+	// Output names must be unique; JSON and YAML decoding would otherwise
+	// keep one of the duplicates silently or fail with an unrelated error
 	trimmed := strings.TrimSpace(record)
-	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
-		if multiRecord, err := v.parseJSONRecord(record); err == nil {
-			return &RecordSpec{
-				Type:  RecordTypeMultiple,
-				Multi: multiRecord,
-			}, nil
+	if name := duplicateOutputName(trimmed); name != "" {
+		return nil, errors.NewConfigurationError(
+			errors.ErrCodeInvalidRecord,
+			fmt.Sprintf("Duplicate output name %q in record specification", name),
+			nil,
+		).WithDetails(map[string]interface{}{
+			"field":       "record",
+			"output_name": name,
+		}).WithSuggestions("Give each secret a unique output name")
+	}
+
+	// A JSON or YAML list of references names each output after its field
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "-\n") {
+		multiRecord, err := v.parseListRecord(trimmed)
+		if err != nil {
+			return nil, errors.NewConfigurationError(
+				errors.ErrCodeInvalidRecord,
+				fmt.Sprintf("Record list is invalid: %v", err),
+				nil,
+			).WithDetails(map[string]interface{}{
+				"field":              "record",
+				"output_name_policy": v.outputNamePolicy,
+			}).WithSuggestions(
+				"Use the 'name: reference' form to choose output names explicitly",
+				"Try a different output_name_policy",
+			)
+		}
+		return v.multiRecordSpec(multiRecord)
+	}
+
+	// "output_name = reference" lines name each output explicitly
+	if isAssignmentRecord(trimmed) {
+		multiRecord, err := v.parseAssignmentRecord(trimmed)
+		if err != nil {
+			return nil, errors.NewConfigurationError(
+				errors.ErrCodeInvalidRecord,
+				fmt.Sprintf("Record assignments are invalid: %v", err),
+				nil,
+			).WithDetails(map[string]interface{}{
+				"field": "record",
+			}).WithSuggestions(
+				"Write one 'output_name = vault/item/field' per line",
+				"Output names may contain letters, digits and underscores and must be unique",
+			)
+		}
+		return v.multiRecordSpec(multiRecord)
+	}
+
+	// Try to parse as JSON first (starts with {)
+	if strings.HasPrefix(trimmed, "{") {
+		multiRecord, err := v.parseJSONRecord(record)
+		if err == nil {
+			return v.multiRecordSpec(multiRecord)
+		}
+		if isInvalidOutputNames(err) {
+			return nil, err
 		}
 	}
 
 	// Try to parse as YAML if it looks like YAML format
 	// YAML format: key: value (must have space after colon and no slash for secret/field)
 	if strings.Contains(record, ": ") {
-		if multiRecord, err := v.parseYAMLRecord(record); err == nil {
-			return &RecordSpec{
-				Type:  RecordTypeMultiple,
-				Multi: multiRecord,
-			}, nil
+		multiRecord, err := v.parseYAMLRecord(record)
+		if err == nil {
+			return v.multiRecordSpec(multiRecord)
+		}
+		if isInvalidOutputNames(err) {
+			return nil, err
 		}
 	}
 
@@ -645,19 +737,60 @@ func (v *Validator) ParseRecord(record string) (*RecordSpec, error) {
 		"field": "record",
 	}).WithUserMessage("Unable to parse record specification as single record, JSON, or YAML format").
 		WithSuggestions(
-			"For single secret: use format 'secret-name/field-name'",
+			"For single secret: use format 'secret-name/field-name' or 'op://vault/item/field'",
 			"For multiple secrets: use valid JSON or YAML format",
 			"Check for syntax errors in JSON/YAML",
 		)
 }
 
+// multiRecordSpec checks every output name of multi at once, so that all
+// invalid names are reported in a single error before any secret is
+// resolved, and warns about names shadowing expression functions.
+func (v *Validator) multiRecordSpec(multi map[string]*SingleRecord) (*RecordSpec, error) {
+	names := make([]string, 0, len(multi))
+	for name := range multi {
+		names = append(names, name)
+	}
+	warnings, err := v.ValidateOutputNames(names)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordSpec{
+		Type:     RecordTypeMultiple,
+		Multi:    multi,
+		Warnings: warnings,
+	}, nil
+}
+
 // parseSingleRecord parses a single record specification
 func (v *Validator) parseSingleRecord(record string) (*SingleRecord, error) {
 	trimmed := strings.TrimSpace(record)
 
-	// Check for vault override syntax: vault:secret/field
+	// A trailing "?" allows the field to be missing from the item
+	if base, optional := strings.CutSuffix(trimmed, OptionalMarker); optional {
+		if strings.HasSuffix(base, OptionalMarker) {
+			return nil, fmt.Errorf("field may only be marked optional once")
+		}
+		singleRecord, err := v.parseSingleRecord(base)
+		if err != nil {
+			return nil, err
+		}
+		singleRecord.Optional = true
+		return singleRecord, nil
+	}
+
+	if IsSecretReference(trimmed) {
+		return v.parseSecretReference(trimmed)
+	}
+
+	// Check for vault override syntax: vault:secret/field. Only a colon before
+	// the first slash selects a vault; field templates may contain colons.
 	var vaultRef, secretPart string
-	if colonIdx := strings.Index(trimmed, ":"); colonIdx > 0 {
+	colonIdx := strings.Index(trimmed, ":")
+	if slashIdx := strings.Index(trimmed, "/"); slashIdx >= 0 && colonIdx > slashIdx {
+		colonIdx = -1
+	}
+	if colonIdx > 0 {
 		vaultRef = strings.TrimSpace(trimmed[:colonIdx])
 		secretPart = strings.TrimSpace(trimmed[colonIdx+1:])
 
@@ -668,9 +801,9 @@ func (v *Validator) parseSingleRecord(record string) (*SingleRecord, error) {
 		secretPart = trimmed
 	}
 
-	// Parse secret/field format
-	parts := strings.Split(secretPart, "/")
-	if len(parts) != 2 {
+	// Parse secret/field format. Field templates may contain further slashes.
+	parts := strings.SplitN(secretPart, "/", 2)
+	if len(parts) != 2 || (strings.Contains(parts[1], "/") && !IsFieldTemplate(parts[1])) {
 		return nil, fmt.Errorf("invalid format, expected 'secret-name/field-name' or 'vault:secret-name/field-name'")
 	}
 
@@ -681,14 +814,64 @@ func (v *Validator) parseSingleRecord(record string) (*SingleRecord, error) {
 		return nil, err
 	}
 
-	if err := v.validateFieldName(fieldName); err != nil {
+	if IsFieldTemplate(fieldName) {
+		if _, err := ParseFieldTemplate(fieldName); err != nil {
+			return nil, err
+		}
+	} else if _, ok := AttachmentName(fieldName); ok {
+		if err := validateAttachmentField(fieldName); err != nil {
+			return nil, err
+		}
+	} else if !IsWildcardField(fieldName) && !IsDocumentField(fieldName) {
+		if err := v.validateFieldName(fieldName); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SingleRecord{
+		SecretName: secretName,
+		FieldName:  fieldName,
+		VaultRef:   vaultRef,
+	}, nil
+}
+
+// parseSecretReference parses an "op://vault/item/field" or
+// "op://vault/item/section/field" reference. Item, section and field names
+// copied from the 1Password apps often contain spaces, so they are checked
+// against the vault character set rather than the stricter one used for the
+// plain "item/field" form.
+func (v *Validator) parseSecretReference(ref string) (*SingleRecord, error) {
+	vaultRef, secretName, section, fieldName, err := SplitSecretReference(ref)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := v.ValidateVault(vaultRef); err != nil {
+		return nil, fmt.Errorf("invalid vault reference: %w", err)
+	}
+	if err := validateReferenceName("secret name", secretName, MaxSecretNameLen); err != nil {
+		return nil, err
+	}
+	if section != "" {
+		if err := validateSectionField(section, fieldName); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := AttachmentName(fieldName); ok {
+		if err := validateAttachmentField(fieldName); err != nil {
+			return nil, err
+		}
+	} else if !IsWildcardField(fieldName) && !IsDocumentField(fieldName) {
+		if err := validateReferenceName("field name", fieldName, MaxFieldLength); err != nil {
+			return nil, err
+		}
+	}
+
 	return &SingleRecord{
 		SecretName: secretName,
 		FieldName:  fieldName,
 		VaultRef:   vaultRef,
+		Section:    section,
 	}, nil
 }
 
@@ -729,32 +912,269 @@ func (v *Validator) parseMultiRecord(data map[string]interface{}) (map[string]*S
 		return nil, fmt.Errorf("too many secrets specified (max %d)", MaxPracticalSecrets)
 	}
 
+	// Report every invalid output name at once
+	names := make([]string, 0, len(data))
+	for outputName := range data {
+		names = append(names, outputName)
+	}
+	if _, err := v.ValidateOutputNames(names); err != nil {
+		return nil, err
+	}
+
 	result := make(map[string]*SingleRecord)
 
 	for outputName, secretSpecRaw := range data {
-		// Validate output name
-		if err := v.validateOutputName(outputName); err != nil {
-			return nil, fmt.Errorf("invalid output name %q: %w", outputName, err)
+		// Parse the secret specification, either a plain string or an
+		// object with a reference and an ordered list of transforms
+		var singleRecord *SingleRecord
+		var err error
+		switch secretSpec := secretSpecRaw.(type) {
+		case string:
+			singleRecord, err = v.parseRecordRef(secretSpec)
+		case map[string]interface{}:
+			singleRecord, err = v.parseRecordObject(secretSpec)
+		default:
+			return nil, fmt.Errorf("secret specification for %q must be a string or an object", outputName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret specification for %q: %w", outputName, err)
 		}
 
-		// Convert secret specification to string
-		secretSpec, ok := secretSpecRaw.(string)
-		if !ok {
-			return nil, fmt.Errorf("secret specification for %q must be a string", outputName)
+		result[outputName] = singleRecord
+	}
+
+	return result, nil
+}
+
+// parseListRecord parses a JSON or YAML list of secret specifications. Each
+// record's output name is derived from its field with the validator's output
+// name policy; two records deriving the same name are rejected.
+func (v *Validator) parseListRecord(record string) (map[string]*SingleRecord, error) {
+	// YAML is a superset of JSON, so one decoder handles both list forms
+	var items []interface{}
+	if err := yaml.Unmarshal([]byte(record), &items); err != nil {
+		return nil, fmt.Errorf("invalid list format: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("record specification cannot be empty")
+	}
+	if len(items) > MaxPracticalSecrets {
+		return nil, fmt.Errorf("too many secrets specified (max %d)", MaxPracticalSecrets)
+	}
+
+	result := make(map[string]*SingleRecord, len(items))
+	sources := make(map[string]string, len(items))
+
+	for i, item := range items {
+		var singleRecord *SingleRecord
+		var ref string
+		var err error
+		switch spec := item.(type) {
+		case string:
+			ref = spec
+			singleRecord, err = v.parseRecordRef(spec)
+		case map[string]interface{}:
+			ref, _ = spec["ref"].(string)
+			singleRecord, err = v.parseRecordObject(spec)
+		default:
+			return nil, fmt.Errorf("entry %d must be a string or an object", i+1)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret specification %q: %w", ref, err)
+		}
+
+		if IsWildcardField(singleRecord.FieldName) {
+			return nil, fmt.Errorf("%q selects every field and cannot be listed; use the 'name: reference' form", ref)
 		}
 
-		// Parse the secret specification
-		singleRecord, err := v.parseSingleRecord(secretSpec)
+		outputName, err := DeriveOutputName(singleRecord.FieldName, v.outputNamePolicy)
 		if err != nil {
-			return nil, fmt.Errorf("invalid secret specification for %q: %w", outputName, err)
+			return nil, fmt.Errorf("cannot name %q: %w", ref, err)
+		}
+		if err := v.validateOutputName(outputName); err != nil {
+			return nil, fmt.Errorf("output name %q derived from %q is invalid: %w", outputName, ref, err)
+		}
+		if previous, exists := sources[outputName]; exists {
+			return nil, fmt.Errorf("%q and %q both become output %q", previous, ref, outputName)
 		}
 
+		sources[outputName] = ref
 		result[outputName] = singleRecord
 	}
 
 	return result, nil
 }
 
+// assignmentLineRegex matches the start of an "output_name = reference" line.
+var assignmentLineRegex = regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// isAssignmentRecord reports whether any line of record assigns a reference
+// to an output name. Plain references never match, as they cannot start with
+// an identifier followed by "=".
+func isAssignmentRecord(record string) bool {
+	for _, line := range strings.Split(record, "\n") {
+		if assignmentLineRegex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAssignmentRecord parses one "output_name = reference" per line. Blank
+// lines and lines starting with "#" are skipped; whitespace around the "="
+// is ignored. Each reference takes any form parseRecordRef accepts.
+func (v *Validator) parseAssignmentRecord(record string) (map[string]*SingleRecord, error) {
+	result := make(map[string]*SingleRecord)
+
+	for i, line := range strings.Split(record, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		outputName, ref, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: missing '=' between output name and reference in %q", i+1, line)
+		}
+		outputName = strings.TrimSpace(outputName)
+		ref = strings.TrimSpace(ref)
+
+		if outputName == "" {
+			return nil, fmt.Errorf("line %d: missing output name before '='", i+1)
+		}
+		if _, exists := result[outputName]; exists {
+			return nil, fmt.Errorf("line %d: duplicate output name %q", i+1, outputName)
+		}
+		if ref == "" {
+			return nil, fmt.Errorf("line %d: missing reference for output %q", i+1, outputName)
+		}
+
+		singleRecord, err := v.parseRecordRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid secret specification for %q: %w", i+1, outputName, err)
+		}
+		result[outputName] = singleRecord
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("record specification cannot be empty")
+	}
+	if len(result) > MaxPracticalSecrets {
+		return nil, fmt.Errorf("too many secrets specified (max %d)", MaxPracticalSecrets)
+	}
+	return result, nil
+}
+
+// parseRecordRef parses a secret reference within a multi-record
+// specification. In addition to the single record forms it accepts
+// "vault/item/field", so each record can name its own vault, and
+// "vault/item/section/field" for a field held in a section of the item.
+func (v *Validator) parseRecordRef(ref string) (*SingleRecord, error) {
+	trimmed := strings.TrimSpace(ref)
+	parts := strings.Split(trimmed, "/")
+	if (len(parts) != 3 && len(parts) != 4) || strings.Contains(parts[0], ":") || IsFieldTemplate(trimmed) {
+		return v.parseSingleRecord(ref)
+	}
+
+	vaultRef := strings.TrimSpace(parts[0])
+	if err := v.ValidateVault(vaultRef); err != nil {
+		return nil, fmt.Errorf("invalid vault reference: %w", err)
+	}
+
+	// The optional marker belongs to the whole reference, not the section
+	field, optional := strings.CutSuffix(strings.TrimSpace(parts[len(parts)-1]), OptionalMarker)
+
+	section := ""
+	if len(parts) == 4 {
+		section = strings.TrimSpace(parts[2])
+		if err := validateSectionField(section, field); err != nil {
+			return nil, err
+		}
+	}
+
+	if optional {
+		field += OptionalMarker
+	}
+	record, err := v.parseSingleRecord(parts[1] + "/" + field)
+	if err != nil {
+		return nil, err
+	}
+	record.VaultRef = vaultRef
+	record.Section = section
+	return record, nil
+}
+
+// duplicateOutputName returns the first output name that appears more than
+// once at the top level of a JSON or YAML record, or "" if there is none.
+func duplicateOutputName(record string) string {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(record), &root); err != nil || len(root.Content) == 0 {
+		return ""
+	}
+
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	seen := make(map[string]bool, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name := mapping.Content[i].Value
+		if seen[name] {
+			return name
+		}
+		seen[name] = true
+	}
+	return ""
+}
+
+// parseRecordObject parses the object form of a secret specification:
+// {"ref": "secret/field", "transforms": ["base64decode", "minlength=32"]}
+func (v *Validator) parseRecordObject(data map[string]interface{}) (*SingleRecord, error) {
+	for key := range data {
+		if key != "ref" && key != "transforms" {
+			return nil, fmt.Errorf("unknown key %q (expected 'ref' and 'transforms')", key)
+		}
+	}
+
+	ref, ok := data["ref"].(string)
+	if !ok || strings.TrimSpace(ref) == "" {
+		return nil, fmt.Errorf("'ref' must be a non-empty string")
+	}
+
+	record, err := v.parseRecordRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rawTransforms, present := data["transforms"]
+	if !present {
+		return record, nil
+	}
+
+	list, ok := rawTransforms.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'transforms' must be a list of strings")
+	}
+
+	transforms := make([]string, 0, len(list))
+	for _, item := range list {
+		step, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("'transforms' must be a list of strings")
+		}
+		transforms = append(transforms, step)
+	}
+
+	if _, err := transform.Parse(transforms); err != nil {
+		return nil, err
+	}
+
+	record.Transforms = transforms
+	return record, nil
+}
+
 // validateSecretName validates a secret name
 func (v *Validator) validateSecretName(secretName string) error {
 	if secretName == "" {
@@ -888,6 +1308,15 @@ func ValidateVaultIdentifier(vault string) error {
 	return validator.ValidateVault(vault)
 }
 
+// ValidateOutputName is a convenience function for validating output names
+func ValidateOutputName(name string) error {
+	validator, err := NewValidator()
+	if err != nil {
+		return err
+	}
+	return validator.validateOutputName(name)
+}
+
 // ValidateRecordFormat validates the format of a record specification
 func ValidateRecordFormat(record string) error {
 	validator, err := NewValidator()
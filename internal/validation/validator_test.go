@@ -228,6 +228,16 @@ func TestValidateReturnType(t *testing.T) {
 			returnType: "both",
 			expectErr:  false,
 		},
+		{
+			name:       "valid file type",
+			returnType: "file",
+			expectErr:  false,
+		},
+		{
+			name:       "valid json type",
+			returnType: "json",
+			expectErr:  false,
+		},
 		{
 			name:       "empty type (default)",
 			returnType: "",
@@ -292,6 +302,35 @@ func TestParseSingleRecord(t *testing.T) {
 			expectedField: "password",
 			expectedVault: "vault-name",
 		},
+		{
+			name:          "field template",
+			record:        "database/{username}:{password}@{host}",
+			expectErr:     false,
+			expectedName:  "database",
+			expectedField: "{username}:{password}@{host}",
+			expectedVault: "",
+		},
+		{
+			name:          "field template with vault override",
+			record:        "prod:database/{username}:{password}@{host}",
+			expectErr:     false,
+			expectedName:  "database",
+			expectedField: "{username}:{password}@{host}",
+			expectedVault: "prod",
+		},
+		{
+			name:          "field template with slashes",
+			record:        "database/postgres://{username}@{host}/app",
+			expectErr:     false,
+			expectedName:  "database",
+			expectedField: "postgres://{username}@{host}/app",
+			expectedVault: "",
+		},
+		{
+			name:      "unterminated field template",
+			record:    "database/{username:{password}",
+			expectErr: true,
+		},
 		{
 			name:          "with spaces",
 			record:        " secret-name / field-name ",
@@ -330,6 +369,37 @@ func TestParseSingleRecord(t *testing.T) {
 			record:    "secret/field@name",
 			expectErr: true,
 		},
+		{
+			name:          "op reference",
+			record:        "op://Production/database-config/password",
+			expectedName:  "database-config",
+			expectedField: "password",
+			expectedVault: "Production",
+		},
+		{
+			name:          "op reference with encoded spaces",
+			record:        "op://My%20Vault/API%20Keys/api%20key",
+			expectedName:  "API Keys",
+			expectedField: "api key",
+			expectedVault: "My Vault",
+		},
+		{
+			name:      "op reference with missing field",
+			record:    "op://Production/database-config",
+			expectErr: true,
+		},
+		{
+			name:          "op reference with backslashes and a trailing slash",
+			record:        `op://Production\database-config\password/`,
+			expectedName:  "database-config",
+			expectedField: "password",
+			expectedVault: "Production",
+		},
+		{
+			name:      "op reference with invalid characters",
+			record:    "op://Production/database%40config/password",
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -554,6 +624,69 @@ api_key: api-secrets/key
 	}
 }
 
+func TestParseRecordWithTransforms(t *testing.T) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	valid := `
+signing_key:
+  ref: keys/signing
+  transforms: [base64decode, minlength=32]
+api_key: api/key
+`
+	spec, err := validator.ParseRecord(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	signing := spec.Multi["signing_key"]
+	if signing == nil || signing.SecretName != "keys" || signing.FieldName != "signing" {
+		t.Fatalf("Unexpected signing_key record: %+v", signing)
+	}
+	if len(signing.Transforms) != 2 || signing.Transforms[0] != "base64decode" {
+		t.Errorf("Expected ordered transforms, got %v", signing.Transforms)
+	}
+	if len(spec.Multi["api_key"].Transforms) != 0 {
+		t.Errorf("Plain records should not carry transforms")
+	}
+
+	invalid := []struct {
+		name   string
+		record string
+		reason string
+	}{
+		{
+			name:   "jsonpath after decode",
+			record: `{"token": {"ref": "app/config", "transforms": ["base64decode", "jsonpath=.token"]}}`,
+			reason: "cannot follow base64decode",
+		},
+		{
+			name:   "unknown key",
+			record: `{"token": {"ref": "app/config", "transform": ["trim"]}}`,
+			reason: "unknown key",
+		},
+		{
+			name:   "missing ref",
+			record: `{"token": {"transforms": ["trim"]}}`,
+			reason: "'ref' must be a non-empty string",
+		},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.parseJSONRecord(tt.record)
+			if err == nil {
+				t.Fatalf("Expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Expected error containing %q, got %q", tt.reason, err.Error())
+			}
+		})
+	}
+}
+
 func TestParseRecord(t *testing.T) {
 	validator, err := NewValidator()
 	if err != nil {
@@ -599,6 +732,22 @@ func TestParseRecord(t *testing.T) {
 			record:    "invalid-format",
 			expectErr: true,
 		},
+		{
+			name:         "newline delimited records with vaults",
+			record:       "db_password: prod/database/password\napi_key: api/key",
+			expectErr:    false,
+			expectedType: RecordTypeMultiple,
+		},
+		{
+			name:      "duplicate output names in YAML",
+			record:    "api_key: secrets/api-key\napi_key: secrets/other",
+			expectErr: true,
+		},
+		{
+			name:      "duplicate output names in JSON",
+			record:    `{"api_key": "secrets/api-key", "api_key": "secrets/other"}`,
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -455,6 +455,19 @@ func SecureZero(data []byte) {
 	runtime.KeepAlive(data)
 }
 
+// SecureAppend appends src to dst like append, but when dst has to grow the
+// old backing array is zeroed so no partial copy of a secret is left behind
+func SecureAppend(dst []byte, src ...byte) []byte {
+	if len(dst)+len(src) <= cap(dst) {
+		return append(dst, src...)
+	}
+
+	grown := make([]byte, len(dst), 2*cap(dst)+len(src))
+	copy(grown, dst)
+	SecureZero(dst[:cap(dst)])
+	return append(grown, src...)
+}
+
 // IsSecureMemoryAvailable checks if secure memory operations are available
 func IsSecureMemoryAvailable() bool {
 	return isSecureMemoryAvailablePlatform()
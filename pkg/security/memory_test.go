@@ -386,6 +386,50 @@ func TestSecureString_Destroy(t *testing.T) {
 	}
 }
 
+func TestSecureString_DestroyZeroesBuffer(t *testing.T) {
+	ss, err := NewSecureString([]byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatalf("NewSecureString() error = %v", err)
+	}
+
+	// Keep a view of the whole backing array, page alignment included
+	backing := ss.data[:cap(ss.data)]
+
+	if err := ss.Destroy(); err != nil {
+		t.Fatalf("Destroy() error = %v", err)
+	}
+
+	for i, b := range backing {
+		if b != 0 {
+			t.Fatalf("Backing buffer not zeroed at index %d: got %d", i, b)
+		}
+	}
+}
+
+func TestSecureAppend(t *testing.T) {
+	dst := make([]byte, 0, 4)
+	dst = SecureAppend(dst, []byte("abcd")...)
+	old := dst[:cap(dst)]
+
+	// Appending past the capacity moves the data and wipes the old array
+	dst = SecureAppend(dst, []byte("efgh")...)
+	if string(dst) != "abcdefgh" {
+		t.Errorf("SecureAppend() = %q, want %q", dst, "abcdefgh")
+	}
+	for i, b := range old {
+		if b != 0 {
+			t.Errorf("Old backing array not zeroed at index %d: got %d", i, b)
+		}
+	}
+
+	// Appending within the capacity reuses the array
+	dst = make([]byte, 0, 8)
+	grown := SecureAppend(dst, 'x')
+	if &grown[0] != &dst[:1][0] {
+		t.Error("SecureAppend() should not reallocate within capacity")
+	}
+}
+
 func TestConstantTimeEqual(t *testing.T) {
 	tests := []struct {
 		name     string